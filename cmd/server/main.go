@@ -5,58 +5,383 @@ import (
 	"clipboard-sync-backend/internal/api"
 	"clipboard-sync-backend/internal/auth"
 	"clipboard-sync-backend/internal/database"
+	"clipboard-sync-backend/internal/mail"
+	"clipboard-sync-backend/internal/models"
+	"clipboard-sync-backend/internal/oauth"
+	"clipboard-sync-backend/internal/password"
+	"clipboard-sync-backend/internal/policy"
 	"clipboard-sync-backend/internal/repository"
 	"clipboard-sync-backend/internal/service"
+	"clipboard-sync-backend/internal/unfurl"
 	"clipboard-sync-backend/internal/websocket"
+	"context"
+	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/spf13/pflag"
 	"log"
-	_ "net/http"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
 func main() {
-	// 1. Load Configuration
-	cfg := configs.LoadConfig()
+	// 1. Load Configuration: defaults, config file, profile, CLIPSYNC_* environment and flags
+	flags := pflag.NewFlagSet(os.Args[0], pflag.ExitOnError)
+	configOptions := configs.RegisterFlags(flags)
+	flags.Parse(os.Args[1:])
+	configLoader, err := configs.Load(configOptions)
+	if err != nil {
+		log.Fatal(err)
+	}
+	cfg := configLoader.Config()
+	if cfg.Profile != "" {
+		log.Printf("Configuration loaded for profile %q", cfg.Profile)
+	} else {
+		log.Println("Configuration loaded successfully.")
+	}
+	switch flags.Arg(0) {
+	case "":
+	case "migrate":
+		os.Exit(runMigrate(cfg, flags.Args()[1:]))
+	default:
+		log.Fatalf("Unknown command %q; the only command is \"migrate\"", flags.Arg(0))
+	}
 
-	// 2. Initialize Database
+	// 2. Initialize Database (applies pending migrations unless database.migrate_on_start is off)
 	db := database.InitDB(cfg)
 
-	// 3. Initialize Repositories
+	// 3. Load JWT signing and verification keys
+	tokenManager, err := auth.NewTokenManager(cfg.JWT)
+	if err != nil {
+		log.Fatalf("Failed to load JWT keys: %v", err)
+	}
+
+	// 4. Initialize Repositories
 	userRepo := repository.NewUserRepository(db)
 	clipboardRepo := repository.NewClipboardRepository(db)
+	linkPreviewRepo := repository.NewLinkPreviewRepository(db)
+	transferJobRepo := repository.NewTransferJobRepository(db)
+	webhookRepo := repository.NewWebhookRepository(db)
+	snippetRepo := repository.NewSnippetRepository(db)
+	teamRepo := repository.NewTeamRepository(db)
+	invitationRepo := repository.NewInvitationRepository(db)
+	auditRepo := repository.NewAuditRepository(db)
+	sessionRepo := repository.NewSessionRepository(db)
+	accessTokenRepo := repository.NewAccessTokenRepository(db)
+	oauthRepo := repository.NewOAuthRepository(db)
+	twoFactorRepo := repository.NewTwoFactorRepository(db)
+	emailRepo := repository.NewEmailRepository(db)
+	accountTokenRepo := repository.NewAccountTokenRepository(db)
+	loginThrottleRepo := repository.NewLoginThrottleRepository(db)
+	passwordHistoryRepo := repository.NewPasswordHistoryRepository(db)
+	dataExportRepo := repository.NewDataExportRepository(db)
+	statsRepo := repository.NewStatsRepository(db)
+	settingsRepo := repository.NewSettingsRepository(db)
 
-	// 4. Initialize Services
-	userService := service.NewUserService(userRepo)
-	clipboardService := service.NewClipboardService(clipboardRepo)
+	// 5. Initialize WebSocket Manager (services push real-time events through it)
+	wsManager := websocket.NewManager()
+	wsWorker := startWorker("WebSocket manager", wsManager.Run)
 
-	// 5. Initialize API Handlers
-	userHandler := api.NewUserHandler(userService)
+	// 6. Initialize Services
+	auditService := service.NewAuditService(auditRepo, teamRepo, cfg.Audit.BatchSize, cfg.Audit.FlushInterval, cfg.Audit.QueueSize)
+	auditWorker := startWorker("audit log writer", auditService.Run)
+	sessionService := service.NewSessionService(sessionRepo, tokenManager, wsManager, auditService, cfg.JWT.RefreshTokenTTL)
+	accessTokenService := service.NewAccessTokenService(accessTokenRepo, auditService)
+	passwordPolicy := password.Policy{MinLength: cfg.Passwords.MinLength, MaxLength: cfg.Passwords.MaxLength}
+	if cfg.Passwords.BreachedListFile != "" {
+		breached, err := password.LoadBreachedList(cfg.Passwords.BreachedListFile)
+		if err != nil {
+			log.Fatalf("Failed to load breached password list: %v", err)
+		}
+		log.Printf("Loaded %d breached password hashes", breached.Len())
+		passwordPolicy.Breached = breached
+	}
+	passwordService := service.NewPasswordService(password.NewHasher(password.Params{
+		Memory:      cfg.Passwords.Argon2.Memory,
+		Iterations:  cfg.Passwords.Argon2.Iterations,
+		Parallelism: cfg.Passwords.Argon2.Parallelism,
+	}), passwordPolicy, passwordHistoryRepo, userRepo, cfg.Passwords.HistorySize)
+	twoFactorService := service.NewTwoFactorService(twoFactorRepo, userRepo, passwordService, auditService)
+	mailTransport, err := mail.NewSender(cfg.Mail.Driver, cfg.Mail.From, cfg.Mail.Dir, mail.SMTPOptions{
+		Host:     cfg.Mail.SMTP.Host,
+		Port:     cfg.Mail.SMTP.Port,
+		Username: cfg.Mail.SMTP.Username,
+		Password: cfg.Mail.SMTP.Password,
+	})
+	if err != nil {
+		log.Fatalf("Failed to initialize mail sender: %v", err)
+	}
+	mailer := service.NewEmailOutbox(emailRepo, mailTransport, cfg.Mail.MaxAttempts)
+	mailWorker := startWorker("email outbox", mailer.Run)
+	accountService := service.NewAccountService(userRepo, accountTokenRepo, tokenManager, passwordService, mailer, sessionService, auditService, cfg.Mail.AppURL, cfg.Accounts.EmailVerificationTTL, cfg.Accounts.PasswordResetTTL)
+	invitationService := service.NewInvitationService(invitationRepo, teamRepo, userRepo, mailer, wsManager, auditService, cfg.Mail.AppURL, cfg.Teams.InvitationTTL)
+	loginGuard := service.NewLoginGuard(loginThrottleRepo, userRepo, mailer, auditService, cfg.Mail.AppURL, service.LoginGuardOptions{
+		MaxFailures:      cfg.Accounts.MaxFailedLogins,
+		MaxFailuresPerIP: cfg.Accounts.MaxFailedLoginsPerIP,
+		LockoutDuration:  cfg.Accounts.LockoutDuration,
+		FailureWindow:    cfg.Accounts.LoginFailureWindow,
+	})
+	userService := service.NewUserService(userRepo, passwordService, invitationService, accountService, auditService, loginGuard)
+	profileService := service.NewProfileService(userRepo, accountService, passwordService, sessionService, mailer, auditService, cfg.Accounts.DeletionGracePeriod)
+	deletionWorker := startWorker("account deletion", profileService.Run)
+	oauthProviders, err := oauth.NewProviders(cfg.OAuth)
+	if err != nil {
+		log.Fatalf("Failed to configure login providers: %v", err)
+	}
+	oauthService := service.NewOAuthService(oauthRepo, userRepo, invitationService, auditService, oauthProviders, cfg.OAuth.CallbackBaseURL, cfg.OAuth.StateTTL, cfg.OAuth.Timeout)
+	teamService := service.NewTeamService(teamRepo, wsManager, auditService)
+	var linkPreviewService service.LinkPreviewService
+	if cfg.Unfurl.Enabled {
+		fetcher := unfurl.NewCachingFetcher(unfurl.NewFetcher(unfurl.Options{
+			Timeout:      cfg.Unfurl.Timeout,
+			MaxBodyBytes: cfg.Unfurl.MaxBodyBytes,
+		}), cfg.Unfurl.CacheTTL, cfg.Unfurl.CacheSize)
+		linkPreviewService = service.NewLinkPreviewService(linkPreviewRepo, fetcher, wsManager, cfg.Unfurl.Timeout)
+	}
+	webhookService := service.NewWebhookService(webhookRepo, teamRepo, cfg.Webhooks.MaxAttempts, cfg.Webhooks.Timeout, cfg.Webhooks.AllowPrivateNetworks)
+	webhookWorker := startWorker("webhook delivery", webhookService.Run)
+	clipboardService := service.NewClipboardService(clipboardRepo, teamRepo, linkPreviewService, webhookService, wsManager, auditService)
+	transferService := service.NewTransferService(transferJobRepo, clipboardRepo, cfg.Transfer.Dir, cfg.Transfer.ExportTTL, cfg.Transfer.MaxImportBytes)
+	transferWorker := startWorker("history exports and imports", transferService.Run)
+	snippetService := service.NewSnippetService(snippetRepo, teamRepo, clipboardRepo, clipboardService, wsManager)
+	dataExportService := service.NewDataExportService(dataExportRepo, service.DataExportSources{
+		Users:        userRepo,
+		Sessions:     sessionRepo,
+		Identities:   oauthRepo,
+		TwoFactor:    twoFactorRepo,
+		AccessTokens: accessTokenRepo,
+		Teams:        teamRepo,
+		Invitations:  invitationRepo,
+		Webhooks:     webhookRepo,
+		Snippets:     snippetRepo,
+		Clipboard:    clipboardRepo,
+		Audit:        auditRepo,
+		Settings:     settingsRepo,
+	}, tokenManager, mailer, auditService, cfg.Transfer.Dir, cfg.Transfer.DataExportTTL)
+	dataExportWorker := startWorker("data exports", dataExportService.Run)
+	settingsService := service.NewSettingsService(settingsRepo, wsManager, auditService)
+	adminService := service.NewAdminService(userRepo, sessionRepo, twoFactorRepo, statsRepo, sessionService, wsManager, wsManager, mailer, auditService)
+
+	// 7. Initialize API and WebSocket Handlers
+	userHandler := api.NewUserHandler(userService, sessionService, twoFactorService)
 	clipboardHandler := api.NewClipboardHandler(clipboardService)
+	transferHandler := api.NewTransferHandler(transferService)
+	webhookHandler := api.NewWebhookHandler(webhookService)
+	snippetHandler := api.NewSnippetHandler(snippetService)
+	teamHandler := api.NewTeamHandler(teamService)
+	invitationHandler := api.NewInvitationHandler(invitationService)
+	auditHandler := api.NewAuditHandler(auditService)
+	sessionHandler := api.NewSessionHandler(sessionService)
+	accessTokenHandler := api.NewAccessTokenHandler(accessTokenService)
+	accountHandler := api.NewAccountHandler(accountService)
+	twoFactorHandler := api.NewTwoFactorHandler(twoFactorService, sessionService)
+	oauthHandler := api.NewOAuthHandler(oauthService, sessionService, twoFactorService)
+	adminHandler := api.NewAdminHandler(adminService, loginGuard)
+	profileHandler := api.NewProfileHandler(profileService)
+	dataExportHandler := api.NewDataExportHandler(dataExportService)
+	settingsHandler := api.NewSettingsHandler(settingsService)
 
-	// 6. Initialize WebSocket Manager and Handler
-	wsManager := websocket.NewManager()
-	go wsManager.Run()
-	wsHandler := websocket.NewWsHandler(wsManager, clipboardService)
+	// 8. Setup Gin Router. Logging, CORS and rate limits follow configuration reloads.
+	requestLogger := api.NewRequestLogger(cfg.Log.Level)
+	cors := api.NewCORS(cfg.Server.CORS)
+	rateLimiter := api.NewRateLimiter(cfg.Server.RateLimit)
+	configLoader.OnReload(func(c *configs.Config) {
+		requestLogger.SetLevel(c.Log.Level)
+		cors.Update(c.Server.CORS)
+		rateLimiter.Update(c.Server.RateLimit)
+	})
+	configLoader.Watch()
+	// Browsers may open WebSockets from the same origins they may call the API from
+	wsHandler := websocket.NewWsHandler(wsManager, clipboardService, webhookService, teamService, cors)
+
+	router := gin.New()
+	router.Use(requestLogger.Handler(), gin.Recovery(), cors.Handler(), rateLimiter.Handler())
 
-	// 7. Setup Gin Router
-	router := gin.Default()
+	// Probes for orchestrators and load balancers
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.Fatalf("Failed to get database handle: %v", err)
+	}
+	healthHandler := api.NewHealthHandler(sqlDB)
+	router.GET("/healthz", healthHandler.Live)
+	router.GET("/readyz", healthHandler.Ready)
+
+	// Verification keys for services that accept our tokens
+	router.GET("/.well-known/jwks.json", auth.JWKSHandler(tokenManager))
+
+	// Mail kept by the capture driver, for development and tests only
+	if capture, ok := mailTransport.(*mail.CaptureSender); ok {
+		log.Println("Mail capture driver enabled; captured mail is served at /dev/mail")
+		devMailHandler := api.NewDevMailHandler(capture)
+		router.GET("/dev/mail", devMailHandler.ListMail)
+		router.DELETE("/dev/mail", devMailHandler.ClearMail)
+	}
 
 	// Public routes (no authentication required)
 	publicRoutes := router.Group("/api/v1")
 	{
 		publicRoutes.POST("/register", userHandler.Register)
 		publicRoutes.POST("/login", userHandler.Login)
+		publicRoutes.POST("/login/2fa", twoFactorHandler.CompleteLogin)
+		publicRoutes.POST("/refresh", sessionHandler.Refresh)
+		publicRoutes.POST("/verify-email", accountHandler.VerifyEmail)
+		publicRoutes.POST("/email/confirm", accountHandler.ConfirmEmailChange)
+		publicRoutes.POST("/password/forgot", accountHandler.ForgotPassword)
+		publicRoutes.POST("/password/reset", accountHandler.ResetPassword)
+		publicRoutes.GET("/oauth/providers", oauthHandler.ListProviders)
+		publicRoutes.GET("/oauth/:provider/login", oauthHandler.StartLogin)
+		publicRoutes.GET("/oauth/:provider/callback", oauthHandler.Callback)
+		publicRoutes.POST("/data-exports/verify", dataExportHandler.VerifyDataExport)
+		publicRoutes.GET("/settings/schema", settingsHandler.GetSettingsSchema)
 	}
 
 	// Authenticated routes
 	authRoutes := router.Group("/api/v1")
-	authRoutes.Use(auth.AuthMiddleware())
+	authRoutes.Use(auth.AuthMiddleware(tokenManager, sessionService, accessTokenService), api.AuditImpersonation(auditService))
 	{
-		authRoutes.POST("/clipboard", clipboardHandler.CreateClipboardEntry)
-		authRoutes.GET("/clipboard/history", clipboardHandler.GetClipboardHistory)
-		authRoutes.GET("/ws", wsHandler.ServeWs) // WebSocket endpoint
+		authRoutes.POST("/logout", auth.RequireSession(), sessionHandler.Logout)
+		authRoutes.GET("/sessions", auth.RequireSession(), sessionHandler.ListSessions)
+		authRoutes.DELETE("/sessions/:id", auth.RequireSession(), sessionHandler.RevokeSession)
+		authRoutes.POST("/verify-email/resend", auth.RequireSession(), accountHandler.ResendVerification)
+		authRoutes.GET("/me", auth.RequireSession(), profileHandler.GetProfile)
+		authRoutes.PATCH("/me", auth.RequireSession(), profileHandler.UpdateProfile)
+		authRoutes.DELETE("/me", auth.RequireSession(), profileHandler.DeleteAccount)
+		authRoutes.POST("/me/restore", auth.RequireSession(), profileHandler.RestoreAccount)
+		authRoutes.PUT("/me/password", auth.RequireSession(), accountHandler.ChangePassword)
+		authRoutes.GET("/me/settings", auth.RequireSession(), settingsHandler.GetSettings)
+		authRoutes.PATCH("/me/settings", auth.RequireSession(), settingsHandler.UpdateSettings)
+		authRoutes.POST("/me/data-exports", auth.RequireSession(), dataExportHandler.RequestDataExport)
+		authRoutes.GET("/me/data-exports", auth.RequireSession(), dataExportHandler.ListDataExports)
+		authRoutes.GET("/me/data-exports/:id", auth.RequireSession(), dataExportHandler.GetDataExport)
+		authRoutes.GET("/me/data-exports/:id/download", auth.RequireSession(), dataExportHandler.DownloadDataExport)
+		authRoutes.GET("/2fa", auth.RequireSession(), twoFactorHandler.GetStatus)
+		authRoutes.POST("/2fa/enroll", auth.RequireSession(), twoFactorHandler.BeginEnrollment)
+		authRoutes.POST("/2fa/confirm", auth.RequireSession(), twoFactorHandler.ConfirmEnrollment)
+		authRoutes.POST("/2fa/disable", auth.RequireSession(), twoFactorHandler.Disable)
+		authRoutes.POST("/2fa/recovery-codes", auth.RequireSession(), twoFactorHandler.RegenerateRecoveryCodes)
+		authRoutes.GET("/tokens", auth.RequireSession(), accessTokenHandler.ListAccessTokens)
+		authRoutes.POST("/tokens", auth.RequireSession(), accessTokenHandler.CreateAccessToken)
+		authRoutes.DELETE("/tokens/:id", auth.RequireSession(), accessTokenHandler.RevokeAccessToken)
+		authRoutes.POST("/clipboard", auth.RequireScope(models.ScopeClipboardWrite), clipboardHandler.CreateClipboardEntry)
+		authRoutes.GET("/clipboard/history", auth.RequireScope(models.ScopeClipboardRead), clipboardHandler.GetClipboardHistory)
+		authRoutes.DELETE("/clipboard/:id", auth.RequireScope(models.ScopeClipboardWrite), clipboardHandler.DeleteClipboardEntry)
+		authRoutes.POST("/clipboard/exports", auth.RequireScope(models.ScopeClipboardRead), transferHandler.CreateExport)
+		authRoutes.GET("/clipboard/exports/:id", auth.RequireScope(models.ScopeClipboardRead), transferHandler.GetExport)
+		authRoutes.GET("/clipboard/exports/:id/download", auth.RequireScope(models.ScopeClipboardRead), transferHandler.DownloadExport)
+		authRoutes.POST("/clipboard/imports", auth.RequireScope(models.ScopeClipboardWrite), transferHandler.CreateImport)
+		authRoutes.GET("/clipboard/imports/:id", auth.RequireScope(models.ScopeClipboardWrite), transferHandler.GetImport)
+		authRoutes.GET("/webhooks", auth.RequireScope(models.ScopeWebhooksRead), webhookHandler.ListWebhooks)
+		authRoutes.POST("/webhooks", auth.RequireScope(models.ScopeWebhooksWrite), webhookHandler.CreateWebhook)
+		authRoutes.GET("/webhooks/:id", auth.RequireScope(models.ScopeWebhooksRead), webhookHandler.GetWebhook)
+		authRoutes.PATCH("/webhooks/:id", auth.RequireScope(models.ScopeWebhooksWrite), webhookHandler.UpdateWebhook)
+		authRoutes.DELETE("/webhooks/:id", auth.RequireScope(models.ScopeWebhooksWrite), webhookHandler.DeleteWebhook)
+		authRoutes.POST("/webhooks/:id/rotate-secret", auth.RequireScope(models.ScopeWebhooksWrite), webhookHandler.RotateSecret)
+		authRoutes.POST("/webhooks/:id/ping", auth.RequireScope(models.ScopeWebhooksWrite), webhookHandler.Ping)
+		authRoutes.GET("/webhooks/:id/deliveries", auth.RequireScope(models.ScopeWebhooksRead), webhookHandler.ListDeliveries)
+		authRoutes.POST("/webhooks/:id/deliveries/:deliveryId/redeliver", auth.RequireScope(models.ScopeWebhooksWrite), webhookHandler.Redeliver)
+		authRoutes.GET("/snippets", auth.RequireScope(models.ScopeSnippetsRead), snippetHandler.ListSnippets)
+		authRoutes.POST("/snippets", auth.RequireScope(models.ScopeSnippetsWrite), snippetHandler.CreateSnippet)
+		authRoutes.GET("/snippets/abbreviations/:abbr", auth.RequireScope(models.ScopeSnippetsRead), snippetHandler.GetSnippetByAbbreviation)
+		authRoutes.GET("/snippets/:id", auth.RequireScope(models.ScopeSnippetsRead), snippetHandler.GetSnippet)
+		authRoutes.PATCH("/snippets/:id", auth.RequireScope(models.ScopeSnippetsWrite), snippetHandler.UpdateSnippet)
+		authRoutes.DELETE("/snippets/:id", auth.RequireScope(models.ScopeSnippetsWrite), snippetHandler.DeleteSnippet)
+		authRoutes.POST("/snippets/:id/expand", auth.RequireScope(models.ScopeSnippetsRead), snippetHandler.ExpandSnippet)
+		authRoutes.POST("/snippets/:id/push", auth.RequireScope(models.ScopeSnippetsRead), auth.RequireScope(models.ScopeClipboardWrite), snippetHandler.PushSnippet)
+		authRoutes.GET("/snippet-folders", auth.RequireScope(models.ScopeSnippetsRead), snippetHandler.ListFolders)
+		authRoutes.POST("/snippet-folders", auth.RequireScope(models.ScopeSnippetsWrite), snippetHandler.CreateFolder)
+		authRoutes.PATCH("/snippet-folders/:id", auth.RequireScope(models.ScopeSnippetsWrite), snippetHandler.UpdateFolder)
+		authRoutes.DELETE("/snippet-folders/:id", auth.RequireScope(models.ScopeSnippetsWrite), snippetHandler.DeleteFolder)
+		authRoutes.GET("/teams", auth.RequireScope(models.ScopeTeamsRead), teamHandler.ListTeams)
+		authRoutes.POST("/teams", auth.RequireScope(models.ScopeTeamsWrite), teamHandler.CreateTeam)
+		authRoutes.GET("/teams/:id", auth.RequireScope(models.ScopeTeamsRead), teamHandler.GetTeam)
+		authRoutes.PATCH("/teams/:id", auth.RequireScope(models.ScopeTeamsWrite), api.RequireTeamPermission(teamService, policy.ManageTeam), teamHandler.RenameTeam)
+		authRoutes.PUT("/teams/:id/two-factor", auth.RequireScope(models.ScopeTeamsWrite), api.RequireTeamPermission(teamService, policy.ManageTeam), teamHandler.SetTwoFactorRequirement)
+		authRoutes.DELETE("/teams/:id", auth.RequireScope(models.ScopeTeamsWrite), api.RequireTeamPermission(teamService, policy.DeleteTeam), teamHandler.DeleteTeam)
+		authRoutes.GET("/teams/:id/members", auth.RequireScope(models.ScopeTeamsRead), teamHandler.ListMembers)
+		authRoutes.PATCH("/teams/:id/members/:userId", auth.RequireScope(models.ScopeTeamsWrite), api.RequireTeamPermission(teamService, policy.ManageMembers), teamHandler.ChangeMemberRole)
+		authRoutes.DELETE("/teams/:id/members/:userId", auth.RequireScope(models.ScopeTeamsWrite), teamHandler.RemoveMember)
+		authRoutes.POST("/teams/:id/leave", auth.RequireScope(models.ScopeTeamsWrite), teamHandler.LeaveTeam)
+		authRoutes.POST("/teams/:id/clipboard", auth.RequireScope(models.ScopeClipboardWrite), api.RequireTeamPermission(teamService, policy.PostEntry), clipboardHandler.CreateTeamClipboardEntry)
+		authRoutes.GET("/teams/:id/clipboard/history", auth.RequireScope(models.ScopeClipboardRead), api.RequireTeamPermission(teamService, policy.ReadHistory), clipboardHandler.GetTeamClipboardHistory)
+		authRoutes.GET("/teams/:id/clipboard/search", auth.RequireScope(models.ScopeClipboardRead), api.RequireTeamPermission(teamService, policy.ReadHistory), clipboardHandler.SearchTeamClipboard)
+		authRoutes.GET("/teams/:id/invitations", auth.RequireScope(models.ScopeTeamsRead), api.RequireTeamPermission(teamService, policy.ManageMembers), invitationHandler.ListTeamInvitations)
+		authRoutes.POST("/teams/:id/invitations", auth.RequireScope(models.ScopeTeamsWrite), api.RequireTeamPermission(teamService, policy.ManageMembers), invitationHandler.InviteByEmail)
+		authRoutes.DELETE("/teams/:id/invitations/:invitationId", auth.RequireScope(models.ScopeTeamsWrite), api.RequireTeamPermission(teamService, policy.ManageMembers), invitationHandler.RevokeInvitation)
+		authRoutes.POST("/teams/:id/join-links", auth.RequireScope(models.ScopeTeamsWrite), api.RequireTeamPermission(teamService, policy.ManageMembers), invitationHandler.CreateJoinLink)
+		authRoutes.GET("/invitations", auth.RequireScope(models.ScopeTeamsRead), invitationHandler.ListMyInvitations)
+		authRoutes.POST("/invitations/:id/accept", auth.RequireScope(models.ScopeTeamsWrite), invitationHandler.AcceptInvitation)
+		authRoutes.POST("/invitations/:id/decline", auth.RequireScope(models.ScopeTeamsWrite), invitationHandler.DeclineInvitation)
+		authRoutes.POST("/join/:token", auth.RequireScope(models.ScopeTeamsWrite), invitationHandler.Join)
+		authRoutes.GET("/audit", auth.RequireScope(models.ScopeAuditRead), auditHandler.ListAudit)
+		authRoutes.GET("/audit/export", auth.RequireScope(models.ScopeAuditRead), auditHandler.ExportAudit)
+		authRoutes.GET("/ws", auth.RequireScope(models.ScopeClipboardRead), wsHandler.ServeWs) // WebSocket endpoint
 	}
 
+	// System administration; access tokens and impersonation sessions cannot reach it
+	adminRoutes := authRoutes.Group("/admin", auth.RequireSession(), auth.RequireAdmin(userService))
+	{
+		adminRoutes.GET("/users", adminHandler.SearchUsers)
+		adminRoutes.GET("/users/:id", adminHandler.GetUser)
+		adminRoutes.POST("/users/:id/suspend", adminHandler.SuspendUser)
+		adminRoutes.POST("/users/:id/unsuspend", adminHandler.UnsuspendUser)
+		adminRoutes.POST("/users/:id/logout", adminHandler.ForceLogout)
+		adminRoutes.POST("/users/:id/two-factor/reset", adminHandler.ResetTwoFactor)
+		adminRoutes.POST("/users/:id/impersonate", adminHandler.Impersonate)
+		adminRoutes.POST("/users/:id/unlock", adminHandler.UnlockUser)
+		adminRoutes.GET("/stats", adminHandler.Stats)
+	}
+
+	// 9. Serve until SIGINT or SIGTERM, then shut down gracefully
+	srv := &http.Server{
+		Addr:              cfg.Server.Port,
+		Handler:           router,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.ListenAndServe()
+	}()
 	fmt.Printf("Server is running on %s\n", cfg.Server.Port)
-	log.Fatal(router.Run(cfg.Server.Port))
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	select {
+	case err := <-serveErr:
+		log.Fatalf("Server failed: %v", err)
+	case sig := <-quit:
+		log.Printf("Received %s, shutting down", sig)
+	}
+	signal.Stop(quit) // A second signal kills the process right away
+
+	// Fail readiness first and give load balancers time to notice before refusing connections
+	healthHandler.SetDraining()
+	time.Sleep(cfg.Server.ReadinessDelay)
+
+	// The whole timeout goes to draining clients and stopping workers
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	// Stop accepting connections and let requests in flight finish
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("HTTP server did not shut down cleanly: %v", err)
+	}
+	if err := <-serveErr; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Printf("HTTP server error: %v", err)
+	}
+
+	// WebSocket connections were hijacked from the HTTP server and are closed separately
+	if err := wsManager.Shutdown(ctx, cfg.Server.ReconnectDelay); err != nil {
+		log.Printf("Not every WebSocket client disconnected: %v", err)
+	}
+
+	// Stop background workers; the audit log goes last so it records what the others did
+	stopWorkers(ctx, wsWorker, webhookWorker, transferWorker, dataExportWorker, deletionWorker, mailWorker, auditWorker)
+
+	if err := sqlDB.Close(); err != nil {
+		log.Printf("Failed to close database: %v", err)
+	}
+	log.Println("Server stopped")
 }
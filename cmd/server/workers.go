@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"log"
+)
+
+// worker is a background loop that runs until its stop channel is closed
+type worker struct {
+	name string
+	stop chan struct{}
+	done chan struct{}
+}
+
+// startWorker runs fn in its own goroutine
+func startWorker(name string, fn func(stop <-chan struct{})) *worker {
+	w := &worker{name: name, stop: make(chan struct{}), done: make(chan struct{})}
+	go func() {
+		defer close(w.done)
+		fn(w.stop)
+	}()
+	return w
+}
+
+// stopWorkers stops workers one at a time, in the order given, so later ones can still take
+// work from earlier ones (the audit log should be last). It gives up once ctx is done.
+func stopWorkers(ctx context.Context, workers ...*worker) {
+	for _, w := range workers {
+		close(w.stop)
+		select {
+		case <-w.done:
+			log.Printf("Stopped %s", w.name)
+		case <-ctx.Done():
+			log.Printf("Gave up waiting for %s to stop: %v", w.name, ctx.Err())
+			return
+		}
+	}
+}
@@ -1,19 +1,51 @@
 package configs
 
-import (
-	"log"
-	"sync"
-
-	"github.com/spf13/viper"
-)
+import "time"
 
 type Config struct {
-	Server   ServerConfig   `mapstructure:"server"`
-	Database DatabaseConfig `mapstructure:"database"` // Add more configs here as needed
+	Profile   string          `mapstructure:"-"` // Environment profile the configuration was loaded for, e.g. "production"
+	Server    ServerConfig    `mapstructure:"server"`
+	Log       LogConfig       `mapstructure:"log"`
+	Database  DatabaseConfig  `mapstructure:"database"` // Add more configs here as needed
+	Unfurl    UnfurlConfig    `mapstructure:"unfurl"`
+	Transfer  TransferConfig  `mapstructure:"transfer"`
+	Webhooks  WebhookConfig   `mapstructure:"webhooks"`
+	Mail      MailConfig      `mapstructure:"mail"`
+	Teams     TeamsConfig     `mapstructure:"teams"`
+	Audit     AuditConfig     `mapstructure:"audit"`
+	JWT       JWTConfig       `mapstructure:"jwt"`
+	OAuth     OAuthConfig     `mapstructure:"oauth"`
+	Accounts  AccountsConfig  `mapstructure:"accounts"`
+	Passwords PasswordsConfig `mapstructure:"passwords"`
 }
 
 type ServerConfig struct {
-	Port string `mapstructure:"port"`
+	Port            string          `mapstructure:"port"`
+	ShutdownTimeout time.Duration   `mapstructure:"shutdown_timeout"` // Longest a graceful shutdown may take before the process exits anyway
+	ReadinessDelay  time.Duration   `mapstructure:"readiness_delay"`  // How long /readyz fails before the server stops accepting requests
+	ReconnectDelay  time.Duration   `mapstructure:"reconnect_delay"`  // When WebSocket clients are told to reconnect after a shutdown
+	CORS            CORSConfig      `mapstructure:"cors"`             // Reloaded while running
+	RateLimit       RateLimitConfig `mapstructure:"rate_limit"`       // Reloaded while running
+}
+
+// CORSConfig lists the browser origins allowed to call the API. "*" allows any origin but is
+// never combined with credentials.
+type CORSConfig struct {
+	AllowedOrigins   []string      `mapstructure:"allowed_origins"`
+	AllowCredentials bool          `mapstructure:"allow_credentials"`
+	MaxAge           time.Duration `mapstructure:"max_age"` // How long browsers may cache preflight results
+}
+
+// RateLimitConfig limits requests per client address with a token bucket
+type RateLimitConfig struct {
+	Enabled           bool    `mapstructure:"enabled"`
+	RequestsPerSecond float64 `mapstructure:"requests_per_second"` // Sustained rate
+	Burst             int     `mapstructure:"burst"`               // Requests allowed at once
+}
+
+// LogConfig is reloaded while running
+type LogConfig struct {
+	Level string `mapstructure:"level"` // "debug", "info", "warn" or "error"; requests are logged at info, failed ones at warn and error
 }
 
 type DatabaseConfig struct {
@@ -23,31 +55,121 @@ type DatabaseConfig struct {
 	Password string `mapstructure:"password"`
 	DBName   string `mapstructure:"dbname"`
 	SSLMode  string `mapstructure:"sslmode"`
+
+	MigrateOnStart bool `mapstructure:"migrate_on_start"` // Apply pending migrations at startup; when off, the server refuses to start until "migrate up" has run
+}
+
+type UnfurlConfig struct {
+	Enabled      bool          `mapstructure:"enabled"`
+	Timeout      time.Duration `mapstructure:"timeout"`        // e.g., "5s"
+	MaxBodyBytes int64         `mapstructure:"max_body_bytes"` // Maximum bytes read per page
+	CacheTTL     time.Duration `mapstructure:"cache_ttl"`      // e.g., "1h"
+	CacheSize    int           `mapstructure:"cache_size"`
+}
+
+type TransferConfig struct {
+	Dir            string        `mapstructure:"dir"`              // Where export and import files are kept
+	ExportTTL      time.Duration `mapstructure:"export_ttl"`       // How long finished exports can be downloaded
+	MaxImportBytes int64         `mapstructure:"max_import_bytes"` // Maximum upload size for imports
+	DataExportTTL  time.Duration `mapstructure:"data_export_ttl"`  // How long personal data exports can be downloaded
+}
+
+type WebhookConfig struct {
+	MaxAttempts          int           `mapstructure:"max_attempts"`           // Deliveries are dead-lettered after this many failures
+	Timeout              time.Duration `mapstructure:"timeout"`                // Per-attempt request timeout
+	AllowPrivateNetworks bool          `mapstructure:"allow_private_networks"` // Permit webhooks to internal addresses
+}
+
+type MailConfig struct {
+	Driver      string     `mapstructure:"driver"`       // "log", "file", "smtp" or "capture"
+	From        string     `mapstructure:"from"`         // Sender address
+	Dir         string     `mapstructure:"dir"`          // Output directory for the file driver
+	AppURL      string     `mapstructure:"app_url"`      // Public base URL used in links sent by email
+	MaxAttempts int        `mapstructure:"max_attempts"` // Outbox messages are marked failed after this many attempts
+	SMTP        SMTPConfig `mapstructure:"smtp"`
 }
 
-var (
-	configOnce sync.Once
-	appConfig  *Config
-)
+type SMTPConfig struct {
+	Host     string `mapstructure:"host"`
+	Port     int    `mapstructure:"port"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+}
+
+type AccountsConfig struct {
+	EmailVerificationTTL time.Duration `mapstructure:"email_verification_ttl"`   // How long verification links work
+	PasswordResetTTL     time.Duration `mapstructure:"password_reset_ttl"`       // How long password reset links work
+	MaxFailedLogins      int           `mapstructure:"max_failed_logins"`        // Failed sign-ins in a row that lock an account
+	MaxFailedLoginsPerIP int           `mapstructure:"max_failed_logins_per_ip"` // Failed sign-ins in a row that lock out a client address
+	LockoutDuration      time.Duration `mapstructure:"lockout_duration"`         // How long lockouts last
+	LoginFailureWindow   time.Duration `mapstructure:"login_failure_window"`     // Failed sign-ins are forgotten after this long without another
+	DeletionGracePeriod  time.Duration `mapstructure:"deletion_grace_period"`    // How long deleted accounts can be restored before their data is erased
+}
+
+type PasswordsConfig struct {
+	MinLength        int          `mapstructure:"min_length"`
+	MaxLength        int          `mapstructure:"max_length"`
+	BreachedListFile string       `mapstructure:"breached_list_file"` // SHA-1 hashes of breached passwords, one "HASH[:COUNT]" per line; empty to skip the check
+	HistorySize      int          `mapstructure:"history_size"`       // New passwords must differ from this many recent ones, counting the current one
+	Argon2           Argon2Config `mapstructure:"argon2"`
+}
+
+// Argon2Config sets the argon2id cost of new password hashes. Raising it upgrades existing
+// hashes as their users sign in.
+type Argon2Config struct {
+	Memory      uint32 `mapstructure:"memory"` // KiB
+	Iterations  uint32 `mapstructure:"iterations"`
+	Parallelism uint8  `mapstructure:"parallelism"`
+}
+
+type TeamsConfig struct {
+	InvitationTTL time.Duration `mapstructure:"invitation_ttl"` // How long email invitations stay valid
+}
+
+type AuditConfig struct {
+	BatchSize     int           `mapstructure:"batch_size"`     // Records written per insert
+	FlushInterval time.Duration `mapstructure:"flush_interval"` // Maximum time a record waits before being written
+	QueueSize     int           `mapstructure:"queue_size"`     // Pending records kept before new ones are dropped
+}
 
-func LoadConfig() *Config {
-	configOnce.Do(func() {
-		v := viper.New()
-		v.AddConfigPath("./configs") // Path to look for the config file
-		v.SetConfigName("config")    // Name of config file (without extension)
-		v.SetConfigType("yaml")      // Type of the config file
+type JWTConfig struct {
+	Issuer          string         `mapstructure:"issuer"`            // "iss" set on issued tokens and required on incoming ones
+	Audience        string         `mapstructure:"audience"`          // "aud" set on issued tokens and required on incoming ones
+	AccessTokenTTL  time.Duration  `mapstructure:"access_token_ttl"`  // Lifetime of issued access tokens
+	RefreshTokenTTL time.Duration  `mapstructure:"refresh_token_ttl"` // Sessions end when unused for this long
+	SigningKey      string         `mapstructure:"signing_key"`       // kid of the key new tokens are signed with
+	Keys            []JWTKeyConfig `mapstructure:"keys"`              // Every key tokens may be verified with
+}
 
-		v.AutomaticEnv() // Read environment variables
+// JWTKeyConfig is one signing or verification key. HS256 keys use Secret or SecretFile; RS256 and
+// EdDSA keys use PEM files, where a public key alone makes a verification-only key (e.g. one being
+// retired). Keys can be set from the environment by index, e.g. CLIPSYNC_JWT_KEYS_0_SECRET.
+type JWTKeyConfig struct {
+	ID             string `mapstructure:"kid"`
+	Algorithm      string `mapstructure:"algorithm"` // "HS256", "RS256" or "EdDSA"
+	Secret         string `mapstructure:"secret"`
+	SecretFile     string `mapstructure:"secret_file"` // Read into Secret at load, e.g. a mounted secret
+	PrivateKeyFile string `mapstructure:"private_key_file"`
+	PublicKeyFile  string `mapstructure:"public_key_file"`
+}
 
-		if err := v.ReadInConfig(); err != nil {
-			log.Fatalf("Error reading config file, %s", err)
-		}
+type OAuthConfig struct {
+	CallbackBaseURL string                `mapstructure:"callback_base_url"` // Public base URL; callbacks go to <base>/api/v1/oauth/<name>/callback
+	StateTTL        time.Duration         `mapstructure:"state_ttl"`         // How long a started login can be completed
+	Timeout         time.Duration         `mapstructure:"timeout"`           // Per-request timeout when talking to providers
+	Providers       []OAuthProviderConfig `mapstructure:"providers"`
+}
 
-		appConfig = &Config{}
-		if err := v.Unmarshal(appConfig); err != nil {
-			log.Fatalf("Unable to decode into struct, %s", err)
-		}
-		log.Println("Configuration loaded successfully.")
-	})
-	return appConfig
+// OAuthProviderConfig is one social login provider. OIDC providers only need IssuerURL, the
+// endpoints are discovered; explicitly configured endpoints override discovery.
+type OAuthProviderConfig struct {
+	Name         string   `mapstructure:"name"` // Used in URLs and stored on linked identities
+	Type         string   `mapstructure:"type"` // "oidc" or "github"
+	ClientID     string   `mapstructure:"client_id"`
+	ClientSecret string   `mapstructure:"client_secret"`
+	IssuerURL    string   `mapstructure:"issuer_url"`
+	AuthURL      string   `mapstructure:"auth_url"`
+	TokenURL     string   `mapstructure:"token_url"`
+	UserInfoURL  string   `mapstructure:"userinfo_url"`
+	Scopes       []string `mapstructure:"scopes"`
 }
@@ -0,0 +1,278 @@
+package configs
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// EnvPrefix prefixes environment variables that override configuration keys: database.host is
+// read from CLIPSYNC_DATABASE_HOST
+const EnvPrefix = "CLIPSYNC"
+
+const defaultConfigFile = "configs/config.yaml"
+
+// Options says where configuration is read from. Later layers override earlier ones: built-in
+// defaults, the config file, the profile's file next to it, CLIPSYNC_* environment variables and
+// finally command-line flags.
+type Options struct {
+	File    string            // Config file; when empty configs/config.yaml is used if it exists
+	Profile string            // Merges config.<profile>.yaml from the config file's directory
+	Set     map[string]string // Individual keys set on the command line
+	flags   *pflag.FlagSet
+}
+
+// RegisterFlags adds the configuration flags to fs and returns the options they fill in once
+// fs is parsed. The profile defaults to $CLIPSYNC_PROFILE.
+func RegisterFlags(fs *pflag.FlagSet) *Options {
+	opts := &Options{flags: fs}
+	fs.StringVarP(&opts.File, "config", "c", "", "path to the config file (default "+defaultConfigFile+")")
+	fs.StringVar(&opts.Profile, "profile", os.Getenv(EnvPrefix+"_PROFILE"), "environment profile, e.g. development or production")
+	fs.StringToStringVar(&opts.Set, "set", nil, "override a config key, e.g. --set server.port=:9090 (repeatable)")
+	fs.String("port", "", "address to listen on (server.port)")
+	fs.String("log-level", "", "log level (log.level)")
+	return opts
+}
+
+// flagKeys maps shorthand flags to the keys they set
+var flagKeys = map[string]string{
+	"port":      "server.port",
+	"log-level": "log.level",
+}
+
+// setDefaults registers a default for every key. Besides filling in what the file leaves out,
+// this is what lets environment variables override keys the file does not mention.
+func setDefaults(v *viper.Viper) {
+	defaults := map[string]interface{}{
+		"server.port":                           ":8080",
+		"server.shutdown_timeout":               "30s",
+		"server.readiness_delay":                "5s",
+		"server.reconnect_delay":                "5s",
+		"server.cors.allowed_origins":           []string{},
+		"server.cors.allow_credentials":         false,
+		"server.cors.max_age":                   "10m",
+		"server.rate_limit.enabled":             false,
+		"server.rate_limit.requests_per_second": 20,
+		"server.rate_limit.burst":               40,
+		"log.level":                             "info",
+		"database.host":                         "localhost",
+		"database.port":                         "5432",
+		"database.user":                         "",
+		"database.password":                     "",
+		"database.dbname":                       "clipboard_sync",
+		"database.sslmode":                      "disable",
+		"database.migrate_on_start":             true,
+		"unfurl.enabled":                        true,
+		"unfurl.timeout":                        "5s",
+		"unfurl.max_body_bytes":                 524288,
+		"unfurl.cache_ttl":                      "1h",
+		"unfurl.cache_size":                     1000,
+		"transfer.dir":                          "./data/transfers",
+		"transfer.export_ttl":                   "24h",
+		"transfer.max_import_bytes":             104857600,
+		"transfer.data_export_ttl":              "168h",
+		"webhooks.max_attempts":                 8,
+		"webhooks.timeout":                      "10s",
+		"webhooks.allow_private_networks":       false,
+		"mail.driver":                           "log",
+		"mail.from":                             "Clipboard Sync <no-reply@localhost>",
+		"mail.dir":                              "./data/mail",
+		"mail.app_url":                          "http://localhost:8080",
+		"mail.max_attempts":                     6,
+		"mail.smtp.host":                        "",
+		"mail.smtp.port":                        587,
+		"mail.smtp.username":                    "",
+		"mail.smtp.password":                    "",
+		"teams.invitation_ttl":                  "168h",
+		"audit.batch_size":                      100,
+		"audit.flush_interval":                  "2s",
+		"audit.queue_size":                      4096,
+		"jwt.issuer":                            "clipboard-sync",
+		"jwt.audience":                          "clipboard-sync-api",
+		"jwt.access_token_ttl":                  "15m",
+		"jwt.refresh_token_ttl":                 "720h",
+		"jwt.signing_key":                       "default",
+		"oauth.callback_base_url":               "http://localhost:8080",
+		"oauth.state_ttl":                       "10m",
+		"oauth.timeout":                         "10s",
+		"accounts.email_verification_ttl":       "48h",
+		"accounts.password_reset_ttl":           "1h",
+		"accounts.max_failed_logins":            5,
+		"accounts.max_failed_logins_per_ip":     50,
+		"accounts.lockout_duration":             "15m",
+		"accounts.login_failure_window":         "15m",
+		"accounts.deletion_grace_period":        "720h",
+		"passwords.min_length":                  8,
+		"passwords.max_length":                  128,
+		"passwords.breached_list_file":          "",
+		"passwords.history_size":                5,
+		"passwords.argon2.memory":               65536,
+		"passwords.argon2.iterations":           3,
+		"passwords.argon2.parallelism":          2,
+	}
+	for key, value := range defaults {
+		v.SetDefault(key, value)
+	}
+}
+
+// newViper assembles every configuration layer into a viper instance. It returns the path of
+// the config file it read, empty when running on defaults and environment variables alone.
+func newViper(opts *Options) (*viper.Viper, string, error) {
+	v := viper.New()
+	setDefaults(v)
+
+	file := opts.File
+	if file == "" {
+		if _, err := os.Stat(defaultConfigFile); err == nil {
+			file = defaultConfigFile
+		}
+	}
+	if file != "" {
+		v.SetConfigFile(file)
+		if err := v.ReadInConfig(); err != nil {
+			return nil, "", fmt.Errorf("failed to read config file %s: %w", file, err)
+		}
+	}
+	if opts.Profile != "" {
+		profileFile := profileFile(opts.Profile, file)
+		f, err := os.Open(profileFile)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read profile %q: %w", opts.Profile, err)
+		}
+		defer f.Close()
+		v.SetConfigType("yaml")
+		if err := v.MergeConfig(f); err != nil {
+			return nil, "", fmt.Errorf("failed to read profile file %s: %w", profileFile, err)
+		}
+	}
+
+	v.SetEnvPrefix(EnvPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+
+	if opts.flags != nil {
+		for name, key := range flagKeys {
+			if f := opts.flags.Lookup(name); f != nil && f.Changed {
+				v.Set(key, f.Value.String())
+			}
+		}
+	}
+	for key, value := range opts.Set {
+		v.Set(key, value)
+	}
+	return v, file, nil
+}
+
+// profileFile returns the path of the profile's file, which sits next to the config file
+func profileFile(profile, file string) string {
+	dir := filepath.Dir(defaultConfigFile)
+	if file != "" {
+		dir = filepath.Dir(file)
+	}
+	return filepath.Join(dir, "config."+profile+".yaml")
+}
+
+// decode unmarshals and validates the assembled configuration
+func decode(v *viper.Viper, profile string) (*Config, error) {
+	cfg := &Config{Profile: profile}
+	if err := v.Unmarshal(cfg); err != nil {
+		return nil, fmt.Errorf("unable to decode configuration: %w", err)
+	}
+	cfg.JWT.Keys = jwtKeysFromEnv(cfg.JWT.Keys)
+	var p problems
+	cfg.JWT.readSecretFiles(&p)
+	if err := cfg.Validate(); err != nil {
+		var invalid *ValidationError
+		if !errors.As(err, &invalid) {
+			return nil, err
+		}
+		p = append(p, invalid.Problems...)
+	}
+	if len(p) > 0 {
+		return nil, &ValidationError{Problems: p}
+	}
+	return cfg, nil
+}
+
+// jwtKeyEnvFields maps the environment variable suffix of each key field to the field
+var jwtKeyEnvFields = map[string]func(k *JWTKeyConfig) *string{
+	"KID":              func(k *JWTKeyConfig) *string { return &k.ID },
+	"ALGORITHM":        func(k *JWTKeyConfig) *string { return &k.Algorithm },
+	"SECRET":           func(k *JWTKeyConfig) *string { return &k.Secret },
+	"SECRET_FILE":      func(k *JWTKeyConfig) *string { return &k.SecretFile },
+	"PRIVATE_KEY_FILE": func(k *JWTKeyConfig) *string { return &k.PrivateKeyFile },
+	"PUBLIC_KEY_FILE":  func(k *JWTKeyConfig) *string { return &k.PublicKeyFile },
+}
+
+// jwtKeysFromEnv applies CLIPSYNC_JWT_KEYS_<n>_<FIELD> variables, which viper cannot map onto
+// list elements. Variables for the index after the last key add a key.
+func jwtKeysFromEnv(keys []JWTKeyConfig) []JWTKeyConfig {
+	for i := 0; ; i++ {
+		found := false
+		k := JWTKeyConfig{}
+		if i < len(keys) {
+			k = keys[i]
+		}
+		for suffix, field := range jwtKeyEnvFields {
+			if value, ok := os.LookupEnv(fmt.Sprintf("%s_JWT_KEYS_%d_%s", EnvPrefix, i, suffix)); ok {
+				*field(&k) = value
+				found = true
+			}
+		}
+		switch {
+		case i < len(keys):
+			keys[i] = k
+		case found:
+			keys = append(keys, k)
+		default:
+			return keys
+		}
+	}
+}
+
+// readSecretFiles reads the secrets of keys configured with secret_file
+func (c *JWTConfig) readSecretFiles(p *problems) {
+	for i := range c.Keys {
+		k := &c.Keys[i]
+		if k.SecretFile == "" {
+			continue
+		}
+		if k.Secret != "" {
+			p.addf("jwt.keys[%d] sets both secret and secret_file", i)
+			continue
+		}
+		data, err := os.ReadFile(k.SecretFile)
+		if err != nil {
+			p.addf("jwt.keys[%d].secret_file: %v", i, err)
+			continue
+		}
+		k.Secret = strings.TrimRight(string(data), "\r\n")
+	}
+}
+
+// Load reads and validates the configuration. A *ValidationError lists every problem found.
+func Load(opts *Options) (*Loader, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	v, file, err := newViper(opts)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := decode(v, opts.Profile)
+	if err != nil {
+		var invalid *ValidationError
+		if file != "" && errors.As(err, &invalid) {
+			invalid.File = file
+		}
+		return nil, err
+	}
+	l := &Loader{opts: opts, file: file}
+	l.current.Store(cfg)
+	return l, nil
+}
@@ -0,0 +1,160 @@
+package configs
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+func TestLayerPrecedence(t *testing.T) {
+	// Each layer sets server.port and one key of its own, so both overriding and falling
+	// through to earlier layers show
+	const file = minimalConfig + `
+server:
+  port: ":8001"
+unfurl:
+  timeout: "7s"
+log:
+  level: "warn"
+`
+	const profile = `
+server:
+  port: ":8002"
+webhooks:
+  timeout: "3s"
+`
+	tests := []struct {
+		name string
+		env  map[string]string
+		args []string
+		want string
+	}{
+		{"file and profile", nil, nil, ":8002"},
+		{"environment", map[string]string{"CLIPSYNC_SERVER_PORT": ":8003"}, nil, ":8003"},
+		{"flag", map[string]string{"CLIPSYNC_SERVER_PORT": ":8003"}, []string{"--port", ":8004"}, ":8004"},
+		{"set", map[string]string{"CLIPSYNC_SERVER_PORT": ":8003"}, []string{"--set", "server.port=:8005"}, ":8005"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := writeFile(t, dir, "config.yaml", file)
+			writeFile(t, dir, "config.staging.yaml", profile)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+			opts := RegisterFlags(fs)
+			if err := fs.Parse(append([]string{"--config", path, "--profile", "staging"}, tt.args...)); err != nil {
+				t.Fatal(err)
+			}
+
+			l, err := Load(opts)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			cfg := l.Config()
+			if cfg.Server.Port != tt.want {
+				t.Errorf("server.port = %q, want %q", cfg.Server.Port, tt.want)
+			}
+			if cfg.Profile != "staging" || l.File() != path {
+				t.Errorf("profile %q from %s, want staging from %s", cfg.Profile, l.File(), path)
+			}
+			if cfg.Mail.From != "Clipboard Sync <no-reply@localhost>" {
+				t.Errorf("mail.from = %q, want the default", cfg.Mail.From)
+			}
+			if cfg.Unfurl.Timeout != 7*time.Second || cfg.Log.Level != "warn" {
+				t.Errorf("unfurl.timeout = %s and log.level = %s, want the file's 7s and warn", cfg.Unfurl.Timeout, cfg.Log.Level)
+			}
+			if cfg.Webhooks.Timeout != 3*time.Second {
+				t.Errorf("webhooks.timeout = %s, want the profile's 3s", cfg.Webhooks.Timeout)
+			}
+		})
+	}
+}
+
+func TestFlagsOverrideEnvironment(t *testing.T) {
+	t.Setenv("CLIPSYNC_LOG_LEVEL", "error")
+	dir := t.TempDir()
+	path := writeFile(t, dir, "config.yaml", minimalConfig)
+
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	opts := RegisterFlags(fs)
+	if err := fs.Parse([]string{"--config", path}); err != nil {
+		t.Fatal(err)
+	}
+	l, err := Load(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := l.Config().Log.Level; got != "error" {
+		t.Fatalf("log.level = %q, want the environment's error", got)
+	}
+
+	fs = pflag.NewFlagSet("test", pflag.ContinueOnError)
+	opts = RegisterFlags(fs)
+	if err := fs.Parse([]string{"--config", path, "--log-level", "debug"}); err != nil {
+		t.Fatal(err)
+	}
+	if l, err = Load(opts); err != nil {
+		t.Fatal(err)
+	}
+	if got := l.Config().Log.Level; got != "debug" {
+		t.Fatalf("log.level = %q, want the flag's debug", got)
+	}
+}
+
+func TestMissingProfileFile(t *testing.T) {
+	if _, err := loadConfig(t, minimalConfig, "staging", nil); err == nil {
+		t.Fatal("Load() with a profile that has no file succeeded")
+	}
+}
+
+func TestValidationReportsEveryProblem(t *testing.T) {
+	const body = `
+server:
+  port: ""
+  cors:
+    allowed_origins: ["https://app.example.com/path"]
+  rate_limit:
+    enabled: true
+    requests_per_second: 0
+log:
+  level: "verbose"
+database:
+  sslmode: "sometimes"
+mail:
+  app_url: "localhost"
+passwords:
+  history_size: -1
+`
+	_, err := loadConfig(t, body, "", nil)
+	var invalid *ValidationError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("Load() error = %v, want a *ValidationError", err)
+	}
+	if filepath.Base(invalid.File) != "config.yaml" {
+		t.Errorf("File = %q, want the config file", invalid.File)
+	}
+	want := []string{
+		"server.port is required",
+		"server.cors.allowed_origins[0] must be",
+		"server.rate_limit.requests_per_second must be positive",
+		"log.level must be one of",
+		"database.user is required",
+		"database.sslmode must be one of",
+		"mail.app_url must be an absolute http or https URL",
+		"passwords.history_size must be at least 0",
+		"jwt.keys must list at least one key",
+	}
+	for _, w := range want {
+		if !hasProblem(invalid.Problems, w) {
+			t.Errorf("problems = %q, missing %q", invalid.Problems, w)
+		}
+	}
+	if len(invalid.Problems) != len(want) {
+		t.Errorf("got %d problems, want %d: %q", len(invalid.Problems), len(want), invalid.Problems)
+	}
+}
@@ -0,0 +1,178 @@
+package configs
+
+import (
+	"log"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Loader holds the loaded configuration and, once Watch is called, reloads it when the config
+// file or the profile's file changes. Only the keys that are safe to change while running are
+// applied: log.level, server.cors and server.rate_limit. Changes to any other key are reported and
+// wait for a restart.
+type Loader struct {
+	opts    *Options
+	file    string
+	current atomic.Pointer[Config]
+
+	mu       sync.Mutex
+	onReload []func(*Config)
+}
+
+// Config returns the current configuration. It must not be modified.
+func (l *Loader) Config() *Config {
+	return l.current.Load()
+}
+
+// File returns the config file in use, empty when running without one
+func (l *Loader) File() string {
+	return l.file
+}
+
+// OnReload registers fn to be called with the new configuration after a reload changed any of
+// the reloadable keys
+func (l *Loader) OnReload(fn func(*Config)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.onReload = append(l.onReload, fn)
+}
+
+// Watch starts reloading the configuration when the config file or the profile's file changes.
+// Edits that fail to parse or validate are logged and ignored, keeping the running configuration.
+func (l *Loader) Watch() {
+	if l.file == "" {
+		log.Println("No config file in use; configuration will not be reloaded")
+		return
+	}
+	paths := []string{l.file}
+	if l.opts.Profile != "" {
+		paths = append(paths, profileFile(l.opts.Profile, l.file))
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Printf("Failed to watch configuration files; configuration will not be reloaded: %v", err)
+		return
+	}
+	// Directories are watched rather than the files, so that files replaced by editors or
+	// ConfigMap updates are still followed
+	files := make([]*watchedFile, len(paths))
+	dirs := make(map[string]bool)
+	for i, path := range paths {
+		path = filepath.Clean(path)
+		real, _ := filepath.EvalSymlinks(path)
+		files[i] = &watchedFile{path: path, real: real}
+		dirs[filepath.Dir(path)] = true
+	}
+	for dir := range dirs {
+		if err := watcher.Add(dir); err != nil {
+			watcher.Close()
+			log.Printf("Failed to watch %s; configuration will not be reloaded: %v", dir, err)
+			return
+		}
+	}
+	go l.watch(watcher, files)
+	log.Printf("Watching %s for configuration changes", strings.Join(paths, " and "))
+}
+
+// watchedFile is a configuration file and the file its path resolved to when last checked
+type watchedFile struct {
+	path string
+	real string
+}
+
+func (l *Loader) watch(watcher *fsnotify.Watcher, files []*watchedFile) {
+	defer watcher.Close()
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if touched(files, event) {
+				l.reload()
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("Error watching configuration files: %v", err)
+		}
+	}
+}
+
+// touched reports whether event changed any of files: a write to one of them, or a symlink
+// among them now resolving elsewhere, which is how Kubernetes updates a mounted ConfigMap
+func touched(files []*watchedFile, event fsnotify.Event) bool {
+	changed := false
+	name := filepath.Clean(event.Name)
+	for _, f := range files {
+		if name == f.path && event.Op&(fsnotify.Write|fsnotify.Create) != 0 {
+			changed = true
+		}
+		if real, _ := filepath.EvalSymlinks(f.path); real != f.real {
+			f.real = real
+			changed = true
+		}
+	}
+	return changed
+}
+
+// reload reassembles every layer, so environment variables and flags keep overriding the file
+func (l *Loader) reload() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	v, _, err := newViper(l.opts)
+	if err != nil {
+		log.Printf("Ignoring configuration change: %v", err)
+		return
+	}
+	next, err := decode(v, l.opts.Profile)
+	if err != nil {
+		log.Printf("Ignoring configuration change: %v", err)
+		return
+	}
+
+	old := l.current.Load()
+	if !reflect.DeepEqual(pinned(*old), pinned(*next)) {
+		log.Println("Configuration changes outside log.level, server.cors and server.rate_limit take effect after a restart")
+	}
+	if reflect.DeepEqual(reloadable(*old), reloadable(*next)) {
+		return
+	}
+
+	updated := *old
+	updated.Log = next.Log
+	updated.Server.CORS = next.Server.CORS
+	updated.Server.RateLimit = next.Server.RateLimit
+	l.current.Store(&updated)
+	log.Printf("Configuration reloaded: log.level=%s, %d CORS origins, rate limit enabled=%t", updated.Log.Level, len(updated.Server.CORS.AllowedOrigins), updated.Server.RateLimit.Enabled)
+	for _, fn := range l.onReload {
+		fn(&updated)
+	}
+}
+
+// reloadableKeys is the part of the configuration applied while running
+type reloadableKeys struct {
+	Log       LogConfig
+	CORS      CORSConfig
+	RateLimit RateLimitConfig
+}
+
+func reloadable(c Config) reloadableKeys {
+	return reloadableKeys{Log: c.Log, CORS: c.Server.CORS, RateLimit: c.Server.RateLimit}
+}
+
+// pinned returns the configuration without its reloadable keys
+func pinned(c Config) Config {
+	c.Log = LogConfig{}
+	c.Server.CORS = CORSConfig{}
+	c.Server.RateLimit = RateLimitConfig{}
+	return c
+}
@@ -0,0 +1,145 @@
+package configs
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// serverConfig returns a valid config file with extra added to its server section
+func serverConfig(extra string) string {
+	return minimalConfig + `
+log:
+  level: "info"
+server:
+  port: ":8080"
+  cors:
+    allowed_origins: ["https://app.example.com"]
+` + extra
+}
+
+var reloadConfig = serverConfig("")
+
+func TestReload(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "config.yaml", reloadConfig)
+	l, err := Load(&Options{File: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var reloaded []*Config
+	l.OnReload(func(c *Config) { reloaded = append(reloaded, c) })
+	running := l.Config()
+
+	// An invalid edit is ignored and the running configuration stays
+	writeFile(t, dir, "config.yaml", serverConfig(`
+  rate_limit:
+    enabled: true
+    burst: 0
+`))
+	l.reload()
+	writeFile(t, dir, "config.yaml", "server: [not yaml")
+	l.reload()
+	if l.Config() != running || len(reloaded) != 0 {
+		t.Fatalf("invalid edits replaced the running configuration with %+v", l.Config())
+	}
+
+	// Reloadable keys apply; the rest wait for a restart
+	writeFile(t, dir, "config.yaml", minimalConfig+`
+server:
+  port: ":9090"
+  cors:
+    allowed_origins: ["https://app.example.com", "https://admin.example.com"]
+log:
+  level: "debug"
+`)
+	l.reload()
+	cfg := l.Config()
+	if len(reloaded) != 1 || reloaded[0] != cfg {
+		t.Fatalf("OnReload called %d times, want once with the new configuration", len(reloaded))
+	}
+	if cfg.Log.Level != "debug" || !reflect.DeepEqual(cfg.Server.CORS.AllowedOrigins, []string{"https://app.example.com", "https://admin.example.com"}) {
+		t.Fatalf("reloaded log.level = %s and origins %v, want the edited ones", cfg.Log.Level, cfg.Server.CORS.AllowedOrigins)
+	}
+	if cfg.Server.Port != ":8080" {
+		t.Fatalf("server.port = %s after a reload, want it kept until a restart", cfg.Server.Port)
+	}
+	if running.Log.Level != "info" {
+		t.Fatal("reload modified the previous configuration in place")
+	}
+
+	// Reloading unchanged reloadable keys does not notify
+	l.reload()
+	if len(reloaded) != 1 {
+		t.Fatalf("OnReload called %d times, want once", len(reloaded))
+	}
+}
+
+func TestReloadKeepsEnvironmentOverrides(t *testing.T) {
+	t.Setenv("CLIPSYNC_LOG_LEVEL", "error")
+	dir := t.TempDir()
+	path := writeFile(t, dir, "config.yaml", reloadConfig)
+	l, err := Load(&Options{File: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, dir, "config.yaml", serverConfig("    allow_credentials: true\n"))
+	l.reload()
+	if cfg := l.Config(); cfg.Log.Level != "error" || !cfg.Server.CORS.AllowCredentials {
+		t.Fatalf("after reload log.level = %s and allow_credentials = %t, want the environment's error and the file's true", cfg.Log.Level, cfg.Server.CORS.AllowCredentials)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "config.yaml", reloadConfig)
+	writeFile(t, dir, "config.staging.yaml", "log:\n  level: \"warn\"\n")
+	l, err := Load(&Options{File: path, Profile: "staging"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reloads := make(chan *Config, 10)
+	l.OnReload(func(c *Config) { reloads <- c })
+	l.Watch()
+
+	// Writes may arrive as several events, so wait for the reload that shows the edit
+	waitFor := func(what string, applied func(c *Config) bool) {
+		t.Helper()
+		timeout := time.After(5 * time.Second)
+		for {
+			select {
+			case c := <-reloads:
+				if applied(c) {
+					return
+				}
+			case <-timeout:
+				t.Fatalf("no reload after %s; running with %+v", what, l.Config().Log)
+			}
+		}
+	}
+
+	writeFile(t, dir, "config.staging.yaml", "log:\n  level: \"error\"\n")
+	waitFor("editing the profile's file", func(c *Config) bool { return c.Log.Level == "error" })
+
+	writeFile(t, dir, "config.yaml", serverConfig("    allow_credentials: true\n"))
+	waitFor("editing the config file", func(c *Config) bool {
+		return c.Server.CORS.AllowCredentials && c.Log.Level == "error"
+	})
+
+	// Replacing the file, as editors and ConfigMap updates do, is followed too
+	tmp := writeFile(t, dir, "config.staging.yaml.tmp", "log:\n  level: \"debug\"\n")
+	if err := os.Rename(tmp, filepath.Join(dir, "config.staging.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	waitFor("replacing the profile's file", func(c *Config) bool { return c.Log.Level == "debug" })
+
+	// Other files in the directory are not configuration
+	writeFile(t, dir, "notes.txt", "hello")
+	select {
+	case c := <-reloads:
+		t.Fatalf("unrelated file caused a reload to %+v", c.Log)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
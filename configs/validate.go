@@ -0,0 +1,264 @@
+package configs
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// exampleJWTSecret is the placeholder secret config.yaml used to ship with. It is public, so it
+// is refused in every profile.
+const exampleJWTSecret = "change-me-to-a-random-secret-of-at-least-32-bytes"
+
+// ValidationError lists every problem found in a configuration, so they can all be fixed at once
+type ValidationError struct {
+	File     string // Config file the configuration was read from, if any
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	source := "configuration"
+	if e.File != "" {
+		source = "configuration (" + e.File + ")"
+	}
+	return fmt.Sprintf("invalid %s:\n  - %s", source, strings.Join(e.Problems, "\n  - "))
+}
+
+// problems collects validation failures
+type problems []string
+
+func (p *problems) addf(format string, args ...interface{}) {
+	*p = append(*p, fmt.Sprintf(format, args...))
+}
+
+func (p *problems) required(key, value string) {
+	if strings.TrimSpace(value) == "" {
+		p.addf("%s is required", key)
+	}
+}
+
+func (p *problems) positive(key string, d time.Duration) {
+	if d <= 0 {
+		p.addf("%s must be a positive duration", key)
+	}
+}
+
+func (p *problems) atLeast(key string, value, min int64) {
+	if value < min {
+		p.addf("%s must be at least %d", key, min)
+	}
+}
+
+func (p *problems) oneOf(key, value string, allowed ...string) {
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	p.addf("%s must be one of %s, not %q", key, strings.Join(allowed, ", "), value)
+}
+
+func (p *problems) absoluteURL(key, value string) {
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		p.addf("%s must be an absolute http or https URL", key)
+	}
+}
+
+// Validate checks the configuration and returns a *ValidationError listing every problem
+func (c *Config) Validate() error {
+	var p problems
+
+	p.required("server.port", c.Server.Port)
+	p.positive("server.shutdown_timeout", c.Server.ShutdownTimeout)
+	if c.Server.ReadinessDelay < 0 {
+		p.addf("server.readiness_delay must not be negative")
+	} else if c.Server.ReadinessDelay >= c.Server.ShutdownTimeout {
+		p.addf("server.readiness_delay (%s) must be shorter than server.shutdown_timeout (%s)", c.Server.ReadinessDelay, c.Server.ShutdownTimeout)
+	}
+	if c.Server.ReconnectDelay < 0 {
+		p.addf("server.reconnect_delay must not be negative")
+	}
+	c.Server.CORS.validate(&p)
+	c.Server.RateLimit.validate(&p)
+	c.Log.validate(&p)
+
+	p.required("database.host", c.Database.Host)
+	p.required("database.port", c.Database.Port)
+	p.required("database.user", c.Database.User)
+	p.required("database.dbname", c.Database.DBName)
+	p.oneOf("database.sslmode", c.Database.SSLMode, "disable", "allow", "prefer", "require", "verify-ca", "verify-full")
+
+	if c.Unfurl.Enabled {
+		p.positive("unfurl.timeout", c.Unfurl.Timeout)
+		p.atLeast("unfurl.max_body_bytes", c.Unfurl.MaxBodyBytes, 1)
+	}
+
+	p.required("transfer.dir", c.Transfer.Dir)
+	p.positive("transfer.export_ttl", c.Transfer.ExportTTL)
+	p.positive("transfer.data_export_ttl", c.Transfer.DataExportTTL)
+	p.atLeast("transfer.max_import_bytes", c.Transfer.MaxImportBytes, 1)
+
+	p.atLeast("webhooks.max_attempts", int64(c.Webhooks.MaxAttempts), 1)
+	p.positive("webhooks.timeout", c.Webhooks.Timeout)
+
+	c.Mail.validate(&p)
+
+	p.positive("teams.invitation_ttl", c.Teams.InvitationTTL)
+
+	p.atLeast("audit.batch_size", int64(c.Audit.BatchSize), 1)
+	p.positive("audit.flush_interval", c.Audit.FlushInterval)
+	p.atLeast("audit.queue_size", int64(c.Audit.QueueSize), 1)
+
+	c.JWT.validate(&p)
+	c.OAuth.validate(&p)
+
+	p.positive("accounts.email_verification_ttl", c.Accounts.EmailVerificationTTL)
+	p.positive("accounts.password_reset_ttl", c.Accounts.PasswordResetTTL)
+	p.atLeast("accounts.max_failed_logins", int64(c.Accounts.MaxFailedLogins), 1)
+	p.atLeast("accounts.max_failed_logins_per_ip", int64(c.Accounts.MaxFailedLoginsPerIP), 1)
+	p.positive("accounts.lockout_duration", c.Accounts.LockoutDuration)
+	p.positive("accounts.login_failure_window", c.Accounts.LoginFailureWindow)
+	p.positive("accounts.deletion_grace_period", c.Accounts.DeletionGracePeriod)
+
+	p.atLeast("passwords.min_length", int64(c.Passwords.MinLength), 1)
+	if c.Passwords.MaxLength != 0 && c.Passwords.MaxLength < c.Passwords.MinLength {
+		p.addf("passwords.max_length must be 0 or at least passwords.min_length (%d)", c.Passwords.MinLength)
+	}
+	p.atLeast("passwords.history_size", int64(c.Passwords.HistorySize), 0)
+	p.atLeast("passwords.argon2.iterations", int64(c.Passwords.Argon2.Iterations), 1)
+	p.atLeast("passwords.argon2.parallelism", int64(c.Passwords.Argon2.Parallelism), 1)
+	p.atLeast("passwords.argon2.memory", int64(c.Passwords.Argon2.Memory), 8*int64(c.Passwords.Argon2.Parallelism))
+
+	if c.Profile == "production" {
+		if c.Mail.Driver == "capture" {
+			p.addf("mail.driver must not be \"capture\" in production; captured mail is served without authentication")
+		}
+		if c.Database.SSLMode == "disable" {
+			p.addf("database.sslmode must not be \"disable\" in production")
+		}
+	}
+
+	if len(p) > 0 {
+		return &ValidationError{Problems: p}
+	}
+	return nil
+}
+
+func (c CORSConfig) validate(p *problems) {
+	for i, origin := range c.AllowedOrigins {
+		if origin == "*" {
+			if c.AllowCredentials {
+				p.addf("server.cors.allow_credentials cannot be combined with the \"*\" origin")
+			}
+			continue
+		}
+		u, err := url.Parse(origin)
+		if err != nil || u.Scheme == "" || u.Host == "" || (u.Path != "" && u.Path != "/") {
+			p.addf("server.cors.allowed_origins[%d] must be \"*\" or an origin such as https://app.example.com, not %q", i, origin)
+		}
+	}
+	if c.MaxAge < 0 {
+		p.addf("server.cors.max_age must not be negative")
+	}
+}
+
+func (c RateLimitConfig) validate(p *problems) {
+	if !c.Enabled {
+		return
+	}
+	if c.RequestsPerSecond <= 0 {
+		p.addf("server.rate_limit.requests_per_second must be positive")
+	}
+	p.atLeast("server.rate_limit.burst", int64(c.Burst), 1)
+}
+
+func (c LogConfig) validate(p *problems) {
+	p.oneOf("log.level", c.Level, "debug", "info", "warn", "error")
+}
+
+func (c MailConfig) validate(p *problems) {
+	p.oneOf("mail.driver", c.Driver, "log", "file", "smtp", "capture")
+	p.required("mail.from", c.From)
+	p.absoluteURL("mail.app_url", c.AppURL)
+	p.atLeast("mail.max_attempts", int64(c.MaxAttempts), 1)
+	switch c.Driver {
+	case "file":
+		p.required("mail.dir", c.Dir)
+	case "smtp":
+		p.required("mail.smtp.host", c.SMTP.Host)
+		if c.SMTP.Port < 1 || c.SMTP.Port > 65535 {
+			p.addf("mail.smtp.port must be between 1 and 65535")
+		}
+	}
+}
+
+func (c JWTConfig) validate(p *problems) {
+	p.required("jwt.issuer", c.Issuer)
+	p.required("jwt.audience", c.Audience)
+	p.positive("jwt.access_token_ttl", c.AccessTokenTTL)
+	p.positive("jwt.refresh_token_ttl", c.RefreshTokenTTL)
+	if len(c.Keys) == 0 {
+		p.addf("jwt.keys must list at least one key")
+		return
+	}
+	seen := make(map[string]bool, len(c.Keys))
+	for i, k := range c.Keys {
+		key := fmt.Sprintf("jwt.keys[%d]", i)
+		if k.ID == "" {
+			p.addf("%s.kid is required", key)
+		} else if seen[k.ID] {
+			p.addf("%s.kid %q is configured twice", key, k.ID)
+		}
+		seen[k.ID] = true
+		switch strings.ToUpper(k.Algorithm) {
+		case "HS256":
+			if k.Secret == "" {
+				p.addf("%s.secret is required; set it with %s_JWT_KEYS_%d_SECRET or %s.secret_file", key, EnvPrefix, i, key)
+			} else if len(k.Secret) < 32 {
+				p.addf("%s.secret must be at least 32 bytes", key)
+			} else if k.Secret == exampleJWTSecret {
+				p.addf("%s.secret is the published example secret; generate a new one, e.g. with openssl rand -base64 48", key)
+			}
+		case "RS256", "EDDSA":
+			if k.PrivateKeyFile == "" && k.PublicKeyFile == "" {
+				p.addf("%s needs private_key_file or public_key_file", key)
+			}
+		default:
+			p.addf("%s.algorithm must be one of HS256, RS256, EdDSA, not %q", key, k.Algorithm)
+		}
+	}
+	if c.SigningKey == "" {
+		p.addf("jwt.signing_key is required")
+	} else if !seen[c.SigningKey] {
+		p.addf("jwt.signing_key %q is not one of jwt.keys", c.SigningKey)
+	}
+}
+
+func (c OAuthConfig) validate(p *problems) {
+	p.positive("oauth.state_ttl", c.StateTTL)
+	p.positive("oauth.timeout", c.Timeout)
+	configured := false
+	seen := make(map[string]bool, len(c.Providers))
+	for i, pc := range c.Providers {
+		if pc.ClientID == "" {
+			continue // Skipped, like the unfilled samples in config.yaml
+		}
+		configured = true
+		key := fmt.Sprintf("oauth.providers[%d]", i)
+		if pc.Name == "" {
+			p.addf("%s.name is required", key)
+		} else if seen[pc.Name] {
+			p.addf("%s.name %q is configured twice", key, pc.Name)
+		}
+		seen[pc.Name] = true
+		p.oneOf(key+".type", pc.Type, "oidc", "github")
+		if pc.Type == "oidc" && pc.IssuerURL == "" && (pc.AuthURL == "" || pc.TokenURL == "" || pc.UserInfoURL == "") {
+			p.addf("%s needs issuer_url, or all of auth_url, token_url and userinfo_url", key)
+		}
+	}
+	if configured {
+		p.absoluteURL("oauth.callback_base_url", c.CallbackBaseURL)
+	}
+}
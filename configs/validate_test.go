@@ -0,0 +1,241 @@
+package configs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testJWTSecret = "0123456789abcdef0123456789abcdef-test"
+
+// writeFile writes body to name in dir and returns its path
+func writeFile(t *testing.T, dir, name, body string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// minimalConfig is the smallest config file that passes validation on top of the defaults
+const minimalConfig = `
+database:
+  user: "clipsync"
+jwt:
+  keys:
+    - kid: "default"
+      algorithm: "HS256"
+      secret: "` + testJWTSecret + `"
+`
+
+// loadConfig loads body as the config file, with profile files from profiles next to it
+func loadConfig(t *testing.T, body, profile string, profiles map[string]string) (*Loader, error) {
+	t.Helper()
+	dir := t.TempDir()
+	file := writeFile(t, dir, "config.yaml", body)
+	for name, profileBody := range profiles {
+		writeFile(t, dir, "config."+name+".yaml", profileBody)
+	}
+	return Load(&Options{File: file, Profile: profile})
+}
+
+func validConfig(t *testing.T) *Config {
+	t.Helper()
+	l, err := loadConfig(t, minimalConfig, "", nil)
+	if err != nil {
+		t.Fatalf("minimal config is invalid: %v", err)
+	}
+	cfg := *l.Config()
+	cfg.JWT.Keys = append([]JWTKeyConfig(nil), cfg.JWT.Keys...)
+	return &cfg
+}
+
+// problemsOf validates cfg and returns its problems
+func problemsOf(t *testing.T, cfg *Config) []string {
+	t.Helper()
+	err := cfg.Validate()
+	if err == nil {
+		return nil
+	}
+	var invalid *ValidationError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("Validate() error = %v, want a *ValidationError", err)
+	}
+	return invalid.Problems
+}
+
+func hasProblem(problems []string, substr string) bool {
+	for _, p := range problems {
+		if strings.Contains(p, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestValidateJWTKeys(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+		want   string
+	}{
+		{
+			name:   "example secret without a profile",
+			modify: func(c *Config) { c.JWT.Keys[0].Secret = exampleJWTSecret },
+			want:   "jwt.keys[0].secret is the published example secret",
+		},
+		{
+			name: "example secret in staging",
+			modify: func(c *Config) {
+				c.Profile = "staging"
+				c.JWT.Keys[0].Secret = exampleJWTSecret
+			},
+			want: "jwt.keys[0].secret is the published example secret",
+		},
+		{
+			name: "example secret in production",
+			modify: func(c *Config) {
+				c.Profile = "production"
+				c.JWT.Keys[0].Secret = exampleJWTSecret
+			},
+			want: "jwt.keys[0].secret is the published example secret",
+		},
+		{
+			name:   "missing secret",
+			modify: func(c *Config) { c.JWT.Keys[0].Secret = "" },
+			want:   "jwt.keys[0].secret is required; set it with CLIPSYNC_JWT_KEYS_0_SECRET",
+		},
+		{
+			name:   "short secret",
+			modify: func(c *Config) { c.JWT.Keys[0].Secret = "too-short" },
+			want:   "jwt.keys[0].secret must be at least 32 bytes",
+		},
+		{
+			name: "duplicate kid",
+			modify: func(c *Config) {
+				c.JWT.Keys = append(c.JWT.Keys, JWTKeyConfig{ID: "default", Algorithm: "RS256", PublicKeyFile: "old.pem"})
+			},
+			want: `jwt.keys[1].kid "default" is configured twice`,
+		},
+		{
+			name:   "unknown signing key",
+			modify: func(c *Config) { c.JWT.SigningKey = "next" },
+			want:   `jwt.signing_key "next" is not one of jwt.keys`,
+		},
+		{
+			name:   "no keys",
+			modify: func(c *Config) { c.JWT.Keys = nil },
+			want:   "jwt.keys must list at least one key",
+		},
+		{
+			name:   "unknown algorithm",
+			modify: func(c *Config) { c.JWT.Keys[0].Algorithm = "none" },
+			want:   "jwt.keys[0].algorithm must be one of HS256, RS256, EdDSA",
+		},
+		{
+			name:   "asymmetric key without files",
+			modify: func(c *Config) { c.JWT.Keys[0] = JWTKeyConfig{ID: "default", Algorithm: "EdDSA"} },
+			want:   "jwt.keys[0] needs private_key_file or public_key_file",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig(t)
+			tt.modify(cfg)
+			if problems := problemsOf(t, cfg); !hasProblem(problems, tt.want) {
+				t.Fatalf("problems = %q, want one containing %q", problems, tt.want)
+			}
+		})
+	}
+}
+
+func TestShippedConfigNeedsJWTSecret(t *testing.T) {
+	_, err := Load(&Options{File: "config.yaml"})
+	var invalid *ValidationError
+	if !errors.As(err, &invalid) || !hasProblem(invalid.Problems, "jwt.keys[0].secret is required") {
+		t.Fatalf("Load() of the shipped config error = %v, want the JWT secret reported missing", err)
+	}
+
+	t.Setenv("CLIPSYNC_JWT_KEYS_0_SECRET", testJWTSecret)
+	l, err := Load(&Options{File: "config.yaml"})
+	if err != nil {
+		t.Fatalf("Load() of the shipped config with the secret in the environment error = %v", err)
+	}
+	if got := l.Config().JWT.Keys[0].Secret; got != testJWTSecret {
+		t.Fatalf("secret = %q, want the one from the environment", got)
+	}
+}
+
+func TestJWTKeysFromEnv(t *testing.T) {
+	t.Setenv("CLIPSYNC_JWT_KEYS_0_SECRET", "from-the-environment-0123456789abcdef")
+	t.Setenv("CLIPSYNC_JWT_KEYS_1_KID", "next")
+	t.Setenv("CLIPSYNC_JWT_KEYS_1_ALGORITHM", "HS256")
+	t.Setenv("CLIPSYNC_JWT_KEYS_1_SECRET", "next-from-the-environment-0123456789")
+	t.Setenv("CLIPSYNC_JWT_SIGNING_KEY", "next")
+
+	l, err := loadConfig(t, minimalConfig, "", nil)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	keys := l.Config().JWT.Keys
+	want := []JWTKeyConfig{
+		{ID: "default", Algorithm: "HS256", Secret: "from-the-environment-0123456789abcdef"},
+		{ID: "next", Algorithm: "HS256", Secret: "next-from-the-environment-0123456789"},
+	}
+	if len(keys) != len(want) {
+		t.Fatalf("keys = %+v, want %+v", keys, want)
+	}
+	for i := range want {
+		if keys[i] != want[i] {
+			t.Errorf("keys[%d] = %+v, want %+v", i, keys[i], want[i])
+		}
+	}
+	if l.Config().JWT.SigningKey != "next" {
+		t.Fatalf("signing key = %q, want next", l.Config().JWT.SigningKey)
+	}
+}
+
+func TestJWTSecretFile(t *testing.T) {
+	dir := t.TempDir()
+	secretFile := writeFile(t, dir, "jwt-secret", testJWTSecret+"\n")
+	withFile := func(extra string) string {
+		return `
+database:
+  user: "clipsync"
+jwt:
+  keys:
+    - kid: "default"
+      algorithm: "HS256"
+      secret_file: "` + secretFile + `"
+` + extra
+	}
+
+	l, err := loadConfig(t, withFile(""), "", nil)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := l.Config().JWT.Keys[0].Secret; got != testJWTSecret {
+		t.Fatalf("secret = %q, want the file's contents without the newline", got)
+	}
+
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"both secret and file", withFile(`      secret: "` + testJWTSecret + `"`), "jwt.keys[0] sets both secret and secret_file"},
+		{"missing file", strings.Replace(withFile(""), secretFile, filepath.Join(dir, "missing"), 1), "jwt.keys[0].secret_file:"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadConfig(t, tt.body, "", nil)
+			var invalid *ValidationError
+			if !errors.As(err, &invalid) || !hasProblem(invalid.Problems, tt.want) {
+				t.Fatalf("Load() error = %v, want a problem containing %q", err, tt.want)
+			}
+		})
+	}
+}
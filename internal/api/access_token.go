@@ -0,0 +1,109 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"clipboard-sync-backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AccessTokenHandler struct {
+	tokenService service.AccessTokenService
+}
+
+func NewAccessTokenHandler(tokenService service.AccessTokenService) *AccessTokenHandler {
+	return &AccessTokenHandler{tokenService: tokenService}
+}
+
+type CreateAccessTokenRequest struct {
+	Name      string   `json:"name" binding:"required,max=100"`
+	Scopes    []string `json:"scopes" binding:"required"`
+	ExpiresIn string   `json:"expires_in"` // Duration such as "720h"; empty for no expiry
+}
+
+// accessTokenErrorStatus maps access token service errors to HTTP status codes
+func accessTokenErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrAccessTokenNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrInvalidTokenName), errors.Is(err, service.ErrInvalidTokenScope),
+		errors.Is(err, service.ErrNoTokenScopes), errors.Is(err, service.ErrInvalidTokenExpiry):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrTooManyAccessTokens):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// CreateAccessToken creates a personal access token; the token is only returned here
+func (h *AccessTokenHandler) CreateAccessToken(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req CreateAccessTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var expiresIn time.Duration
+	if req.ExpiresIn != "" {
+		d, err := time.ParseDuration(req.ExpiresIn)
+		if err != nil || d <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid expires_in"})
+			return
+		}
+		expiresIn = d
+	}
+
+	pat, token, err := h.tokenService.CreateToken(userID.(uint), req.Name, req.Scopes, expiresIn, requestInfo(c))
+	if err != nil {
+		c.JSON(accessTokenErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Access token created", "access_token": pat, "token": token})
+}
+
+// ListAccessTokens lists the caller's personal access tokens
+func (h *AccessTokenHandler) ListAccessTokens(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	tokens, err := h.tokenService.ListTokens(userID.(uint))
+	if err != nil {
+		c.JSON(accessTokenErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"access_tokens": tokens})
+}
+
+// RevokeAccessToken revokes one of the caller's personal access tokens
+func (h *AccessTokenHandler) RevokeAccessToken(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	tokenID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.tokenService.RevokeToken(userID.(uint), tokenID, requestInfo(c)); err != nil {
+		c.JSON(accessTokenErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Access token revoked successfully"})
+}
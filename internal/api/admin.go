@@ -0,0 +1,257 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"clipboard-sync-backend/internal/models"
+	"clipboard-sync-backend/internal/repository"
+	"clipboard-sync-backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AdminHandler struct {
+	adminService service.AdminService
+	loginGuard   service.LoginGuard
+}
+
+func NewAdminHandler(adminService service.AdminService, loginGuard service.LoginGuard) *AdminHandler {
+	return &AdminHandler{adminService: adminService, loginGuard: loginGuard}
+}
+
+type AdminReasonRequest struct {
+	Reason string `json:"reason" binding:"required"` // Recorded in the audit log
+}
+
+// adminErrorStatus maps admin service errors to HTTP status codes
+func adminErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrAdminReasonRequired), errors.Is(err, service.ErrInvalidUserStatus),
+		errors.Is(err, service.ErrInvalidStatsWindow):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrAdminSelfAction), errors.Is(err, service.ErrTargetIsAdmin):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrUserSuspended), errors.Is(err, service.ErrUserNotSuspended),
+		errors.Is(err, service.ErrTwoFactorNotEnabled):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// AuditImpersonation records every request made while an administrator impersonates a user,
+// whatever the handler does. It must run after auth.AuthMiddleware.
+func AuditImpersonation(auditService service.AuditService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if _, ok := c.Get("impersonatorID"); !ok {
+			return
+		}
+		userID := c.MustGet("userID").(uint)
+		auditService.Record(service.AuditEvent{
+			UserID:  &userID,
+			Action:  models.AuditImpersonatedRequest,
+			Details: map[string]interface{}{"status": c.Writer.Status(), "session_id": c.GetUint("sessionID")},
+			Request: requestInfo(c),
+		})
+	}
+}
+
+// SearchUsers pages through users, filtered by ?q= (email, username or display name) and
+// ?status= (active, suspended or admin)
+func (h *AdminHandler) SearchUsers(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+	filter := repository.UserSearchFilter{Query: c.Query("q"), Status: c.Query("status")}
+
+	users, total, err := h.adminService.SearchUsers(filter, limit, offset)
+	if err != nil {
+		c.JSON(adminErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"users": users, "total": total, "limit": limit, "offset": offset})
+}
+
+// GetUser shows a user with their active sessions
+func (h *AdminHandler) GetUser(c *gin.Context) {
+	userID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	user, err := h.adminService.GetUser(userID)
+	if err != nil {
+		c.JSON(adminErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"user": user})
+}
+
+// SuspendUser disables a user's account and signs them out everywhere
+func (h *AdminHandler) SuspendUser(c *gin.Context) {
+	adminID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	userID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	var req AdminReasonRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.adminService.SuspendUser(adminID.(uint), userID, req.Reason, requestInfo(c))
+	if err != nil {
+		c.JSON(adminErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "User suspended", "user": user})
+}
+
+// UnsuspendUser lifts a user's suspension
+func (h *AdminHandler) UnsuspendUser(c *gin.Context) {
+	adminID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	userID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	user, err := h.adminService.UnsuspendUser(adminID.(uint), userID, requestInfo(c))
+	if err != nil {
+		c.JSON(adminErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Suspension lifted", "user": user})
+}
+
+// ForceLogout signs a user out of every session and closes their live connections
+func (h *AdminHandler) ForceLogout(c *gin.Context) {
+	adminID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	userID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.adminService.ForceLogout(adminID.(uint), userID, requestInfo(c)); err != nil {
+		c.JSON(adminErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "User signed out everywhere"})
+}
+
+// ResetTwoFactor turns off a user's two-factor authentication
+func (h *AdminHandler) ResetTwoFactor(c *gin.Context) {
+	adminID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	userID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.adminService.ResetTwoFactor(adminID.(uint), userID, requestInfo(c)); err != nil {
+		c.JSON(adminErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication reset"})
+}
+
+// Impersonate issues a short-lived access token for acting as a user; a reason is required
+func (h *AdminHandler) Impersonate(c *gin.Context) {
+	adminID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	userID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	var req AdminReasonRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	impersonation, err := h.adminService.Impersonate(adminID.(uint), userID, req.Reason, requestInfo(c))
+	if err != nil {
+		c.JSON(adminErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Impersonation started; every request is audited", "impersonation": impersonation})
+}
+
+// Stats reports server-wide usage; ?window= (e.g. "168h", default 30 days) sets the period
+// recent counts cover
+func (h *AdminHandler) Stats(c *gin.Context) {
+	var window time.Duration
+	if w := c.Query("window"); w != "" {
+		d, err := time.ParseDuration(w)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid window"})
+			return
+		}
+		window = d
+	}
+
+	stats, err := h.adminService.Stats(window)
+	if err != nil {
+		c.JSON(adminErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"stats": stats})
+}
+
+// UnlockUser lifts a sign-in lockout on a user's account
+func (h *AdminHandler) UnlockUser(c *gin.Context) {
+	adminID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	userID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.loginGuard.Unlock(adminID.(uint), userID, requestInfo(c)); err != nil {
+		c.JSON(adminErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Account unlocked successfully"})
+}
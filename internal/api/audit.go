@@ -0,0 +1,140 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"clipboard-sync-backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuditHandler struct {
+	auditService service.AuditService
+}
+
+func NewAuditHandler(auditService service.AuditService) *AuditHandler {
+	return &AuditHandler{auditService: auditService}
+}
+
+// requestInfo describes the client behind a request for the audit log
+func requestInfo(c *gin.Context) *service.RequestInfo {
+	info := &service.RequestInfo{
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		Method:    c.Request.Method,
+		Path:      c.FullPath(),
+	}
+	if impersonatorID, ok := c.Get("impersonatorID"); ok {
+		id := impersonatorID.(uint)
+		info.ImpersonatorID = &id
+	}
+	return info
+}
+
+// auditErrorStatus maps audit service errors to HTTP status codes
+func auditErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrNotTeamMember), errors.Is(err, service.ErrTeamPermission):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrUnsupportedAuditFormat):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// parseAuditQuery reads ?team_id=, ?action= (comma-separated), ?since= and ?until= (RFC 3339)
+func parseAuditQuery(c *gin.Context) (service.AuditQuery, bool) {
+	var query service.AuditQuery
+	if raw := c.Query("team_id"); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid team_id"})
+			return query, false
+		}
+		teamID := uint(id)
+		query.TeamID = &teamID
+	}
+	for _, action := range strings.Split(c.Query("action"), ",") {
+		if action = strings.TrimSpace(action); action != "" {
+			query.Actions = append(query.Actions, action)
+		}
+	}
+	for name, dst := range map[string]**time.Time{"since": &query.Since, "until": &query.Until} {
+		raw := c.Query(name)
+		if raw == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name + ", expected an RFC 3339 timestamp"})
+			return query, false
+		}
+		*dst = &t
+	}
+	return query, true
+}
+
+// ListAudit lists the caller's own audited actions, or a team's with ?team_id= (team admins only)
+func (h *AuditHandler) ListAudit(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	query, ok := parseAuditQuery(c)
+	if !ok {
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 {
+		limit = 20
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	logs, err := h.auditService.List(userID.(uint), query, limit, offset)
+	if err != nil {
+		c.JSON(auditErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"logs": logs})
+}
+
+// ExportAudit downloads the audit records ListAudit would return, as ?format=json (default) or csv
+func (h *AuditHandler) ExportAudit(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	query, ok := parseAuditQuery(c)
+	if !ok {
+		return
+	}
+
+	format := strings.ToLower(c.DefaultQuery("format", service.AuditFormatJSON))
+	contentType := "application/json"
+	if format == service.AuditFormatCSV {
+		contentType = "text/csv"
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="audit-log-%s.%s"`, time.Now().UTC().Format("20060102"), format))
+
+	if _, err := h.auditService.Export(userID.(uint), query, format, c.Writer); err != nil {
+		// Errors before the first byte are reported normally; later ones can only cut the download short
+		if !c.Writer.Written() {
+			c.Header("Content-Disposition", "")
+			c.JSON(auditErrorStatus(err), gin.H{"error": err.Error()})
+		}
+	}
+}
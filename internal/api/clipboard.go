@@ -1,9 +1,13 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 
+	"clipboard-sync-backend/internal/clipformat"
+	"clipboard-sync-backend/internal/models"
 	"clipboard-sync-backend/internal/service"
 
 	"github.com/gin-gonic/gin"
@@ -17,12 +21,32 @@ func NewClipboardHandler(clipboardService service.ClipboardService) *ClipboardHa
 	return &ClipboardHandler{clipboardService: clipboardService}
 }
 
+// FormatRequest is one typed representation of a clipboard entry
+type FormatRequest struct {
+	MIMEType string `json:"mime_type" binding:"required"`
+	Content  string `json:"content" binding:"required"`
+}
+
+// CreateEntryRequest accepts either a single content_type/content pair or a list of formats
 type CreateEntryRequest struct {
-	ContentType string `json:"content_type" binding:"required"`
-	Content     string `json:"content" binding:"required"`
+	ContentType string `json:"content_type"`
+	Content     string `json:"content"`
+	Formats     []FormatRequest `json:"formats" binding:"dive"`
 	SourceDevice string `json:"source_device"`
 }
 
+// Representations converts the request into the list of representations to store
+func (r *CreateEntryRequest) Representations() []clipformat.Representation {
+	var reps []clipformat.Representation
+	if r.ContentType != "" && r.Content != "" {
+		reps = append(reps, clipformat.Representation{MIMEType: r.ContentType, Content: r.Content})
+	}
+	for _, f := range r.Formats {
+		reps = append(reps, clipformat.Representation{MIMEType: f.MIMEType, Content: f.Content})
+	}
+	return reps
+}
+
 // CreateClipboardEntry handles creating a new clipboard entry
 func (h *ClipboardHandler) CreateClipboardEntry(c *gin.Context) {
 	userID, exists := c.Get("userID")
@@ -37,13 +61,16 @@ func (h *ClipboardHandler) CreateClipboardEntry(c *gin.Context) {
 		return
 	}
 
-	entry, err := h.clipboardService.CreateClipboardEntry(
+	entry, err := h.clipboardService.CreateClipboardEntryWithFormats(
 		userID.(uint),
-		req.ContentType,
-		req.Content,
+		req.Representations(),
 		req.SourceDevice,
 	)
 	if err != nil {
+		if service.IsClipboardValidationError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -77,7 +104,138 @@ func (h *ClipboardHandler) GetClipboardHistory(c *gin.Context) {
 		return
 	}
 
+	// Clients list the representations they can handle, e.g. ?formats=text/html,text/plain
+	if accept := clipformat.ParseAccept(c.Query("formats")); len(accept) > 0 {
+		for i := range entries {
+			entries[i] = service.SelectRepresentations(entries[i], accept)
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{"message": "Clipboard history retrieved successfully", "entries": entries})
 }
 
 
+
+// DeleteClipboardEntry handles deleting one of the user's clipboard entries
+func (h *ClipboardHandler) DeleteClipboardEntry(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	entryID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid entry ID"})
+		return
+	}
+
+	if err := h.clipboardService.DeleteClipboardEntry(userID.(uint), uint(entryID)); err != nil {
+		if errors.Is(err, service.ErrEntryNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, service.ErrTeamPermission) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Clipboard entry deleted successfully"})
+}
+
+// teamClipboardError writes the response for a failed team clipboard request
+func teamClipboardError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrNotTeamMember), errors.Is(err, service.ErrTeamPermission):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case service.IsClipboardValidationError(err):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// CreateTeamClipboardEntry posts an entry to a team's shared clipboard
+func (h *ClipboardHandler) CreateTeamClipboardEntry(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	teamID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req CreateEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entry, err := h.clipboardService.CreateTeamClipboardEntry(userID.(uint), teamID, req.Representations(), req.SourceDevice)
+	if err != nil {
+		teamClipboardError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Team clipboard entry created successfully", "entry": entry})
+}
+
+// GetTeamClipboardHistory returns a team's shared clipboard history
+func (h *ClipboardHandler) GetTeamClipboardHistory(c *gin.Context) {
+	h.listTeamClipboard(c, false)
+}
+
+// SearchTeamClipboard finds team entries whose text contains ?q=
+func (h *ClipboardHandler) SearchTeamClipboard(c *gin.Context) {
+	h.listTeamClipboard(c, true)
+}
+
+func (h *ClipboardHandler) listTeamClipboard(c *gin.Context, search bool) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	teamID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	query := strings.TrimSpace(c.Query("q"))
+	if search && query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Search query 'q' is required"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 {
+		limit = 20
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	var entries []models.ClipboardEntry
+	if search {
+		entries, err = h.clipboardService.SearchTeamClipboard(userID.(uint), teamID, query, limit, offset)
+	} else {
+		entries, err = h.clipboardService.GetTeamClipboardHistory(userID.(uint), teamID, limit, offset)
+	}
+	if err != nil {
+		teamClipboardError(c, err)
+		return
+	}
+
+	if accept := clipformat.ParseAccept(c.Query("formats")); len(accept) > 0 {
+		for i := range entries {
+			entries[i] = service.SelectRepresentations(entries[i], accept)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Team clipboard history retrieved successfully", "entries": entries})
+}
@@ -0,0 +1,146 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"clipboard-sync-backend/internal/models"
+	"clipboard-sync-backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DataExportHandler struct {
+	dataExportService service.DataExportService
+}
+
+func NewDataExportHandler(dataExportService service.DataExportService) *DataExportHandler {
+	return &DataExportHandler{dataExportService: dataExportService}
+}
+
+type VerifyDataExportRequest struct {
+	Signature string `json:"signature" binding:"required"` // Contents of manifest.sig
+}
+
+// dataExportErrorStatus maps data export service errors to HTTP status codes
+func dataExportErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrDataExportNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrDataExportInProgress), errors.Is(err, service.ErrDataExportNotReady):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrDataExportExpired):
+		return http.StatusGone
+	case errors.Is(err, service.ErrInvalidDataExportSignature):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func dataExportDownloadURL(exportID uint) string {
+	return fmt.Sprintf("/api/v1/me/data-exports/%d/download", exportID)
+}
+
+// RequestDataExport starts gathering a copy of everything we hold about the caller
+func (h *DataExportHandler) RequestDataExport(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	export, err := h.dataExportService.RequestExport(userID.(uint), requestInfo(c))
+	if err != nil {
+		c.JSON(dataExportErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "Data export started; we will email you when it is ready", "export": export})
+}
+
+// ListDataExports lists the caller's data exports
+func (h *DataExportHandler) ListDataExports(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	exports, err := h.dataExportService.ListExports(userID.(uint))
+	if err != nil {
+		c.JSON(dataExportErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"exports": exports})
+}
+
+// GetDataExport reports the status of a data export and its download link once ready
+func (h *DataExportHandler) GetDataExport(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	exportID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	export, err := h.dataExportService.GetExport(userID.(uint), exportID)
+	if err != nil {
+		c.JSON(dataExportErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	resp := gin.H{"export": export}
+	if export.Status == models.JobCompleted && export.FilePath != "" {
+		resp["download_url"] = dataExportDownloadURL(export.ID)
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// DownloadDataExport streams a completed data export archive
+func (h *DataExportHandler) DownloadDataExport(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	exportID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	export, f, err := h.dataExportService.OpenExport(userID.(uint), exportID, requestInfo(c))
+	if err != nil {
+		c.JSON(dataExportErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	defer f.Close()
+
+	filename := fmt.Sprintf("clipboard-sync-data-%s.zip", export.CreatedAt.Format("20060102"))
+	c.DataFromReader(http.StatusOK, export.FileSize, "application/zip", f, map[string]string{
+		"Content-Disposition": fmt.Sprintf(`attachment; filename="%s"`, filename),
+		"X-Content-SHA256":    export.ArchiveSHA256,
+	})
+}
+
+// VerifyDataExport checks the signature shipped in a data export archive
+func (h *DataExportHandler) VerifyDataExport(c *gin.Context) {
+	var req VerifyDataExportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	signature, err := h.dataExportService.VerifySignature(req.Signature)
+	if err != nil {
+		c.JSON(dataExportErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"valid": true, "signature": signature})
+}
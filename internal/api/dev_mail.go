@@ -0,0 +1,40 @@
+package api
+
+import (
+	"net/http"
+
+	"clipboard-sync-backend/internal/mail"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DevMailHandler exposes mail kept by the capture driver. It is only routed when that driver is
+// configured, which is meant for development and tests: the messages contain live links.
+type DevMailHandler struct {
+	capture *mail.CaptureSender
+}
+
+func NewDevMailHandler(capture *mail.CaptureSender) *DevMailHandler {
+	return &DevMailHandler{capture: capture}
+}
+
+// ListMail lists captured messages, optionally only those sent to ?to=
+func (h *DevMailHandler) ListMail(c *gin.Context) {
+	to := c.Query("to")
+	if to != "" {
+		msg, ok := h.capture.Last(to)
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No mail sent to that address"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"messages": []mail.Message{msg}})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"messages": h.capture.Messages()})
+}
+
+// ClearMail forgets captured messages
+func (h *DevMailHandler) ClearMail(c *gin.Context) {
+	h.capture.Reset()
+	c.JSON(http.StatusOK, gin.H{"message": "Captured mail cleared"})
+}
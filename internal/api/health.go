@@ -0,0 +1,52 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Pinger checks that a dependency is reachable (implemented by *sql.DB)
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// HealthHandler serves liveness and readiness probes. Readiness is switched off first when the
+// server shuts down, so load balancers stop sending traffic before connections are closed.
+type HealthHandler struct {
+	db       Pinger
+	draining atomic.Bool
+}
+
+func NewHealthHandler(db Pinger) *HealthHandler {
+	return &HealthHandler{db: db}
+}
+
+// SetDraining makes readiness fail from now on
+func (h *HealthHandler) SetDraining() {
+	h.draining.Store(true)
+}
+
+// Live reports that the process is up
+func (h *HealthHandler) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Ready reports whether the server should receive traffic: it is not shutting down and the
+// database answers
+func (h *HealthHandler) Ready(c *gin.Context) {
+	if h.draining.Load() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "shutting_down"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+	if err := h.db.PingContext(ctx); err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "database_unavailable"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
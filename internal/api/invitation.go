@@ -0,0 +1,234 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"clipboard-sync-backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type InvitationHandler struct {
+	invitationService service.InvitationService
+}
+
+func NewInvitationHandler(invitationService service.InvitationService) *InvitationHandler {
+	return &InvitationHandler{invitationService: invitationService}
+}
+
+type InviteRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	Role  string `json:"role"` // Defaults to "member"
+}
+
+type JoinLinkRequest struct {
+	Role      string `json:"role"`       // Defaults to "member"
+	ExpiresIn string `json:"expires_in"` // Duration such as "72h"; empty for no expiry
+	MaxUses   int    `json:"max_uses"`   // 0 for unlimited
+}
+
+// invitationErrorStatus maps invitation service errors to HTTP status codes
+func invitationErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrInvitationNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrInvitationExpired), errors.Is(err, service.ErrInvitationUsed):
+		return http.StatusGone
+	case errors.Is(err, service.ErrAlreadyTeamMember):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrInvalidMaxUses):
+		return http.StatusBadRequest
+	default:
+		return teamErrorStatus(err)
+	}
+}
+
+// InviteByEmail invites an email address into a team
+func (h *InvitationHandler) InviteByEmail(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	teamID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req InviteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	invitation, err := h.invitationService.InviteByEmail(userID.(uint), teamID, req.Email, req.Role)
+	if err != nil {
+		c.JSON(invitationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Invitation sent", "invitation": invitation})
+}
+
+// CreateJoinLink mints a shareable join link; the URL is only returned here
+func (h *InvitationHandler) CreateJoinLink(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	teamID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req JoinLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil && c.Request.ContentLength > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var expiresIn time.Duration
+	if req.ExpiresIn != "" {
+		d, err := time.ParseDuration(req.ExpiresIn)
+		if err != nil || d < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid expires_in"})
+			return
+		}
+		expiresIn = d
+	}
+
+	invitation, token, err := h.invitationService.CreateJoinLink(userID.(uint), teamID, req.Role, expiresIn, req.MaxUses)
+	if err != nil {
+		c.JSON(invitationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":    "Join link created",
+		"invitation": invitation,
+		"token":      token,
+		"join_url":   h.invitationService.JoinURL(token),
+	})
+}
+
+// ListTeamInvitations lists a team's pending invitations and join links
+func (h *InvitationHandler) ListTeamInvitations(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	teamID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	invitations, err := h.invitationService.ListTeamInvitations(userID.(uint), teamID)
+	if err != nil {
+		c.JSON(invitationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"invitations": invitations})
+}
+
+// RevokeInvitation withdraws an invitation or join link
+func (h *InvitationHandler) RevokeInvitation(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	teamID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	invitationID, ok := parseIDParam(c, "invitationId")
+	if !ok {
+		return
+	}
+
+	if err := h.invitationService.RevokeInvitation(userID.(uint), teamID, invitationID); err != nil {
+		c.JSON(invitationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Invitation revoked"})
+}
+
+// ListMyInvitations lists pending invitations addressed to the caller's email
+func (h *InvitationHandler) ListMyInvitations(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	invitations, err := h.invitationService.ListMyInvitations(userID.(uint))
+	if err != nil {
+		c.JSON(invitationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"invitations": invitations})
+}
+
+// AcceptInvitation joins the team an invitation is for
+func (h *InvitationHandler) AcceptInvitation(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	invitationID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	team, err := h.invitationService.AcceptInvitation(userID.(uint), invitationID)
+	if err != nil {
+		c.JSON(invitationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Invitation accepted", "team": team})
+}
+
+// DeclineInvitation declines an invitation
+func (h *InvitationHandler) DeclineInvitation(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	invitationID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.invitationService.DeclineInvitation(userID.(uint), invitationID); err != nil {
+		c.JSON(invitationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Invitation declined"})
+}
+
+// Join redeems a join link or emailed invitation token
+func (h *InvitationHandler) Join(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	team, err := h.invitationService.JoinWithToken(userID.(uint), c.Param("token"))
+	if err != nil {
+		c.JSON(invitationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Joined team successfully", "team": team})
+}
@@ -0,0 +1,220 @@
+package api
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"clipboard-sync-backend/configs"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CORS answers cross-origin requests from the configured browser origins. Its settings can be
+// replaced while the server runs.
+type CORS struct {
+	cfg atomic.Pointer[configs.CORSConfig]
+}
+
+func NewCORS(cfg configs.CORSConfig) *CORS {
+	m := &CORS{}
+	m.Update(cfg)
+	return m
+}
+
+// Update replaces the allowed origins
+func (m *CORS) Update(cfg configs.CORSConfig) {
+	m.cfg.Store(&cfg)
+}
+
+// matchOrigin reports whether origin is listed and whether any origin is allowed with "*"
+func matchOrigin(cfg *configs.CORSConfig, origin string) (allowed, wildcard bool) {
+	for _, o := range cfg.AllowedOrigins {
+		if o == "*" {
+			wildcard = true
+		}
+		if strings.EqualFold(strings.TrimSuffix(o, "/"), origin) {
+			allowed = true
+		}
+	}
+	return allowed, wildcard
+}
+
+// AllowsOrigin reports whether the current settings allow origin, listed or through "*"
+func (m *CORS) AllowsOrigin(origin string) bool {
+	allowed, wildcard := matchOrigin(m.cfg.Load(), origin)
+	return allowed || wildcard
+}
+
+// Handler adds CORS headers for allowed origins and answers their preflight requests
+func (m *CORS) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+		cfg := m.cfg.Load()
+		allowed, wildcard := matchOrigin(cfg, origin)
+		c.Writer.Header().Add("Vary", "Origin")
+		if !allowed && !wildcard {
+			c.Next()
+			return
+		}
+
+		h := c.Writer.Header()
+		if allowed {
+			h.Set("Access-Control-Allow-Origin", origin)
+			if cfg.AllowCredentials {
+				h.Set("Access-Control-Allow-Credentials", "true")
+			}
+		} else {
+			h.Set("Access-Control-Allow-Origin", "*")
+		}
+		h.Set("Access-Control-Expose-Headers", "Content-Disposition, X-Content-SHA256, Retry-After")
+
+		if c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != "" {
+			h.Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE")
+			if reqHeaders := c.GetHeader("Access-Control-Request-Headers"); reqHeaders != "" {
+				h.Set("Access-Control-Allow-Headers", reqHeaders)
+			}
+			if cfg.MaxAge > 0 {
+				h.Set("Access-Control-Max-Age", strconv.Itoa(int(cfg.MaxAge.Seconds())))
+			}
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+		c.Next()
+	}
+}
+
+// rateLimitIdle is how long a client's bucket is kept after its last request
+const rateLimitIdle = 10 * time.Minute
+
+// RateLimiter limits requests per client address with a token bucket. Its rate can be replaced
+// while the server runs; buckets keep their current fill.
+type RateLimiter struct {
+	cfg atomic.Pointer[configs.RateLimitConfig]
+
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func NewRateLimiter(cfg configs.RateLimitConfig) *RateLimiter {
+	m := &RateLimiter{buckets: make(map[string]*tokenBucket), lastSweep: time.Now()}
+	m.Update(cfg)
+	return m
+}
+
+// Update replaces the rate and burst; disabling the limiter forgets every client
+func (m *RateLimiter) Update(cfg configs.RateLimitConfig) {
+	m.cfg.Store(&cfg)
+	if !cfg.Enabled {
+		m.mu.Lock()
+		m.buckets = make(map[string]*tokenBucket)
+		m.mu.Unlock()
+	}
+}
+
+// allow takes a token from key's bucket. When none is left it returns how long until one is.
+func (m *RateLimiter) allow(key string, now time.Time) (bool, time.Duration) {
+	cfg := m.cfg.Load()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if now.Sub(m.lastSweep) > rateLimitIdle {
+		for k, b := range m.buckets {
+			if now.Sub(b.last) > rateLimitIdle {
+				delete(m.buckets, k)
+			}
+		}
+		m.lastSweep = now
+	}
+
+	burst := float64(cfg.Burst)
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: burst, last: now}
+		m.buckets[key] = b
+	}
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*cfg.RequestsPerSecond)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	wait := time.Duration((1 - b.tokens) / cfg.RequestsPerSecond * float64(time.Second))
+	return false, wait
+}
+
+// Handler rejects clients over their rate with 429 and a Retry-After header
+func (m *RateLimiter) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !m.cfg.Load().Enabled {
+			c.Next()
+			return
+		}
+		ok, wait := m.allow(c.ClientIP(), time.Now())
+		if !ok {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Too many requests; slow down"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequestLogger logs requests according to the configured level: every request at "debug" (with
+// the user agent) and "info", failed ones at "warn" and server errors only at "error". The level
+// can be changed while the server runs.
+type RequestLogger struct {
+	level atomic.Value // string
+}
+
+func NewRequestLogger(level string) *RequestLogger {
+	m := &RequestLogger{}
+	m.SetLevel(level)
+	return m
+}
+
+// SetLevel replaces the log level
+func (m *RequestLogger) SetLevel(level string) {
+	m.level.Store(level)
+}
+
+// Handler logs each request once it has been served
+func (m *RequestLogger) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		level := m.level.Load().(string)
+		switch level {
+		case "warn":
+			if status < http.StatusBadRequest {
+				return
+			}
+		case "error":
+			if status < http.StatusInternalServerError {
+				return
+			}
+		}
+		if level == "debug" {
+			log.Printf("%s %s %d %s %s %q", c.Request.Method, c.Request.URL.Path, status, time.Since(start).Round(time.Microsecond), c.ClientIP(), c.Request.UserAgent())
+			return
+		}
+		log.Printf("%s %s %d %s %s", c.Request.Method, c.Request.URL.Path, status, time.Since(start).Round(time.Microsecond), c.ClientIP())
+	}
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"clipboard-sync-backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OAuthHandler struct {
+	oauthService     service.OAuthService
+	sessionService   service.SessionService
+	twoFactorService service.TwoFactorService
+}
+
+func NewOAuthHandler(oauthService service.OAuthService, sessionService service.SessionService, twoFactorService service.TwoFactorService) *OAuthHandler {
+	return &OAuthHandler{oauthService: oauthService, sessionService: sessionService, twoFactorService: twoFactorService}
+}
+
+// oauthErrorStatus maps social login errors to HTTP status codes
+func oauthErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrUnknownOAuthProvider):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrInvalidOAuthState):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrOAuthEmailUnverified), errors.Is(err, service.ErrAccountSuspended):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrOAuthProviderFailed):
+		return http.StatusBadGateway
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// ListProviders lists the providers users can sign in with
+func (h *OAuthHandler) ListProviders(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"providers": h.oauthService.Providers()})
+}
+
+// StartLogin redirects the user to the provider's sign-in page
+func (h *OAuthHandler) StartLogin(c *gin.Context) {
+	authURL, err := h.oauthService.StartLogin(c.Param("provider"), c.Query("device"))
+	if err != nil {
+		c.JSON(oauthErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// Callback completes the login when the provider redirects back and starts a session, or a
+// two-factor challenge
+func (h *OAuthHandler) Callback(c *gin.Context) {
+	if providerErr := c.Query("error"); providerErr != "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Sign-in was not completed", "provider_error": providerErr, "provider_error_description": c.Query("error_description")})
+		return
+	}
+
+	login, err := h.oauthService.CompleteLogin(c.Param("provider"), c.Query("state"), c.Query("code"), requestInfo(c))
+	if err != nil {
+		c.JSON(oauthErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	startSession(c, h.sessionService, h.twoFactorService, login.User, c.Param("provider"), login.Device, gin.H{"new_user": login.NewUser})
+}
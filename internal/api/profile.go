@@ -0,0 +1,135 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"clipboard-sync-backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ProfileHandler struct {
+	profileService service.ProfileService
+}
+
+func NewProfileHandler(profileService service.ProfileService) *ProfileHandler {
+	return &ProfileHandler{profileService: profileService}
+}
+
+type UpdateProfileRequest struct {
+	Username          *string `json:"username"` // Empty clears it
+	DisplayName       *string `json:"display_name"`
+	ProfilePictureURL *string `json:"profile_picture_url"`
+	PreferredLanguage *string `json:"preferred_language"`
+	Email             *string `json:"email" binding:"omitempty,email"` // Changed once the new address is verified
+}
+
+type DeleteAccountRequest struct {
+	Password string `json:"password"` // Not needed by accounts that only use social login
+}
+
+// profileErrorStatus maps profile service errors to HTTP status codes
+func profileErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrInvalidUsername), errors.Is(err, service.ErrInvalidDisplayName),
+		errors.Is(err, service.ErrInvalidPictureURL), errors.Is(err, service.ErrInvalidLanguage):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrUsernameTaken), errors.Is(err, service.ErrEmailTaken),
+		errors.Is(err, service.ErrDeletionNotScheduled):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrWrongPassword):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// GetProfile returns the caller's profile
+func (h *ProfileHandler) GetProfile(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	user, err := h.profileService.GetProfile(userID.(uint))
+	if err != nil {
+		c.JSON(profileErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"user": user})
+}
+
+// UpdateProfile changes the caller's profile. A new email address is only applied once the
+// verification link sent to it is followed.
+func (h *ProfileHandler) UpdateProfile(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req UpdateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.profileService.UpdateProfile(userID.(uint), service.ProfileUpdate{
+		Username:          req.Username,
+		DisplayName:       req.DisplayName,
+		ProfilePictureURL: req.ProfilePictureURL,
+		PreferredLanguage: req.PreferredLanguage,
+		Email:             req.Email,
+	}, requestInfo(c))
+	if err != nil {
+		c.JSON(profileErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Profile updated successfully", "user": user})
+}
+
+// DeleteAccount schedules the caller's account for deletion after the grace period
+func (h *ProfileHandler) DeleteAccount(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil && c.Request.ContentLength > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.profileService.ScheduleDeletion(userID.(uint), req.Password, requestInfo(c))
+	if err != nil {
+		c.JSON(profileErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "Account scheduled for deletion", "deletion_scheduled_at": user.DeletionScheduledAt})
+}
+
+// RestoreAccount cancels the scheduled deletion of the caller's account
+func (h *ProfileHandler) RestoreAccount(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	user, err := h.profileService.CancelDeletion(userID.(uint), requestInfo(c))
+	if err != nil {
+		c.JSON(profileErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Account deletion cancelled", "user": user})
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+
+	"clipboard-sync-backend/internal/service"
+	"clipboard-sync-backend/internal/settings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxSettingsPatchBytes bounds the size of a settings patch
+const maxSettingsPatchBytes = 64 << 10
+
+type SettingsHandler struct {
+	settingsService service.SettingsService
+}
+
+func NewSettingsHandler(settingsService service.SettingsService) *SettingsHandler {
+	return &SettingsHandler{settingsService: settingsService}
+}
+
+// settingsErrorStatus maps settings service errors to HTTP status codes
+func settingsErrorStatus(err error) int {
+	var invalid *settings.ValidationError
+	switch {
+	case errors.As(err, &invalid):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, service.ErrInvalidSettingsPatch):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrSettingsBusy):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// GetSettings returns the caller's settings, with defaults filled in for those never changed
+func (h *SettingsHandler) GetSettings(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	view, err := h.settingsService.GetSettings(userID.(uint))
+	if err != nil {
+		c.JSON(settingsErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, view)
+}
+
+// UpdateSettings applies a JSON merge patch to the caller's settings. The body is sent as
+// application/merge-patch+json (or application/json); setting a member to null resets it to its
+// default. Schema violations are reported together with 422.
+func (h *SettingsHandler) UpdateSettings(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	if ct := c.GetHeader("Content-Type"); ct != "" {
+		mediaType, _, err := mime.ParseMediaType(ct)
+		if err != nil || (mediaType != "application/merge-patch+json" && mediaType != "application/json") {
+			c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Settings must be sent as application/merge-patch+json"})
+			return
+		}
+	}
+
+	var patch map[string]interface{}
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxSettingsPatchBytes)
+	if err := json.NewDecoder(body).Decode(&patch); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Settings patch is too large"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": service.ErrInvalidSettingsPatch.Error()})
+		return
+	}
+
+	view, err := h.settingsService.UpdateSettings(userID.(uint), patch, requestInfo(c))
+	if err != nil {
+		var invalid *settings.ValidationError
+		if errors.As(err, &invalid) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Settings do not match the schema", "problems": invalid.Problems})
+			return
+		}
+		c.JSON(settingsErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, view)
+}
+
+// GetSettingsSchema returns the JSON Schema settings are validated against
+func (h *SettingsHandler) GetSettingsSchema(c *gin.Context) {
+	c.Data(http.StatusOK, "application/schema+json", settings.SchemaJSON())
+}
@@ -0,0 +1,383 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"clipboard-sync-backend/internal/repository"
+	"clipboard-sync-backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SnippetHandler struct {
+	snippetService service.SnippetService
+}
+
+func NewSnippetHandler(snippetService service.SnippetService) *SnippetHandler {
+	return &SnippetHandler{snippetService: snippetService}
+}
+
+type CreateSnippetRequest struct {
+	Name         string   `json:"name" binding:"required,max=255"`
+	Body         string   `json:"body" binding:"required"`
+	Abbreviation string   `json:"abbreviation" binding:"max=50"`
+	FolderID     *uint    `json:"folder_id"`
+	TeamID       *uint    `json:"team_id"` // Share with a team the user belongs to
+	Tags         []string `json:"tags"`
+}
+
+type UpdateSnippetRequest struct {
+	Name         *string  `json:"name" binding:"omitempty,min=1,max=255"`
+	Body         *string  `json:"body" binding:"omitempty,min=1"`
+	Abbreviation *string  `json:"abbreviation" binding:"omitempty,max=50"`
+	FolderID     *uint    `json:"folder_id"`
+	ClearFolder  bool     `json:"clear_folder"`
+	TeamID       *uint    `json:"team_id"`
+	Unshare      bool     `json:"unshare"`
+	Tags         []string `json:"tags"`
+}
+
+type ExpandSnippetRequest struct {
+	Variables map[string]string `json:"variables"`
+	Timezone  string            `json:"timezone"` // IANA name, e.g. "Europe/Berlin"
+	Device    string            `json:"device"`   // Push target; all devices when empty
+}
+
+type SnippetFolderRequest struct {
+	Name       *string `json:"name" binding:"omitempty,min=1,max=100"`
+	ParentID   *uint   `json:"parent_id"`
+	MoveToRoot bool    `json:"move_to_root"`
+}
+
+// snippetErrorStatus maps snippet service errors to HTTP status codes
+func snippetErrorStatus(err error) int {
+	var missing *service.MissingVariablesError
+	switch {
+	case errors.Is(err, service.ErrSnippetNotFound), errors.Is(err, service.ErrSnippetFolderNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrSnippetReadOnly), errors.Is(err, service.ErrNotTeamMember), errors.Is(err, service.ErrTeamPermission):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrAbbreviationTaken), errors.Is(err, service.ErrDeviceNotConnected), errors.Is(err, service.ErrFolderCycle):
+		return http.StatusConflict
+	case errors.As(err, &missing), errors.Is(err, service.ErrInvalidTimezone), service.IsClipboardValidationError(err):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// snippetError writes an error response, listing missing placeholders when relevant
+func snippetError(c *gin.Context, err error) {
+	resp := gin.H{"error": err.Error()}
+	var missing *service.MissingVariablesError
+	if errors.As(err, &missing) {
+		resp["missing"] = missing.Names
+	}
+	c.JSON(snippetErrorStatus(err), resp)
+}
+
+// CreateSnippet adds a snippet to the user's library
+func (h *SnippetHandler) CreateSnippet(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req CreateSnippetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	snippet, err := h.snippetService.CreateSnippet(userID.(uint), service.SnippetInput{
+		Name:         req.Name,
+		Body:         req.Body,
+		Abbreviation: req.Abbreviation,
+		FolderID:     req.FolderID,
+		TeamID:       req.TeamID,
+		Tags:         req.Tags,
+	})
+	if err != nil {
+		snippetError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Snippet created successfully", "snippet": snippet})
+}
+
+// ListSnippets lists snippets, filtered by ?folder_id=, ?tag=, ?q= or ?team_id=
+func (h *SnippetHandler) ListSnippets(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	filter := repository.SnippetFilter{Tag: c.Query("tag"), Query: c.Query("q")}
+	for name, dst := range map[string]**uint{"folder_id": &filter.FolderID, "team_id": &filter.TeamID} {
+		raw := c.Query(name)
+		if raw == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name})
+			return
+		}
+		v := uint(id)
+		*dst = &v
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	snippets, err := h.snippetService.ListSnippets(userID.(uint), filter, limit, offset)
+	if err != nil {
+		snippetError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"snippets": snippets})
+}
+
+// GetSnippet returns a single snippet
+func (h *SnippetHandler) GetSnippet(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	snippetID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	snippet, err := h.snippetService.GetSnippet(userID.(uint), snippetID)
+	if err != nil {
+		snippetError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"snippet": snippet})
+}
+
+// GetSnippetByAbbreviation resolves a keyboard trigger to a snippet
+func (h *SnippetHandler) GetSnippetByAbbreviation(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	snippet, err := h.snippetService.GetSnippetByAbbreviation(userID.(uint), c.Param("abbr"))
+	if err != nil {
+		snippetError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"snippet": snippet})
+}
+
+// UpdateSnippet changes a snippet's fields
+func (h *SnippetHandler) UpdateSnippet(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	snippetID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req UpdateSnippetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	snippet, err := h.snippetService.UpdateSnippet(userID.(uint), snippetID, service.SnippetUpdate{
+		Name:         req.Name,
+		Body:         req.Body,
+		Abbreviation: req.Abbreviation,
+		FolderID:     req.FolderID,
+		ClearFolder:  req.ClearFolder,
+		TeamID:       req.TeamID,
+		Unshare:      req.Unshare,
+		Tags:         req.Tags,
+	})
+	if err != nil {
+		snippetError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Snippet updated successfully", "snippet": snippet})
+}
+
+// DeleteSnippet removes a snippet
+func (h *SnippetHandler) DeleteSnippet(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	snippetID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.snippetService.DeleteSnippet(userID.(uint), snippetID); err != nil {
+		snippetError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Snippet deleted successfully"})
+}
+
+// ExpandSnippet returns a snippet's body with its placeholders filled in
+func (h *SnippetHandler) ExpandSnippet(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	snippetID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req ExpandSnippetRequest
+	if err := c.ShouldBindJSON(&req); err != nil && c.Request.ContentLength > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	text, err := h.snippetService.ExpandSnippet(userID.(uint), snippetID, service.ExpandOptions{Variables: req.Variables, Timezone: req.Timezone})
+	if err != nil {
+		snippetError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"content": text})
+}
+
+// PushSnippet expands a snippet and sends it to a device as a clipboard entry
+func (h *SnippetHandler) PushSnippet(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	snippetID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req ExpandSnippetRequest
+	if err := c.ShouldBindJSON(&req); err != nil && c.Request.ContentLength > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entry, err := h.snippetService.PushSnippet(userID.(uint), snippetID, service.ExpandOptions{Variables: req.Variables, Timezone: req.Timezone}, req.Device)
+	if err != nil {
+		snippetError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Snippet pushed successfully", "entry": entry})
+}
+
+// ListFolders lists the user's snippet folders
+func (h *SnippetHandler) ListFolders(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	folders, err := h.snippetService.ListFolders(userID.(uint))
+	if err != nil {
+		snippetError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"folders": folders})
+}
+
+// CreateFolder creates a snippet folder
+func (h *SnippetHandler) CreateFolder(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req SnippetFolderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Name == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Folder name is required"})
+		return
+	}
+
+	folder, err := h.snippetService.CreateFolder(userID.(uint), *req.Name, req.ParentID)
+	if err != nil {
+		snippetError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Folder created successfully", "folder": folder})
+}
+
+// UpdateFolder renames or moves a snippet folder
+func (h *SnippetHandler) UpdateFolder(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	folderID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req SnippetFolderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	folder, err := h.snippetService.UpdateFolder(userID.(uint), folderID, req.Name, req.ParentID, req.MoveToRoot)
+	if err != nil {
+		snippetError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Folder updated successfully", "folder": folder})
+}
+
+// DeleteFolder deletes a folder; its snippets and subfolders move to the parent
+func (h *SnippetHandler) DeleteFolder(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	folderID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.snippetService.DeleteFolder(userID.(uint), folderID); err != nil {
+		snippetError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Folder deleted successfully"})
+}
@@ -0,0 +1,300 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"clipboard-sync-backend/internal/policy"
+	"clipboard-sync-backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TeamHandler struct {
+	teamService service.TeamService
+}
+
+func NewTeamHandler(teamService service.TeamService) *TeamHandler {
+	return &TeamHandler{teamService: teamService}
+}
+
+type TeamNameRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+type ChangeRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// teamErrorStatus maps team service errors to HTTP status codes
+func teamErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrTeamNotFound), errors.Is(err, service.ErrTeamMemberNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrTeamPermission), errors.Is(err, service.ErrNotTeamMember):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrTeamNameTaken), errors.Is(err, service.ErrLastTeamOwner):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrInvalidTeamName), errors.Is(err, service.ErrInvalidTeamRole):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// RequireTeamPermission rejects requests for the team in the :id path parameter unless the
+// caller's role grants perm. Services check again; this stops unauthorized requests before any
+// body is read.
+func RequireTeamPermission(teamService service.TeamService, perm policy.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userID")
+		if !exists {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+			return
+		}
+		teamID, ok := parseIDParam(c, "id")
+		if !ok {
+			c.Abort()
+			return
+		}
+		if err := teamService.Authorize(userID.(uint), teamID, perm); err != nil {
+			c.AbortWithStatusJSON(teamErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.Next()
+	}
+}
+
+// CreateTeam creates a team with the caller as owner
+func (h *TeamHandler) CreateTeam(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req TeamNameRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	team, err := h.teamService.CreateTeam(userID.(uint), req.Name)
+	if err != nil {
+		c.JSON(teamErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Team created successfully", "team": team})
+}
+
+// ListTeams lists the caller's teams and their role in each
+func (h *TeamHandler) ListTeams(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	teams, err := h.teamService.ListMyTeams(userID.(uint))
+	if err != nil {
+		c.JSON(teamErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"teams": teams})
+}
+
+// GetTeam returns a team the caller belongs to
+func (h *TeamHandler) GetTeam(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	teamID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	team, err := h.teamService.GetTeam(userID.(uint), teamID)
+	if err != nil {
+		c.JSON(teamErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"team": team})
+}
+
+// RenameTeam changes a team's name
+func (h *TeamHandler) RenameTeam(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	teamID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req TeamNameRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	team, err := h.teamService.RenameTeam(userID.(uint), teamID, req.Name)
+	if err != nil {
+		c.JSON(teamErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Team renamed successfully", "team": team})
+}
+
+type TwoFactorRequirementRequest struct {
+	Required *bool `json:"required" binding:"required"`
+}
+
+// SetTwoFactorRequirement turns the team's two-factor requirement on or off
+func (h *TeamHandler) SetTwoFactorRequirement(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	teamID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req TwoFactorRequirementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	team, err := h.teamService.SetTwoFactorRequired(userID.(uint), teamID, *req.Required)
+	if err != nil {
+		c.JSON(teamErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor requirement updated", "team": team})
+}
+
+// DeleteTeam deletes a team
+func (h *TeamHandler) DeleteTeam(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	teamID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.teamService.DeleteTeam(userID.(uint), teamID); err != nil {
+		c.JSON(teamErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Team deleted successfully"})
+}
+
+// ListMembers lists a team's members
+func (h *TeamHandler) ListMembers(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	teamID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	members, err := h.teamService.ListMembers(userID.(uint), teamID)
+	if err != nil {
+		c.JSON(teamErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"members": members})
+}
+
+// ChangeMemberRole sets a member's role
+func (h *TeamHandler) ChangeMemberRole(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	teamID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	memberID, ok := parseIDParam(c, "userId")
+	if !ok {
+		return
+	}
+
+	var req ChangeRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.teamService.ChangeMemberRole(userID.(uint), teamID, memberID, req.Role); err != nil {
+		c.JSON(teamErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Member role updated successfully"})
+}
+
+// RemoveMember removes a member from a team
+func (h *TeamHandler) RemoveMember(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	teamID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	memberID, ok := parseIDParam(c, "userId")
+	if !ok {
+		return
+	}
+
+	if err := h.teamService.RemoveMember(userID.(uint), teamID, memberID); err != nil {
+		c.JSON(teamErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Member removed successfully"})
+}
+
+// LeaveTeam removes the caller from a team
+func (h *TeamHandler) LeaveTeam(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	teamID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.teamService.LeaveTeam(userID.(uint), teamID); err != nil {
+		c.JSON(teamErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Left team successfully"})
+}
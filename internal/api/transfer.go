@@ -0,0 +1,189 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"clipboard-sync-backend/internal/models"
+	"clipboard-sync-backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TransferHandler struct {
+	transferService service.TransferService
+}
+
+func NewTransferHandler(transferService service.TransferService) *TransferHandler {
+	return &TransferHandler{transferService: transferService}
+}
+
+type CreateExportRequest struct {
+	Format string `json:"format"` // "json" (default), "csv" or "zip"
+}
+
+// transferErrorStatus maps transfer service errors to HTTP status codes
+func transferErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrTransferJobNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrUnsupportedTransferFormat):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrExportNotReady):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrExportExpired):
+		return http.StatusGone
+	case errors.Is(err, service.ErrImportTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, service.ErrTransferUnavailable):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func exportDownloadURL(jobID uint) string {
+	return fmt.Sprintf("/api/v1/clipboard/exports/%d/download", jobID)
+}
+
+// CreateExport starts an asynchronous export of the user's clipboard history
+func (h *TransferHandler) CreateExport(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req CreateExportRequest
+	if err := c.ShouldBindJSON(&req); err != nil && c.Request.ContentLength > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	job, err := h.transferService.StartExport(userID.(uint), req.Format)
+	if err != nil {
+		c.JSON(transferErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "Export started", "job": job})
+}
+
+// GetExport reports the status of an export job and its download link once ready
+func (h *TransferHandler) GetExport(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	jobID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+		return
+	}
+
+	job, err := h.transferService.GetExportJob(userID.(uint), uint(jobID))
+	if err != nil {
+		c.JSON(transferErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	resp := gin.H{"job": job}
+	if job.Status == models.JobCompleted {
+		resp["download_url"] = exportDownloadURL(job.ID)
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// DownloadExport streams a completed export file
+func (h *TransferHandler) DownloadExport(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	jobID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+		return
+	}
+
+	job, f, err := h.transferService.OpenExport(userID.(uint), uint(jobID))
+	if err != nil {
+		c.JSON(transferErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	defer f.Close()
+
+	contentTypes := map[string]string{
+		service.TransferFormatJSON: "application/json",
+		service.TransferFormatCSV:  "text/csv",
+		service.TransferFormatZIP:  "application/zip",
+	}
+	filename := fmt.Sprintf("clipboard-history-%s.%s", job.CreatedAt.Format("20060102"), job.Format)
+	c.DataFromReader(http.StatusOK, job.FileSize, contentTypes[job.Format], f, map[string]string{
+		"Content-Disposition": fmt.Sprintf(`attachment; filename="%s"`, filename),
+	})
+}
+
+// CreateImport accepts an uploaded export file ("file" form field) and imports it asynchronously
+func (h *TransferHandler) CreateImport(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "An export file is required in the 'file' field"})
+		return
+	}
+
+	// The format defaults to the file extension
+	format := c.PostForm("format")
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(fileHeader.Filename)), ".")
+	}
+
+	src, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read uploaded file"})
+		return
+	}
+	defer src.Close()
+
+	job, err := h.transferService.StartImport(userID.(uint), format, src)
+	if err != nil {
+		c.JSON(transferErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "Import started", "job": job})
+}
+
+// GetImport reports import progress and per-row errors
+func (h *TransferHandler) GetImport(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	jobID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+		return
+	}
+
+	job, err := h.transferService.GetImportJob(userID.(uint), uint(jobID))
+	if err != nil {
+		c.JSON(transferErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"job": job})
+}
@@ -0,0 +1,216 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"clipboard-sync-backend/internal/models"
+	"clipboard-sync-backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TwoFactorHandler struct {
+	twoFactorService service.TwoFactorService
+	sessionService   service.SessionService
+}
+
+func NewTwoFactorHandler(twoFactorService service.TwoFactorService, sessionService service.SessionService) *TwoFactorHandler {
+	return &TwoFactorHandler{twoFactorService: twoFactorService, sessionService: sessionService}
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"` // TOTP code, or a recovery code where accepted
+}
+
+type DisableTwoFactorRequest struct {
+	Password string `json:"password"` // Required unless the account only uses social login
+	Code     string `json:"code" binding:"required"`
+}
+
+type LoginChallengeRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required"`
+}
+
+// twoFactorErrorStatus maps two-factor service errors to HTTP status codes
+func twoFactorErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrTwoFactorAlreadyEnabled), errors.Is(err, service.ErrTwoFactorNotEnabled),
+		errors.Is(err, service.ErrTwoFactorNotPending):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrInvalidTwoFactorCode), errors.Is(err, service.ErrReauthenticationFailed),
+		errors.Is(err, service.ErrInvalidLoginChallenge):
+		return http.StatusUnauthorized
+	case errors.Is(err, service.ErrAccountSuspended):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// startSession finishes a login whose first factor (method) passed. Users with two-factor
+// authentication get a challenge to answer at /login/2fa instead of tokens.
+func startSession(c *gin.Context, sessions service.SessionService, twoFactor service.TwoFactorService, user *models.User, method, device string, extra gin.H) {
+	response := gin.H{"user_id": user.ID}
+	for k, v := range extra {
+		response[k] = v
+	}
+
+	if user.TwoFactorEnabled && twoFactor != nil {
+		challenge, err := twoFactor.CreateLoginChallenge(user.ID, method, device)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start two-factor login"})
+			return
+		}
+		response["message"] = "Two-factor authentication required"
+		response["two_factor_required"] = true
+		response["challenge_token"] = challenge
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	tokens, err := sessions.CreateSession(user.ID, device, requestInfo(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+	response["message"] = "Login successful"
+	response["token"] = tokens.AccessToken
+	response["refresh_token"] = tokens.RefreshToken
+	response["expires_in"] = tokens.ExpiresIn
+	response["session_id"] = tokens.SessionID
+	c.JSON(http.StatusOK, response)
+}
+
+// CompleteLogin answers a login challenge with a TOTP or recovery code and starts the session
+func (h *TwoFactorHandler) CompleteLogin(c *gin.Context) {
+	var req LoginChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.twoFactorService.CompleteLoginChallenge(req.ChallengeToken, req.Code, requestInfo(c))
+	if err != nil {
+		c.JSON(twoFactorErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := h.sessionService.CreateSession(result.UserID, result.Device, requestInfo(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Login successful",
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn,
+		"session_id":    tokens.SessionID,
+		"user_id":       result.UserID,
+	})
+}
+
+// GetStatus reports the caller's two-factor setup
+func (h *TwoFactorHandler) GetStatus(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	status, err := h.twoFactorService.Status(userID.(uint))
+	if err != nil {
+		c.JSON(twoFactorErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"two_factor": status})
+}
+
+// BeginEnrollment generates a secret for the caller's authenticator app
+func (h *TwoFactorHandler) BeginEnrollment(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	enrollment, err := h.twoFactorService.BeginEnrollment(userID.(uint))
+	if err != nil {
+		c.JSON(twoFactorErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Scan the code with your authenticator app, then confirm with a code", "enrollment": enrollment})
+}
+
+// ConfirmEnrollment enables two-factor authentication; the recovery codes are only returned here
+func (h *TwoFactorHandler) ConfirmEnrollment(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	codes, err := h.twoFactorService.ConfirmEnrollment(userID.(uint), req.Code, requestInfo(c))
+	if err != nil {
+		c.JSON(twoFactorErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication enabled", "recovery_codes": codes})
+}
+
+// Disable turns two-factor authentication off after re-authentication
+func (h *TwoFactorHandler) Disable(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req DisableTwoFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.twoFactorService.Disable(userID.(uint), req.Password, req.Code, requestInfo(c)); err != nil {
+		c.JSON(twoFactorErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+// RegenerateRecoveryCodes replaces the caller's recovery codes; the new codes are only returned here
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	codes, err := h.twoFactorService.RegenerateRecoveryCodes(userID.(uint), req.Code, requestInfo(c))
+	if err != nil {
+		c.JSON(twoFactorErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Recovery codes regenerated", "recovery_codes": codes})
+}
@@ -1,25 +1,42 @@
 package api
 
 import (
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
 
-	"clipboard-sync-backend/internal/auth"
 	"clipboard-sync-backend/internal/service"
 
 	"github.com/gin-gonic/gin"
 )
 
 type UserHandler struct {
-	userService service.UserService
+	userService      service.UserService
+	sessionService   service.SessionService
+	twoFactorService service.TwoFactorService
 }
 
-func NewUserHandler(userService service.UserService) *UserHandler {
-	return &UserHandler{userService: userService}
+func NewUserHandler(userService service.UserService, sessionService service.SessionService, twoFactorService service.TwoFactorService) *UserHandler {
+	return &UserHandler{userService: userService, sessionService: sessionService, twoFactorService: twoFactorService}
+}
+
+// userErrorStatus maps user service errors to HTTP status codes
+func userErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrEmailTaken):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrPasswordTooShort), errors.Is(err, service.ErrPasswordTooLong),
+		errors.Is(err, service.ErrPasswordBreached):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
 }
 
 type RegisterRequest struct {
 	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=6"`
+	Password string `json:"password" binding:"required"` // Checked against the password policy
 }
 
 // Register handles user registration
@@ -30,9 +47,9 @@ func (h *UserHandler) Register(c *gin.Context) {
 		return
 	}
 
-	user, err := h.userService.RegisterUser(req.Email, req.Password)
+	user, err := h.userService.RegisterUser(req.Email, req.Password, requestInfo(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(userErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -42,9 +59,10 @@ func (h *UserHandler) Register(c *gin.Context) {
 type LoginRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required"`
+	Device   string `json:"device" binding:"max=255"` // Shown in the session list; defaults to the User-Agent
 }
 
-// Login handles user login
+// Login handles user login and starts a session for the device, or a two-factor challenge
 func (h *UserHandler) Login(c *gin.Context) {
 	var req LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -52,19 +70,23 @@ func (h *UserHandler) Login(c *gin.Context) {
 		return
 	}
 
-	user, err := h.userService.LoginUser(req.Email, req.Password)
+	user, err := h.userService.LoginUser(req.Email, req.Password, requestInfo(c))
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		var throttled *service.LoginThrottledError
+		switch {
+		case errors.As(err, &throttled):
+			retryAfter := int(math.Ceil(throttled.RetryAfter.Seconds()))
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error(), "retry_after": retryAfter})
+		case errors.Is(err, service.ErrInvalidCredentials):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrAccountSuspended):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sign in"})
+		}
 		return
 	}
 
-	token, err := auth.GenerateToken(user.ID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{"message": "Login successful", "token": token, "user_id": user.ID})
+	startSession(c, h.sessionService, h.twoFactorService, user, "password", req.Device, nil)
 }
-
-
@@ -1,56 +1,359 @@
 package auth
 
 import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
 	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
 	"time"
 
+	"clipboard-sync-backend/configs"
+
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// Supported signing algorithms
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+const minHMACSecretLength = 32
+
+var ErrInvalidToken = errors.New("invalid token")
+
 // Claims defines the JWT claims structure
 type Claims struct {
-	UserID uint `json:"user_id"`
+	UserID    uint `json:"user_id"`
+	SessionID uint `json:"sid,omitempty"` // Session the token was issued to; revoking it invalidates the token
+	// ImpersonatorID is the administrator acting as UserID, for impersonation tokens
+	ImpersonatorID uint `json:"imp,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// ActionClaims are carried by single-purpose tokens sent by email, such as password resets. The
+// purpose is part of the audience, so they can never pass as access tokens or as each other.
+type ActionClaims struct {
+	jwt.RegisteredClaims
+}
+
+// DocumentClaims vouch for a document we produced, such as the manifest of a data export, by
+// its SHA-256. The "jti" names the document.
+type DocumentClaims struct {
+	Digest string `json:"sha256"`
 	jwt.RegisteredClaims
 }
 
-// Secret key for JWT signing (should be loaded from config in production)
-var jwtSecret = []byte("your_super_secret_jwt_key") // TODO: Load from config
+// key is a loaded signing or verification key
+type key struct {
+	id      string
+	method  jwt.SigningMethod
+	signKey interface{} // nil for verification-only keys
+	verify  interface{}
+}
+
+// TokenManager issues and verifies access tokens. Every configured key can verify tokens carrying
+// its kid, which lets a new signing key be rolled out while tokens signed with the old one are
+// still in circulation.
+type TokenManager struct {
+	issuer   string
+	audience string
+	ttl      time.Duration
+	signing  *key
+	keys     map[string]*key
+	order    []string // kids in configuration order, for the JWKS document
+}
+
+// NewTokenManager loads the configured keys
+func NewTokenManager(cfg configs.JWTConfig) (*TokenManager, error) {
+	if cfg.AccessTokenTTL <= 0 {
+		cfg.AccessTokenTTL = 15 * time.Minute
+	}
+	m := &TokenManager{
+		issuer:   cfg.Issuer,
+		audience: cfg.Audience,
+		ttl:      cfg.AccessTokenTTL,
+		keys:     make(map[string]*key, len(cfg.Keys)),
+	}
+	for _, kc := range cfg.Keys {
+		k, err := loadKey(kc)
+		if err != nil {
+			return nil, fmt.Errorf("jwt key %q: %w", kc.ID, err)
+		}
+		if _, dup := m.keys[k.id]; dup {
+			return nil, fmt.Errorf("jwt key %q is configured twice", k.id)
+		}
+		m.keys[k.id] = k
+		m.order = append(m.order, k.id)
+	}
+
+	signing, ok := m.keys[cfg.SigningKey]
+	if !ok {
+		return nil, fmt.Errorf("jwt signing key %q is not configured", cfg.SigningKey)
+	}
+	if signing.signKey == nil {
+		return nil, fmt.Errorf("jwt signing key %q has no private key", cfg.SigningKey)
+	}
+	m.signing = signing
+	return m, nil
+}
+
+func loadKey(kc configs.JWTKeyConfig) (*key, error) {
+	if kc.ID == "" {
+		return nil, errors.New("kid is required")
+	}
+	k := &key{id: kc.ID}
+	switch normalizeAlgorithm(kc.Algorithm) {
+	case AlgHS256:
+		if len(kc.Secret) < minHMACSecretLength {
+			return nil, fmt.Errorf("HS256 secret must be at least %d bytes", minHMACSecretLength)
+		}
+		k.method = jwt.SigningMethodHS256
+		k.signKey = []byte(kc.Secret)
+		k.verify = []byte(kc.Secret)
+	case AlgRS256:
+		k.method = jwt.SigningMethodRS256
+		if kc.PrivateKeyFile != "" {
+			b, err := os.ReadFile(kc.PrivateKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			priv, err := jwt.ParseRSAPrivateKeyFromPEM(b)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", kc.PrivateKeyFile, err)
+			}
+			k.signKey, k.verify = priv, &priv.PublicKey
+		}
+		if kc.PublicKeyFile != "" {
+			b, err := os.ReadFile(kc.PublicKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			pub, err := jwt.ParseRSAPublicKeyFromPEM(b)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", kc.PublicKeyFile, err)
+			}
+			k.verify = pub
+		}
+	case AlgEdDSA:
+		k.method = jwt.SigningMethodEdDSA
+		if kc.PrivateKeyFile != "" {
+			b, err := os.ReadFile(kc.PrivateKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			priv, err := jwt.ParseEdPrivateKeyFromPEM(b)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", kc.PrivateKeyFile, err)
+			}
+			k.signKey, k.verify = priv, priv.(crypto.Signer).Public()
+		}
+		if kc.PublicKeyFile != "" {
+			b, err := os.ReadFile(kc.PublicKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			pub, err := jwt.ParseEdPublicKeyFromPEM(b)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", kc.PublicKeyFile, err)
+			}
+			k.verify = pub
+		}
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q, expected HS256, RS256 or EdDSA", kc.Algorithm)
+	}
+	if k.verify == nil {
+		return nil, errors.New("private_key_file or public_key_file is required")
+	}
+	return k, nil
+}
+
+// AccessTokenTTL is the lifetime of issued access tokens
+func (m *TokenManager) AccessTokenTTL() time.Duration {
+	return m.ttl
+}
+
+// GenerateToken generates a new access token for a user's session
+func (m *TokenManager) GenerateToken(userID, sessionID uint) (string, error) {
+	return m.signAccessToken(&Claims{UserID: userID, SessionID: sessionID})
+}
+
+// GenerateImpersonationToken generates an access token that lets an administrator act as a user
+// within an impersonation session
+func (m *TokenManager) GenerateImpersonationToken(userID, sessionID, impersonatorID uint) (string, error) {
+	return m.signAccessToken(&Claims{UserID: userID, SessionID: sessionID, ImpersonatorID: impersonatorID})
+}
+
+// signAccessToken fills in the registered claims of an access token and signs it
+func (m *TokenManager) signAccessToken(claims *Claims) (string, error) {
+	now := time.Now()
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		Issuer:    m.issuer,
+		ExpiresAt: jwt.NewNumericDate(now.Add(m.ttl)),
+		IssuedAt:  jwt.NewNumericDate(now),
+	}
+	if m.audience != "" {
+		claims.Audience = jwt.ClaimStrings{m.audience}
+	}
+
+	token := jwt.NewWithClaims(m.signing.method, claims)
+	token.Header["kid"] = m.signing.id
+	tokenString, err := token.SignedString(m.signing.signKey)
+	if err != nil {
+		return "", errors.New("failed to sign token")
+	}
+	return tokenString, nil
+}
+
+// ParseToken parses and validates an access token against the key named by its kid
+func (m *TokenManager) ParseToken(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	if err := m.parse(tokenString, m.audience, claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// actionAudience is the audience of action tokens for purpose
+func (m *TokenManager) actionAudience(purpose string) string {
+	if m.audience == "" {
+		return purpose
+	}
+	return m.audience + ":" + purpose
+}
 
-// GenerateToken generates a new JWT token for a given user ID
-func GenerateToken(userID uint) (string, error) {
-	expirationTime := time.Now().Add(24 * time.Hour) // Token valid for 24 hours
-	claims := &Claims{
-		UserID: userID,
+// GenerateActionToken signs a token for purpose on behalf of userID. tokenID becomes the "jti",
+// which callers record to make the token single-use.
+func (m *TokenManager) GenerateActionToken(purpose string, userID uint, tokenID string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := &ActionClaims{
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expirationTime),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Issuer:    m.issuer,
+			Subject:   strconv.FormatUint(uint64(userID), 10),
+			Audience:  jwt.ClaimStrings{m.actionAudience(purpose)},
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ID:        tokenID,
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString(jwtSecret)
+	token := jwt.NewWithClaims(m.signing.method, claims)
+	token.Header["kid"] = m.signing.id
+	tokenString, err := token.SignedString(m.signing.signKey)
 	if err != nil {
 		return "", errors.New("failed to sign token")
 	}
 	return tokenString, nil
 }
 
-// ParseToken parses and validates a JWT token
-func ParseToken(tokenString string) (*Claims, error) {
-	claims := &Claims{}
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		return jwtSecret, nil
-	})
+// ParseActionToken validates a token issued for purpose and returns its user and token ID
+func (m *TokenManager) ParseActionToken(tokenString, purpose string) (uint, string, error) {
+	claims := &ActionClaims{}
+	if err := m.parse(tokenString, m.actionAudience(purpose), claims); err != nil {
+		return 0, "", err
+	}
+	userID, err := strconv.ParseUint(claims.Subject, 10, 64)
+	if err != nil || claims.ID == "" {
+		return 0, "", ErrInvalidToken
+	}
+	return uint(userID), claims.ID, nil
+}
+
+// SignDocument signs digest, the hex SHA-256 of a document of the given kind produced for userID.
+// Anyone can check the signature against the JWKS until ttl has passed.
+func (m *TokenManager) SignDocument(kind string, userID uint, documentID, digest string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := &DocumentClaims{
+		Digest: digest,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    m.issuer,
+			Subject:   strconv.FormatUint(uint64(userID), 10),
+			Audience:  jwt.ClaimStrings{m.actionAudience(kind)},
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ID:        documentID,
+		},
+	}
 
+	token := jwt.NewWithClaims(m.signing.method, claims)
+	token.Header["kid"] = m.signing.id
+	tokenString, err := token.SignedString(m.signing.signKey)
 	if err != nil {
+		return "", errors.New("failed to sign document")
+	}
+	return tokenString, nil
+}
+
+// VerifyDocument validates a signature made by SignDocument for kind and returns its claims
+func (m *TokenManager) VerifyDocument(tokenString, kind string) (*DocumentClaims, error) {
+	claims := &DocumentClaims{}
+	if err := m.parse(tokenString, m.actionAudience(kind), claims); err != nil {
 		return nil, err
 	}
+	if claims.Digest == "" || claims.ID == "" {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// parse verifies a token against the key named by its kid and checks its registered claims
+func (m *TokenManager) parse(tokenString, audience string, claims jwt.Claims) error {
+	opts := []jwt.ParserOption{jwt.WithExpirationRequired()}
+	if m.issuer != "" {
+		opts = append(opts, jwt.WithIssuer(m.issuer))
+	}
+	if audience != "" {
+		opts = append(opts, jwt.WithAudience(audience))
+	}
+
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		k, ok := m.keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown key %q", ErrInvalidToken, kid)
+		}
+		// The algorithm is pinned per key so an RS256 public key can never be used as an HMAC secret
+		if token.Method.Alg() != k.method.Alg() {
+			return nil, fmt.Errorf("%w: unexpected signing method %s", ErrInvalidToken, token.Method.Alg())
+		}
+		return k.verify, nil
+	}, opts...)
+
+	if err != nil {
+		return err
+	}
 
 	if !token.Valid {
-		return nil, errors.New("invalid token")
+		return ErrInvalidToken
 	}
 
-	return claims, nil
+	return nil
 }
 
+// publicKeys returns the asymmetric verification keys in configuration order
+func (m *TokenManager) publicKeys() []*key {
+	var keys []*key
+	for _, kid := range m.order {
+		k := m.keys[kid]
+		switch k.verify.(type) {
+		case *rsa.PublicKey, ed25519.PublicKey:
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
 
+// normalizeAlgorithm accepts algorithm names case-insensitively
+func normalizeAlgorithm(alg string) string {
+	for _, known := range []string{AlgHS256, AlgRS256, AlgEdDSA} {
+		if strings.EqualFold(alg, known) {
+			return known
+		}
+	}
+	return alg
+}
@@ -1,14 +1,39 @@
 package auth
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
-// AuthMiddleware authenticates requests using JWT
-func AuthMiddleware() gin.HandlerFunc {
+// PersonalAccessTokenPrefix marks personal access tokens so they can be told apart from JWTs
+const PersonalAccessTokenPrefix = "cspat_"
+
+var ErrInsufficientScope = errors.New("token does not have the required scope")
+
+// SessionChecker reports whether a session is still active (implemented by service.SessionService)
+type SessionChecker interface {
+	SessionActive(sessionID uint) (bool, error)
+}
+
+// AdminChecker reports whether a user is a system administrator (implemented by service.UserService)
+type AdminChecker interface {
+	IsAdmin(userID uint) (bool, error)
+}
+
+// PersonalAccessTokenAuthenticator resolves a personal access token to its user and scopes
+// (implemented by service.AccessTokenService). Unknown, expired and revoked tokens give ErrInvalidToken.
+type PersonalAccessTokenAuthenticator interface {
+	AuthenticatePersonalAccessToken(token, ip string) (uint, []string, error)
+}
+
+// AuthMiddleware authenticates requests using a JWT or a personal access token. JWTs must belong
+// to a session the checker still considers active, so signing out or revoking a device takes
+// effect immediately. Requests made with a personal access token carry its scopes, which
+// RequireScope checks.
+func AuthMiddleware(tokens *TokenManager, sessions SessionChecker, pats PersonalAccessTokenAuthenticator) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -25,17 +50,115 @@ func AuthMiddleware() gin.HandlerFunc {
 		}
 
 		tokenString := parts[1]
-		claims, err := ParseToken(tokenString)
+		if pats != nil && strings.HasPrefix(tokenString, PersonalAccessTokenPrefix) {
+			userID, scopes, err := pats.AuthenticatePersonalAccessToken(tokenString, c.ClientIP())
+			if err != nil {
+				if errors.Is(err, ErrInvalidToken) {
+					c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+				} else {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check access token"})
+				}
+				c.Abort()
+				return
+			}
+			c.Set("userID", userID)
+			c.Set("tokenScopes", scopes)
+			c.Next()
+			return
+		}
+
+		claims, err := tokens.ParseToken(tokenString)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
 			c.Abort()
 			return
 		}
 
-		// Set userID in context for subsequent handlers
+		if sessions != nil {
+			active, err := sessions.SessionActive(claims.SessionID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check session"})
+				c.Abort()
+				return
+			}
+			if !active {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Session has been revoked or has expired"})
+				c.Abort()
+				return
+			}
+		}
+
+		// Set userID and sessionID in context for subsequent handlers
 		c.Set("userID", claims.UserID)
+		c.Set("sessionID", claims.SessionID)
+		if claims.ImpersonatorID != 0 {
+			c.Set("impersonatorID", claims.ImpersonatorID)
+		}
 		c.Next()
 	}
 }
 
+// HasScope reports whether the request may use scope. Signed-in sessions hold every scope;
+// personal access tokens only those they were granted.
+func HasScope(c *gin.Context, scope string) bool {
+	scopes, ok := c.Get("tokenScopes")
+	if !ok {
+		return true
+	}
+	for _, s := range scopes.([]string) {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
 
+// RequireScope rejects requests made with a personal access token lacking scope
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !HasScope(c, scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": ErrInsufficientScope.Error(), "required_scope": scope})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireSession rejects requests made with a personal access token or while an administrator
+// impersonates the user. It guards account management such as sessions and tokens themselves,
+// which scripts and impersonators should never reach.
+func RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := c.Get("tokenScopes"); ok {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "This endpoint requires signing in; access tokens cannot be used"})
+			return
+		}
+		if _, ok := c.Get("impersonatorID"); ok {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "This endpoint cannot be used while impersonating a user"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireAdmin rejects requests from users who are not system administrators. It must run after
+// AuthMiddleware.
+func RequireAdmin(admins AdminChecker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := c.Get("userID")
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+			return
+		}
+		isAdmin, err := admins.IsAdmin(userID.(uint))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions"})
+			return
+		}
+		if !isAdmin {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Administrator access required"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,151 @@
+package clipformat
+
+import (
+	"errors"
+	"mime"
+	"strings"
+)
+
+// Supported MIME types for clipboard representations
+const (
+	MIMEPlainText = "text/plain"
+	MIMEHTML      = "text/html"
+	MIMERTF       = "text/rtf"
+	MIMEURIList   = "text/uri-list" // File lists, one URI per line
+	MIMEPNG       = "image/png"
+	MIMEJPEG      = "image/jpeg"
+	MIMEGIF       = "image/gif"
+	MIMEWebP      = "image/webp"
+)
+
+// MaxRepresentationSize caps the size of a single representation's content in bytes
+const MaxRepresentationSize = 10 << 20
+
+var (
+	ErrUnsupportedMIMEType     = errors.New("unsupported clipboard MIME type")
+	ErrEmptyRepresentation     = errors.New("clipboard representation content is empty")
+	ErrRepresentationTooLarge  = errors.New("clipboard representation exceeds size limit")
+	ErrNoRepresentations       = errors.New("clipboard entry has no representations")
+	ErrDuplicateRepresentation = errors.New("duplicate clipboard representation MIME type")
+)
+
+// allowedMIMETypes is the allowlist of representations the server accepts and relays
+var allowedMIMETypes = map[string]bool{
+	MIMEPlainText: true,
+	MIMEHTML:      true,
+	MIMERTF:       true,
+	MIMEURIList:   true,
+	MIMEPNG:       true,
+	MIMEJPEG:      true,
+	MIMEGIF:       true,
+	MIMEWebP:      true,
+}
+
+// legacyAliases maps the short content types used by older clients to MIME types
+var legacyAliases = map[string]string{
+	"text":            MIMEPlainText,
+	"html":            MIMEHTML,
+	"rtf":             MIMERTF,
+	"application/rtf": MIMERTF,
+	"files":           MIMEURIList,
+	"image":           MIMEPNG,
+}
+
+// Representation is a single typed rendition of a clipboard entry's content
+type Representation struct {
+	MIMEType string
+	Content  string
+}
+
+// NormalizeMIMEType resolves legacy aliases, strips parameters and checks the allowlist
+func NormalizeMIMEType(contentType string) (string, error) {
+	ct := strings.ToLower(strings.TrimSpace(contentType))
+	if alias, ok := legacyAliases[ct]; ok {
+		return alias, nil
+	}
+	if mediaType, _, err := mime.ParseMediaType(ct); err == nil {
+		ct = mediaType
+	}
+	if alias, ok := legacyAliases[ct]; ok {
+		return alias, nil
+	}
+	if !allowedMIMETypes[ct] {
+		return "", ErrUnsupportedMIMEType
+	}
+	return ct, nil
+}
+
+// Prepare validates and normalizes a set of representations. HTML is sanitized and a
+// plain-text fallback is derived when only rich formats were supplied.
+func Prepare(reps []Representation) ([]Representation, error) {
+	if len(reps) == 0 {
+		return nil, ErrNoRepresentations
+	}
+
+	prepared := make([]Representation, 0, len(reps)+1)
+	seen := make(map[string]bool, len(reps))
+	for _, rep := range reps {
+		mimeType, err := NormalizeMIMEType(rep.MIMEType)
+		if err != nil {
+			return nil, err
+		}
+		if seen[mimeType] {
+			return nil, ErrDuplicateRepresentation
+		}
+		if rep.Content == "" {
+			return nil, ErrEmptyRepresentation
+		}
+		if len(rep.Content) > MaxRepresentationSize {
+			return nil, ErrRepresentationTooLarge
+		}
+
+		content := rep.Content
+		if mimeType == MIMEHTML {
+			content = SanitizeHTML(content)
+		}
+		seen[mimeType] = true
+		prepared = append(prepared, Representation{MIMEType: mimeType, Content: content})
+	}
+
+	if !seen[MIMEPlainText] {
+		if text := DerivePlainText(prepared); text != "" {
+			prepared = append(prepared, Representation{MIMEType: MIMEPlainText, Content: text})
+		}
+	}
+	return prepared, nil
+}
+
+// DerivePlainText builds a plain-text fallback from the richest textual representation available
+func DerivePlainText(reps []Representation) string {
+	for _, mimeType := range []string{MIMEHTML, MIMERTF, MIMEURIList} {
+		for _, rep := range reps {
+			if rep.MIMEType != mimeType {
+				continue
+			}
+			var text string
+			switch mimeType {
+			case MIMEHTML:
+				text = HTMLToText(rep.Content)
+			case MIMERTF:
+				text = RTFToText(rep.Content)
+			case MIMEURIList:
+				text = URIListToText(rep.Content)
+			}
+			if text = strings.TrimSpace(text); text != "" {
+				return text
+			}
+		}
+	}
+	return ""
+}
+
+// Primary returns the representation that best describes the entry as a whole:
+// plain text when present, otherwise the first supplied representation.
+func Primary(reps []Representation) Representation {
+	for _, rep := range reps {
+		if rep.MIMEType == MIMEPlainText {
+			return rep
+		}
+	}
+	return reps[0]
+}
@@ -0,0 +1,175 @@
+package clipformat
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeMIMEType(t *testing.T) {
+	tests := []struct {
+		input, want string
+		wantErr     error
+	}{
+		{input: "text/plain", want: MIMEPlainText},
+		{input: " Text/HTML; charset=utf-8 ", want: MIMEHTML},
+		{input: "text", want: MIMEPlainText},
+		{input: "html", want: MIMEHTML},
+		{input: "application/rtf", want: MIMERTF},
+		{input: "application/RTF; charset=ascii", want: MIMERTF},
+		{input: "files", want: MIMEURIList},
+		{input: "image", want: MIMEPNG},
+		{input: "image/webp", want: MIMEWebP},
+		{input: "image/svg+xml", wantErr: ErrUnsupportedMIMEType},
+		{input: "application/javascript", wantErr: ErrUnsupportedMIMEType},
+		{input: "text/*", wantErr: ErrUnsupportedMIMEType},
+		{input: "", wantErr: ErrUnsupportedMIMEType},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeMIMEType(tt.input)
+		if !errors.Is(err, tt.wantErr) || got != tt.want {
+			t.Errorf("NormalizeMIMEType(%q) = %q, %v; want %q, %v", tt.input, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestPrepare(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   []Representation
+		want    []Representation
+		wantErr error
+	}{
+		{
+			name:    "nothing",
+			wantErr: ErrNoRepresentations,
+		},
+		{
+			name:  "plain text only",
+			input: []Representation{{MIMEType: "text", Content: "hello"}},
+			want:  []Representation{{MIMEType: MIMEPlainText, Content: "hello"}},
+		},
+		{
+			name: "html is sanitized and gets a text fallback",
+			input: []Representation{
+				{MIMEType: "text/html; charset=utf-8", Content: `<p onclick="x">Hi <b>there</b></p><script>alert(1)</script>`},
+			},
+			want: []Representation{
+				{MIMEType: MIMEHTML, Content: `<p>Hi <b>there</b></p>`},
+				{MIMEType: MIMEPlainText, Content: "Hi there"},
+			},
+		},
+		{
+			name: "supplied plain text is kept over a derived one",
+			input: []Representation{
+				{MIMEType: MIMEHTML, Content: `<p>rich</p>`},
+				{MIMEType: MIMEPlainText, Content: "plain"},
+			},
+			want: []Representation{
+				{MIMEType: MIMEHTML, Content: `<p>rich</p>`},
+				{MIMEType: MIMEPlainText, Content: "plain"},
+			},
+		},
+		{
+			name:  "rtf gets a text fallback",
+			input: []Representation{{MIMEType: "rtf", Content: `{\rtf1\ansi{\fonttbl\f0 Arial;}\f0 Hello\par World}`}},
+			want: []Representation{
+				{MIMEType: MIMERTF, Content: `{\rtf1\ansi{\fonttbl\f0 Arial;}\f0 Hello\par World}`},
+				{MIMEType: MIMEPlainText, Content: "Hello\nWorld"},
+			},
+		},
+		{
+			name:  "uri list gets a text fallback",
+			input: []Representation{{MIMEType: "files", Content: "# comment\r\nfile:///home/a/x.txt\r\nhttps://example.com/y\r\n"}},
+			want: []Representation{
+				{MIMEType: MIMEURIList, Content: "# comment\r\nfile:///home/a/x.txt\r\nhttps://example.com/y\r\n"},
+				{MIMEType: MIMEPlainText, Content: "/home/a/x.txt\nhttps://example.com/y"},
+			},
+		},
+		{
+			name:  "image alone has no text fallback",
+			input: []Representation{{MIMEType: MIMEPNG, Content: "iVBORw0KGgo="}},
+			want:  []Representation{{MIMEType: MIMEPNG, Content: "iVBORw0KGgo="}},
+		},
+		{
+			name:  "html without text gets no fallback",
+			input: []Representation{{MIMEType: MIMEHTML, Content: `<img src="https://example.com/a.png">`}},
+			want:  []Representation{{MIMEType: MIMEHTML, Content: `<img src="https://example.com/a.png"/>`}},
+		},
+		{
+			name:    "duplicate MIME type",
+			input:   []Representation{{MIMEType: MIMEPlainText, Content: "a"}, {MIMEType: "TEXT/PLAIN", Content: "b"}},
+			wantErr: ErrDuplicateRepresentation,
+		},
+		{
+			name:    "duplicate through an alias",
+			input:   []Representation{{MIMEType: MIMERTF, Content: "a"}, {MIMEType: "application/rtf", Content: "b"}},
+			wantErr: ErrDuplicateRepresentation,
+		},
+		{
+			name:    "unsupported MIME type",
+			input:   []Representation{{MIMEType: MIMEPlainText, Content: "a"}, {MIMEType: "application/x-msdownload", Content: "MZ"}},
+			wantErr: ErrUnsupportedMIMEType,
+		},
+		{
+			name:    "empty content",
+			input:   []Representation{{MIMEType: MIMEPlainText}},
+			wantErr: ErrEmptyRepresentation,
+		},
+		{
+			name:  "content at the size limit",
+			input: []Representation{{MIMEType: MIMEPlainText, Content: strings.Repeat("a", MaxRepresentationSize)}},
+			want:  []Representation{{MIMEType: MIMEPlainText, Content: strings.Repeat("a", MaxRepresentationSize)}},
+		},
+		{
+			name: "content over the size limit",
+			input: []Representation{
+				{MIMEType: MIMEPlainText, Content: "small"},
+				{MIMEType: MIMEHTML, Content: strings.Repeat("a", MaxRepresentationSize+1)},
+			},
+			wantErr: ErrRepresentationTooLarge,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Prepare(tt.input)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Prepare() error = %v, want %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Prepare() returned %d representations, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("representation %d = %+.80v, want %+.80v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDerivePlainTextPrefersHTML(t *testing.T) {
+	reps := []Representation{
+		{MIMEType: MIMEURIList, Content: "https://example.com/list"},
+		{MIMEType: MIMERTF, Content: `{\rtf1 from rtf}`},
+		{MIMEType: MIMEHTML, Content: `<p>from html</p>`},
+	}
+	if got := DerivePlainText(reps); got != "from html" {
+		t.Fatalf("DerivePlainText() = %q, want the HTML's text", got)
+	}
+	// An HTML representation without text falls through to the next format
+	reps[2].Content = `<img src="https://example.com/a.png">`
+	if got := DerivePlainText(reps); got != "from rtf" {
+		t.Fatalf("DerivePlainText() = %q, want the RTF's text", got)
+	}
+}
+
+func TestPrimary(t *testing.T) {
+	reps := []Representation{{MIMEType: MIMEHTML, Content: "<p>a</p>"}, {MIMEType: MIMEPlainText, Content: "a"}}
+	if got := Primary(reps); got.MIMEType != MIMEPlainText {
+		t.Fatalf("Primary() = %s, want plain text", got.MIMEType)
+	}
+	if got := Primary(reps[:1]); got.MIMEType != MIMEHTML {
+		t.Fatalf("Primary() without plain text = %s, want the first representation", got.MIMEType)
+	}
+}
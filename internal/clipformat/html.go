@@ -0,0 +1,155 @@
+package clipformat
+
+import (
+	"bytes"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// allowedElements lists the HTML elements kept by SanitizeHTML; everything else is unwrapped
+var allowedElements = map[atom.Atom]bool{
+	atom.A: true, atom.B: true, atom.Blockquote: true, atom.Br: true, atom.Code: true,
+	atom.Del: true, atom.Div: true, atom.Em: true, atom.H1: true, atom.H2: true,
+	atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true, atom.Hr: true,
+	atom.I: true, atom.Img: true, atom.Li: true, atom.Ol: true, atom.P: true,
+	atom.Pre: true, atom.S: true, atom.Span: true, atom.Strong: true, atom.Sub: true,
+	atom.Sup: true, atom.Table: true, atom.Tbody: true, atom.Td: true, atom.Tfoot: true,
+	atom.Th: true, atom.Thead: true, atom.Tr: true, atom.U: true, atom.Ul: true,
+}
+
+// droppedElements are removed together with their content
+var droppedElements = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Iframe: true, atom.Object: true,
+	atom.Embed: true, atom.Noscript: true, atom.Template: true, atom.Form: true,
+	atom.Title: true, atom.Meta: true, atom.Link: true, atom.Svg: true, atom.Math: true,
+}
+
+// allowedAttributes lists permitted attributes per element
+var allowedAttributes = map[atom.Atom]map[string]bool{
+	atom.A:   {"href": true, "title": true},
+	atom.Img: {"src": true, "alt": true, "title": true, "width": true, "height": true},
+	atom.Td:  {"colspan": true, "rowspan": true},
+	atom.Th:  {"colspan": true, "rowspan": true},
+}
+
+// SanitizeHTML strips scripts, event handlers, unknown elements and unsafe URLs from an HTML fragment
+func SanitizeHTML(input string) string {
+	nodes, err := html.ParseFragment(strings.NewReader(input), &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body})
+	if err != nil {
+		return html.EscapeString(input)
+	}
+
+	var buf bytes.Buffer
+	for _, n := range nodes {
+		for _, clean := range sanitizeNode(n) {
+			if err := html.Render(&buf, clean); err != nil {
+				return html.EscapeString(input)
+			}
+		}
+	}
+	return buf.String()
+}
+
+// sanitizeNode returns the sanitized replacement nodes for n (zero, one or its unwrapped children)
+func sanitizeNode(n *html.Node) []*html.Node {
+	switch n.Type {
+	case html.TextNode:
+		return []*html.Node{{Type: html.TextNode, Data: n.Data}}
+	case html.ElementNode:
+		if droppedElements[n.DataAtom] {
+			return nil
+		}
+		children := sanitizeChildren(n)
+		if !allowedElements[n.DataAtom] {
+			return children
+		}
+		clean := &html.Node{Type: html.ElementNode, Data: n.Data, DataAtom: n.DataAtom}
+		for _, attr := range n.Attr {
+			if attr.Namespace != "" || !allowedAttributes[n.DataAtom][attr.Key] {
+				continue
+			}
+			if (attr.Key == "href" || attr.Key == "src") && !isSafeURL(attr.Val, n.DataAtom == atom.Img) {
+				continue
+			}
+			clean.Attr = append(clean.Attr, html.Attribute{Key: attr.Key, Val: attr.Val})
+		}
+		for _, child := range children {
+			clean.AppendChild(child)
+		}
+		return []*html.Node{clean}
+	case html.DocumentNode:
+		return sanitizeChildren(n)
+	default:
+		// Comments, doctypes and raw nodes are dropped
+		return nil
+	}
+}
+
+func sanitizeChildren(n *html.Node) []*html.Node {
+	var out []*html.Node
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		out = append(out, sanitizeNode(c)...)
+	}
+	return out
+}
+
+// isSafeURL allows http(s) and mailto links, plus inline raster images for img sources
+func isSafeURL(raw string, isImage bool) bool {
+	u := strings.ToLower(strings.TrimSpace(raw))
+	switch {
+	case strings.HasPrefix(u, "http://"), strings.HasPrefix(u, "https://"):
+		return true
+	case strings.HasPrefix(u, "mailto:"):
+		return !isImage
+	case isImage && strings.HasPrefix(u, "data:image/"):
+		return !strings.HasPrefix(u, "data:image/svg")
+	default:
+		return false
+	}
+}
+
+// blockElements cause a line break when converting HTML to plain text
+var blockElements = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Br: true, atom.Li: true, atom.Tr: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true,
+	atom.H6: true, atom.Pre: true, atom.Blockquote: true, atom.Hr: true,
+}
+
+// HTMLToText extracts readable text from an HTML fragment
+func HTMLToText(input string) string {
+	nodes, err := html.ParseFragment(strings.NewReader(input), &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body})
+	if err != nil {
+		return ""
+	}
+
+	var sb strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && droppedElements[n.DataAtom] {
+			return
+		}
+		if n.Type == html.TextNode {
+			sb.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+		if n.Type == html.ElementNode && blockElements[n.DataAtom] {
+			sb.WriteString("\n")
+		}
+	}
+	for _, n := range nodes {
+		walk(n)
+	}
+
+	lines := strings.Split(sb.String(), "\n")
+	out := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			out = append(out, line)
+		}
+	}
+	return strings.Join(out, "\n")
+}
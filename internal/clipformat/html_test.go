@@ -0,0 +1,85 @@
+package clipformat
+
+import "testing"
+
+func TestSanitizeHTML(t *testing.T) {
+	tests := []struct {
+		name, input, want string
+	}{
+		{"allowed markup is kept", `<p>Hello <b>bold</b> <em>world</em></p>`, `<p>Hello <b>bold</b> <em>world</em></p>`},
+		{"script removed with its content", `<p>a</p><script>alert(1)</script><p>b</p>`, `<p>a</p><p>b</p>`},
+		{"style removed with its content", `<style>p{color:red}</style><p>a</p>`, `<p>a</p>`},
+		{"nested script removed", `<div><span>x<script>alert(1)</script></span></div>`, `<div><span>x</span></div>`},
+		{"iframe, object and embed removed", `<iframe src="https://evil.example"></iframe><object data="x"></object><embed src="x">ok`, `ok`},
+		{"form removed", `<form action="https://evil.example"><input name="p"></form>ok`, `ok`},
+		{"unknown elements unwrapped", `<article><section>text</section></article>`, `text`},
+		{"event handlers dropped", `<p onclick="alert(1)" onmouseover="alert(2)">x</p>`, `<p>x</p>`},
+		{"upper-case event handler dropped", `<img src="https://example.com/a.png" ONERROR="alert(1)">`, `<img src="https://example.com/a.png"/>`},
+		{"style and class attributes dropped", `<span style="background:url(javascript:alert(1))" class="c">x</span>`, `<span>x</span>`},
+		{"svg removed", `<svg onload="alert(1)"><script>alert(1)</script><a href="javascript:alert(1)">x</a></svg>ok`, `ok`},
+		{"math removed", `<math><mtext><a href="javascript:alert(1)">x</a></mtext></math>ok`, `ok`},
+		{"comments dropped", `<!-- <script>alert(1)</script> -->ok`, `ok`},
+		{"text is escaped", `a &lt;script&gt; b`, `a &lt;script&gt; b`},
+		{"table attributes kept", `<table><tbody><tr><td colspan="2" onclick="x">c</td></tr></tbody></table>`, `<table><tbody><tr><td colspan="2">c</td></tr></tbody></table>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SanitizeHTML(tt.input); got != tt.want {
+				t.Fatalf("SanitizeHTML(%q) =\n  %q\nwant\n  %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSanitizeHTMLURLs(t *testing.T) {
+	tests := []struct {
+		name, input, want string
+	}{
+		{"https link", `<a href="https://example.com/x">x</a>`, `<a href="https://example.com/x">x</a>`},
+		{"mailto link", `<a href="mailto:a@example.com">x</a>`, `<a href="mailto:a@example.com">x</a>`},
+		{"javascript link", `<a href="javascript:alert(1)">x</a>`, `<a>x</a>`},
+		{"mixed-case javascript", `<a href="JaVaScRiPt:alert(1)">x</a>`, `<a>x</a>`},
+		{"leading whitespace", `<a href="  javascript:alert(1)">x</a>`, `<a>x</a>`},
+		{"tab inside scheme", "<a href=\"java\tscript:alert(1)\">x</a>", `<a>x</a>`},
+		{"newline inside scheme", "<a href=\"java\nscript:alert(1)\">x</a>", `<a>x</a>`},
+		{"entity-encoded scheme", `<a href="&#106;avascript:alert(1)">x</a>`, `<a>x</a>`},
+		{"hex entity with control character", `<a href="&#x01;javascript:alert(1)">x</a>`, `<a>x</a>`},
+		{"vbscript link", `<a href="vbscript:msgbox(1)">x</a>`, `<a>x</a>`},
+		{"relative link", `<a href="/local">x</a>`, `<a>x</a>`},
+		{"data link", `<a href="data:text/html,&lt;script&gt;alert(1)&lt;/script&gt;">x</a>`, `<a>x</a>`},
+		{"data image in a link", `<a href="data:image/png;base64,AAAA">x</a>`, `<a>x</a>`},
+		{"https image", `<img src="https://example.com/a.png" alt="a">`, `<img src="https://example.com/a.png" alt="a"/>`},
+		{"inline png image", `<img src="data:image/png;base64,AAAA">`, `<img src="data:image/png;base64,AAAA"/>`},
+		{"inline svg image", `<img src="data:image/svg+xml;base64,AAAA">`, `<img/>`},
+		{"mixed-case inline svg image", `<img src="DATA:Image/SVG+xml,&lt;svg onload=alert(1)&gt;">`, `<img/>`},
+		{"inline html as image", `<img src="data:text/html,x">`, `<img/>`},
+		{"javascript image", `<img src=" JAVASCRIPT:alert(1)">`, `<img/>`},
+		{"mailto image", `<img src="mailto:a@example.com">`, `<img/>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SanitizeHTML(tt.input); got != tt.want {
+				t.Fatalf("SanitizeHTML(%q) =\n  %q\nwant\n  %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTMLToText(t *testing.T) {
+	tests := []struct {
+		name, input, want string
+	}{
+		{"blocks become lines", `<h1>Title</h1><p>First   paragraph</p><ul><li>one</li><li>two</li></ul>`, "Title\nFirst paragraph\none\ntwo"},
+		{"inline markup joins", `<p>Hello <b>bold</b> world</p>`, "Hello bold world"},
+		{"scripts and styles are not text", `<style>p{}</style><p>a</p><script>alert(1)</script>`, "a"},
+		{"entities decoded", `<p>a &amp; b</p>`, "a & b"},
+		{"line breaks", `a<br>b`, "a\nb"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HTMLToText(tt.input); got != tt.want {
+				t.Fatalf("HTMLToText(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
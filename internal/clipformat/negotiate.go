@@ -0,0 +1,57 @@
+package clipformat
+
+import (
+	"strings"
+)
+
+// ParseAccept splits a comma-separated list of MIME types (e.g. from a query parameter).
+// Wildcards such as "image/*" and "*/*" are kept as-is.
+func ParseAccept(value string) []string {
+	var accept []string
+	for _, part := range strings.Split(value, ",") {
+		part = strings.ToLower(strings.TrimSpace(part))
+		if i := strings.Index(part, ";"); i >= 0 {
+			part = strings.TrimSpace(part[:i])
+		}
+		if part != "" {
+			accept = append(accept, part)
+		}
+	}
+	return accept
+}
+
+// Matches reports whether mimeType satisfies any of the accepted patterns
+func Matches(mimeType string, accept []string) bool {
+	for _, pattern := range accept {
+		if pattern == "*/*" || pattern == mimeType {
+			return true
+		}
+		if strings.HasSuffix(pattern, "/*") && strings.HasPrefix(mimeType, strings.TrimSuffix(pattern, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+// Select filters representations down to those the client accepts, in the order supplied.
+// An empty accept list means everything. Plain text is returned as a last resort so that
+// clients always receive something they can paste.
+func Select(reps []Representation, accept []string) []Representation {
+	if len(accept) == 0 {
+		return reps
+	}
+	var selected []Representation
+	for _, rep := range reps {
+		if Matches(rep.MIMEType, accept) {
+			selected = append(selected, rep)
+		}
+	}
+	if len(selected) == 0 {
+		for _, rep := range reps {
+			if rep.MIMEType == MIMEPlainText {
+				return []Representation{rep}
+			}
+		}
+	}
+	return selected
+}
@@ -0,0 +1,73 @@
+package clipformat
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseAccept(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"", nil},
+		{"text/html", []string{"text/html"}},
+		{" Text/HTML ;q=0.9, image/* , ,*/*;q=0.1", []string{"text/html", "image/*", "*/*"}},
+	}
+	for _, tt := range tests {
+		if got := ParseAccept(tt.input); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseAccept(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		mimeType string
+		accept   []string
+		want     bool
+	}{
+		{MIMEHTML, []string{"text/html"}, true},
+		{MIMEHTML, []string{"text/plain"}, false},
+		{MIMEPNG, []string{"image/*"}, true},
+		{MIMEPlainText, []string{"image/*"}, false},
+		{MIMERTF, []string{"*/*"}, true},
+		{MIMEHTML, []string{"text/htm"}, false},
+		{"imagefoo/png", []string{"image/*"}, false},
+		{MIMEHTML, nil, false},
+	}
+	for _, tt := range tests {
+		if got := Matches(tt.mimeType, tt.accept); got != tt.want {
+			t.Errorf("Matches(%q, %q) = %v, want %v", tt.mimeType, tt.accept, got, tt.want)
+		}
+	}
+}
+
+func TestSelect(t *testing.T) {
+	html := Representation{MIMEType: MIMEHTML, Content: "<p>a</p>"}
+	png := Representation{MIMEType: MIMEPNG, Content: "iVBORw0KGgo="}
+	text := Representation{MIMEType: MIMEPlainText, Content: "a"}
+	all := []Representation{html, png, text}
+
+	tests := []struct {
+		name   string
+		reps   []Representation
+		accept string
+		want   []Representation
+	}{
+		{"no preference gets everything", all, "", all},
+		{"exact type", all, "text/html", []Representation{html}},
+		{"wildcard keeps supplied order", all, "text/*", []Representation{html, text}},
+		{"several patterns", all, "image/png, text/plain", []Representation{png, text}},
+		{"anything", all, "*/*", all},
+		{"nothing acceptable falls back to plain text", all, "application/pdf", []Representation{text}},
+		{"nothing acceptable and no plain text", []Representation{html, png}, "application/pdf", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Select(tt.reps, ParseAccept(tt.accept)); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Select(%q) = %+v, want %+v", tt.accept, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package clipformat
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// rtfDestinations are groups whose content is metadata rather than document text
+var rtfDestinations = map[string]bool{
+	"fonttbl": true, "colortbl": true, "stylesheet": true, "info": true,
+	"pict": true, "header": true, "footer": true, "object": true, "generator": true,
+}
+
+// RTFToText performs a best-effort conversion of an RTF document to plain text
+func RTFToText(input string) string {
+	var sb strings.Builder
+	type group struct{ skip bool }
+	stack := []group{{}}
+	skipping := func() bool { return stack[len(stack)-1].skip }
+
+	for i := 0; i < len(input); i++ {
+		ch := input[i]
+		switch ch {
+		case '{':
+			stack = append(stack, group{skip: skipping()})
+		case '}':
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+		case '\\':
+			if i+1 >= len(input) {
+				continue
+			}
+			next := input[i+1]
+			switch {
+			case next == '\\' || next == '{' || next == '}':
+				if !skipping() {
+					sb.WriteByte(next)
+				}
+				i++
+			case next == '*':
+				stack[len(stack)-1].skip = true
+				i++
+			case next == '\'' && i+3 < len(input):
+				if v, err := strconv.ParseUint(input[i+2:i+4], 16, 8); err == nil && !skipping() {
+					sb.WriteRune(rune(v))
+				}
+				i += 3
+			case isASCIILetter(next):
+				j := i + 1
+				for j < len(input) && isASCIILetter(input[j]) {
+					j++
+				}
+				word := input[i+1 : j]
+				k := j
+				if k < len(input) && input[k] == '-' {
+					k++
+				}
+				for k < len(input) && input[k] >= '0' && input[k] <= '9' {
+					k++
+				}
+				param := input[j:k]
+				if k < len(input) && input[k] == ' ' {
+					k++
+				}
+				i = k - 1
+
+				if rtfDestinations[word] {
+					stack[len(stack)-1].skip = true
+					continue
+				}
+				if skipping() {
+					continue
+				}
+				switch word {
+				case "par", "line":
+					sb.WriteByte('\n')
+				case "tab":
+					sb.WriteByte('\t')
+				case "u":
+					if v, err := strconv.Atoi(param); err == nil {
+						if v < 0 {
+							v += 65536
+						}
+						sb.WriteRune(rune(v))
+						// Skip the single-character ANSI fallback that follows \uN
+						if i+1 < len(input) && input[i+1] != '\\' && input[i+1] != '{' && input[i+1] != '}' {
+							i++
+						}
+					}
+				}
+			default:
+				i++
+			}
+		case '\r', '\n':
+			// Raw line breaks in RTF source are not significant
+		default:
+			if !skipping() {
+				sb.WriteByte(ch)
+			}
+		}
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+func isASCIILetter(b byte) bool {
+	return (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
+
+// URIListToText converts a text/uri-list (RFC 2483) into newline-separated paths or URLs
+func URIListToText(input string) string {
+	var out []string
+	for _, line := range strings.Split(input, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if u, err := url.Parse(line); err == nil && u.Scheme == "file" {
+			line = u.Path
+		}
+		out = append(out, line)
+	}
+	return strings.Join(out, "\n")
+}
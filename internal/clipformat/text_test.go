@@ -0,0 +1,43 @@
+package clipformat
+
+import "testing"
+
+func TestRTFToText(t *testing.T) {
+	tests := []struct {
+		name, input, want string
+	}{
+		{"paragraphs", `{\rtf1\ansi\deff0 {\fonttbl {\f0 Times;}}\f0\fs24 Hello\par World}`, "Hello\nWorld"},
+		{"font and color tables skipped", `{\rtf1{\fonttbl\f0 Arial;}{\colortbl;\red255\green0\blue0;}\cf1 red}`, "red"},
+		{"ignorable destinations skipped", `{\rtf1{\*\generator Riched20;}text}`, "text"},
+		{"escaped braces and backslashes", `{\rtf1 a\{b\}c\\d}`, `a{b}c\d`},
+		{"hex escapes", `{\rtf1 caf\'e9}`, "café"},
+		{"unicode with fallback", "{\\rtf1 \\u8364?5}", "€5"},
+		{"negative unicode", "{\\rtf1 \\u-3913?}", "\uf0b7"},
+		{"tabs", `{\rtf1 a\tab b}`, "a\tb"},
+		{"raw newlines ignored", "{\\rtf1 a\r\nb}", "ab"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RTFToText(tt.input); got != tt.want {
+				t.Fatalf("RTFToText(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestURIListToText(t *testing.T) {
+	tests := []struct {
+		name, input, want string
+	}{
+		{"file URIs become paths", "file:///home/a/x.txt\r\nfile:///tmp/y%20z.png\r\n", "/home/a/x.txt\n/tmp/y z.png"},
+		{"other URIs kept", "https://example.com/a\n", "https://example.com/a"},
+		{"comments and blank lines skipped", "# copied by Files\n\n  https://example.com/b  \n", "https://example.com/b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := URIListToText(tt.input); got != tt.want {
+				t.Fatalf("URIListToText(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
@@ -1,8 +1,10 @@
 package database
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"strings"
 	"sync"
 
 	"clipboard-sync-backend/configs"
@@ -18,34 +20,68 @@ var (
 	once       sync.Once
 )
 
-// InitDB initializes the database connection
+// Models lists every model stored in the database. The migrations create their tables; Verify
+// checks the two agree.
+func Models() []interface{} {
+	return []interface{}{&models.User{}, &models.ClipboardEntry{}, &models.ClipboardRepresentation{}, &models.LinkPreview{}, &models.ExportJob{}, &models.ImportJob{}, &models.WebhookSubscription{}, &models.WebhookDelivery{}, &models.Team{}, &models.TeamMember{}, &models.TeamInvitation{}, &models.SnippetFolder{}, &models.Snippet{}, &models.AuditLog{}, &models.Session{}, &models.RefreshToken{}, &models.PersonalAccessToken{}, &models.UserIdentity{}, &models.OAuthState{}, &models.TwoFactorSecret{}, &models.RecoveryCode{}, &models.LoginChallenge{}, &models.EmailNotification{}, &models.AccountToken{}, &models.LoginThrottle{}, &models.PasswordHistory{}, &models.DataExport{}, &models.UserSettings{}}
+}
+
+// Open connects to the database without touching its schema
+func Open(cfg *configs.Config) (*gorm.DB, error) {
+	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s",
+		cfg.Database.Host,
+		cfg.Database.User,
+		cfg.Database.Password,
+		cfg.Database.DBName,
+		cfg.Database.Port,
+		cfg.Database.SSLMode,
+	)
+	return gorm.Open(postgres.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Info), // Log SQL queries
+	})
+}
+
+// InitDB initializes the database connection and brings its schema up to date. With
+// database.migrate_on_start disabled it only checks that no migration is pending. Either way
+// the server does not start on a schema that lacks anything the models expect.
 func InitDB(cfg *configs.Config) *gorm.DB {
 	once.Do(func() {
-		dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s",
-			cfg.Database.Host,
-			cfg.Database.User,
-			cfg.Database.Password,
-			cfg.Database.DBName,
-			cfg.Database.Port,
-			cfg.Database.SSLMode,
-		)
-
 		var err error
-		dbInstance, err = gorm.Open(postgres.Open(dsn), &gorm.Config{
-			Logger: logger.Default.LogMode(logger.Info), // Log SQL queries
-		})
+		dbInstance, err = Open(cfg)
 		if err != nil {
 			log.Fatalf("Failed to connect to database: %v", err)
 		}
 
 		log.Println("Database connection established.")
 
-		// Auto-migrate models
-		err = dbInstance.AutoMigrate(&models.User{}, &models.ClipboardEntry{}, &models.Team{}, &models.TeamMember{})
+		migrator, err := NewMigrator(dbInstance)
 		if err != nil {
-			log.Fatalf("Failed to auto-migrate database: %v", err)
+			log.Fatal(err)
+		}
+		ctx := context.Background()
+		if !cfg.Database.MigrateOnStart {
+			pending, err := migrator.Pending(ctx)
+			if err != nil {
+				log.Fatalf("Failed to check database migrations: %v", err)
+			}
+			if len(pending) > 0 {
+				log.Fatalf("%v: %d pending migrations; run \"migrate up\" first", ErrPendingMigrations, len(pending))
+			}
+		} else {
+			applied, err := migrator.Up(ctx)
+			if err != nil {
+				log.Fatalf("Failed to migrate database: %v", err)
+			}
+			log.Printf("Database migrations completed (%d applied).", len(applied))
+		}
+
+		missing, err := Verify(dbInstance)
+		if err != nil {
+			log.Fatalf("Failed to check the database schema: %v", err)
+		}
+		if len(missing) > 0 {
+			log.Fatalf("%v: the migrations do not create what the models expect:\n  - %s", ErrSchemaDrift, strings.Join(missing, "\n  - "))
 		}
-		log.Println("Database auto-migration completed.")
 	})
 	return dbInstance
 }
@@ -0,0 +1,241 @@
+package database
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testDSNEnv names the variable holding a PostgreSQL connection string for the tests that need
+// a database, e.g. "host=localhost user=postgres password=postgres dbname=postgres sslmode=disable".
+// Those tests are skipped when it is unset.
+const testDSNEnv = "CLIPSYNC_TEST_DATABASE_DSN"
+
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations, err := Migrations()
+	if err != nil {
+		t.Fatalf("Migrations() error = %v", err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("no embedded migrations")
+	}
+	for i, m := range migrations {
+		if m.Version != int64(i+1) {
+			t.Errorf("migration %d_%s has version %d, want %d; versions must have no gaps", m.Version, m.Name, m.Version, i+1)
+		}
+	}
+}
+
+func TestLoadMigrations(t *testing.T) {
+	file := func(body string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(body)} }
+	tests := []struct {
+		name     string
+		files    fstest.MapFS
+		versions []int64
+		wantErr  string
+	}{
+		{
+			name: "sorted by version",
+			files: fstest.MapFS{
+				"migrations/0010_b.up.sql":   file("SELECT 10"),
+				"migrations/0010_b.down.sql": file("SELECT -10"),
+				"migrations/0002_a.up.sql":   file("SELECT 2"),
+				"migrations/0002_a.down.sql": file("SELECT -2"),
+			},
+			versions: []int64{2, 10},
+		},
+		{
+			name: "missing down",
+			files: fstest.MapFS{
+				"migrations/0001_a.up.sql": file("SELECT 1"),
+			},
+			wantErr: "needs both an up and a down file",
+		},
+		{
+			name: "bad name",
+			files: fstest.MapFS{
+				"migrations/first.sql": file("SELECT 1"),
+			},
+			wantErr: "is not named",
+		},
+		{
+			name: "one version, two names",
+			files: fstest.MapFS{
+				"migrations/0001_a.up.sql":   file("SELECT 1"),
+				"migrations/0001_b.down.sql": file("SELECT -1"),
+			},
+			wantErr: "is named both",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := loadMigrations(tt.files)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			var versions []int64
+			for _, m := range migrations {
+				versions = append(versions, m.Version)
+			}
+			if len(versions) != len(tt.versions) {
+				t.Fatalf("versions = %v, want %v", versions, tt.versions)
+			}
+			for i := range versions {
+				if versions[i] != tt.versions[i] {
+					t.Fatalf("versions = %v, want %v", versions, tt.versions)
+				}
+			}
+		})
+	}
+}
+
+// testDB connects to the test database with a fresh schema first on the search path, dropped
+// when the test ends
+func testDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv(testDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", testDSNEnv)
+	}
+	admin, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	suffix := make([]byte, 6)
+	rand.Read(suffix)
+	schema := "clipsync_test_" + hex.EncodeToString(suffix)
+	if err := admin.Exec("CREATE SCHEMA " + schema).Error; err != nil {
+		t.Fatalf("failed to create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		admin.Exec("DROP SCHEMA " + schema + " CASCADE")
+		if sqlDB, err := admin.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	db, err := gorm.Open(postgres.Open(dsn+" search_path="+schema), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func assertNoDrift(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	missing, err := Verify(db)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if len(missing) > 0 {
+		t.Fatalf("migrated schema is missing what the models expect:\n  - %s", strings.Join(missing, "\n  - "))
+	}
+}
+
+func TestMigrationsMatchModels(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	m, err := NewMigrator(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	applied, err := m.Up(ctx)
+	if err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+	if len(applied) != len(m.migrations) {
+		t.Fatalf("Up() applied %d migrations, want %d", len(applied), len(m.migrations))
+	}
+	assertNoDrift(t, db)
+
+	if applied, err := m.Up(ctx); err != nil || len(applied) != 0 {
+		t.Fatalf("second Up() = %d applied, %v; want nothing to do", len(applied), err)
+	}
+
+	reverted, err := m.Down(ctx, len(m.migrations))
+	if err != nil {
+		t.Fatalf("Down() error = %v", err)
+	}
+	if len(reverted) != len(m.migrations) {
+		t.Fatalf("Down() rolled back %d migrations, want %d", len(reverted), len(m.migrations))
+	}
+	for _, model := range Models() {
+		if db.Migrator().HasTable(model) {
+			t.Errorf("table for %T still exists after rolling everything back", model)
+		}
+	}
+	if _, err := m.Down(ctx, 1); err != ErrNoMigrations {
+		t.Fatalf("Down() on an empty database error = %v, want ErrNoMigrations", err)
+	}
+
+	// Every migration's down must undo its up well enough to apply it again
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("Up() after Down() error = %v", err)
+	}
+	assertNoDrift(t, db)
+}
+
+// A database AutoMigrated before versioned migrations has the baseline tables but no
+// schema_migrations; the later migrations must add everything since
+func TestMigrationsUpgradeAutoMigratedBaseline(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	m, err := NewMigrator(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.Exec(m.migrations[0].Up).Error; err != nil {
+		t.Fatalf("failed to create the baseline schema: %v", err)
+	}
+	if err := db.Exec("INSERT INTO users (email, password) VALUES ('a@example.com', 'x')").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec("INSERT INTO teams (name, creator_id) SELECT 'team', id FROM users").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec("INSERT INTO team_members (team_id, user_id) SELECT teams.id, users.id FROM teams, users").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec("INSERT INTO clipboard_entries (user_id, content_type, content) SELECT id, 'text/plain', 'héllo' FROM users").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+	assertNoDrift(t, db)
+
+	var role string
+	if err := db.Raw("SELECT role FROM team_members").Scan(&role).Error; err != nil || role != "member" {
+		t.Fatalf("baseline member role = %q, %v; want \"member\"", role, err)
+	}
+
+	// Must match the hash the clipboard service gives new entries
+	sum := sha256.Sum256([]byte("text/plain\x00héllo"))
+	var hash string
+	if err := db.Raw("SELECT content_hash FROM clipboard_entries").Scan(&hash).Error; err != nil || hash != hex.EncodeToString(sum[:]) {
+		t.Fatalf("backfilled content_hash = %q, %v; want %x", hash, err, sum)
+	}
+}
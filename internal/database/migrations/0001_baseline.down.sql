@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS "team_members";
+DROP TABLE IF EXISTS "clipboard_entries";
+DROP TABLE IF EXISTS "teams";
+DROP TABLE IF EXISTS "users";
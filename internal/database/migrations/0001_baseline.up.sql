@@ -0,0 +1,53 @@
+-- Baseline: the schema AutoMigrate created before versioned migrations, when only users, teams,
+-- clipboard entries and team members existed. The migrations after it add what AutoMigrate added
+-- since, each guarded with IF NOT EXISTS so a database AutoMigrated by any earlier build gets
+-- exactly what it is missing.
+
+CREATE TABLE IF NOT EXISTS "users" (
+    "id" bigserial,
+    "email" text NOT NULL,
+    "password" text NOT NULL,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "uni_users_email" UNIQUE ("email")
+);
+
+CREATE TABLE IF NOT EXISTS "teams" (
+    "id" bigserial,
+    "name" text NOT NULL,
+    "creator_id" bigint NOT NULL,
+    "created_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_teams_creator" FOREIGN KEY ("creator_id") REFERENCES "users"("id"),
+    CONSTRAINT "uni_teams_name" UNIQUE ("name")
+);
+CREATE INDEX IF NOT EXISTS "idx_teams_deleted_at" ON "teams" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "clipboard_entries" (
+    "id" bigserial,
+    "user_id" bigint NOT NULL,
+    "content_type" varchar(50) NOT NULL,
+    "content" text NOT NULL,
+    "source_device" varchar(255),
+    "is_shared" boolean DEFAULT false,
+    "team_id" bigint,
+    "created_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_clipboard_entries_user" FOREIGN KEY ("user_id") REFERENCES "users"("id"),
+    CONSTRAINT "fk_clipboard_entries_team" FOREIGN KEY ("team_id") REFERENCES "teams"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_clipboard_entries_deleted_at" ON "clipboard_entries" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "team_members" (
+    "id" bigserial,
+    "team_id" bigint NOT NULL,
+    "user_id" bigint NOT NULL,
+    "role" text,
+    "joined_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_team_members_user" FOREIGN KEY ("user_id") REFERENCES "users"("id"),
+    CONSTRAINT "fk_teams_members" FOREIGN KEY ("team_id") REFERENCES "teams"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_team_members_deleted_at" ON "team_members" ("deleted_at");
@@ -0,0 +1,4 @@
+ALTER TABLE "team_members" ALTER COLUMN "role" DROP NOT NULL;
+ALTER TABLE "team_members" ALTER COLUMN "role" DROP DEFAULT;
+ALTER TABLE "team_members" ALTER COLUMN "role" TYPE text;
+DROP TABLE IF EXISTS "clipboard_representations";
@@ -0,0 +1,18 @@
+-- Clipboard entries carry every format they were copied in; team roles get a default
+
+CREATE TABLE IF NOT EXISTS "clipboard_representations" (
+    "id" bigserial,
+    "entry_id" bigint NOT NULL,
+    "mime_type" varchar(100) NOT NULL,
+    "content" text NOT NULL,
+    "size" bigint NOT NULL,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_clipboard_entries_representations" FOREIGN KEY ("entry_id") REFERENCES "clipboard_entries"("id") ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_entry_mime" ON "clipboard_representations" ("entry_id","mime_type");
+
+UPDATE "team_members" SET "role" = 'member' WHERE "role" IS NULL OR "role" = '';
+ALTER TABLE "team_members" ALTER COLUMN "role" TYPE varchar(50);
+ALTER TABLE "team_members" ALTER COLUMN "role" SET DEFAULT 'member';
+ALTER TABLE "team_members" ALTER COLUMN "role" SET NOT NULL;
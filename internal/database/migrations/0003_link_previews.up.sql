@@ -0,0 +1,19 @@
+-- Link previews unfurled from copied URLs
+
+CREATE TABLE IF NOT EXISTS "link_previews" (
+    "id" bigserial,
+    "entry_id" bigint NOT NULL,
+    "url" text NOT NULL,
+    "canonical_url" text,
+    "title" varchar(500),
+    "description" text,
+    "favicon_url" text,
+    "status" varchar(20) NOT NULL DEFAULT 'pending',
+    "error" text,
+    "fetched_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_clipboard_entries_link_preview" FOREIGN KEY ("entry_id") REFERENCES "clipboard_entries"("id") ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_link_previews_entry_id" ON "link_previews" ("entry_id");
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS "idx_clipboard_entries_content_hash";
+ALTER TABLE "clipboard_entries" DROP COLUMN IF EXISTS "content_hash";
+DROP TABLE IF EXISTS "import_jobs";
+DROP TABLE IF EXISTS "export_jobs";
@@ -0,0 +1,41 @@
+-- History export and import jobs; entries get a content hash to dedupe imports
+
+CREATE TABLE IF NOT EXISTS "export_jobs" (
+    "id" bigserial,
+    "user_id" bigint NOT NULL,
+    "format" varchar(10) NOT NULL,
+    "status" varchar(20) NOT NULL DEFAULT 'pending',
+    "entry_count" bigint,
+    "file_path" text,
+    "file_size" bigint,
+    "error" text,
+    "created_at" timestamptz,
+    "completed_at" timestamptz,
+    "expires_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_export_jobs_user" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_export_jobs_user_id" ON "export_jobs" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "import_jobs" (
+    "id" bigserial,
+    "user_id" bigint NOT NULL,
+    "format" varchar(10) NOT NULL,
+    "status" varchar(20) NOT NULL DEFAULT 'pending',
+    "file_path" text,
+    "total_rows" bigint,
+    "processed" bigint,
+    "imported" bigint,
+    "duplicates" bigint,
+    "failed" bigint,
+    "row_errors" jsonb,
+    "error" text,
+    "created_at" timestamptz,
+    "completed_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_import_jobs_user" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_import_jobs_user_id" ON "import_jobs" ("user_id");
+
+ALTER TABLE "clipboard_entries" ADD COLUMN IF NOT EXISTS "content_hash" varchar(64);
+CREATE INDEX IF NOT EXISTS "idx_clipboard_entries_content_hash" ON "clipboard_entries" ("content_hash");
@@ -0,0 +1,41 @@
+-- Outgoing webhook subscriptions and their delivery queue
+
+CREATE TABLE IF NOT EXISTS "webhook_subscriptions" (
+    "id" bigserial,
+    "user_id" bigint NOT NULL,
+    "team_id" bigint,
+    "url" text NOT NULL,
+    "secret" varchar(128) NOT NULL,
+    "events" text NOT NULL,
+    "description" varchar(255),
+    "active" boolean NOT NULL DEFAULT true,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_webhook_subscriptions_user" FOREIGN KEY ("user_id") REFERENCES "users"("id"),
+    CONSTRAINT "fk_webhook_subscriptions_team" FOREIGN KEY ("team_id") REFERENCES "teams"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_webhook_subscriptions_deleted_at" ON "webhook_subscriptions" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_webhook_subscriptions_team_id" ON "webhook_subscriptions" ("team_id");
+CREATE INDEX IF NOT EXISTS "idx_webhook_subscriptions_user_id" ON "webhook_subscriptions" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "webhook_deliveries" (
+    "id" bigserial,
+    "subscription_id" bigint NOT NULL,
+    "event_id" varchar(64) NOT NULL,
+    "event_type" varchar(50) NOT NULL,
+    "payload" text NOT NULL,
+    "status" varchar(20) NOT NULL DEFAULT 'pending',
+    "attempts" bigint NOT NULL DEFAULT 0,
+    "next_attempt_at" timestamptz,
+    "last_status_code" bigint,
+    "last_error" text,
+    "created_at" timestamptz,
+    "delivered_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_webhook_deliveries_subscription" FOREIGN KEY ("subscription_id") REFERENCES "webhook_subscriptions"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_webhook_deliveries_due" ON "webhook_deliveries" ("status","next_attempt_at");
+CREATE INDEX IF NOT EXISTS "idx_webhook_deliveries_event_id" ON "webhook_deliveries" ("event_id");
+CREATE INDEX IF NOT EXISTS "idx_webhook_deliveries_subscription_id" ON "webhook_deliveries" ("subscription_id");
@@ -0,0 +1,38 @@
+-- Snippet library and folders
+
+CREATE TABLE IF NOT EXISTS "snippet_folders" (
+    "id" bigserial,
+    "user_id" bigint NOT NULL,
+    "parent_id" bigint,
+    "name" varchar(100) NOT NULL,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_snippet_folders_user" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_snippet_folders_deleted_at" ON "snippet_folders" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_snippet_folders_user_id" ON "snippet_folders" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "snippets" (
+    "id" bigserial,
+    "user_id" bigint NOT NULL,
+    "folder_id" bigint,
+    "team_id" bigint,
+    "name" varchar(255) NOT NULL,
+    "body" text NOT NULL,
+    "abbreviation" varchar(50),
+    "tags" text,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_snippets_user" FOREIGN KEY ("user_id") REFERENCES "users"("id"),
+    CONSTRAINT "fk_snippets_folder" FOREIGN KEY ("folder_id") REFERENCES "snippet_folders"("id"),
+    CONSTRAINT "fk_snippets_team" FOREIGN KEY ("team_id") REFERENCES "teams"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_snippets_deleted_at" ON "snippets" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_snippets_team_id" ON "snippets" ("team_id");
+CREATE INDEX IF NOT EXISTS "idx_snippets_folder_id" ON "snippets" ("folder_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_snippets_user_abbreviation" ON "snippets" ("user_id","abbreviation") WHERE abbreviation <> '';
+CREATE INDEX IF NOT EXISTS "idx_snippets_user_id" ON "snippets" ("user_id");
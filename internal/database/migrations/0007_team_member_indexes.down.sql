@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS "idx_team_members_team_user";
+DROP INDEX IF EXISTS "idx_team_members_user_id";
@@ -0,0 +1,4 @@
+-- One membership per user and team
+
+CREATE INDEX IF NOT EXISTS "idx_team_members_user_id" ON "team_members" ("user_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_team_members_team_user" ON "team_members" ("team_id","user_id");
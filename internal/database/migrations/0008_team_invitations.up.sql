@@ -0,0 +1,25 @@
+-- Team invitations by email and join link
+
+CREATE TABLE IF NOT EXISTS "team_invitations" (
+    "id" bigserial,
+    "team_id" bigint NOT NULL,
+    "inviter_id" bigint NOT NULL,
+    "kind" varchar(10) NOT NULL,
+    "email" varchar(255),
+    "token_hash" varchar(64) NOT NULL,
+    "role" varchar(50) NOT NULL,
+    "status" varchar(20) NOT NULL,
+    "max_uses" bigint NOT NULL DEFAULT 0,
+    "uses" bigint NOT NULL DEFAULT 0,
+    "expires_at" timestamptz,
+    "accepted_by" bigint,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_team_invitations_team" FOREIGN KEY ("team_id") REFERENCES "teams"("id"),
+    CONSTRAINT "fk_team_invitations_inviter" FOREIGN KEY ("inviter_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_team_invitations_status" ON "team_invitations" ("status");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_team_invitations_token_hash" ON "team_invitations" ("token_hash");
+CREATE INDEX IF NOT EXISTS "idx_team_invitations_email" ON "team_invitations" ("email");
+CREATE INDEX IF NOT EXISTS "idx_team_invitations_team_id" ON "team_invitations" ("team_id");
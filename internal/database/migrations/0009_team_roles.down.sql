@@ -0,0 +1 @@
+ALTER TABLE "team_members" ALTER COLUMN "role" SET DEFAULT 'member';
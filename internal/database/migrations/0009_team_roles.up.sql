@@ -0,0 +1,3 @@
+-- New members join as editors; stored "member" roles keep working as editor
+
+ALTER TABLE "team_members" ALTER COLUMN "role" SET DEFAULT 'editor';
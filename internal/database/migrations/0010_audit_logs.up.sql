@@ -0,0 +1,18 @@
+-- Audit log
+
+CREATE TABLE IF NOT EXISTS "audit_logs" (
+    "log_id" bigserial,
+    "user_id" bigint,
+    "team_id" bigint,
+    "action_type" varchar(100) NOT NULL,
+    "action_details" jsonb,
+    "ip_address" varchar(45),
+    "user_agent" text,
+    "request_details" jsonb,
+    "timestamp_utc" timestamptz NOT NULL,
+    PRIMARY KEY ("log_id")
+);
+CREATE INDEX IF NOT EXISTS "idx_audit_logs_timestamp" ON "audit_logs" ("timestamp_utc");
+CREATE INDEX IF NOT EXISTS "idx_audit_logs_action_type" ON "audit_logs" ("action_type");
+CREATE INDEX IF NOT EXISTS "idx_audit_logs_team_id" ON "audit_logs" ("team_id");
+CREATE INDEX IF NOT EXISTS "idx_audit_logs_user_id" ON "audit_logs" ("user_id");
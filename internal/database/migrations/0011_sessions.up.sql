@@ -0,0 +1,31 @@
+-- Server-side sessions with rotating refresh tokens
+
+CREATE TABLE IF NOT EXISTS "user_sessions" (
+    "id" bigserial,
+    "user_id" bigint NOT NULL,
+    "device" varchar(255),
+    "ip_address" varchar(45),
+    "user_agent" text,
+    "created_at" timestamptz,
+    "last_used_at" timestamptz,
+    "expires_at" timestamptz NOT NULL,
+    "revoked_at" timestamptz,
+    "revoke_reason" varchar(50),
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_user_sessions_user" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_user_sessions_revoked_at" ON "user_sessions" ("revoked_at");
+CREATE INDEX IF NOT EXISTS "idx_user_sessions_expires_at" ON "user_sessions" ("expires_at");
+CREATE INDEX IF NOT EXISTS "idx_user_sessions_user_id" ON "user_sessions" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "refresh_tokens" (
+    "id" bigserial,
+    "session_id" bigint NOT NULL,
+    "token_hash" char(64) NOT NULL,
+    "expires_at" timestamptz NOT NULL,
+    "rotated_at" timestamptz,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_refresh_tokens_token_hash" ON "refresh_tokens" ("token_hash");
+CREATE INDEX IF NOT EXISTS "idx_refresh_tokens_session_id" ON "refresh_tokens" ("session_id");
@@ -0,0 +1,3 @@
+ALTER TABLE "users" DROP COLUMN IF EXISTS "auth_provider";
+DROP TABLE IF EXISTS "oauth_states";
+DROP TABLE IF EXISTS "user_identities";
@@ -0,0 +1,30 @@
+-- Social login identities and in-flight OAuth states
+
+CREATE TABLE IF NOT EXISTS "user_identities" (
+    "id" bigserial,
+    "user_id" bigint NOT NULL,
+    "provider" varchar(50) NOT NULL,
+    "subject" varchar(255) NOT NULL,
+    "email" varchar(255),
+    "created_at" timestamptz,
+    "last_login_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_user_identities_user" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_identity_provider_subject" ON "user_identities" ("provider","subject");
+CREATE INDEX IF NOT EXISTS "idx_user_identities_user_id" ON "user_identities" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "oauth_states" (
+    "id" bigserial,
+    "state_hash" char(64) NOT NULL,
+    "provider" varchar(50) NOT NULL,
+    "code_verifier" varchar(128) NOT NULL,
+    "device" varchar(255),
+    "expires_at" timestamptz NOT NULL,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_oauth_states_expires_at" ON "oauth_states" ("expires_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_oauth_states_state_hash" ON "oauth_states" ("state_hash");
+
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "auth_provider" varchar(50) NOT NULL DEFAULT 'email_password';
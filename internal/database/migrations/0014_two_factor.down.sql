@@ -0,0 +1,5 @@
+ALTER TABLE "teams" DROP COLUMN IF EXISTS "require_two_factor";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "two_factor_enabled";
+DROP TABLE IF EXISTS "login_challenges";
+DROP TABLE IF EXISTS "user_recovery_codes";
+DROP TABLE IF EXISTS "user_totp_secrets";
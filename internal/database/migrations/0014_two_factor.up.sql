@@ -0,0 +1,44 @@
+-- TOTP two-factor authentication, recovery codes and team requirements
+
+CREATE TABLE IF NOT EXISTS "user_totp_secrets" (
+    "id" bigserial,
+    "user_id" bigint NOT NULL,
+    "secret" varchar(64) NOT NULL,
+    "confirmed_at" timestamptz,
+    "last_used_step" bigint NOT NULL DEFAULT 0,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_user_totp_secrets_user" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_user_totp_secrets_user_id" ON "user_totp_secrets" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "user_recovery_codes" (
+    "id" bigserial,
+    "user_id" bigint NOT NULL,
+    "code_hash" char(64) NOT NULL,
+    "used_at" timestamptz,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_user_recovery_codes_user" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_user_recovery_codes_code_hash" ON "user_recovery_codes" ("code_hash");
+CREATE INDEX IF NOT EXISTS "idx_user_recovery_codes_user_id" ON "user_recovery_codes" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "login_challenges" (
+    "id" bigserial,
+    "token_hash" char(64) NOT NULL,
+    "user_id" bigint NOT NULL,
+    "method" varchar(50) NOT NULL,
+    "device" varchar(255),
+    "attempts" bigint NOT NULL DEFAULT 0,
+    "expires_at" timestamptz NOT NULL,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_login_challenges_user" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_login_challenges_expires_at" ON "login_challenges" ("expires_at");
+CREATE INDEX IF NOT EXISTS "idx_login_challenges_user_id" ON "login_challenges" ("user_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_login_challenges_token_hash" ON "login_challenges" ("token_hash");
+
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "two_factor_enabled" boolean NOT NULL DEFAULT false;
+ALTER TABLE "teams" ADD COLUMN IF NOT EXISTS "require_two_factor" boolean NOT NULL DEFAULT false;
@@ -0,0 +1,34 @@
+-- Email verification, password reset tokens and the email outbox
+
+CREATE TABLE IF NOT EXISTS "email_notifications" (
+    "notification_id" bigserial,
+    "recipient_email" varchar(255) NOT NULL,
+    "subject" text NOT NULL,
+    "body" text NOT NULL,
+    "status" varchar(20) NOT NULL DEFAULT 'pending',
+    "attempts" bigint NOT NULL DEFAULT 0,
+    "next_attempt_at" timestamptz,
+    "last_error" text,
+    "sent_at" timestamptz,
+    "created_at" timestamptz,
+    PRIMARY KEY ("notification_id")
+);
+CREATE INDEX IF NOT EXISTS "idx_email_notifications_due" ON "email_notifications" ("status","next_attempt_at");
+
+CREATE TABLE IF NOT EXISTS "account_tokens" (
+    "id" bigserial,
+    "user_id" bigint NOT NULL,
+    "purpose" varchar(50) NOT NULL,
+    "token_id" varchar(64) NOT NULL,
+    "email" varchar(255) NOT NULL,
+    "expires_at" timestamptz NOT NULL,
+    "used_at" timestamptz,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_account_tokens_user" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_account_tokens_expires_at" ON "account_tokens" ("expires_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_account_tokens_token_id" ON "account_tokens" ("token_id");
+CREATE INDEX IF NOT EXISTS "idx_account_tokens_user_id" ON "account_tokens" ("user_id");
+
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "email_verified_at" timestamptz;
@@ -0,0 +1,14 @@
+-- Login brute-force protection; administrators
+
+CREATE TABLE IF NOT EXISTS "login_throttles" (
+    "id" bigserial,
+    "scope" varchar(20) NOT NULL,
+    "key" varchar(255) NOT NULL,
+    "failures" bigint NOT NULL DEFAULT 0,
+    "last_failure_at" timestamptz NOT NULL,
+    "locked_until" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_login_throttle_key" ON "login_throttles" ("scope","key");
+
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "is_admin" boolean NOT NULL DEFAULT false;
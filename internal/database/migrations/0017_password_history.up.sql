@@ -0,0 +1,11 @@
+-- Password history
+
+CREATE TABLE IF NOT EXISTS "password_histories" (
+    "id" bigserial,
+    "user_id" bigint NOT NULL,
+    "password_hash" text NOT NULL,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_password_histories_user" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_password_histories_user_id" ON "password_histories" ("user_id");
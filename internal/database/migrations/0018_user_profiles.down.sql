@@ -0,0 +1,13 @@
+DROP INDEX IF EXISTS "idx_users_username";
+DROP INDEX IF EXISTS "idx_users_deletion_scheduled_at";
+DROP INDEX IF EXISTS "idx_users_deleted_at";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "deleted_at";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "updated_at";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "created_at";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "deletion_scheduled_at";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "last_login_at";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "preferred_language";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "profile_picture_url";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "display_name";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "username";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "pending_email";
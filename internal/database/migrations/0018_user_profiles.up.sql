@@ -0,0 +1,15 @@
+-- User profiles, email changes and scheduled deletion
+
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "pending_email" varchar(255);
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "username" varchar(50);
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "display_name" varchar(100);
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "profile_picture_url" varchar(255);
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "preferred_language" varchar(10) NOT NULL DEFAULT 'en-US';
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "last_login_at" timestamptz;
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "deletion_scheduled_at" timestamptz;
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "created_at" timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP;
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "updated_at" timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP;
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "deleted_at" timestamptz;
+CREATE INDEX IF NOT EXISTS "idx_users_deleted_at" ON "users" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_users_deletion_scheduled_at" ON "users" ("deletion_scheduled_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_users_username" ON "users" ("username");
@@ -0,0 +1,21 @@
+-- Personal data exports
+
+CREATE TABLE IF NOT EXISTS "data_exports" (
+    "id" bigserial,
+    "user_id" bigint NOT NULL,
+    "status" varchar(20) NOT NULL DEFAULT 'pending',
+    "file_path" text,
+    "file_size" bigint,
+    "archive_sha256" varchar(64),
+    "manifest_sha256" varchar(64),
+    "error" text,
+    "download_count" bigint NOT NULL DEFAULT 0,
+    "last_downloaded_at" timestamptz,
+    "created_at" timestamptz,
+    "completed_at" timestamptz,
+    "expires_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_data_exports_user" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_data_exports_expires_at" ON "data_exports" ("expires_at");
+CREATE INDEX IF NOT EXISTS "idx_data_exports_user_id" ON "data_exports" ("user_id");
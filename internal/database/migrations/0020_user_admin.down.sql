@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS "idx_user_sessions_impersonator_id";
+DROP INDEX IF EXISTS "idx_users_suspended_at";
+ALTER TABLE "user_sessions" DROP COLUMN IF EXISTS "impersonator_id";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "suspension_reason";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "suspended_at";
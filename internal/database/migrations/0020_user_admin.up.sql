@@ -0,0 +1,7 @@
+-- Account suspension and audited impersonation
+
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "suspended_at" timestamptz;
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "suspension_reason" varchar(255);
+ALTER TABLE "user_sessions" ADD COLUMN IF NOT EXISTS "impersonator_id" bigint;
+CREATE INDEX IF NOT EXISTS "idx_users_suspended_at" ON "users" ("suspended_at");
+CREATE INDEX IF NOT EXISTS "idx_user_sessions_impersonator_id" ON "user_sessions" ("impersonator_id");
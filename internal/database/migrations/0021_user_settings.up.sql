@@ -0,0 +1,12 @@
+-- Per-user settings
+
+CREATE TABLE IF NOT EXISTS "user_settings" (
+    "settings_id" bigserial,
+    "user_id" bigint NOT NULL,
+    "settings_data" jsonb NOT NULL DEFAULT '{}',
+    "version" bigint NOT NULL DEFAULT 0,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("settings_id"),
+    CONSTRAINT "fk_user_settings_user" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_user_settings_user_id" ON "user_settings" ("user_id");
@@ -0,0 +1,3 @@
+-- Webhooks receive clipboard content with entry events only when they opt in
+
+ALTER TABLE "webhook_subscriptions" ADD COLUMN IF NOT EXISTS "include_content" boolean NOT NULL DEFAULT false;
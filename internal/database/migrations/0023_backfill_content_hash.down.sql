@@ -0,0 +1 @@
+-- Nothing to undo: the backfilled hashes are the ones these entries would have been created with
@@ -0,0 +1,6 @@
+-- Entries created before content hashes existed get theirs, so importing an export of them is
+-- recognised as a duplicate. Matches contentHash: SHA-256 of "<content type>\0<content>".
+
+UPDATE "clipboard_entries"
+SET "content_hash" = encode(sha256(convert_to("content_type", 'UTF8') || '\x00'::bytea || convert_to("content", 'UTF8')), 'hex')
+WHERE "content_hash" IS NULL OR "content_hash" = '';
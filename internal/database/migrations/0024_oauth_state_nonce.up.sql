@@ -0,0 +1,3 @@
+-- Pending social logins remember the OIDC nonce sent to the provider
+
+ALTER TABLE "oauth_states" ADD COLUMN IF NOT EXISTS "nonce" varchar(64);
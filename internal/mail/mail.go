@@ -0,0 +1,188 @@
+// Package mail sends transactional email such as team invitations.
+package mail
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Message is a plain-text email
+type Message struct {
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+// Sender delivers email messages
+type Sender interface {
+	Send(msg Message) error
+}
+
+// SMTPOptions configures the SMTP driver
+type SMTPOptions struct {
+	Host     string
+	Port     int    // Defaults to 587
+	Username string // Leave empty for servers that do not require authentication
+	Password string
+}
+
+// NewSender returns the sender selected by driver: "log" (default), "file", "smtp" or "capture"
+func NewSender(driver, from, dir string, smtpOpts SMTPOptions) (Sender, error) {
+	switch driver {
+	case "", "log":
+		return NewLogSender(from), nil
+	case "file":
+		return NewFileSender(from, dir)
+	case "smtp":
+		return NewSMTPSender(from, smtpOpts)
+	case "capture":
+		return NewCaptureSender(), nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", driver)
+	}
+}
+
+// render formats msg as an RFC 5322 message
+func render(from string, msg Message, now time.Time) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", msg.Subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+type logSender struct {
+	from string
+}
+
+// NewLogSender returns a Sender that writes messages to the server log, for development
+func NewLogSender(from string) Sender {
+	return &logSender{from: from}
+}
+
+// Send logs the message
+func (s *logSender) Send(msg Message) error {
+	log.Printf("Mail to %s from %s: %s\n%s", msg.To, s.from, msg.Subject, msg.Body)
+	return nil
+}
+
+type fileSender struct {
+	from string
+	dir  string
+	seq  atomic.Uint64
+}
+
+// NewFileSender returns a Sender that writes each message to an .eml file in dir, for tests and
+// local development
+func NewFileSender(from, dir string) (Sender, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create mail directory: %w", err)
+	}
+	return &fileSender{from: from, dir: dir}, nil
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9@._-]+`)
+
+// Send writes the message to a new file
+func (s *fileSender) Send(msg Message) error {
+	now := time.Now()
+	name := fmt.Sprintf("%s-%04d-%s.eml", now.UTC().Format("20060102T150405.000000000"), s.seq.Add(1), unsafeFileChars.ReplaceAllString(msg.To, "_"))
+	if err := os.WriteFile(filepath.Join(s.dir, name), render(s.from, msg, now), 0o640); err != nil {
+		return fmt.Errorf("failed to write mail file: %w", err)
+	}
+	return nil
+}
+
+type smtpSender struct {
+	from     string
+	envelope string // Bare address used in MAIL FROM
+	addr     string
+	auth     smtp.Auth
+}
+
+// NewSMTPSender returns a Sender that relays messages through an SMTP server. STARTTLS is used
+// whenever the server offers it, and credentials are only sent over TLS or to localhost.
+func NewSMTPSender(from string, opts SMTPOptions) (Sender, error) {
+	if opts.Host == "" {
+		return nil, fmt.Errorf("smtp host is required")
+	}
+	addr, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from address: %w", err)
+	}
+	if opts.Port == 0 {
+		opts.Port = 587
+	}
+	s := &smtpSender{from: from, envelope: addr.Address, addr: net.JoinHostPort(opts.Host, strconv.Itoa(opts.Port))}
+	if opts.Username != "" {
+		s.auth = smtp.PlainAuth("", opts.Username, opts.Password, opts.Host)
+	}
+	return s, nil
+}
+
+// Send delivers the message to the SMTP server
+func (s *smtpSender) Send(msg Message) error {
+	if err := smtp.SendMail(s.addr, s.auth, s.envelope, []string{msg.To}, render(s.from, msg, time.Now())); err != nil {
+		return fmt.Errorf("smtp delivery failed: %w", err)
+	}
+	return nil
+}
+
+// CaptureSender keeps sent messages in memory so tests and local tools can read them back
+type CaptureSender struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+// NewCaptureSender returns an empty CaptureSender
+func NewCaptureSender() *CaptureSender {
+	return &CaptureSender{}
+}
+
+// Send records the message
+func (s *CaptureSender) Send(msg Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = append(s.messages, msg)
+	return nil
+}
+
+// Messages returns the messages sent so far, oldest first
+func (s *CaptureSender) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.messages...)
+}
+
+// Last returns the most recent message sent to address, if any
+func (s *CaptureSender) Last(to string) (Message, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := len(s.messages) - 1; i >= 0; i-- {
+		if strings.EqualFold(s.messages[i].To, to) {
+			return s.messages[i], true
+		}
+	}
+	return Message{}, false
+}
+
+// Reset forgets all captured messages
+func (s *CaptureSender) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = nil
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// Account token purposes
+const (
+	TokenPurposeVerifyEmail   = "verify_email"
+	TokenPurposePasswordReset = "password_reset"
+	TokenPurposeChangeEmail   = "change_email" // Email holds the new address being verified
+)
+
+// AccountToken tracks a signed token sent by email. The signature makes the token unforgeable
+// and carries its expiry; this record makes it single-use. TokenID is the token's "jti".
+type AccountToken struct {
+	ID        uint      `gorm:"primaryKey"`
+	UserID    uint      `gorm:"not null;index"`
+	User      User      `gorm:"foreignKey:UserID"`
+	Purpose   string    `gorm:"type:varchar(50);not null"`
+	TokenID   string    `gorm:"type:varchar(64);not null;uniqueIndex"`
+	Email     string    `gorm:"type:varchar(255);not null"` // Address the token was sent to; changing it voids the token
+	ExpiresAt time.Time `gorm:"not null;index"`
+	UsedAt    *time.Time
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+// TableName specifies the table name for GORM
+func (AccountToken) TableName() string {
+	return "account_tokens"
+}
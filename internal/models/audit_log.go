@@ -0,0 +1,102 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Audit action types
+const (
+	AuditUserLogin             = "user_login"
+	AuditUserLoginFailed       = "user_login_failed"
+	AuditUserRegistered        = "user_registered"
+	AuditUserLogout            = "user_logout"
+	AuditIdentityLinked        = "identity_linked"
+	AuditTwoFactorEnabled      = "two_factor_enabled"
+	AuditTwoFactorDisabled     = "two_factor_disabled"
+	AuditRecoveryCodesReset    = "recovery_codes_regenerated"
+	AuditRecoveryCodeUsed      = "recovery_code_used"
+	AuditEmailVerified         = "email_verified"
+	AuditEmailChanged          = "email_changed"
+	AuditProfileUpdated        = "profile_updated"
+	AuditSettingsUpdated       = "settings_updated"
+	AuditDeletionScheduled     = "account_deletion_scheduled"
+	AuditDeletionCancelled     = "account_deletion_cancelled"
+	AuditAccountDeleted        = "account_deleted"
+	AuditDataExportRequested   = "data_export_requested"
+	AuditDataExportDownloaded  = "data_export_downloaded"
+	AuditPasswordResetRequest  = "password_reset_requested"
+	AuditPasswordReset         = "password_reset"
+	AuditPasswordChanged       = "password_changed"
+	AuditAccountLocked         = "account_locked"
+	AuditAccountUnlocked       = "account_unlocked"
+	AuditAccountSuspended      = "account_suspended"
+	AuditAccountUnsuspended    = "account_unsuspended"
+	AuditForcedLogout          = "forced_logout"
+	AuditTwoFactorReset        = "two_factor_reset"
+	AuditImpersonationStarted  = "impersonation_started"
+	AuditImpersonatedRequest   = "impersonated_request"
+	AuditSessionRevoked        = "session_revoked"
+	AuditTokenCreated          = "access_token_created"
+	AuditTokenRevoked          = "access_token_revoked"
+	AuditEntryDeleted          = "clipboard_entry_deleted"
+	AuditTeamCreated           = "team_created"
+	AuditTeamRenamed           = "team_renamed"
+	AuditTeamDeleted           = "team_deleted"
+	AuditTeamMemberJoined      = "team_member_joined"
+	AuditTeamMemberRoleChanged = "team_member_role_changed"
+	AuditTeamMemberRemoved     = "team_member_removed"
+	AuditTeamMemberLeft        = "team_member_left"
+	AuditTeamInvitationSent    = "team_invitation_sent"
+	AuditTeamJoinLinkCreated   = "team_join_link_created"
+	AuditTeamInvitationRevoked = "team_invitation_revoked"
+	AuditTeamTwoFactorChanged  = "team_two_factor_requirement_changed"
+)
+
+// AuditDetails is a free-form JSONB object describing an audited action
+type AuditDetails map[string]interface{}
+
+// Value implements driver.Valuer
+func (d AuditDetails) Value() (driver.Value, error) {
+	if d == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(d)
+	return string(b), err
+}
+
+// Scan implements sql.Scanner
+func (d *AuditDetails) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*d = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, d)
+	case string:
+		return json.Unmarshal([]byte(v), d)
+	default:
+		return errors.New("unsupported type for AuditDetails")
+	}
+}
+
+// AuditLog records a security-relevant action. UserID is the acting user, unknown for failed
+// logins of non-existent accounts; TeamID is set for actions on a team so its admins can see them.
+type AuditLog struct {
+	ID             uint         `gorm:"primaryKey;column:log_id" json:"id"`
+	UserID         *uint        `gorm:"index" json:"user_id,omitempty"`
+	TeamID         *uint        `gorm:"index" json:"team_id,omitempty"`
+	ActionType     string       `gorm:"type:varchar(100);not null;index" json:"action_type"`
+	ActionDetails  AuditDetails `gorm:"type:jsonb" json:"action_details,omitempty"`
+	IPAddress      string       `gorm:"type:varchar(45)" json:"ip_address,omitempty"`
+	UserAgent      string       `gorm:"type:text" json:"user_agent,omitempty"`
+	RequestDetails AuditDetails `gorm:"type:jsonb" json:"request_details,omitempty"`
+	Timestamp      time.Time    `gorm:"column:timestamp_utc;not null;index" json:"timestamp"`
+}
+
+// TableName specifies the table name for GORM
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
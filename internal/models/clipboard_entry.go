@@ -10,8 +10,11 @@ type ClipboardEntry struct {
 	ID        uint           `gorm:"primaryKey" json:"id"`
 	UserID    uint           `gorm:"not null" json:"user_id"`
 	User      User           `gorm:"foreignKey:UserID" json:"-"`
-	ContentType string       `gorm:"type:varchar(50);not null" json:"content_type"` // e.g., "text", "image"
-	Content   string         `gorm:"type:text;not null" json:"content"`         // Encrypted content
+	ContentType string       `gorm:"type:varchar(50);not null" json:"content_type"` // MIME type of Content, e.g., "text/plain", "image/png"
+	Content   string         `gorm:"type:text;not null" json:"content"`         // Encrypted content; plain-text fallback when available
+	ContentHash string       `gorm:"type:varchar(64);index" json:"-"` // SHA-256 of content type and content, used for import dedupe
+	Representations []ClipboardRepresentation `gorm:"foreignKey:EntryID;constraint:OnDelete:CASCADE" json:"representations,omitempty"`
+	LinkPreview *LinkPreview `gorm:"foreignKey:EntryID;constraint:OnDelete:CASCADE" json:"link_preview,omitempty"`
 	SourceDevice string      `gorm:"type:varchar(255)" json:"source_device"` // e.g., "Chrome on Windows", "Firefox on Android"
 	IsShared  bool           `gorm:"default:false" json:"is_shared"`
 	TeamID    *uint          `json:"team_id,omitempty"` // Nullable for personal entries
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+)
+
+// ClipboardRepresentation is one typed rendition (plain text, HTML, RTF, file list, image) of a clipboard entry
+type ClipboardRepresentation struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	EntryID   uint      `gorm:"not null;uniqueIndex:idx_entry_mime" json:"entry_id"`
+	MIMEType  string    `gorm:"type:varchar(100);not null;uniqueIndex:idx_entry_mime" json:"mime_type"` // e.g., "text/plain", "text/html", "image/png"
+	Content   string    `gorm:"type:text;not null" json:"content"`                                      // Binary formats are base64-encoded
+	Size      int       `gorm:"not null" json:"size"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName specifies the table name for GORM
+func (ClipboardRepresentation) TableName() string {
+	return "clipboard_representations"
+}
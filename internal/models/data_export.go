@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// DataExport tracks an asynchronous export of everything we hold about a user, for data
+// access requests. The archive is a ZIP whose manifest lists every file with its SHA-256 and
+// carries a signature verifiable against our JWKS.
+type DataExport struct {
+	ID               uint       `gorm:"primaryKey" json:"id"`
+	UserID           uint       `gorm:"not null;index" json:"-"`
+	User             User       `gorm:"foreignKey:UserID" json:"-"`
+	Status           string     `gorm:"type:varchar(20);not null;default:'pending'" json:"status"`
+	FilePath         string     `gorm:"type:text" json:"-"`
+	FileSize         int64      `json:"file_size"`
+	ArchiveSHA256    string     `gorm:"type:varchar(64)" json:"archive_sha256,omitempty"`
+	ManifestSHA256   string     `gorm:"type:varchar(64)" json:"manifest_sha256,omitempty"`
+	Error            string     `gorm:"type:text" json:"error,omitempty"`
+	DownloadCount    int        `gorm:"not null;default:0" json:"download_count"`
+	LastDownloadedAt *time.Time `json:"last_downloaded_at,omitempty"`
+	CreatedAt        time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	CompletedAt      *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt        *time.Time `gorm:"index" json:"expires_at,omitempty"`
+}
+
+// TableName specifies the table name for GORM
+func (DataExport) TableName() string {
+	return "data_exports"
+}
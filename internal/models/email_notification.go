@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// Email outbox statuses
+const (
+	EmailPending = "pending" // Waiting for its first or next attempt
+	EmailSent    = "sent"
+	EmailFailed  = "failed" // Gave up after the maximum number of attempts
+)
+
+// EmailNotification is a message in the outbox. Services enqueue mail here and a worker hands it
+// to the configured transport, retrying with backoff, so a slow or unavailable mail server never
+// fails the request that sent it.
+type EmailNotification struct {
+	ID             uint       `gorm:"primaryKey;column:notification_id" json:"id"`
+	RecipientEmail string     `gorm:"type:varchar(255);not null" json:"recipient_email"`
+	Subject        string     `gorm:"type:text;not null" json:"subject"`
+	Body           string     `gorm:"type:text;not null" json:"-"`
+	Status         string     `gorm:"type:varchar(20);not null;default:'pending';index:idx_email_notifications_due,priority:1" json:"status"`
+	Attempts       int        `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt  time.Time  `gorm:"index:idx_email_notifications_due,priority:2" json:"next_attempt_at"`
+	LastError      string     `gorm:"type:text" json:"last_error,omitempty"`
+	SentAt         *time.Time `json:"sent_at,omitempty"`
+	CreatedAt      time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName specifies the table name for GORM
+func (EmailNotification) TableName() string {
+	return "email_notifications"
+}
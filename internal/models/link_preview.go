@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+)
+
+// LinkPreview holds unfurled metadata for a clipboard entry whose content is a URL
+type LinkPreview struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	EntryID      uint       `gorm:"not null;uniqueIndex" json:"entry_id"`
+	URL          string     `gorm:"type:text;not null" json:"url"`
+	CanonicalURL string     `gorm:"type:text" json:"canonical_url"`
+	Title        string     `gorm:"type:varchar(500)" json:"title"`
+	Description  string     `gorm:"type:text" json:"description"`
+	FaviconURL   string     `gorm:"type:text" json:"favicon_url"`
+	Status       string     `gorm:"type:varchar(20);not null;default:'pending'" json:"status"` // e.g., "pending", "ready", "failed"
+	Error        string     `gorm:"type:text" json:"-"`
+	FetchedAt    *time.Time `json:"fetched_at,omitempty"`
+	CreatedAt    time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// Link preview statuses
+const (
+	LinkPreviewPending = "pending"
+	LinkPreviewReady   = "ready"
+	LinkPreviewFailed  = "failed"
+)
+
+// TableName specifies the table name for GORM
+func (LinkPreview) TableName() string {
+	return "link_previews"
+}
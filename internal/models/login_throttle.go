@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Login throttle scopes
+const (
+	ThrottleScopeAccount = "account" // Keyed by the email being signed in to, whether or not it has an account
+	ThrottleScopeIP      = "ip"
+)
+
+// LoginThrottle counts recent failed sign-ins for an account or a client address. Repeated
+// failures slow further attempts down, and reaching the threshold locks them out for a while.
+type LoginThrottle struct {
+	ID            uint       `gorm:"primaryKey" json:"-"`
+	Scope         string     `gorm:"type:varchar(20);not null;uniqueIndex:idx_login_throttle_key" json:"scope"`
+	Key           string     `gorm:"type:varchar(255);not null;uniqueIndex:idx_login_throttle_key" json:"key"`
+	Failures      int        `gorm:"not null;default:0" json:"failures"`
+	LastFailureAt time.Time  `gorm:"not null" json:"last_failure_at"`
+	LockedUntil   *time.Time `json:"locked_until,omitempty"`
+}
+
+// TableName specifies the table name for GORM
+func (LoginThrottle) TableName() string {
+	return "login_throttles"
+}
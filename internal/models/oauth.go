@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// UserIdentity links a user to an account at an OAuth/OIDC provider. Subject is the provider's
+// stable user ID; emails can change and are only used to link on first sign-in.
+type UserIdentity struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	UserID      uint       `gorm:"not null;index" json:"-"`
+	User        User       `gorm:"foreignKey:UserID" json:"-"`
+	Provider    string     `gorm:"type:varchar(50);not null;uniqueIndex:idx_identity_provider_subject" json:"provider"`
+	Subject     string     `gorm:"type:varchar(255);not null;uniqueIndex:idx_identity_provider_subject" json:"-"`
+	Email       string     `gorm:"type:varchar(255)" json:"email"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
+}
+
+// TableName specifies the table name for GORM
+func (UserIdentity) TableName() string {
+	return "user_identities"
+}
+
+// OAuthState is a pending social login. Only the SHA-256 of the state parameter is stored; the
+// PKCE verifier stays on the server so an intercepted authorization code is useless on its own.
+type OAuthState struct {
+	ID           uint      `gorm:"primaryKey"`
+	StateHash    string    `gorm:"type:char(64);not null;uniqueIndex"`
+	Provider     string    `gorm:"type:varchar(50);not null"`
+	CodeVerifier string    `gorm:"type:varchar(128);not null"`
+	Nonce        string    `gorm:"type:varchar(64)"`  // Must come back in the OIDC ID token
+	Device       string    `gorm:"type:varchar(255)"` // Session device name requested when the login started
+	ExpiresAt    time.Time `gorm:"not null;index"`
+	CreatedAt    time.Time `gorm:"autoCreateTime"`
+}
+
+// TableName specifies the table name for GORM
+func (OAuthState) TableName() string {
+	return "oauth_states"
+}
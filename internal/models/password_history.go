@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// PasswordHistory keeps hashes of a user's earlier passwords so they cannot be reused
+type PasswordHistory struct {
+	ID           uint      `gorm:"primaryKey" json:"-"`
+	UserID       uint      `gorm:"not null;index" json:"-"`
+	User         User      `gorm:"foreignKey:UserID" json:"-"`
+	PasswordHash string    `gorm:"not null" json:"-"`
+	CreatedAt    time.Time `gorm:"autoCreateTime" json:"-"` // When the password was replaced
+}
+
+// TableName specifies the table name for GORM
+func (PasswordHistory) TableName() string {
+	return "password_histories"
+}
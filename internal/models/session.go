@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+// Session revocation reasons
+const (
+	SessionRevokedLogout         = "logout"
+	SessionRevokedByUser         = "revoked"
+	SessionRevokedReuse          = "refresh_token_reuse"
+	SessionRevokedReset          = "password_reset"
+	SessionRevokedPasswordChange = "password_changed"
+	SessionRevokedAccountDeleted = "account_deleted"
+	SessionRevokedSuspended      = "account_suspended"
+	SessionRevokedByAdmin        = "admin_logout"
+)
+
+// Session is a signed-in device. Access tokens name their session, so revoking it cuts the device
+// off even though its access token has not expired yet.
+type Session struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	UserID       uint       `gorm:"not null;index" json:"-"`
+	User         User       `gorm:"foreignKey:UserID" json:"-"`
+	Device       string     `gorm:"type:varchar(255)" json:"device"`
+	IPAddress    string     `gorm:"type:varchar(45)" json:"ip_address"`
+	UserAgent    string     `gorm:"type:text" json:"user_agent"`
+	CreatedAt    time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	LastUsedAt   time.Time  `json:"last_used_at"`
+	ExpiresAt    time.Time  `gorm:"not null;index" json:"expires_at"` // When the latest refresh token expires
+	RevokedAt    *time.Time `gorm:"index" json:"revoked_at,omitempty"`
+	RevokeReason string     `gorm:"type:varchar(50)" json:"revoke_reason,omitempty"`
+	// ImpersonatorID is the administrator acting as the user; such sessions cannot be refreshed
+	ImpersonatorID *uint `gorm:"index" json:"impersonator_id,omitempty"`
+	Current        bool  `gorm:"-" json:"current"` // Whether this is the session making the request
+}
+
+// TableName specifies the table name for GORM
+func (Session) TableName() string {
+	return "user_sessions"
+}
+
+// Active reports whether the session can still be used
+func (s *Session) Active(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
+
+// RefreshToken is one link in a session's rotation chain. Only the SHA-256 of the token is stored;
+// a token that has already been rotated out is never valid again, and presenting it revokes the
+// session because it means the token was copied.
+type RefreshToken struct {
+	ID        uint       `gorm:"primaryKey"`
+	SessionID uint       `gorm:"not null;index"`
+	TokenHash string     `gorm:"type:char(64);not null;uniqueIndex"`
+	ExpiresAt time.Time  `gorm:"not null"`
+	RotatedAt *time.Time // Set once the token has been exchanged for a new one
+	CreatedAt time.Time  `gorm:"autoCreateTime"`
+}
+
+// TableName specifies the table name for GORM
+func (RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
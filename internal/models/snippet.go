@@ -0,0 +1,66 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SnippetFolder groups a user's snippets; folders can be nested
+type SnippetFolder struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	UserID    uint           `gorm:"not null;index" json:"user_id"`
+	User      User           `gorm:"foreignKey:UserID" json:"-"`
+	ParentID  *uint          `json:"parent_id,omitempty"`
+	Name      string         `gorm:"type:varchar(100);not null" json:"name"`
+	CreatedAt time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+}
+
+// TableName specifies the table name for GORM
+func (SnippetFolder) TableName() string {
+	return "snippet_folders"
+}
+
+// Snippet is a durable, named piece of text with {{placeholders}} expanded on request
+type Snippet struct {
+	ID           uint           `gorm:"primaryKey" json:"id"`
+	UserID       uint           `gorm:"not null;index;uniqueIndex:idx_snippets_user_abbreviation,where:abbreviation <> ''" json:"user_id"`
+	User         User           `gorm:"foreignKey:UserID" json:"-"`
+	FolderID     *uint          `gorm:"index" json:"folder_id,omitempty"`
+	Folder       *SnippetFolder `gorm:"foreignKey:FolderID" json:"-"`
+	TeamID       *uint          `gorm:"index" json:"team_id,omitempty"` // Set when shared with a team
+	Team         *Team          `gorm:"foreignKey:TeamID" json:"-"`
+	Name         string         `gorm:"type:varchar(255);not null" json:"name"`
+	Body         string         `gorm:"type:text;not null" json:"body"`                                                                           // Template, e.g. "Hi {{name}}, sent {{date}}"
+	Abbreviation string         `gorm:"type:varchar(50);uniqueIndex:idx_snippets_user_abbreviation,where:abbreviation <> ''" json:"abbreviation"` // Keyboard trigger, e.g. ";sig"
+	Tags         string         `gorm:"type:text" json:"-"`                                                                                       // Comma-separated
+	TagList      []string       `gorm:"-" json:"tags"`
+	CreatedAt    time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+}
+
+// TableName specifies the table name for GORM
+func (Snippet) TableName() string {
+	return "snippets"
+}
+
+// BeforeSave stores TagList in the Tags column
+func (s *Snippet) BeforeSave(tx *gorm.DB) error {
+	if s.TagList != nil {
+		s.Tags = strings.Join(s.TagList, ",")
+	}
+	return nil
+}
+
+// AfterFind expands the Tags column into TagList
+func (s *Snippet) AfterFind(tx *gorm.DB) error {
+	s.TagList = []string{}
+	if s.Tags != "" {
+		s.TagList = strings.Split(s.Tags, ",")
+	}
+	return nil
+}
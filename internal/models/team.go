@@ -6,23 +6,33 @@ import (
 	"gorm.io/gorm"
 )
 
+// Team member roles; see the policy package for what each may do
+const (
+	TeamRoleOwner  = "owner"
+	TeamRoleAdmin  = "admin"
+	TeamRoleEditor = "editor"
+	TeamRoleViewer = "viewer"
+	TeamRoleMember = "member" // Legacy role from before owner/editor/viewer, treated as editor
+)
+
 type Team struct {
-	ID        uint           `gorm:"primaryKey" json:"id"`
-	Name      string         `gorm:"unique;not null" json:"name"`
-	CreatorID uint           `gorm:"not null" json:"creator_id"`
-	Creator   User           `gorm:"foreignKey:CreatorID" json:"-"`
-	Members   []TeamMember   `gorm:"foreignKey:TeamID" json:"members"`
-	CreatedAt time.Time      `gorm:"autoCreateTime" json:"created_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+	ID               uint           `gorm:"primaryKey" json:"id"`
+	Name             string         `gorm:"unique;not null" json:"name"`
+	CreatorID        uint           `gorm:"not null" json:"creator_id"`
+	Creator          User           `gorm:"foreignKey:CreatorID" json:"-"`
+	Members          []TeamMember   `gorm:"foreignKey:TeamID" json:"members"`
+	RequireTwoFactor bool           `gorm:"not null;default:false" json:"require_two_factor"` // Members without two-factor authentication are locked out of team resources
+	CreatedAt        time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	DeletedAt        gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
 }
 
 type TeamMember struct {
 	ID        uint           `gorm:"primaryKey" json:"id"`
-	TeamID    uint           `gorm:"not null" json:"team_id"`
+	TeamID    uint           `gorm:"not null;uniqueIndex:idx_team_members_team_user" json:"team_id"`
 	Team      Team           `gorm:"foreignKey:TeamID" json:"-"`
-	UserID    uint           `gorm:"not null" json:"user_id"`
+	UserID    uint           `gorm:"not null;uniqueIndex:idx_team_members_team_user;index" json:"user_id"`
 	User      User           `gorm:"foreignKey:UserID" json:"-"`
-	Role      string         `gorm:"type:varchar(50);not null;default:\'member\'" json:"role"` // e.g., "admin", "member"
+	Role      string         `gorm:"type:varchar(50);not null;default:'editor'" json:"role"` // "owner", "admin", "editor" or "viewer"
 	JoinedAt  time.Time      `gorm:"autoCreateTime" json:"joined_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
 }
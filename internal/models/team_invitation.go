@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// Team invitation kinds
+const (
+	InvitationKindEmail = "email" // Addressed to one email address
+	InvitationKindLink  = "link"  // Shareable join link
+)
+
+// Team invitation statuses
+const (
+	InvitationPending  = "pending"
+	InvitationAccepted = "accepted"
+	InvitationDeclined = "declined"
+	InvitationRevoked  = "revoked"
+)
+
+// TeamInvitation invites an email address, or anyone holding the join link, into a team
+type TeamInvitation struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	TeamID     uint       `gorm:"not null;index" json:"team_id"`
+	Team       Team       `gorm:"foreignKey:TeamID" json:"-"`
+	TeamName   string     `gorm:"-" json:"team_name,omitempty"` // Filled in when listing an invitee's invitations
+	InviterID  uint       `gorm:"not null" json:"inviter_id"`
+	Inviter    User       `gorm:"foreignKey:InviterID" json:"-"`
+	Kind       string     `gorm:"type:varchar(10);not null" json:"kind"`
+	Email      string     `gorm:"type:varchar(255);index" json:"email,omitempty"` // Lower-cased; empty for links
+	TokenHash  string     `gorm:"type:varchar(64);uniqueIndex;not null" json:"-"` // SHA-256 of the secret token
+	Role       string     `gorm:"type:varchar(50);not null" json:"role"`          // Role granted on acceptance
+	Status     string     `gorm:"type:varchar(20);not null;index" json:"status"`
+	MaxUses    int        `gorm:"not null;default:0" json:"max_uses"` // 0 means unlimited (links only)
+	Uses       int        `gorm:"not null;default:0" json:"uses"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	AcceptedBy *uint      `json:"accepted_by,omitempty"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName specifies the table name for GORM
+func (TeamInvitation) TableName() string {
+	return "team_invitations"
+}
+
+// Expired reports whether the invitation is past its expiry time
+func (i *TeamInvitation) Expired(now time.Time) bool {
+	return i.ExpiresAt != nil && now.After(*i.ExpiresAt)
+}
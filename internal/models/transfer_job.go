@@ -0,0 +1,94 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Export/import job statuses
+const (
+	JobPending   = "pending"
+	JobRunning   = "running"
+	JobCompleted = "completed"
+	JobFailed    = "failed"
+)
+
+// ExportJob tracks an asynchronous clipboard history export
+type ExportJob struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	UserID      uint       `gorm:"not null;index" json:"user_id"`
+	User        User       `gorm:"foreignKey:UserID" json:"-"`
+	Format      string     `gorm:"type:varchar(10);not null" json:"format"` // e.g., "json", "csv", "zip"
+	Status      string     `gorm:"type:varchar(20);not null;default:'pending'" json:"status"`
+	EntryCount  int        `json:"entry_count"`
+	FilePath    string     `gorm:"type:text" json:"-"`
+	FileSize    int64      `json:"file_size"`
+	Error       string     `gorm:"type:text" json:"error,omitempty"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
+
+// TableName specifies the table name for GORM
+func (ExportJob) TableName() string {
+	return "export_jobs"
+}
+
+// ImportRowError describes why a single row of an import was rejected
+type ImportRowError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+// ImportRowErrors is stored as a JSONB column
+type ImportRowErrors []ImportRowError
+
+// Value implements driver.Valuer
+func (e ImportRowErrors) Value() (driver.Value, error) {
+	if e == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal(e)
+	return string(b), err
+}
+
+// Scan implements sql.Scanner
+func (e *ImportRowErrors) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*e = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, e)
+	case string:
+		return json.Unmarshal([]byte(v), e)
+	default:
+		return errors.New("unsupported type for ImportRowErrors")
+	}
+}
+
+// ImportJob tracks an asynchronous clipboard history import and its per-row outcome
+type ImportJob struct {
+	ID          uint            `gorm:"primaryKey" json:"id"`
+	UserID      uint            `gorm:"not null;index" json:"user_id"`
+	User        User            `gorm:"foreignKey:UserID" json:"-"`
+	Format      string          `gorm:"type:varchar(10);not null" json:"format"`
+	Status      string          `gorm:"type:varchar(20);not null;default:'pending'" json:"status"`
+	FilePath    string          `gorm:"type:text" json:"-"`
+	TotalRows   int             `json:"total_rows"` // Known once the whole file has been read
+	Processed   int             `json:"processed"`
+	Imported    int             `json:"imported"`
+	Duplicates  int             `json:"duplicates"`
+	Failed      int             `json:"failed"`
+	RowErrors   ImportRowErrors `gorm:"type:jsonb" json:"errors"`
+	Error       string          `gorm:"type:text" json:"error,omitempty"`
+	CreatedAt   time.Time       `gorm:"autoCreateTime" json:"created_at"`
+	CompletedAt *time.Time      `json:"completed_at,omitempty"`
+}
+
+// TableName specifies the table name for GORM
+func (ImportJob) TableName() string {
+	return "import_jobs"
+}
@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// TwoFactorSecret is a user's TOTP authenticator secret. It is pending until the user proves
+// their app works by entering a code; only then is two-factor authentication switched on.
+type TwoFactorSecret struct {
+	ID           uint       `gorm:"primaryKey"`
+	UserID       uint       `gorm:"not null;uniqueIndex"`
+	User         User       `gorm:"foreignKey:UserID"`
+	Secret       string     `gorm:"type:varchar(64);not null"`
+	ConfirmedAt  *time.Time // Nil while enrollment is pending
+	LastUsedStep int64      `gorm:"not null;default:0"` // Codes for this step or earlier are rejected as replays
+	CreatedAt    time.Time  `gorm:"autoCreateTime"`
+}
+
+// TableName specifies the table name for GORM
+func (TwoFactorSecret) TableName() string {
+	return "user_totp_secrets"
+}
+
+// RecoveryCode is a single-use code that stands in for a TOTP code when the authenticator is
+// lost. Only the SHA-256 of the code is stored.
+type RecoveryCode struct {
+	ID        uint   `gorm:"primaryKey"`
+	UserID    uint   `gorm:"not null;index"`
+	User      User   `gorm:"foreignKey:UserID"`
+	CodeHash  string `gorm:"type:char(64);not null;uniqueIndex"`
+	UsedAt    *time.Time
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+// TableName specifies the table name for GORM
+func (RecoveryCode) TableName() string {
+	return "user_recovery_codes"
+}
+
+// LoginChallenge is a login that passed the first factor and waits for a TOTP or recovery code.
+// Only the SHA-256 of the challenge token is stored.
+type LoginChallenge struct {
+	ID        uint      `gorm:"primaryKey"`
+	TokenHash string    `gorm:"type:char(64);not null;uniqueIndex"`
+	UserID    uint      `gorm:"not null;index"`
+	User      User      `gorm:"foreignKey:UserID"`
+	Method    string    `gorm:"type:varchar(50);not null"` // How the first factor was passed, e.g. "password" or a provider name
+	Device    string    `gorm:"type:varchar(255)"`
+	Attempts  int       `gorm:"not null;default:0"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+// TableName specifies the table name for GORM
+func (LoginChallenge) TableName() string {
+	return "login_challenges"
+}
@@ -1,15 +1,48 @@
 package models
 
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AuthProviderEmailPassword marks accounts created through email and password registration;
+// accounts provisioned on first social login carry the provider's name instead
+const AuthProviderEmailPassword = "email_password"
+
+// DefaultLanguage is the preferred language of users who have not chosen one
+const DefaultLanguage = "en-US"
+
 type User struct {
-	ID       uint   `gorm:"primaryKey" json:"id"`
-	Email    string `gorm:"unique;not null" json:"email"`
-	Password string `gorm:"not null" json:"-"` // Store hashed password
-	// Add other user-related fields as needed, e.g., created_at, updated_at
+	ID              uint       `gorm:"primaryKey" json:"id"`
+	Email           string     `gorm:"unique;not null" json:"email"`
+	Password        string     `gorm:"not null" json:"-"` // Store hashed password; empty for accounts that only use social login
+	AuthProvider    string     `gorm:"type:varchar(50);not null;default:'email_password'" json:"auth_provider"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"` // Nil until the user follows a verification link
+	// PendingEmail is a new address the user asked to switch to; it replaces Email once verified
+	PendingEmail string `gorm:"type:varchar(255)" json:"pending_email,omitempty"`
+	// TwoFactorEnabled mirrors a confirmed TOTP secret so team checks need no extra lookup
+	TwoFactorEnabled bool `gorm:"not null;default:false" json:"two_factor_enabled"`
+	// IsAdmin grants the system administration endpoints under /api/v1/admin
+	IsAdmin bool `gorm:"not null;default:false" json:"is_admin"`
+	// SuspendedAt is set while an administrator has disabled the account; it cannot sign in
+	SuspendedAt      *time.Time `gorm:"index" json:"suspended_at,omitempty"`
+	SuspensionReason string     `gorm:"type:varchar(255)" json:"suspension_reason,omitempty"`
+
+	Username          *string    `gorm:"type:varchar(50);uniqueIndex" json:"username,omitempty"` // Optional; unique when set
+	DisplayName       string     `gorm:"type:varchar(100)" json:"display_name"`
+	ProfilePictureURL string     `gorm:"type:varchar(255)" json:"profile_picture_url,omitempty"`
+	PreferredLanguage string     `gorm:"type:varchar(10);not null;default:'en-US'" json:"preferred_language"`
+	LastLoginAt       *time.Time `json:"last_login_at,omitempty"`
+	// DeletionScheduledAt is when the account and its data will be erased; nil unless the user
+	// asked to delete it
+	DeletionScheduledAt *time.Time     `gorm:"index" json:"deletion_scheduled_at,omitempty"`
+	CreatedAt           time.Time      `gorm:"autoCreateTime;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt           time.Time      `gorm:"autoUpdateTime;not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
+	DeletedAt           gorm.DeletedAt `gorm:"index" json:"-"` // Set once the account is erased; the row stays, scrubbed, for audit history
 }
 
 // TableName specifies the table name for GORM
 func (User) TableName() string {
 	return "users"
 }
-
-
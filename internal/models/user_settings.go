@@ -0,0 +1,52 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// SettingsDocument is a user's settings as a JSONB object. It holds only the values the user
+// changed; the settings package supplies defaults for the rest.
+type SettingsDocument map[string]interface{}
+
+// Value implements driver.Valuer
+func (d SettingsDocument) Value() (driver.Value, error) {
+	if d == nil {
+		return "{}", nil
+	}
+	b, err := json.Marshal(d)
+	return string(b), err
+}
+
+// Scan implements sql.Scanner
+func (d *SettingsDocument) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*d = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, d)
+	case string:
+		return json.Unmarshal([]byte(v), d)
+	default:
+		return errors.New("unsupported type for SettingsDocument")
+	}
+}
+
+// UserSettings stores a user's settings document. Version is bumped on every change so clients
+// can tell whether the settings they hold are current.
+type UserSettings struct {
+	ID           uint             `gorm:"primaryKey;column:settings_id" json:"-"`
+	UserID       uint             `gorm:"not null;uniqueIndex" json:"-"`
+	User         User             `gorm:"foreignKey:UserID" json:"-"`
+	SettingsData SettingsDocument `gorm:"type:jsonb;not null;default:'{}'" json:"settings"`
+	Version      int64            `gorm:"not null;default:0" json:"version"`
+	UpdatedAt    time.Time        `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName specifies the table name for GORM
+func (UserSettings) TableName() string {
+	return "user_settings"
+}
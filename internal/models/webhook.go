@@ -0,0 +1,104 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Webhook event types
+const (
+	WebhookEventEntryCreated    = "entry.created"
+	WebhookEventEntryDeleted    = "entry.deleted"
+	WebhookEventDeviceConnected = "device.connected"
+	WebhookEventPing            = "ping"
+)
+
+// WebhookEvents lists the event types a subscription may select
+var WebhookEvents = []string{
+	WebhookEventEntryCreated,
+	WebhookEventEntryDeleted,
+	WebhookEventDeviceConnected,
+}
+
+// WebhookSubscription is an outgoing webhook owned by a user or, when TeamID is set, by a team
+type WebhookSubscription struct {
+	ID             uint           `gorm:"primaryKey" json:"id"`
+	UserID         uint           `gorm:"not null;index" json:"user_id"` // Creator; the owner for personal webhooks
+	User           User           `gorm:"foreignKey:UserID" json:"-"`
+	TeamID         *uint          `gorm:"index" json:"team_id,omitempty"` // Nullable for personal webhooks
+	Team           *Team          `gorm:"foreignKey:TeamID" json:"-"`
+	URL            string         `gorm:"type:text;not null" json:"url"`
+	Secret         string         `gorm:"type:varchar(128);not null" json:"-"` // HMAC signing secret
+	Events         string         `gorm:"type:text;not null" json:"-"`         // Comma-separated event types
+	EventTypes     []string       `gorm:"-" json:"events"`
+	Description    string         `gorm:"type:varchar(255)" json:"description"`
+	Active         bool           `gorm:"not null;default:true" json:"active"`
+	IncludeContent bool           `gorm:"not null;default:false" json:"include_content"` // Entry events carry the clipboard content; only metadata otherwise
+	CreatedAt      time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+}
+
+// TableName specifies the table name for GORM
+func (WebhookSubscription) TableName() string {
+	return "webhook_subscriptions"
+}
+
+// BeforeSave stores EventTypes in the Events column
+func (w *WebhookSubscription) BeforeSave(tx *gorm.DB) error {
+	if w.EventTypes != nil {
+		w.Events = strings.Join(w.EventTypes, ",")
+	}
+	return nil
+}
+
+// AfterFind expands the Events column into EventTypes
+func (w *WebhookSubscription) AfterFind(tx *gorm.DB) error {
+	w.EventTypes = nil
+	if w.Events != "" {
+		w.EventTypes = strings.Split(w.Events, ",")
+	}
+	return nil
+}
+
+// Subscribes reports whether the subscription wants the given event type
+func (w *WebhookSubscription) Subscribes(eventType string) bool {
+	for _, e := range w.EventTypes {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Webhook delivery statuses
+const (
+	DeliveryPending      = "pending"
+	DeliverySucceeded    = "succeeded"
+	DeliveryFailed       = "failed"        // Failed at least once, retry scheduled
+	DeliveryDeadLettered = "dead_lettered" // Gave up after the maximum number of attempts
+)
+
+// WebhookDelivery is one event sent (or to be sent) to a subscription; together they form the delivery log
+type WebhookDelivery struct {
+	ID             uint                `gorm:"primaryKey" json:"id"`
+	SubscriptionID uint                `gorm:"not null;index" json:"subscription_id"`
+	Subscription   WebhookSubscription `gorm:"foreignKey:SubscriptionID" json:"-"`
+	EventID        string              `gorm:"type:varchar(64);not null;index" json:"event_id"`
+	EventType      string              `gorm:"type:varchar(50);not null" json:"event_type"`
+	Payload        string              `gorm:"type:text;not null" json:"payload"`
+	Status         string              `gorm:"type:varchar(20);not null;default:'pending';index:idx_webhook_deliveries_due,priority:1" json:"status"`
+	Attempts       int                 `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt  time.Time           `gorm:"index:idx_webhook_deliveries_due,priority:2" json:"next_attempt_at"`
+	LastStatusCode int                 `json:"last_status_code,omitempty"`
+	LastError      string              `gorm:"type:text" json:"last_error,omitempty"`
+	CreatedAt      time.Time           `gorm:"autoCreateTime" json:"created_at"`
+	DeliveredAt    *time.Time          `json:"delivered_at,omitempty"`
+}
+
+// TableName specifies the table name for GORM
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
@@ -0,0 +1,212 @@
+// Package oauthtest runs an OpenID Connect provider for tests. It implements discovery, the
+// authorization code flow with PKCE, and the userinfo endpoint; ID tokens are not signed.
+package oauthtest
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// User is an account at the provider
+type User struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// grant is an authorization code waiting to be redeemed
+type grant struct {
+	user        User
+	redirectURI string
+	challenge   string
+	nonce       string
+}
+
+// Server is a running OpenID Connect provider whose issuer is its URL
+type Server struct {
+	*httptest.Server
+	ClientID     string
+	ClientSecret string
+
+	// TamperIDToken, when set, edits the claims of issued ID tokens; deleting every claim leaves
+	// the ID token out of the token response
+	TamperIDToken func(claims map[string]interface{})
+
+	mu     sync.Mutex
+	codes  map[string]grant
+	tokens map[string]User
+}
+
+// NewServer starts a provider that accepts the given client; close it when done
+func NewServer(clientID, clientSecret string) *Server {
+	s := &Server{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		codes:        make(map[string]grant),
+		tokens:       make(map[string]User),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", s.discovery)
+	mux.HandleFunc("/token", s.token)
+	mux.HandleFunc("/userinfo", s.userinfo)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// Authorize signs user in at authURL as their browser would, and returns the code and state the
+// provider redirects back with. Requests the provider would reject return an error.
+func (s *Server) Authorize(authURL string, user User) (code, state string, err error) {
+	u, err := url.Parse(authURL)
+	if err != nil {
+		return "", "", err
+	}
+	if u.Scheme+"://"+u.Host != s.URL || u.Path != "/authorize" {
+		return "", "", fmt.Errorf("authorization request sent to %s", authURL)
+	}
+	q := u.Query()
+	switch {
+	case q.Get("response_type") != "code":
+		return "", "", errors.New("response_type must be code")
+	case q.Get("client_id") != s.ClientID:
+		return "", "", fmt.Errorf("unknown client %q", q.Get("client_id"))
+	case q.Get("redirect_uri") == "":
+		return "", "", errors.New("redirect_uri is required")
+	case q.Get("code_challenge") == "" || q.Get("code_challenge_method") != "S256":
+		return "", "", errors.New("an S256 code challenge is required")
+	case !strings.Contains(" "+q.Get("scope")+" ", " openid "):
+		return "", "", errors.New("the openid scope is required")
+	}
+
+	code = randomString()
+	s.mu.Lock()
+	s.codes[code] = grant{
+		user:        user,
+		redirectURI: q.Get("redirect_uri"),
+		challenge:   q.Get("code_challenge"),
+		nonce:       q.Get("nonce"),
+	}
+	s.mu.Unlock()
+	return code, q.Get("state"), nil
+}
+
+func (s *Server) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{
+		"issuer":                 s.URL,
+		"authorization_endpoint": s.URL + "/authorize",
+		"token_endpoint":         s.URL + "/token",
+		"userinfo_endpoint":      s.URL + "/userinfo",
+	})
+}
+
+func (s *Server) token(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		tokenError(w, "invalid_request", err.Error())
+		return
+	}
+	if r.PostForm.Get("client_id") != s.ClientID || r.PostForm.Get("client_secret") != s.ClientSecret {
+		tokenError(w, "invalid_client", "")
+		return
+	}
+	if r.PostForm.Get("grant_type") != "authorization_code" {
+		tokenError(w, "unsupported_grant_type", "")
+		return
+	}
+
+	s.mu.Lock()
+	code := r.PostForm.Get("code")
+	g, ok := s.codes[code]
+	delete(s.codes, code) // Codes are single-use
+	s.mu.Unlock()
+	if !ok {
+		tokenError(w, "invalid_grant", "unknown code")
+		return
+	}
+	if r.PostForm.Get("redirect_uri") != g.redirectURI {
+		tokenError(w, "invalid_grant", "redirect_uri does not match")
+		return
+	}
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(sum[:]) != g.challenge {
+		tokenError(w, "invalid_grant", "code_verifier does not match the challenge")
+		return
+	}
+
+	accessToken := randomString()
+	s.mu.Lock()
+	s.tokens[accessToken] = g.user
+	s.mu.Unlock()
+
+	claims := map[string]interface{}{
+		"iss": s.URL,
+		"sub": g.user.Subject,
+		"aud": s.ClientID,
+		"iat": time.Now().Unix(),
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+	if g.nonce != "" {
+		claims["nonce"] = g.nonce
+	}
+	if s.TamperIDToken != nil {
+		s.TamperIDToken(claims)
+	}
+	resp := map[string]string{"access_token": accessToken, "token_type": "Bearer"}
+	if len(claims) > 0 {
+		resp["id_token"] = unsignedJWT(claims)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) userinfo(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	user, ok := s.tokens[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")]
+	s.mu.Unlock()
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_token"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"sub":            user.Subject,
+		"email":          user.Email,
+		"email_verified": user.EmailVerified,
+		"name":           user.Name,
+	})
+}
+
+func tokenError(w http.ResponseWriter, code, description string) {
+	writeJSON(w, http.StatusBadRequest, map[string]string{"error": code, "error_description": description})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// unsignedJWT encodes claims as a JWT with the "none" algorithm
+func unsignedJWT(claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": "none", "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	return base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload) + "."
+}
+
+func randomString() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,194 @@
+package oauth
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"clipboard-sync-backend/configs"
+)
+
+// discoveryDocument is the subset of OpenID Provider Metadata the login flow needs
+type discoveryDocument struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserInfoEndpoint      string `json:"userinfo_endpoint"`
+}
+
+// oidcProvider signs users in with any OpenID Connect provider. The identity is read from the
+// userinfo endpoint with the access token. The ID token comes straight from the token endpoint
+// over TLS, so its signature need not be verified (OIDC Core 3.1.3.7), but its issuer, audience
+// and nonce are checked to tie it to this login and client.
+type oidcProvider struct {
+	cfg    configs.OAuthProviderConfig
+	client *http.Client
+
+	mu        sync.Mutex
+	endpoints *discoveryDocument
+}
+
+// NewOIDCProvider creates an OpenID Connect provider. Endpoints that are not configured are
+// discovered from the issuer on first use, so a provider that is down at startup does not stop
+// the server.
+func NewOIDCProvider(cfg configs.OAuthProviderConfig, client *http.Client) (Provider, error) {
+	if cfg.IssuerURL == "" && (cfg.AuthURL == "" || cfg.TokenURL == "" || cfg.UserInfoURL == "") {
+		return nil, errors.New("issuer_url or all of auth_url, token_url and userinfo_url are required")
+	}
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"openid", "email", "profile"}
+	}
+	return &oidcProvider{cfg: cfg, client: client}, nil
+}
+
+func (p *oidcProvider) Name() string {
+	return p.cfg.Name
+}
+
+// discover returns the provider's endpoints, fetching the discovery document once
+func (p *oidcProvider) discover(ctx context.Context) (*discoveryDocument, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.endpoints != nil {
+		return p.endpoints, nil
+	}
+
+	doc := discoveryDocument{
+		Issuer:                strings.TrimSuffix(p.cfg.IssuerURL, "/"),
+		AuthorizationEndpoint: p.cfg.AuthURL,
+		TokenEndpoint:         p.cfg.TokenURL,
+		UserInfoEndpoint:      p.cfg.UserInfoURL,
+	}
+	if doc.AuthorizationEndpoint == "" || doc.TokenEndpoint == "" || doc.UserInfoEndpoint == "" {
+		issuer := strings.TrimSuffix(p.cfg.IssuerURL, "/")
+		var discovered discoveryDocument
+		if err := getJSON(ctx, p.client, issuer+"/.well-known/openid-configuration", "", &discovered); err != nil {
+			return nil, fmt.Errorf("openid discovery failed: %w", err)
+		}
+		if strings.TrimSuffix(discovered.Issuer, "/") != issuer {
+			return nil, fmt.Errorf("%w: discovery document is for issuer %q", ErrProviderResponse, discovered.Issuer)
+		}
+		if doc.AuthorizationEndpoint == "" {
+			doc.AuthorizationEndpoint = discovered.AuthorizationEndpoint
+		}
+		if doc.TokenEndpoint == "" {
+			doc.TokenEndpoint = discovered.TokenEndpoint
+		}
+		if doc.UserInfoEndpoint == "" {
+			doc.UserInfoEndpoint = discovered.UserInfoEndpoint
+		}
+		if doc.AuthorizationEndpoint == "" || doc.TokenEndpoint == "" || doc.UserInfoEndpoint == "" {
+			return nil, fmt.Errorf("%w: discovery document is missing endpoints", ErrProviderResponse)
+		}
+	}
+	p.endpoints = &doc
+	return p.endpoints, nil
+}
+
+// AuthCodeURL returns where to send the user to sign in
+func (p *oidcProvider) AuthCodeURL(ctx context.Context, state, nonce, codeChallenge, redirectURI string) (string, error) {
+	endpoints, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	return authCodeURL(endpoints.AuthorizationEndpoint, p.cfg.ClientID, redirectURI, state, nonce, codeChallenge, p.cfg.Scopes)
+}
+
+// idTokenClaims are the ID token claims checked at sign-in
+type idTokenClaims struct {
+	Issuer   string      `json:"iss"`
+	Subject  string      `json:"sub"`
+	Audience interface{} `json:"aud"` // A string or a list of strings
+	Nonce    string      `json:"nonce"`
+}
+
+// checkIDToken decodes an ID token received from the token endpoint and checks that it was issued
+// by issuer, when known, to clientID for the login that sent nonce
+func checkIDToken(raw, issuer, clientID, nonce string) (*idTokenClaims, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed id token", ErrProviderResponse)
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed id token: %v", ErrProviderResponse, err)
+	}
+	var claims idTokenClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, fmt.Errorf("%w: malformed id token: %v", ErrProviderResponse, err)
+	}
+
+	if issuer != "" && strings.TrimSuffix(claims.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("%w: id token is from issuer %q", ErrProviderResponse, claims.Issuer)
+	}
+	audience := false
+	switch aud := claims.Audience.(type) {
+	case string:
+		audience = aud == clientID
+	case []interface{}:
+		for _, a := range aud {
+			if a == clientID {
+				audience = true
+			}
+		}
+	}
+	if !audience {
+		return nil, fmt.Errorf("%w: id token is not for this client", ErrProviderResponse)
+	}
+	if claims.Nonce != nonce {
+		return nil, fmt.Errorf("%w: id token nonce does not match the login", ErrProviderResponse)
+	}
+	return &claims, nil
+}
+
+// oidcUserInfo is the standard claims response. Some providers send email_verified as a string.
+type oidcUserInfo struct {
+	Subject       string      `json:"sub"`
+	Email         string      `json:"email"`
+	EmailVerified interface{} `json:"email_verified"`
+	Name          string      `json:"name"`
+}
+
+// Exchange redeems the authorization code and looks up who signed in
+func (p *oidcProvider) Exchange(ctx context.Context, code, codeVerifier, nonce, redirectURI string) (*Identity, error) {
+	endpoints, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tok, err := exchangeCode(ctx, p.client, endpoints.TokenEndpoint, p.cfg.ClientID, p.cfg.ClientSecret, code, codeVerifier, redirectURI)
+	if err != nil {
+		return nil, err
+	}
+	if tok.IDToken == "" {
+		return nil, fmt.Errorf("%w: token response has no id token", ErrProviderResponse)
+	}
+	claims, err := checkIDToken(tok.IDToken, endpoints.Issuer, p.cfg.ClientID, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	var info oidcUserInfo
+	if err := getJSON(ctx, p.client, endpoints.UserInfoEndpoint, tok.AccessToken, &info); err != nil {
+		return nil, err
+	}
+	if info.Subject == "" {
+		return nil, fmt.Errorf("%w: userinfo has no subject", ErrProviderResponse)
+	}
+	// Userinfo must describe the user the ID token was issued for (OIDC Core 5.3.2)
+	if info.Subject != claims.Subject {
+		return nil, fmt.Errorf("%w: userinfo subject does not match the id token", ErrProviderResponse)
+	}
+	verified := false
+	switch v := info.EmailVerified.(type) {
+	case bool:
+		verified = v
+	case string:
+		verified = v == "true"
+	}
+	return &Identity{Subject: info.Subject, Email: info.Email, EmailVerified: verified, Name: info.Name}, nil
+}
@@ -0,0 +1,205 @@
+package oauth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"clipboard-sync-backend/configs"
+	"clipboard-sync-backend/internal/oauth/oauthtest"
+)
+
+const testRedirectURI = "https://clip.example.com/api/v1/oauth/idp/callback"
+
+var alice = oauthtest.User{Subject: "alice-123", Email: "alice@example.com", EmailVerified: true, Name: "Alice"}
+
+func newTestOIDCProvider(t *testing.T, idp *oauthtest.Server) Provider {
+	t.Helper()
+	p, err := NewOIDCProvider(configs.OAuthProviderConfig{
+		Name:         "idp",
+		Type:         TypeOIDC,
+		ClientID:     idp.ClientID,
+		ClientSecret: idp.ClientSecret,
+		IssuerURL:    idp.URL + "/",
+	}, idp.Client())
+	if err != nil {
+		t.Fatalf("NewOIDCProvider() error = %v", err)
+	}
+	return p
+}
+
+// signIn runs the browser part of a login and returns the authorization code
+func signIn(t *testing.T, idp *oauthtest.Server, p Provider, state, nonce, challenge string, user oauthtest.User) string {
+	t.Helper()
+	authURL, err := p.AuthCodeURL(context.Background(), state, nonce, challenge, testRedirectURI)
+	if err != nil {
+		t.Fatalf("AuthCodeURL() error = %v", err)
+	}
+	code, gotState, err := idp.Authorize(authURL, user)
+	if err != nil {
+		t.Fatalf("provider rejected the authorization request: %v", err)
+	}
+	if gotState != state {
+		t.Fatalf("provider returned state %q, want %q", gotState, state)
+	}
+	return code
+}
+
+func TestNewPKCE(t *testing.T) {
+	verifier, challenge, err := NewPKCE()
+	if err != nil {
+		t.Fatalf("NewPKCE() error = %v", err)
+	}
+	if len(verifier) < 43 || len(verifier) > 128 {
+		t.Fatalf("verifier has %d characters, RFC 7636 requires 43 to 128", len(verifier))
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	if want := base64.RawURLEncoding.EncodeToString(sum[:]); challenge != want {
+		t.Fatalf("challenge = %q, want S256 of the verifier %q", challenge, want)
+	}
+	if other, _, _ := NewPKCE(); other == verifier {
+		t.Fatal("NewPKCE() returned the same verifier twice")
+	}
+}
+
+func TestOIDCAuthCodeURL(t *testing.T) {
+	idp := oauthtest.NewServer("client-1", "secret-1")
+	defer idp.Close()
+	p := newTestOIDCProvider(t, idp)
+
+	authURL, err := p.AuthCodeURL(context.Background(), "state-1", "nonce-1", "challenge-1", testRedirectURI)
+	if err != nil {
+		t.Fatalf("AuthCodeURL() error = %v", err)
+	}
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := u.Scheme + "://" + u.Host + u.Path; got != idp.URL+"/authorize" {
+		t.Fatalf("authorization endpoint = %s, want the discovered %s/authorize", got, idp.URL)
+	}
+	want := map[string]string{
+		"response_type":         "code",
+		"client_id":             "client-1",
+		"redirect_uri":          testRedirectURI,
+		"state":                 "state-1",
+		"nonce":                 "nonce-1",
+		"code_challenge":        "challenge-1",
+		"code_challenge_method": "S256",
+		"scope":                 "openid email profile",
+	}
+	for k, v := range want {
+		if got := u.Query().Get(k); got != v {
+			t.Errorf("%s = %q, want %q", k, got, v)
+		}
+	}
+}
+
+func TestOIDCExchange(t *testing.T) {
+	idp := oauthtest.NewServer("client-1", "secret-1")
+	defer idp.Close()
+	p := newTestOIDCProvider(t, idp)
+	verifier, challenge, err := NewPKCE()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code := signIn(t, idp, p, "state-1", "nonce-1", challenge, alice)
+	identity, err := p.Exchange(context.Background(), code, verifier, "nonce-1", testRedirectURI)
+	if err != nil {
+		t.Fatalf("Exchange() error = %v", err)
+	}
+	want := Identity{Subject: "alice-123", Email: "alice@example.com", EmailVerified: true, Name: "Alice"}
+	if *identity != want {
+		t.Fatalf("Exchange() = %+v, want %+v", *identity, want)
+	}
+
+	// Codes are single-use
+	if _, err := p.Exchange(context.Background(), code, verifier, "nonce-1", testRedirectURI); !errors.Is(err, ErrProviderResponse) {
+		t.Fatalf("second Exchange() of a code error = %v, want ErrProviderResponse", err)
+	}
+}
+
+func TestOIDCExchangeRejects(t *testing.T) {
+	tests := []struct {
+		name     string
+		verifier string // Replaces the real verifier when set
+		nonce    string // Expected by Exchange; the login sends "nonce-1"
+		tamper   func(claims map[string]interface{})
+	}{
+		{name: "wrong PKCE verifier", verifier: "not-the-verifier-not-the-verifier-not-the-verifier", nonce: "nonce-1"},
+		{name: "nonce of another login", nonce: "nonce-2"},
+		{name: "ID token without nonce", nonce: "nonce-1", tamper: func(c map[string]interface{}) { delete(c, "nonce") }},
+		{name: "ID token from another issuer", nonce: "nonce-1", tamper: func(c map[string]interface{}) { c["iss"] = "https://evil.example.com" }},
+		{name: "ID token for another client", nonce: "nonce-1", tamper: func(c map[string]interface{}) { c["aud"] = []string{"client-2"} }},
+		{name: "ID token for another user", nonce: "nonce-1", tamper: func(c map[string]interface{}) { c["sub"] = "mallory-456" }},
+		{name: "no ID token", nonce: "nonce-1", tamper: func(c map[string]interface{}) {
+			for k := range c {
+				delete(c, k)
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idp := oauthtest.NewServer("client-1", "secret-1")
+			defer idp.Close()
+			idp.TamperIDToken = tt.tamper
+			p := newTestOIDCProvider(t, idp)
+			verifier, challenge, err := NewPKCE()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.verifier != "" {
+				verifier = tt.verifier
+			}
+
+			code := signIn(t, idp, p, "state-1", "nonce-1", challenge, alice)
+			if _, err := p.Exchange(context.Background(), code, verifier, tt.nonce, testRedirectURI); !errors.Is(err, ErrProviderResponse) {
+				t.Fatalf("Exchange() error = %v, want ErrProviderResponse", err)
+			}
+		})
+	}
+}
+
+func TestOIDCAudienceList(t *testing.T) {
+	idp := oauthtest.NewServer("client-1", "secret-1")
+	defer idp.Close()
+	idp.TamperIDToken = func(c map[string]interface{}) { c["aud"] = []string{"client-2", "client-1"} }
+	p := newTestOIDCProvider(t, idp)
+	verifier, challenge, err := NewPKCE()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code := signIn(t, idp, p, "state-1", "nonce-1", challenge, alice)
+	if _, err := p.Exchange(context.Background(), code, verifier, "nonce-1", testRedirectURI); err != nil {
+		t.Fatalf("Exchange() with the client among several audiences error = %v", err)
+	}
+}
+
+func TestOIDCDiscoveryIssuerMismatch(t *testing.T) {
+	idp := oauthtest.NewServer("client-1", "secret-1")
+	defer idp.Close()
+	// A discovery document served for one issuer but naming another must not be trusted
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, idp.URL+r.URL.Path, http.StatusFound)
+	}))
+	defer proxy.Close()
+	p, err := NewOIDCProvider(configs.OAuthProviderConfig{
+		Name:      "idp",
+		Type:      TypeOIDC,
+		ClientID:  "client-1",
+		IssuerURL: proxy.URL,
+	}, proxy.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.AuthCodeURL(context.Background(), "s", "n", "c", testRedirectURI); !errors.Is(err, ErrProviderResponse) {
+		t.Fatalf("AuthCodeURL() error = %v, want ErrProviderResponse", err)
+	}
+}
@@ -0,0 +1,215 @@
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"clipboard-sync-backend/configs"
+)
+
+// Provider types
+const (
+	TypeOIDC   = "oidc"
+	TypeGitHub = "github"
+)
+
+const maxResponseBytes = 1 << 20
+
+var (
+	ErrUnsupportedProvider = errors.New("unsupported oauth provider type")
+	// ErrProviderResponse is returned when a provider rejects a request or answers with something unusable
+	ErrProviderResponse = errors.New("unexpected response from oauth provider")
+)
+
+// Identity is what a provider tells us about the user who signed in
+type Identity struct {
+	Subject       string // The provider's stable user ID
+	Email         string
+	EmailVerified bool // Whether the provider vouches for the address; required before linking by email
+	Name          string
+}
+
+// Provider is an OAuth 2.0 authorization server users can sign in with. Logins use the
+// authorization code flow with PKCE. Providers that issue ID tokens check that they carry the
+// nonce sent with the authorization request; others ignore it.
+type Provider interface {
+	Name() string
+	AuthCodeURL(ctx context.Context, state, nonce, codeChallenge, redirectURI string) (string, error)
+	Exchange(ctx context.Context, code, codeVerifier, nonce, redirectURI string) (*Identity, error)
+}
+
+// Factory builds a provider from its configuration
+type Factory func(cfg configs.OAuthProviderConfig, client *http.Client) (Provider, error)
+
+var factories = map[string]Factory{
+	TypeOIDC:   NewOIDCProvider,
+	TypeGitHub: NewGitHubProvider,
+}
+
+// Register makes a provider type available to NewProviders; call it before NewProviders runs,
+// e.g. from an init function
+func Register(providerType string, factory Factory) {
+	factories[providerType] = factory
+}
+
+// NewProviders builds the configured providers keyed by name. Providers without a client ID are
+// skipped so the sample configuration can list them unfilled.
+func NewProviders(cfg configs.OAuthConfig) (map[string]Provider, error) {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	client := &http.Client{Timeout: timeout}
+
+	providers := make(map[string]Provider)
+	for _, pc := range cfg.Providers {
+		if pc.ClientID == "" {
+			continue
+		}
+		if pc.Name == "" {
+			return nil, errors.New("oauth provider without a name")
+		}
+		if _, dup := providers[pc.Name]; dup {
+			return nil, fmt.Errorf("duplicate oauth provider %q", pc.Name)
+		}
+		factory, ok := factories[pc.Type]
+		if !ok {
+			return nil, fmt.Errorf("%w %q for provider %q", ErrUnsupportedProvider, pc.Type, pc.Name)
+		}
+		p, err := factory(pc, client)
+		if err != nil {
+			return nil, fmt.Errorf("oauth provider %q: %w", pc.Name, err)
+		}
+		providers[pc.Name] = p
+	}
+	return providers, nil
+}
+
+// NewPKCE returns a code verifier and its S256 challenge (RFC 7636)
+func NewPKCE() (verifier, challenge string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", fmt.Errorf("failed to generate code verifier: %w", err)
+	}
+	verifier = base64.RawURLEncoding.EncodeToString(b)
+	sum := sha256.Sum256([]byte(verifier))
+	return verifier, base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// authCodeURL builds an authorization request for the code flow with PKCE; nonce is left out
+// when empty
+func authCodeURL(endpoint, clientID, redirectURI, state, nonce, codeChallenge string, scopes []string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid authorization endpoint: %w", err)
+	}
+	q := u.Query()
+	q.Set("response_type", "code")
+	q.Set("client_id", clientID)
+	q.Set("redirect_uri", redirectURI)
+	q.Set("state", state)
+	if nonce != "" {
+		q.Set("nonce", nonce)
+	}
+	q.Set("code_challenge", codeChallenge)
+	q.Set("code_challenge_method", "S256")
+	if len(scopes) > 0 {
+		q.Set("scope", strings.Join(scopes, " "))
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// exchangeCode redeems an authorization code at the token endpoint. The client authenticates
+// with client_secret_post, which both OIDC providers and GitHub accept.
+func exchangeCode(ctx context.Context, client *http.Client, tokenURL, clientID, clientSecret, code, codeVerifier, redirectURI string) (*tokenResponse, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirectURI},
+		"code_verifier": {codeVerifier},
+		"client_id":     {clientID},
+	}
+	if clientSecret != "" {
+		form.Set("client_secret", clientSecret)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var tok tokenResponse
+	status, err := doJSON(client, req, &tok)
+	if err != nil {
+		return nil, err
+	}
+	// GitHub reports errors with a 200 status, so the body is checked as well
+	if tok.Error != "" {
+		return nil, fmt.Errorf("%w: %s", ErrProviderResponse, strings.TrimSpace(tok.Error+" "+tok.ErrorDescription))
+	}
+	if status != http.StatusOK || tok.AccessToken == "" {
+		return nil, fmt.Errorf("%w: token endpoint returned status %d", ErrProviderResponse, status)
+	}
+	return &tok, nil
+}
+
+// getJSON fetches url with the access token and decodes the JSON response into v
+func getJSON(ctx context.Context, client *http.Client, rawURL, accessToken string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+	status, err := doJSON(client, req, v)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("%w: %s returned status %d", ErrProviderResponse, req.URL.Path, status)
+	}
+	return nil
+}
+
+// doJSON sends req and decodes a JSON body into v whatever the status, which is returned
+func doJSON(client *http.Client, req *http.Request, v interface{}) (int, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("request to oauth provider failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return resp.StatusCode, fmt.Errorf("failed to read oauth provider response: %w", err)
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return resp.StatusCode, nil
+		}
+		return resp.StatusCode, fmt.Errorf("%w: %v", ErrProviderResponse, err)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,139 @@
+package password
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// cheapParams keep the tests fast; production uses DefaultParams
+var cheapParams = Params{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+// referenceHash is the argon2id example from the reference implementation's README:
+// password "password", salt "somesalt", m=65536, t=2, p=1
+const referenceHash = "$argon2id$v=19$m=65536,t=2,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc"
+
+func TestHashRoundTrip(t *testing.T) {
+	h := NewHasher(cheapParams)
+	hash, err := h.Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$") {
+		t.Fatalf("Hash() = %s, want a PHC argon2id string with the configured parameters", hash)
+	}
+	params, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		t.Fatalf("decodeArgon2id() error = %v", err)
+	}
+	want := cheapParams
+	if params != want || len(salt) != 16 || len(key) != 32 {
+		t.Fatalf("decoded %+v with %d-byte salt and %d-byte key, want %+v", params, len(salt), len(key), want)
+	}
+
+	if ok, err := h.Verify(hash, "correct horse"); !ok || err != nil {
+		t.Fatalf("Verify() with the right password = %v, %v", ok, err)
+	}
+	if ok, err := h.Verify(hash, "Correct horse"); ok || err != nil {
+		t.Fatalf("Verify() with a wrong password = %v, %v", ok, err)
+	}
+	if other, _ := h.Hash("correct horse"); other == hash {
+		t.Fatal("Hash() reused a salt")
+	}
+}
+
+func TestVerifyReferenceHash(t *testing.T) {
+	// Parameters come from the hash, not from the hasher
+	h := NewHasher(cheapParams)
+	if ok, err := h.Verify(referenceHash, "password"); !ok || err != nil {
+		t.Fatalf("Verify() of the reference hash = %v, %v", ok, err)
+	}
+	if ok, _ := h.Verify(referenceHash, "passwore"); ok {
+		t.Fatal("Verify() of the reference hash accepted a wrong password")
+	}
+}
+
+func TestVerifyBcrypt(t *testing.T) {
+	h := NewHasher(cheapParams)
+	legacy, err := bcrypt.GenerateFromPassword([]byte("correct horse"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, prefix := range []string{"$2a$", "$2b$", "$2y$"} {
+		hash := prefix + string(legacy[4:])
+		if ok, err := h.Verify(hash, "correct horse"); !ok || err != nil {
+			t.Fatalf("Verify(%s...) with the right password = %v, %v", prefix, ok, err)
+		}
+		if ok, err := h.Verify(hash, "wrong"); ok || err != nil {
+			t.Fatalf("Verify(%s...) with a wrong password = %v, %v", prefix, ok, err)
+		}
+		if !h.NeedsRehash(hash) {
+			t.Fatalf("NeedsRehash(%s...) = false, want bcrypt hashes upgraded", prefix)
+		}
+	}
+}
+
+func TestVerifyMalformedHash(t *testing.T) {
+	h := NewHasher(cheapParams)
+	for _, hash := range []string{
+		"",
+		"plaintext",
+		"$argon2i$v=19$m=65536,t=2,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc",
+		"$argon2id$v=16$m=65536,t=2,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc",
+		"$argon2id$v=19$m=65536$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc",
+		"$argon2id$v=19$m=65536,t=2,p=1$!!!$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc",
+		"$argon2id$v=19$m=65536,t=2,p=1$c29tZXNhbHQ$",
+	} {
+		if ok, err := h.Verify(hash, "password"); ok || !errors.Is(err, ErrUnknownHashFormat) {
+			t.Errorf("Verify(%q) = %v, %v; want ErrUnknownHashFormat", hash, ok, err)
+		}
+		if !h.NeedsRehash(hash) {
+			t.Errorf("NeedsRehash(%q) = false, want true", hash)
+		}
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	old, err := NewHasher(cheapParams).Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		changed func(p *Params)
+		want    bool
+	}{
+		{"same parameters", func(p *Params) {}, false},
+		{"more memory", func(p *Params) { p.Memory *= 2 }, true},
+		{"more iterations", func(p *Params) { p.Iterations++ }, true},
+		{"more parallelism", func(p *Params) { p.Parallelism++ }, true},
+		{"longer key", func(p *Params) { p.KeyLength = 64 }, true},
+		{"less memory", func(p *Params) { p.Memory /= 2 }, false},
+		{"longer salt only", func(p *Params) { p.SaltLength = 32 }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := cheapParams
+			tt.changed(&params)
+			h := NewHasher(params)
+			if got := h.NeedsRehash(old); got != tt.want {
+				t.Fatalf("NeedsRehash() = %v, want %v", got, tt.want)
+			}
+			// The old hash still verifies whatever the new parameters are
+			if ok, err := h.Verify(old, "correct horse"); !ok || err != nil {
+				t.Fatalf("Verify() after the change = %v, %v", ok, err)
+			}
+		})
+	}
+}
+
+func TestNewHasherDefaults(t *testing.T) {
+	h := NewHasher(Params{Iterations: 5}).(*argon2idHasher)
+	want := DefaultParams
+	want.Iterations = 5
+	if h.params != want {
+		t.Fatalf("params = %+v, want %+v", h.params, want)
+	}
+}
@@ -0,0 +1,105 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	ErrTooShort = errors.New("password is too short")
+	ErrTooLong  = errors.New("password is too long")
+	ErrBreached = errors.New("password has appeared in a data breach; choose a different one")
+)
+
+// Policy is what new passwords must satisfy. Reuse of earlier passwords is checked by the caller,
+// which has the user's history.
+type Policy struct {
+	MinLength int // In characters
+	MaxLength int // In characters; zero for no limit
+	Breached  *BreachedList
+}
+
+// Check validates password against the policy
+func (p Policy) Check(password string) error {
+	n := utf8.RuneCountInString(password)
+	if n < p.MinLength {
+		return fmt.Errorf("%w; use at least %d characters", ErrTooShort, p.MinLength)
+	}
+	if p.MaxLength > 0 && n > p.MaxLength {
+		return fmt.Errorf("%w; use at most %d characters", ErrTooLong, p.MaxLength)
+	}
+	if p.Breached != nil && p.Breached.Contains(password) {
+		return ErrBreached
+	}
+	return nil
+}
+
+// BreachedList holds SHA-1 hashes of passwords known from data breaches, in the format of the
+// Pwned Passwords range API: one "HASH" or "HASH:COUNT" per line. Hashes are grouped by their
+// five-character prefix, the way range lookups are, so plaintext passwords are never kept.
+type BreachedList struct {
+	ranges map[string]map[string]struct{} // Prefix to suffixes
+	size   int
+}
+
+// LoadBreachedList reads a breached password list file. Blank lines and lines starting with #
+// are skipped.
+func LoadBreachedList(path string) (*BreachedList, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open breached password list: %w", err)
+	}
+	defer f.Close()
+
+	list := &BreachedList{ranges: make(map[string]map[string]struct{})}
+	scanner := bufio.NewScanner(f)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		hash := strings.ToUpper(text)
+		if i := strings.IndexByte(hash, ':'); i >= 0 {
+			hash = hash[:i]
+		}
+		if len(hash) != sha1.Size*2 {
+			return nil, fmt.Errorf("breached password list line %d: not a SHA-1 hash", line)
+		}
+		if _, err := hex.DecodeString(hash); err != nil {
+			return nil, fmt.Errorf("breached password list line %d: not a SHA-1 hash", line)
+		}
+		prefix, suffix := hash[:5], hash[5:]
+		if list.ranges[prefix] == nil {
+			list.ranges[prefix] = make(map[string]struct{})
+		}
+		if _, ok := list.ranges[prefix][suffix]; !ok {
+			list.ranges[prefix][suffix] = struct{}{}
+			list.size++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read breached password list: %w", err)
+	}
+	return list, nil
+}
+
+// Len returns the number of hashes in the list
+func (l *BreachedList) Len() int {
+	return l.size
+}
+
+// Contains reports whether password is on the list
+func (l *BreachedList) Contains(password string) bool {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	_, ok := l.ranges[hash[:5]][hash[5:]]
+	return ok
+}
@@ -0,0 +1,103 @@
+package password
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// SHA-1 of "password" is 5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8
+const breachedList = `# Pwned Passwords sample
+5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:9545824
+
+  7c4a8d09ca3762af61e59520943dc26494f8941b:123
+5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8
+`
+
+func writeList(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestBreachedList(t *testing.T) {
+	list, err := LoadBreachedList(writeList(t, breachedList))
+	if err != nil {
+		t.Fatalf("LoadBreachedList() error = %v", err)
+	}
+	if list.Len() != 2 {
+		t.Fatalf("Len() = %d, want 2 without the duplicate", list.Len())
+	}
+	for pw, want := range map[string]bool{
+		"password":  true,
+		"123456":    true, // Listed in lower case with a count
+		"Password":  false,
+		"password ": false,
+		"":          false,
+	} {
+		if got := list.Contains(pw); got != want {
+			t.Errorf("Contains(%q) = %v, want %v", pw, got, want)
+		}
+	}
+
+	// Lookups go by prefix then suffix: sharing the five-character prefix is not a match
+	if _, ok := list.ranges["5BAA6"]["1E4C9B93F3F0682250B6CF8331B7EE68FD8"]; !ok {
+		t.Fatalf("ranges = %v, want password's suffix under prefix 5BAA6", list.ranges)
+	}
+	list.ranges["5BAA6"] = map[string]struct{}{"0000000000000000000000000000000000F": {}}
+	if list.Contains("password") {
+		t.Fatal("Contains() matched on the prefix alone")
+	}
+}
+
+func TestLoadBreachedListErrors(t *testing.T) {
+	tests := []struct {
+		name, body, want string
+	}{
+		{"short hash", "5BAA61E4C9B93F3F\n", "line 1: not a SHA-1 hash"},
+		{"not hex", "# header\nZZAA61E4C9B93F3F0682250B6CF8331B7EE68FD8\n", "line 2: not a SHA-1 hash"},
+		{"sha-256", strings.Repeat("a", 64) + "\n", "line 1: not a SHA-1 hash"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadBreachedList(writeList(t, tt.body)); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("LoadBreachedList() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+	if _, err := LoadBreachedList(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Fatal("LoadBreachedList() of a missing file succeeded")
+	}
+}
+
+func TestPolicyCheck(t *testing.T) {
+	list, err := LoadBreachedList(writeList(t, breachedList))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := Policy{MinLength: 8, MaxLength: 12, Breached: list}
+	tests := []struct {
+		password string
+		want     error
+	}{
+		{"correct h", nil},
+		{"short", ErrTooShort},
+		{"ééééééé", ErrTooShort}, // Seven characters in fourteen bytes
+		{"éééééééé", nil},
+		{"much too long pw", ErrTooLong},
+		{"password", ErrBreached},
+	}
+	for _, tt := range tests {
+		if err := p.Check(tt.password); !errors.Is(err, tt.want) {
+			t.Errorf("Check(%q) = %v, want %v", tt.password, err, tt.want)
+		}
+	}
+	if err := (Policy{MinLength: 8}).Check(strings.Repeat("a", 1000)); err != nil {
+		t.Fatalf("Check() without a maximum = %v", err)
+	}
+}
@@ -0,0 +1,80 @@
+// Package policy decides what each team role may do. It is the single source of truth for team
+// permissions; services, REST middleware and the WebSocket path all ask it.
+package policy
+
+import "clipboard-sync-backend/internal/models"
+
+// Permission is an action a team member may be allowed to perform
+type Permission string
+
+const (
+	PostEntry           Permission = "post_entry"            // Add entries to the team clipboard
+	ReadHistory         Permission = "read_history"          // Read and search the team clipboard, receive live entries
+	DeleteOthersEntries Permission = "delete_others_entries" // Delete entries posted by other members
+	ManageMembers       Permission = "manage_members"        // Invite, remove and change roles of members
+	ManageWebhooks      Permission = "manage_webhooks"       // Create and manage team webhooks
+	ManageTeam          Permission = "manage_team"           // Rename the team
+	DeleteTeam          Permission = "delete_team"           // Delete the team
+	ViewAuditLog        Permission = "view_audit_log"        // Read and export the team's audit log
+)
+
+// rolePermissions is the permission matrix. Roles not listed have no permissions.
+var rolePermissions = map[string][]Permission{
+	models.TeamRoleOwner:  {PostEntry, ReadHistory, DeleteOthersEntries, ManageMembers, ManageWebhooks, ManageTeam, DeleteTeam, ViewAuditLog},
+	models.TeamRoleAdmin:  {PostEntry, ReadHistory, DeleteOthersEntries, ManageMembers, ManageWebhooks, ManageTeam, ViewAuditLog},
+	models.TeamRoleEditor: {PostEntry, ReadHistory},
+	models.TeamRoleViewer: {ReadHistory},
+}
+
+// roleRank orders roles by authority; members may only manage roles ranked below their own
+var roleRank = map[string]int{
+	models.TeamRoleViewer: 1,
+	models.TeamRoleEditor: 2,
+	models.TeamRoleAdmin:  3,
+	models.TeamRoleOwner:  4,
+}
+
+// Roles lists the assignable roles from most to least privileged
+var Roles = []string{models.TeamRoleOwner, models.TeamRoleAdmin, models.TeamRoleEditor, models.TeamRoleViewer}
+
+// Normalize maps legacy role names onto current ones; "member" predates the role model and
+// behaves like "editor"
+func Normalize(role string) string {
+	if role == models.TeamRoleMember {
+		return models.TeamRoleEditor
+	}
+	return role
+}
+
+// ValidRole reports whether role can be assigned to a member
+func ValidRole(role string) bool {
+	_, ok := roleRank[role]
+	return ok
+}
+
+// Can reports whether a member with role holds perm
+func Can(role string, perm Permission) bool {
+	for _, p := range rolePermissions[Normalize(role)] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// CanManage reports whether a member with actorRole may change the role of, or remove, a member
+// with targetRole. Owners may manage anyone; others only members ranked below them.
+func CanManage(actorRole, targetRole string) bool {
+	actorRole, targetRole = Normalize(actorRole), Normalize(targetRole)
+	if !Can(actorRole, ManageMembers) {
+		return false
+	}
+	return actorRole == models.TeamRoleOwner || roleRank[actorRole] > roleRank[targetRole]
+}
+
+// CanAssign reports whether a member with actorRole may grant role to someone, whether by
+// changing a role or through an invitation. Nobody can grant a role above their own.
+func CanAssign(actorRole, role string) bool {
+	actorRole = Normalize(actorRole)
+	return Can(actorRole, ManageMembers) && ValidRole(role) && roleRank[role] <= roleRank[actorRole]
+}
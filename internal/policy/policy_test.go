@@ -0,0 +1,97 @@
+package policy
+
+import (
+	"testing"
+
+	"clipboard-sync-backend/internal/models"
+)
+
+var allPermissions = []Permission{PostEntry, ReadHistory, DeleteOthersEntries, ManageMembers, ManageWebhooks, ManageTeam, DeleteTeam, ViewAuditLog}
+
+func TestCan(t *testing.T) {
+	tests := []struct {
+		role  string
+		allow []Permission
+	}{
+		{models.TeamRoleOwner, allPermissions},
+		{models.TeamRoleAdmin, []Permission{PostEntry, ReadHistory, DeleteOthersEntries, ManageMembers, ManageWebhooks, ManageTeam, ViewAuditLog}},
+		{models.TeamRoleEditor, []Permission{PostEntry, ReadHistory}},
+		{models.TeamRoleMember, []Permission{PostEntry, ReadHistory}},
+		{models.TeamRoleViewer, []Permission{ReadHistory}},
+		{"", nil},
+		{"superuser", nil},
+	}
+	for _, tt := range tests {
+		allowed := make(map[Permission]bool, len(tt.allow))
+		for _, p := range tt.allow {
+			allowed[p] = true
+		}
+		for _, perm := range allPermissions {
+			if got := Can(tt.role, perm); got != allowed[perm] {
+				t.Errorf("Can(%q, %s) = %v, want %v", tt.role, perm, got, allowed[perm])
+			}
+		}
+	}
+}
+
+func TestCanManage(t *testing.T) {
+	const (
+		owner  = models.TeamRoleOwner
+		admin  = models.TeamRoleAdmin
+		editor = models.TeamRoleEditor
+		member = models.TeamRoleMember
+		viewer = models.TeamRoleViewer
+	)
+	// want[actor][target]; pairs not listed are refused. Unknown roles rank below every real one.
+	want := map[string]map[string]bool{
+		owner: {owner: true, admin: true, editor: true, member: true, viewer: true, "unknown": true},
+		admin: {editor: true, member: true, viewer: true, "unknown": true},
+	}
+	roles := []string{owner, admin, editor, member, viewer, "unknown"}
+	for _, actor := range roles {
+		for _, target := range roles {
+			if got := CanManage(actor, target); got != want[actor][target] {
+				t.Errorf("CanManage(%q, %q) = %v, want %v", actor, target, got, want[actor][target])
+			}
+		}
+	}
+}
+
+func TestCanAssign(t *testing.T) {
+	const (
+		owner  = models.TeamRoleOwner
+		admin  = models.TeamRoleAdmin
+		editor = models.TeamRoleEditor
+		member = models.TeamRoleMember
+		viewer = models.TeamRoleViewer
+	)
+	// want[actor][role]; pairs not listed are refused. "member" is legacy and never assignable.
+	want := map[string]map[string]bool{
+		owner: {owner: true, admin: true, editor: true, viewer: true},
+		admin: {admin: true, editor: true, viewer: true},
+	}
+	roles := []string{owner, admin, editor, member, viewer, "unknown"}
+	for _, actor := range roles {
+		for _, role := range roles {
+			if got := CanAssign(actor, role); got != want[actor][role] {
+				t.Errorf("CanAssign(%q, %q) = %v, want %v", actor, role, got, want[actor][role])
+			}
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		role, want string
+	}{
+		{models.TeamRoleMember, models.TeamRoleEditor},
+		{models.TeamRoleOwner, models.TeamRoleOwner},
+		{models.TeamRoleViewer, models.TeamRoleViewer},
+		{"unknown", "unknown"},
+	}
+	for _, tt := range tests {
+		if got := Normalize(tt.role); got != tt.want {
+			t.Errorf("Normalize(%q) = %q, want %q", tt.role, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"time"
+
+	"clipboard-sync-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// AccessTokenRepository defines the interface for personal access token data operations
+type AccessTokenRepository interface {
+	CreateToken(token *models.PersonalAccessToken) error
+	GetTokenByID(id uint) (*models.PersonalAccessToken, error)
+	GetTokenByHash(tokenHash string) (*models.PersonalAccessToken, error)
+	GetTokensByUserID(userID uint) ([]models.PersonalAccessToken, error)
+	CountActiveTokens(userID uint, now time.Time) (int64, error)
+	TouchToken(id uint, usedAt time.Time, ip string) error
+	RevokeToken(id uint, now time.Time) error
+}
+
+type accessTokenRepository struct {
+	db *gorm.DB
+}
+
+// NewAccessTokenRepository creates a new AccessTokenRepository
+func NewAccessTokenRepository(db *gorm.DB) AccessTokenRepository {
+	return &accessTokenRepository{db: db}
+}
+
+// CreateToken creates a new personal access token
+func (r *accessTokenRepository) CreateToken(token *models.PersonalAccessToken) error {
+	return r.db.Create(token).Error
+}
+
+// GetTokenByID retrieves a personal access token by ID
+func (r *accessTokenRepository) GetTokenByID(id uint) (*models.PersonalAccessToken, error) {
+	var token models.PersonalAccessToken
+	if err := r.db.First(&token, id).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// GetTokenByHash retrieves a personal access token by the hash of its secret, with its user
+func (r *accessTokenRepository) GetTokenByHash(tokenHash string) (*models.PersonalAccessToken, error) {
+	var token models.PersonalAccessToken
+	if err := r.db.Preload("User").Where("token_hash = ?", tokenHash).First(&token).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// GetTokensByUserID retrieves the user's unrevoked tokens, newest first
+func (r *accessTokenRepository) GetTokensByUserID(userID uint) ([]models.PersonalAccessToken, error) {
+	var tokens []models.PersonalAccessToken
+	if err := r.db.Where("user_id = ? AND revoked_at IS NULL", userID).Order("created_at DESC").Find(&tokens).Error; err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// CountActiveTokens counts the user's unrevoked, unexpired tokens
+func (r *accessTokenRepository) CountActiveTokens(userID uint, now time.Time) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.PersonalAccessToken{}).
+		Where("user_id = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", userID, now).
+		Count(&count).Error
+	return count, err
+}
+
+// TouchToken records when and from where a token was last used
+func (r *accessTokenRepository) TouchToken(id uint, usedAt time.Time, ip string) error {
+	return r.db.Model(&models.PersonalAccessToken{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"last_used_at": usedAt, "last_used_ip": ip}).Error
+}
+
+// RevokeToken marks a token revoked
+func (r *accessTokenRepository) RevokeToken(id uint, now time.Time) error {
+	return r.db.Model(&models.PersonalAccessToken{}).Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", now).Error
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"time"
+
+	"clipboard-sync-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// AccountTokenRepository defines the interface for email verification and password reset tokens
+type AccountTokenRepository interface {
+	CreateToken(token *models.AccountToken) error
+	GetTokenByTokenID(tokenID string) (*models.AccountToken, error)
+	UseToken(id uint, now time.Time) (bool, error)
+	VoidUserTokens(userID uint, purpose string, now time.Time) error
+}
+
+type accountTokenRepository struct {
+	db *gorm.DB
+}
+
+// NewAccountTokenRepository creates a new AccountTokenRepository
+func NewAccountTokenRepository(db *gorm.DB) AccountTokenRepository {
+	return &accountTokenRepository{db: db}
+}
+
+// CreateToken creates a new account token, voiding the user's earlier unused ones for the same
+// purpose so only the latest email works
+func (r *accountTokenRepository) CreateToken(token *models.AccountToken) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.AccountToken{}).
+			Where("user_id = ? AND purpose = ? AND used_at IS NULL", token.UserID, token.Purpose).
+			Update("used_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return tx.Create(token).Error
+	})
+}
+
+// GetTokenByTokenID retrieves an account token by its jti
+func (r *accountTokenRepository) GetTokenByTokenID(tokenID string) (*models.AccountToken, error) {
+	var token models.AccountToken
+	if err := r.db.Where("token_id = ?", tokenID).First(&token).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// UseToken marks a token used; it reports false if it already was
+func (r *accountTokenRepository) UseToken(id uint, now time.Time) (bool, error) {
+	result := r.db.Model(&models.AccountToken{}).Where("id = ? AND used_at IS NULL", id).Update("used_at", now)
+	return result.RowsAffected > 0, result.Error
+}
+
+// VoidUserTokens marks all of the user's unused tokens for purpose used
+func (r *accountTokenRepository) VoidUserTokens(userID uint, purpose string, now time.Time) error {
+	return r.db.Model(&models.AccountToken{}).Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+		Update("used_at", now).Error
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"time"
+
+	"clipboard-sync-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// AuditFilter narrows down audit log listings; zero values are ignored
+type AuditFilter struct {
+	UserID  *uint // Actions performed by this user
+	TeamID  *uint // Actions performed on this team
+	Actions []string
+	Since   *time.Time
+	Until   *time.Time
+}
+
+// AuditRepository defines the interface for audit log data operations
+type AuditRepository interface {
+	CreateLogs(logs []models.AuditLog) error
+	ListLogs(filter AuditFilter, limit, offset int) ([]models.AuditLog, error)
+}
+
+type auditRepository struct {
+	db *gorm.DB
+}
+
+// NewAuditRepository creates a new AuditRepository
+func NewAuditRepository(db *gorm.DB) AuditRepository {
+	return &auditRepository{db: db}
+}
+
+// CreateLogs inserts a batch of audit log records
+func (r *auditRepository) CreateLogs(logs []models.AuditLog) error {
+	if len(logs) == 0 {
+		return nil
+	}
+	return r.db.CreateInBatches(logs, 100).Error
+}
+
+// ListLogs retrieves audit log records matching the filter, newest first
+func (r *auditRepository) ListLogs(filter AuditFilter, limit, offset int) ([]models.AuditLog, error) {
+	query := r.db.Model(&models.AuditLog{})
+	if filter.UserID != nil {
+		query = query.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.TeamID != nil {
+		query = query.Where("team_id = ?", *filter.TeamID)
+	}
+	if len(filter.Actions) > 0 {
+		query = query.Where("action_type IN ?", filter.Actions)
+	}
+	if filter.Since != nil {
+		query = query.Where("timestamp_utc >= ?", *filter.Since)
+	}
+	if filter.Until != nil {
+		query = query.Where("timestamp_utc < ?", *filter.Until)
+	}
+
+	var logs []models.AuditLog
+	if err := query.Order("timestamp_utc DESC, log_id DESC").Limit(limit).Offset(offset).Find(&logs).Error; err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
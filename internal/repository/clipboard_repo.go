@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"time"
+
 	"clipboard-sync-backend/internal/models"
 
 	"gorm.io/gorm"
@@ -10,6 +12,12 @@ import (
 type ClipboardRepository interface {
 	CreateEntry(entry *models.ClipboardEntry) error
 	GetEntriesByUserID(userID uint, limit, offset int) ([]models.ClipboardEntry, error)
+	GetEntryByID(id uint) (*models.ClipboardEntry, error)
+	DeleteEntry(id uint) error
+	GetEntriesAfterID(userID, afterID uint, limit int) ([]models.ClipboardEntry, error)
+	EntryExists(userID uint, contentHash string, createdAt time.Time) (bool, error)
+	GetEntriesByTeamID(teamID uint, limit, offset int) ([]models.ClipboardEntry, error)
+	SearchTeamEntries(teamID uint, query string, limit, offset int) ([]models.ClipboardEntry, error)
 	// Add more clipboard-related repository methods as needed
 }
 
@@ -22,7 +30,7 @@ func NewClipboardRepository(db *gorm.DB) ClipboardRepository {
 	return &clipboardRepository{db: db}
 }
 
-// CreateEntry creates a new clipboard entry in the database
+// CreateEntry creates a new clipboard entry in the database, together with its representations
 func (r *clipboardRepository) CreateEntry(entry *models.ClipboardEntry) error {
 	return r.db.Create(entry).Error
 }
@@ -31,7 +39,60 @@ func (r *clipboardRepository) CreateEntry(entry *models.ClipboardEntry) error {
 func (r *clipboardRepository) GetEntriesByUserID(userID uint, limit, offset int) ([]models.ClipboardEntry, error) {
 	var entries []models.ClipboardEntry
 	// Order by CreatedAt in descending order to get most recent first
-	if err := r.db.Where("user_id = ? AND is_shared = ?", userID, false).Order("created_at DESC").Limit(limit).Offset(offset).Find(&entries).Error; err != nil {
+	if err := r.db.Preload("Representations").Preload("LinkPreview").Where("user_id = ? AND is_shared = ?", userID, false).Order("created_at DESC").Limit(limit).Offset(offset).Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// GetEntryByID retrieves a clipboard entry with its representations and link preview
+func (r *clipboardRepository) GetEntryByID(id uint) (*models.ClipboardEntry, error) {
+	var entry models.ClipboardEntry
+	if err := r.db.Preload("Representations").Preload("LinkPreview").First(&entry, id).Error; err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// GetEntriesAfterID pages through a user's personal entries in ascending ID order (keyset pagination)
+func (r *clipboardRepository) GetEntriesAfterID(userID, afterID uint, limit int) ([]models.ClipboardEntry, error) {
+	var entries []models.ClipboardEntry
+	if err := r.db.Preload("Representations").Where("user_id = ? AND is_shared = ? AND id > ?", userID, false, afterID).Order("id ASC").Limit(limit).Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// EntryExists reports whether the user already has an entry with the same content hash copied at the same time
+func (r *clipboardRepository) EntryExists(userID uint, contentHash string, createdAt time.Time) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.ClipboardEntry{}).
+		Where("user_id = ? AND content_hash = ? AND created_at >= ? AND created_at < ?", userID, contentHash, createdAt.Truncate(time.Second), createdAt.Truncate(time.Second).Add(time.Second)).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// DeleteEntry soft-deletes a clipboard entry
+func (r *clipboardRepository) DeleteEntry(id uint) error {
+	return r.db.Delete(&models.ClipboardEntry{}, id).Error
+}
+
+// GetEntriesByTeamID retrieves a team's shared clipboard entries, most recent first
+func (r *clipboardRepository) GetEntriesByTeamID(teamID uint, limit, offset int) ([]models.ClipboardEntry, error) {
+	var entries []models.ClipboardEntry
+	if err := r.db.Preload("Representations").Preload("LinkPreview").Where("team_id = ? AND is_shared = ?", teamID, true).Order("created_at DESC").Limit(limit).Offset(offset).Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// SearchTeamEntries finds a team's text entries containing query (case-insensitive), most recent first
+func (r *clipboardRepository) SearchTeamEntries(teamID uint, query string, limit, offset int) ([]models.ClipboardEntry, error) {
+	var entries []models.ClipboardEntry
+	if err := r.db.Preload("Representations").Preload("LinkPreview").
+		Where("team_id = ? AND is_shared = ? AND content_type LIKE 'text/%'", teamID, true).
+		Where("content ILIKE ?", "%"+escapeLike(query)+"%").
+		Order("created_at DESC").Limit(limit).Offset(offset).Find(&entries).Error; err != nil {
 		return nil, err
 	}
 	return entries, nil
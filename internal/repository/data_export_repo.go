@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"time"
+
+	"clipboard-sync-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// DataExportRepository defines the interface for personal data export data operations
+type DataExportRepository interface {
+	CreateDataExport(export *models.DataExport) error
+	UpdateDataExport(export *models.DataExport) error
+	GetDataExportByID(id uint) (*models.DataExport, error)
+	GetDataExportsByUserID(userID uint) ([]models.DataExport, error)
+	HasUnfinishedDataExport(userID uint) (bool, error)
+	RecordDataExportDownload(id uint, at time.Time) error
+	GetExpiredDataExports(now time.Time, limit int) ([]models.DataExport, error)
+	ClearDataExportFile(id uint) error
+}
+
+type dataExportRepository struct {
+	db *gorm.DB
+}
+
+// NewDataExportRepository creates a new DataExportRepository
+func NewDataExportRepository(db *gorm.DB) DataExportRepository {
+	return &dataExportRepository{db: db}
+}
+
+// CreateDataExport creates a new data export
+func (r *dataExportRepository) CreateDataExport(export *models.DataExport) error {
+	return r.db.Create(export).Error
+}
+
+// UpdateDataExport saves the current state of a data export
+func (r *dataExportRepository) UpdateDataExport(export *models.DataExport) error {
+	return r.db.Save(export).Error
+}
+
+// GetDataExportByID retrieves a data export by its ID
+func (r *dataExportRepository) GetDataExportByID(id uint) (*models.DataExport, error) {
+	var export models.DataExport
+	if err := r.db.First(&export, id).Error; err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+// GetDataExportsByUserID retrieves the user's data exports, newest first
+func (r *dataExportRepository) GetDataExportsByUserID(userID uint) ([]models.DataExport, error) {
+	var exports []models.DataExport
+	if err := r.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&exports).Error; err != nil {
+		return nil, err
+	}
+	return exports, nil
+}
+
+// HasUnfinishedDataExport reports whether the user has a data export that is pending or running
+func (r *dataExportRepository) HasUnfinishedDataExport(userID uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.DataExport{}).
+		Where("user_id = ? AND status IN ?", userID, []string{models.JobPending, models.JobRunning}).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// RecordDataExportDownload counts a download of the archive
+func (r *dataExportRepository) RecordDataExportDownload(id uint, at time.Time) error {
+	return r.db.Model(&models.DataExport{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"download_count":     gorm.Expr("download_count + 1"),
+		"last_downloaded_at": at,
+	}).Error
+}
+
+// GetExpiredDataExports retrieves expired data exports whose archive is still on disk
+func (r *dataExportRepository) GetExpiredDataExports(now time.Time, limit int) ([]models.DataExport, error) {
+	var exports []models.DataExport
+	if err := r.db.Where("expires_at <= ? AND file_path <> ''", now).
+		Order("expires_at ASC").Limit(limit).Find(&exports).Error; err != nil {
+		return nil, err
+	}
+	return exports, nil
+}
+
+// ClearDataExportFile forgets the archive of a data export once it has been removed
+func (r *dataExportRepository) ClearDataExportFile(id uint) error {
+	return r.db.Model(&models.DataExport{}).Where("id = ?", id).Update("file_path", "").Error
+}
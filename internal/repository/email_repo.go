@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"time"
+
+	"clipboard-sync-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// EmailRepository defines the interface for email outbox data operations
+type EmailRepository interface {
+	EnqueueEmail(email *models.EmailNotification) error
+	UpdateEmail(email *models.EmailNotification) error
+	ClaimDueEmails(now time.Time, lease time.Duration, limit int) ([]models.EmailNotification, error)
+}
+
+type emailRepository struct {
+	db *gorm.DB
+}
+
+// NewEmailRepository creates a new EmailRepository
+func NewEmailRepository(db *gorm.DB) EmailRepository {
+	return &emailRepository{db: db}
+}
+
+// EnqueueEmail adds a message to the outbox
+func (r *emailRepository) EnqueueEmail(email *models.EmailNotification) error {
+	return r.db.Create(email).Error
+}
+
+// UpdateEmail saves the outcome of a delivery attempt
+func (r *emailRepository) UpdateEmail(email *models.EmailNotification) error {
+	return r.db.Save(email).Error
+}
+
+// ClaimDueEmails selects pending messages whose next attempt is due and pushes their next attempt
+// out by lease, so that concurrent workers (or replicas) don't send the same message twice
+func (r *emailRepository) ClaimDueEmails(now time.Time, lease time.Duration, limit int) ([]models.EmailNotification, error) {
+	var emails []models.EmailNotification
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Raw(`SELECT * FROM email_notifications
+			WHERE status = ? AND next_attempt_at <= ?
+			ORDER BY next_attempt_at
+			LIMIT ? FOR UPDATE SKIP LOCKED`,
+			models.EmailPending, now, limit).Scan(&emails).Error; err != nil {
+			return err
+		}
+		if len(emails) == 0 {
+			return nil
+		}
+		ids := make([]uint, len(emails))
+		for i, e := range emails {
+			ids[i] = e.ID
+		}
+		return tx.Model(&models.EmailNotification{}).Where("notification_id IN ?", ids).Update("next_attempt_at", now.Add(lease)).Error
+	})
+	return emails, err
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"clipboard-sync-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvitationUnavailable is returned when an invitation was used up, expired or withdrawn
+// between being loaded and being redeemed
+var ErrInvitationUnavailable = errors.New("invitation is no longer available")
+
+// InvitationRepository defines the interface for team invitation data operations
+type InvitationRepository interface {
+	CreateInvitation(invitation *models.TeamInvitation) error
+	UpdateInvitation(invitation *models.TeamInvitation) error
+	GetInvitationByID(id uint) (*models.TeamInvitation, error)
+	GetInvitationByTokenHash(hash string) (*models.TeamInvitation, error)
+	GetPendingInvitationsByTeamID(teamID uint) ([]models.TeamInvitation, error)
+	GetPendingInvitationsByEmail(email string) ([]models.TeamInvitation, error)
+	GetInvitationsByInviterID(inviterID uint) ([]models.TeamInvitation, error)
+	RedeemInvitation(invitation *models.TeamInvitation, userID uint) error
+}
+
+type invitationRepository struct {
+	db *gorm.DB
+}
+
+// NewInvitationRepository creates a new InvitationRepository
+func NewInvitationRepository(db *gorm.DB) InvitationRepository {
+	return &invitationRepository{db: db}
+}
+
+// CreateInvitation creates a new invitation
+func (r *invitationRepository) CreateInvitation(invitation *models.TeamInvitation) error {
+	return r.db.Create(invitation).Error
+}
+
+// UpdateInvitation saves changes to an invitation
+func (r *invitationRepository) UpdateInvitation(invitation *models.TeamInvitation) error {
+	return r.db.Save(invitation).Error
+}
+
+// GetInvitationByID retrieves an invitation with its team preloaded
+func (r *invitationRepository) GetInvitationByID(id uint) (*models.TeamInvitation, error) {
+	var invitation models.TeamInvitation
+	if err := r.db.Preload("Team").First(&invitation, id).Error; err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+// GetInvitationByTokenHash retrieves an invitation by the hash of its token
+func (r *invitationRepository) GetInvitationByTokenHash(hash string) (*models.TeamInvitation, error) {
+	var invitation models.TeamInvitation
+	if err := r.db.Preload("Team").Where("token_hash = ?", hash).First(&invitation).Error; err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+// GetPendingInvitationsByTeamID retrieves a team's open invitations and join links
+func (r *invitationRepository) GetPendingInvitationsByTeamID(teamID uint) ([]models.TeamInvitation, error) {
+	var invitations []models.TeamInvitation
+	if err := r.db.Where("team_id = ? AND status = ?", teamID, models.InvitationPending).
+		Order("created_at DESC").Find(&invitations).Error; err != nil {
+		return nil, err
+	}
+	return invitations, nil
+}
+
+// GetInvitationsByInviterID retrieves every invitation and join link the user created, oldest first
+func (r *invitationRepository) GetInvitationsByInviterID(inviterID uint) ([]models.TeamInvitation, error) {
+	var invitations []models.TeamInvitation
+	if err := r.db.Preload("Team").Where("inviter_id = ?", inviterID).Order("created_at ASC").Find(&invitations).Error; err != nil {
+		return nil, err
+	}
+	return invitations, nil
+}
+
+// GetPendingInvitationsByEmail retrieves unexpired email invitations addressed to email
+func (r *invitationRepository) GetPendingInvitationsByEmail(email string) ([]models.TeamInvitation, error) {
+	var invitations []models.TeamInvitation
+	if err := r.db.Preload("Team").
+		Joins("JOIN teams ON teams.id = team_invitations.team_id AND teams.deleted_at IS NULL").
+		Where("team_invitations.kind = ? AND team_invitations.email = ? AND team_invitations.status = ?", models.InvitationKindEmail, email, models.InvitationPending).
+		Where("team_invitations.expires_at IS NULL OR team_invitations.expires_at > ?", time.Now()).
+		Order("team_invitations.created_at ASC").Find(&invitations).Error; err != nil {
+		return nil, err
+	}
+	return invitations, nil
+}
+
+// RedeemInvitation consumes one use of the invitation and adds the user to its team with the
+// invitation's role. Email invitations are marked accepted; links stay pending until used up.
+func (r *invitationRepository) RedeemInvitation(invitation *models.TeamInvitation, userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		updates := map[string]interface{}{"uses": gorm.Expr("uses + 1"), "updated_at": time.Now()}
+		if invitation.Kind == models.InvitationKindEmail {
+			updates["status"] = models.InvitationAccepted
+			updates["accepted_by"] = userID
+		}
+		res := tx.Model(&models.TeamInvitation{}).
+			Where("id = ? AND status = ? AND (max_uses = 0 OR uses < max_uses)", invitation.ID, models.InvitationPending).
+			Where("expires_at IS NULL OR expires_at > ?", time.Now()).
+			Updates(updates)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrInvitationUnavailable
+		}
+		return tx.Create(&models.TeamMember{TeamID: invitation.TeamID, UserID: userID, Role: invitation.Role}).Error
+	})
+}
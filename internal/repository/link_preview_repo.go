@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"clipboard-sync-backend/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LinkPreviewRepository defines the interface for link preview data operations
+type LinkPreviewRepository interface {
+	UpsertPreview(preview *models.LinkPreview) error
+	GetPreviewByEntryID(entryID uint) (*models.LinkPreview, error)
+}
+
+type linkPreviewRepository struct {
+	db *gorm.DB
+}
+
+// NewLinkPreviewRepository creates a new LinkPreviewRepository
+func NewLinkPreviewRepository(db *gorm.DB) LinkPreviewRepository {
+	return &linkPreviewRepository{db: db}
+}
+
+// UpsertPreview creates the preview for an entry or replaces the existing one
+func (r *linkPreviewRepository) UpsertPreview(preview *models.LinkPreview) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "entry_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"url", "canonical_url", "title", "description", "favicon_url", "status", "error", "fetched_at", "updated_at"}),
+	}).Create(preview).Error
+}
+
+// GetPreviewByEntryID retrieves the preview belonging to a clipboard entry
+func (r *linkPreviewRepository) GetPreviewByEntryID(entryID uint) (*models.LinkPreview, error) {
+	var preview models.LinkPreview
+	if err := r.db.Where("entry_id = ?", entryID).First(&preview).Error; err != nil {
+		return nil, err
+	}
+	return &preview, nil
+}
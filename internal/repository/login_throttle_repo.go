@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"time"
+
+	"clipboard-sync-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// LoginThrottleRepository defines the interface for failed sign-in tracking
+type LoginThrottleRepository interface {
+	GetThrottle(scope, key string) (*models.LoginThrottle, error)
+	RecordFailure(scope, key string, now time.Time, window time.Duration) (*models.LoginThrottle, error)
+	Lock(scope, key string, until time.Time) error
+	ClearThrottle(scope, key string) error
+}
+
+type loginThrottleRepository struct {
+	db *gorm.DB
+}
+
+// NewLoginThrottleRepository creates a new LoginThrottleRepository
+func NewLoginThrottleRepository(db *gorm.DB) LoginThrottleRepository {
+	return &loginThrottleRepository{db: db}
+}
+
+// GetThrottle retrieves the failure record for a scope and key
+func (r *loginThrottleRepository) GetThrottle(scope, key string) (*models.LoginThrottle, error) {
+	var throttle models.LoginThrottle
+	if err := r.db.Where("scope = ? AND key = ?", scope, key).First(&throttle).Error; err != nil {
+		return nil, err
+	}
+	return &throttle, nil
+}
+
+// RecordFailure counts a failed sign-in in one statement, so concurrent attempts all count. The
+// count starts over when the previous failure is older than window.
+func (r *loginThrottleRepository) RecordFailure(scope, key string, now time.Time, window time.Duration) (*models.LoginThrottle, error) {
+	var throttle models.LoginThrottle
+	err := r.db.Raw(`INSERT INTO login_throttles (scope, key, failures, last_failure_at)
+		VALUES (?, ?, 1, ?)
+		ON CONFLICT (scope, key) DO UPDATE SET
+			failures = CASE WHEN login_throttles.last_failure_at < ? THEN 1 ELSE login_throttles.failures + 1 END,
+			last_failure_at = EXCLUDED.last_failure_at
+		RETURNING *`,
+		scope, key, now, now.Add(-window)).Scan(&throttle).Error
+	if err != nil {
+		return nil, err
+	}
+	return &throttle, nil
+}
+
+// Lock locks a scope and key out until the given time and starts its failure count over
+func (r *loginThrottleRepository) Lock(scope, key string, until time.Time) error {
+	return r.db.Model(&models.LoginThrottle{}).Where("scope = ? AND key = ?", scope, key).
+		Updates(map[string]interface{}{"locked_until": until, "failures": 0}).Error
+}
+
+// ClearThrottle forgets failures and any lock for a scope and key
+func (r *loginThrottleRepository) ClearThrottle(scope, key string) error {
+	return r.db.Where("scope = ? AND key = ?", scope, key).Delete(&models.LoginThrottle{}).Error
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"time"
+
+	"clipboard-sync-backend/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// OAuthRepository defines the interface for social login state and linked identity data operations
+type OAuthRepository interface {
+	CreateState(state *models.OAuthState) error
+	ConsumeState(stateHash string) (*models.OAuthState, error)
+	DeleteExpiredStates(now time.Time) error
+	GetIdentity(provider, subject string) (*models.UserIdentity, error)
+	CreateIdentity(identity *models.UserIdentity) error
+	CreateUserWithIdentity(user *models.User, identity *models.UserIdentity) error
+	TouchIdentity(id uint, now time.Time) error
+	GetIdentitiesByUserID(userID uint) ([]models.UserIdentity, error)
+}
+
+type oauthRepository struct {
+	db *gorm.DB
+}
+
+// NewOAuthRepository creates a new OAuthRepository
+func NewOAuthRepository(db *gorm.DB) OAuthRepository {
+	return &oauthRepository{db: db}
+}
+
+// CreateState creates a pending login
+func (r *oauthRepository) CreateState(state *models.OAuthState) error {
+	return r.db.Create(state).Error
+}
+
+// ConsumeState retrieves and deletes a pending login so each state can be used only once
+func (r *oauthRepository) ConsumeState(stateHash string) (*models.OAuthState, error) {
+	var state models.OAuthState
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("state_hash = ?", stateHash).First(&state).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&state).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// DeleteExpiredStates removes logins that were started but never completed
+func (r *oauthRepository) DeleteExpiredStates(now time.Time) error {
+	return r.db.Where("expires_at <= ?", now).Delete(&models.OAuthState{}).Error
+}
+
+// GetIdentity retrieves the identity a provider knows by subject
+func (r *oauthRepository) GetIdentity(provider, subject string) (*models.UserIdentity, error) {
+	var identity models.UserIdentity
+	if err := r.db.Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error; err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+// CreateIdentity links an identity to an existing user
+func (r *oauthRepository) CreateIdentity(identity *models.UserIdentity) error {
+	return r.db.Create(identity).Error
+}
+
+// CreateUserWithIdentity provisions a user together with the identity they signed in with
+func (r *oauthRepository) CreateUserWithIdentity(user *models.User, identity *models.UserIdentity) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		identity.UserID = user.ID
+		return tx.Create(identity).Error
+	})
+}
+
+// TouchIdentity records a sign-in through the identity
+func (r *oauthRepository) TouchIdentity(id uint, now time.Time) error {
+	return r.db.Model(&models.UserIdentity{}).Where("id = ?", id).Update("last_login_at", now).Error
+}
+
+// GetIdentitiesByUserID retrieves the provider accounts linked to a user
+func (r *oauthRepository) GetIdentitiesByUserID(userID uint) ([]models.UserIdentity, error) {
+	var identities []models.UserIdentity
+	if err := r.db.Where("user_id = ?", userID).Order("created_at ASC").Find(&identities).Error; err != nil {
+		return nil, err
+	}
+	return identities, nil
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"clipboard-sync-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// PasswordHistoryRepository defines the interface for password changes and history
+type PasswordHistoryRepository interface {
+	GetPasswordHistory(userID uint, limit int) ([]models.PasswordHistory, error)
+	ChangePassword(userID uint, oldHash, newHash string, keep int) error
+}
+
+type passwordHistoryRepository struct {
+	db *gorm.DB
+}
+
+// NewPasswordHistoryRepository creates a new PasswordHistoryRepository
+func NewPasswordHistoryRepository(db *gorm.DB) PasswordHistoryRepository {
+	return &passwordHistoryRepository{db: db}
+}
+
+// GetPasswordHistory retrieves the user's most recently replaced password hashes, newest first
+func (r *passwordHistoryRepository) GetPasswordHistory(userID uint, limit int) ([]models.PasswordHistory, error) {
+	var history []models.PasswordHistory
+	if err := r.db.Where("user_id = ?", userID).Order("created_at DESC, id DESC").Limit(limit).Find(&history).Error; err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// ChangePassword sets the user's password hash, moving the old one into their history and
+// keeping only the newest keep entries there
+func (r *passwordHistoryRepository) ChangePassword(userID uint, oldHash, newHash string, keep int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).Where("id = ?", userID).Update("password", newHash).Error; err != nil {
+			return err
+		}
+		if keep <= 0 {
+			return tx.Where("user_id = ?", userID).Delete(&models.PasswordHistory{}).Error
+		}
+		if oldHash != "" {
+			if err := tx.Create(&models.PasswordHistory{UserID: userID, PasswordHash: oldHash}).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Where("user_id = ? AND id NOT IN (?)", userID,
+			tx.Session(&gorm.Session{NewDB: true}).Model(&models.PasswordHistory{}).Select("id").Where("user_id = ?", userID).Order("created_at DESC, id DESC").Limit(keep)).
+			Delete(&models.PasswordHistory{}).Error
+	})
+}
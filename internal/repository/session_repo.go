@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"clipboard-sync-backend/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrRefreshTokenReused is returned when a refresh token that was already rotated out is
+	// presented again; its session has been revoked
+	ErrRefreshTokenReused = errors.New("refresh token reused")
+	// ErrSessionInactive is returned when a refresh token belongs to a revoked or expired session
+	ErrSessionInactive = errors.New("session is no longer active")
+)
+
+// SessionRepository defines the interface for session and refresh token data operations
+type SessionRepository interface {
+	CreateSession(session *models.Session, token *models.RefreshToken) error
+	GetSessionByID(id uint) (*models.Session, error)
+	GetActiveSessionsByUserID(userID uint, now time.Time) ([]models.Session, error)
+	GetSessionsByUserID(userID uint) ([]models.Session, error)
+	RotateRefreshToken(tokenHash string, next *models.RefreshToken, now time.Time) (*models.Session, error)
+	RevokeSession(id uint, reason string, now time.Time) error
+	RevokeUserSessions(userID, exceptID uint, reason string, now time.Time) ([]uint, error)
+}
+
+type sessionRepository struct {
+	db *gorm.DB
+}
+
+// NewSessionRepository creates a new SessionRepository
+func NewSessionRepository(db *gorm.DB) SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+// CreateSession creates a session together with its first refresh token
+func (r *sessionRepository) CreateSession(session *models.Session, token *models.RefreshToken) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(session).Error; err != nil {
+			return err
+		}
+		token.SessionID = session.ID
+		return tx.Create(token).Error
+	})
+}
+
+// GetSessionByID retrieves a session by ID
+func (r *sessionRepository) GetSessionByID(id uint) (*models.Session, error) {
+	var session models.Session
+	if err := r.db.First(&session, id).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// GetActiveSessionsByUserID retrieves the user's unrevoked, unexpired sessions, most recently used first
+func (r *sessionRepository) GetActiveSessionsByUserID(userID uint, now time.Time) ([]models.Session, error) {
+	var sessions []models.Session
+	if err := r.db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, now).
+		Order("last_used_at DESC").Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// GetSessionsByUserID retrieves all of the user's sessions, including revoked and expired ones
+func (r *sessionRepository) GetSessionsByUserID(userID uint) ([]models.Session, error) {
+	var sessions []models.Session
+	if err := r.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// RotateRefreshToken exchanges a refresh token for next and extends the session. The token row is
+// locked so two concurrent refreshes with the same token cannot both succeed. Presenting a token
+// that was already rotated revokes the session and returns ErrRefreshTokenReused.
+func (r *sessionRepository) RotateRefreshToken(tokenHash string, next *models.RefreshToken, now time.Time) (*models.Session, error) {
+	var session models.Session
+	reused := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var token models.RefreshToken
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("token_hash = ?", tokenHash).First(&token).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&session, token.SessionID).Error; err != nil {
+			return err
+		}
+		if token.RotatedAt != nil {
+			reused = true
+			if session.RevokedAt != nil {
+				return nil
+			}
+			return revokeSessions(tx, models.SessionRevokedReuse, now, "id = ?", session.ID)
+		}
+		if !session.Active(now) || !now.Before(token.ExpiresAt) {
+			return ErrSessionInactive
+		}
+
+		if err := tx.Model(&token).Update("rotated_at", now).Error; err != nil {
+			return err
+		}
+		next.SessionID = session.ID
+		if err := tx.Create(next).Error; err != nil {
+			return err
+		}
+		session.LastUsedAt = now
+		session.ExpiresAt = next.ExpiresAt
+		return tx.Model(&session).Updates(map[string]interface{}{"last_used_at": now, "expires_at": next.ExpiresAt}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	if reused {
+		return &session, ErrRefreshTokenReused
+	}
+	return &session, nil
+}
+
+// RevokeSession marks a session revoked; revoking an already revoked session is a no-op
+func (r *sessionRepository) RevokeSession(id uint, reason string, now time.Time) error {
+	return revokeSessions(r.db, reason, now, "id = ?", id)
+}
+
+// RevokeUserSessions revokes all of the user's active sessions except exceptID (0 for none) and
+// returns the IDs it revoked
+func (r *sessionRepository) RevokeUserSessions(userID, exceptID uint, reason string, now time.Time) ([]uint, error) {
+	var ids []uint
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Session{}).Where("user_id = ? AND id <> ? AND revoked_at IS NULL", userID, exceptID).
+			Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		return revokeSessions(tx, reason, now, "id IN ?", ids)
+	})
+	return ids, err
+}
+
+// revokeSessions revokes the still active sessions matching the condition
+func revokeSessions(tx *gorm.DB, reason string, now time.Time, query string, args ...interface{}) error {
+	return tx.Model(&models.Session{}).Where(query, args...).Where("revoked_at IS NULL").
+		Updates(map[string]interface{}{"revoked_at": now, "revoke_reason": reason}).Error
+}
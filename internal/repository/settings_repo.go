@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"clipboard-sync-backend/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrSettingsVersionConflict is returned when settings changed since they were read
+var ErrSettingsVersionConflict = errors.New("settings were changed concurrently")
+
+// SettingsRepository defines the interface for user settings data operations
+type SettingsRepository interface {
+	GetSettingsByUserID(userID uint) (*models.UserSettings, error)
+	SaveSettings(settings *models.UserSettings) error
+}
+
+type settingsRepository struct {
+	db *gorm.DB
+}
+
+// NewSettingsRepository creates a new SettingsRepository
+func NewSettingsRepository(db *gorm.DB) SettingsRepository {
+	return &settingsRepository{db: db}
+}
+
+// GetSettingsByUserID retrieves a user's settings
+func (r *settingsRepository) GetSettingsByUserID(userID uint) (*models.UserSettings, error) {
+	var settings models.UserSettings
+	if err := r.db.Where("user_id = ?", userID).First(&settings).Error; err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// SaveSettings stores a settings document read with GetSettingsByUserID, or creates the user's
+// row if settings.ID is zero, and bumps its version. If the row was written by someone else in
+// the meantime nothing is saved and ErrSettingsVersionConflict is returned, so the caller can
+// re-read and reapply its change.
+func (r *settingsRepository) SaveSettings(settings *models.UserSettings) error {
+	now := time.Now()
+	if settings.ID == 0 {
+		settings.Version = 1
+		settings.UpdatedAt = now
+		res := r.db.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "user_id"}}, DoNothing: true}).Create(settings)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrSettingsVersionConflict
+		}
+		return nil
+	}
+
+	res := r.db.Model(&models.UserSettings{}).
+		Where("settings_id = ? AND version = ?", settings.ID, settings.Version).
+		Updates(map[string]interface{}{
+			"settings_data": settings.SettingsData,
+			"version":       gorm.Expr("version + 1"),
+			"updated_at":    now,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrSettingsVersionConflict
+	}
+	settings.Version++
+	settings.UpdatedAt = now
+	return nil
+}
@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"strings"
+
+	"clipboard-sync-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// SnippetFilter narrows down snippet listings; zero values are ignored
+type SnippetFilter struct {
+	FolderID *uint
+	TeamID   *uint // List the team's shared snippets instead of the user's own
+	Tag      string
+	Query    string // Matched against name and body
+}
+
+// SnippetRepository defines the interface for snippet and snippet folder data operations
+type SnippetRepository interface {
+	CreateSnippet(snippet *models.Snippet) error
+	UpdateSnippet(snippet *models.Snippet) error
+	DeleteSnippet(id uint) error
+	GetSnippetByID(id uint) (*models.Snippet, error)
+	GetSnippetByAbbreviation(userID uint, abbreviation string) (*models.Snippet, error)
+	ListSnippets(userID uint, filter SnippetFilter, limit, offset int) ([]models.Snippet, error)
+	CreateFolder(folder *models.SnippetFolder) error
+	UpdateFolder(folder *models.SnippetFolder) error
+	DeleteFolder(id uint) error
+	GetFolderByID(id uint) (*models.SnippetFolder, error)
+	GetFoldersByUserID(userID uint) ([]models.SnippetFolder, error)
+}
+
+type snippetRepository struct {
+	db *gorm.DB
+}
+
+// NewSnippetRepository creates a new SnippetRepository
+func NewSnippetRepository(db *gorm.DB) SnippetRepository {
+	return &snippetRepository{db: db}
+}
+
+// CreateSnippet creates a new snippet
+func (r *snippetRepository) CreateSnippet(snippet *models.Snippet) error {
+	return r.db.Create(snippet).Error
+}
+
+// UpdateSnippet saves changes to a snippet
+func (r *snippetRepository) UpdateSnippet(snippet *models.Snippet) error {
+	return r.db.Save(snippet).Error
+}
+
+// DeleteSnippet soft-deletes a snippet
+func (r *snippetRepository) DeleteSnippet(id uint) error {
+	return r.db.Delete(&models.Snippet{}, id).Error
+}
+
+// GetSnippetByID retrieves a snippet by its ID
+func (r *snippetRepository) GetSnippetByID(id uint) (*models.Snippet, error) {
+	var snippet models.Snippet
+	if err := r.db.First(&snippet, id).Error; err != nil {
+		return nil, err
+	}
+	return &snippet, nil
+}
+
+// GetSnippetByAbbreviation retrieves one of the user's snippets by its keyboard trigger
+func (r *snippetRepository) GetSnippetByAbbreviation(userID uint, abbreviation string) (*models.Snippet, error) {
+	var snippet models.Snippet
+	if err := r.db.Where("user_id = ? AND abbreviation = ?", userID, abbreviation).First(&snippet).Error; err != nil {
+		return nil, err
+	}
+	return &snippet, nil
+}
+
+// ListSnippets retrieves the user's snippets (or a team's shared snippets) matching the filter
+func (r *snippetRepository) ListSnippets(userID uint, filter SnippetFilter, limit, offset int) ([]models.Snippet, error) {
+	query := r.db.Model(&models.Snippet{})
+	if filter.TeamID != nil {
+		query = query.Where("team_id = ?", *filter.TeamID)
+	} else {
+		query = query.Where("user_id = ?", userID)
+	}
+	if filter.FolderID != nil {
+		query = query.Where("folder_id = ?", *filter.FolderID)
+	}
+	if filter.Tag != "" {
+		query = query.Where("',' || tags || ',' LIKE ?", "%,"+escapeLike(filter.Tag)+",%")
+	}
+	if filter.Query != "" {
+		pattern := "%" + escapeLike(filter.Query) + "%"
+		query = query.Where("name ILIKE ? OR body ILIKE ?", pattern, pattern)
+	}
+
+	var snippets []models.Snippet
+	if err := query.Order("name ASC").Limit(limit).Offset(offset).Find(&snippets).Error; err != nil {
+		return nil, err
+	}
+	return snippets, nil
+}
+
+// CreateFolder creates a new snippet folder
+func (r *snippetRepository) CreateFolder(folder *models.SnippetFolder) error {
+	return r.db.Create(folder).Error
+}
+
+// UpdateFolder saves changes to a snippet folder
+func (r *snippetRepository) UpdateFolder(folder *models.SnippetFolder) error {
+	return r.db.Save(folder).Error
+}
+
+// DeleteFolder deletes a folder, moving its snippets and subfolders to the folder's parent
+func (r *snippetRepository) DeleteFolder(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var folder models.SnippetFolder
+		if err := tx.First(&folder, id).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Snippet{}).Where("folder_id = ?", id).Update("folder_id", folder.ParentID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.SnippetFolder{}).Where("parent_id = ?", id).Update("parent_id", folder.ParentID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&folder).Error
+	})
+}
+
+// GetFolderByID retrieves a snippet folder by its ID
+func (r *snippetRepository) GetFolderByID(id uint) (*models.SnippetFolder, error) {
+	var folder models.SnippetFolder
+	if err := r.db.First(&folder, id).Error; err != nil {
+		return nil, err
+	}
+	return &folder, nil
+}
+
+// GetFoldersByUserID retrieves all of a user's snippet folders
+func (r *snippetRepository) GetFoldersByUserID(userID uint) ([]models.SnippetFolder, error) {
+	var folders []models.SnippetFolder
+	if err := r.db.Where("user_id = ?", userID).Order("name ASC").Find(&folders).Error; err != nil {
+		return nil, err
+	}
+	return folders, nil
+}
+
+// escapeLike escapes LIKE wildcards in user input
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
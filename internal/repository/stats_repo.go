@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"time"
+
+	"clipboard-sync-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// UsageStats are server-wide counts for the administration API. "Recent" counts cover the
+// period since the time the stats were asked for.
+type UsageStats struct {
+	Users              int64 `json:"users"`
+	RecentlyActive     int64 `json:"recently_active_users"` // Signed in recently
+	NewUsers           int64 `json:"new_users"`             // Registered recently
+	SuspendedUsers     int64 `json:"suspended_users"`
+	Admins             int64 `json:"admins"`
+	TwoFactorUsers     int64 `json:"two_factor_users"`
+	PendingDeletions   int64 `json:"pending_deletions"`
+	Teams              int64 `json:"teams"`
+	ClipboardEntries   int64 `json:"clipboard_entries"`
+	NewEntries         int64 `json:"new_clipboard_entries"` // Created recently
+	Snippets           int64 `json:"snippets"`
+	Webhooks           int64 `json:"webhooks"`
+	ActiveSessions     int64 `json:"active_sessions"`
+	ActiveAccessTokens int64 `json:"active_access_tokens"`
+}
+
+// StatsRepository defines the interface for server-wide usage statistics
+type StatsRepository interface {
+	GetUsageStats(now, since time.Time) (*UsageStats, error)
+}
+
+type statsRepository struct {
+	db *gorm.DB
+}
+
+// NewStatsRepository creates a new StatsRepository
+func NewStatsRepository(db *gorm.DB) StatsRepository {
+	return &statsRepository{db: db}
+}
+
+// GetUsageStats counts users and their data; recent counts start at since
+func (r *statsRepository) GetUsageStats(now, since time.Time) (*UsageStats, error) {
+	var stats UsageStats
+	counts := []struct {
+		model interface{}
+		where string
+		args  []interface{}
+		dest  *int64
+	}{
+		{&models.User{}, "", nil, &stats.Users},
+		{&models.User{}, "last_login_at >= ?", []interface{}{since}, &stats.RecentlyActive},
+		{&models.User{}, "created_at >= ?", []interface{}{since}, &stats.NewUsers},
+		{&models.User{}, "suspended_at IS NOT NULL", nil, &stats.SuspendedUsers},
+		{&models.User{}, "is_admin", nil, &stats.Admins},
+		{&models.User{}, "two_factor_enabled", nil, &stats.TwoFactorUsers},
+		{&models.User{}, "deletion_scheduled_at IS NOT NULL", nil, &stats.PendingDeletions},
+		{&models.Team{}, "", nil, &stats.Teams},
+		{&models.ClipboardEntry{}, "", nil, &stats.ClipboardEntries},
+		{&models.ClipboardEntry{}, "created_at >= ?", []interface{}{since}, &stats.NewEntries},
+		{&models.Snippet{}, "", nil, &stats.Snippets},
+		{&models.WebhookSubscription{}, "", nil, &stats.Webhooks},
+		{&models.Session{}, "revoked_at IS NULL AND expires_at > ?", []interface{}{now}, &stats.ActiveSessions},
+		{&models.PersonalAccessToken{}, "revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", []interface{}{now}, &stats.ActiveAccessTokens},
+	}
+	for _, c := range counts {
+		query := r.db.Model(c.model)
+		if c.where != "" {
+			query = query.Where(c.where, c.args...)
+		}
+		if err := query.Count(c.dest).Error; err != nil {
+			return nil, err
+		}
+	}
+	return &stats, nil
+}
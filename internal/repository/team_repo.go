@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"errors"
+
+	"clipboard-sync-backend/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrLastTeamOwner is returned when a change would leave a team without an owner
+var ErrLastTeamOwner = errors.New("a team must keep at least one owner")
+
+// TeamRepository defines the interface for team and membership data operations
+type TeamRepository interface {
+	CreateTeam(team *models.Team, creatorRole string) error
+	UpdateTeam(team *models.Team) error
+	DeleteTeam(id uint) error
+	GetTeamByID(id uint) (*models.Team, error)
+	TeamNameExists(name string) (bool, error)
+	GetMembershipsByUserID(userID uint) ([]models.TeamMember, error)
+	GetMember(teamID, userID uint) (*models.TeamMember, error)
+	GetMembers(teamID uint) ([]models.TeamMember, error)
+	AddMember(member *models.TeamMember) error
+	UpdateMemberRole(teamID, userID uint, role string) error
+	RemoveMember(teamID, userID uint) error
+}
+
+type teamRepository struct {
+	db *gorm.DB
+}
+
+// NewTeamRepository creates a new TeamRepository
+func NewTeamRepository(db *gorm.DB) TeamRepository {
+	return &teamRepository{db: db}
+}
+
+// CreateTeam creates a team and adds its creator as the first member
+func (r *teamRepository) CreateTeam(team *models.Team, creatorRole string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Members").Create(team).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.TeamMember{TeamID: team.ID, UserID: team.CreatorID, Role: creatorRole}).Error
+	})
+}
+
+// UpdateTeam saves changes to a team
+func (r *teamRepository) UpdateTeam(team *models.Team) error {
+	return r.db.Omit("Members").Save(team).Error
+}
+
+// DeleteTeam soft-deletes a team and removes all of its memberships
+func (r *teamRepository) DeleteTeam(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("team_id = ?", id).Delete(&models.TeamMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Team{}, id).Error
+	})
+}
+
+// GetTeamByID retrieves a team by ID
+func (r *teamRepository) GetTeamByID(id uint) (*models.Team, error) {
+	var team models.Team
+	if err := r.db.First(&team, id).Error; err != nil {
+		return nil, err
+	}
+	return &team, nil
+}
+
+// TeamNameExists reports whether a team name is taken, including by deleted teams
+func (r *teamRepository) TeamNameExists(name string) (bool, error) {
+	var count int64
+	err := r.db.Unscoped().Model(&models.Team{}).Where("LOWER(name) = LOWER(?)", name).Count(&count).Error
+	return count > 0, err
+}
+
+// GetMembershipsByUserID retrieves the user's memberships with their teams preloaded
+func (r *teamRepository) GetMembershipsByUserID(userID uint) ([]models.TeamMember, error) {
+	var members []models.TeamMember
+	if err := r.db.Preload("Team").Joins("JOIN teams ON teams.id = team_members.team_id AND teams.deleted_at IS NULL").
+		Where("team_members.user_id = ?", userID).Order("team_members.joined_at ASC").Find(&members).Error; err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// GetMember retrieves a user's membership in a team with the team and user joined in
+func (r *teamRepository) GetMember(teamID, userID uint) (*models.TeamMember, error) {
+	var member models.TeamMember
+	if err := r.db.Joins("Team").Joins("User").
+		Where("team_members.team_id = ? AND team_members.user_id = ?", teamID, userID).First(&member).Error; err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// GetMembers retrieves a team's members with their users preloaded
+func (r *teamRepository) GetMembers(teamID uint) ([]models.TeamMember, error) {
+	var members []models.TeamMember
+	if err := r.db.Preload("User").Where("team_id = ?", teamID).Order("joined_at ASC").Find(&members).Error; err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// AddMember adds a user to a team
+func (r *teamRepository) AddMember(member *models.TeamMember) error {
+	return r.db.Create(member).Error
+}
+
+// UpdateMemberRole changes a member's role, refusing to demote the last owner
+func (r *teamRepository) UpdateMemberRole(teamID, userID uint, role string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if role != models.TeamRoleOwner {
+			if err := ensureOtherOwner(tx, teamID, userID); err != nil {
+				return err
+			}
+		}
+		res := tx.Model(&models.TeamMember{}).Where("team_id = ? AND user_id = ?", teamID, userID).Update("role", role)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+// RemoveMember removes a user from a team, refusing to remove the last owner
+func (r *teamRepository) RemoveMember(teamID, userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := ensureOtherOwner(tx, teamID, userID); err != nil {
+			return err
+		}
+		res := tx.Unscoped().Where("team_id = ? AND user_id = ?", teamID, userID).Delete(&models.TeamMember{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+// ensureOtherOwner locks the team's owner and admin rows and fails if userID is the only owner.
+// Teams created before the owner role existed have only admins; for them the last admin is
+// protected instead. Locking keeps two concurrent demotions from each seeing the other as the
+// remaining owner.
+func ensureOtherOwner(tx *gorm.DB, teamID, userID uint) error {
+	var managers []models.TeamMember
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("team_id = ? AND role IN ?", teamID, []string{models.TeamRoleOwner, models.TeamRoleAdmin}).Find(&managers).Error; err != nil {
+		return err
+	}
+
+	protected := models.TeamRoleOwner
+	hasOwner := false
+	for _, m := range managers {
+		if m.Role == models.TeamRoleOwner {
+			hasOwner = true
+			break
+		}
+	}
+	if !hasOwner {
+		protected = models.TeamRoleAdmin
+	}
+
+	targetProtected, othersRemain := false, false
+	for _, m := range managers {
+		if m.Role != protected {
+			continue
+		}
+		if m.UserID == userID {
+			targetProtected = true
+		} else {
+			othersRemain = true
+		}
+	}
+	if targetProtected && !othersRemain {
+		return ErrLastTeamOwner
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"time"
+
+	"clipboard-sync-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// TransferJobRepository defines the interface for export and import job data operations
+type TransferJobRepository interface {
+	CreateExportJob(job *models.ExportJob) error
+	UpdateExportJob(job *models.ExportJob) error
+	GetExportJobByID(id uint) (*models.ExportJob, error)
+	GetExpiredExportJobs(now time.Time, limit int) ([]models.ExportJob, error)
+	ClearExportJobFile(id uint) error
+	CreateImportJob(job *models.ImportJob) error
+	UpdateImportJob(job *models.ImportJob) error
+	GetImportJobByID(id uint) (*models.ImportJob, error)
+}
+
+type transferJobRepository struct {
+	db *gorm.DB
+}
+
+// NewTransferJobRepository creates a new TransferJobRepository
+func NewTransferJobRepository(db *gorm.DB) TransferJobRepository {
+	return &transferJobRepository{db: db}
+}
+
+// CreateExportJob creates a new export job
+func (r *transferJobRepository) CreateExportJob(job *models.ExportJob) error {
+	return r.db.Create(job).Error
+}
+
+// UpdateExportJob saves the current state of an export job
+func (r *transferJobRepository) UpdateExportJob(job *models.ExportJob) error {
+	return r.db.Save(job).Error
+}
+
+// GetExportJobByID retrieves an export job by its ID
+func (r *transferJobRepository) GetExportJobByID(id uint) (*models.ExportJob, error) {
+	var job models.ExportJob
+	if err := r.db.First(&job, id).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// GetExpiredExportJobs retrieves expired export jobs whose file is still on disk
+func (r *transferJobRepository) GetExpiredExportJobs(now time.Time, limit int) ([]models.ExportJob, error) {
+	var jobs []models.ExportJob
+	if err := r.db.Where("expires_at <= ? AND file_path <> ''", now).
+		Order("expires_at ASC").Limit(limit).Find(&jobs).Error; err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// ClearExportJobFile forgets the file of an export job once it has been removed
+func (r *transferJobRepository) ClearExportJobFile(id uint) error {
+	return r.db.Model(&models.ExportJob{}).Where("id = ?", id).Update("file_path", "").Error
+}
+
+// CreateImportJob creates a new import job
+func (r *transferJobRepository) CreateImportJob(job *models.ImportJob) error {
+	return r.db.Create(job).Error
+}
+
+// UpdateImportJob saves the current state (progress, counters, row errors) of an import job
+func (r *transferJobRepository) UpdateImportJob(job *models.ImportJob) error {
+	return r.db.Save(job).Error
+}
+
+// GetImportJobByID retrieves an import job by its ID
+func (r *transferJobRepository) GetImportJobByID(id uint) (*models.ImportJob, error) {
+	var job models.ImportJob
+	if err := r.db.First(&job, id).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
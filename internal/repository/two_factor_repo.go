@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"time"
+
+	"clipboard-sync-backend/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TwoFactorRepository defines the interface for TOTP secrets, recovery codes and login challenges
+type TwoFactorRepository interface {
+	GetSecret(userID uint) (*models.TwoFactorSecret, error)
+	SavePendingSecret(secret *models.TwoFactorSecret) error
+	EnableTwoFactor(userID uint, step int64, codes []models.RecoveryCode, now time.Time) error
+	DisableTwoFactor(userID uint) error
+	UseTOTPStep(userID uint, step int64) (bool, error)
+	ReplaceRecoveryCodes(userID uint, codes []models.RecoveryCode) error
+	UseRecoveryCode(userID uint, codeHash string, now time.Time) (bool, error)
+	CountUnusedRecoveryCodes(userID uint) (int64, error)
+	CreateChallenge(challenge *models.LoginChallenge) error
+	GetChallengeByHash(tokenHash string) (*models.LoginChallenge, error)
+	UseChallengeAttempt(id uint, maxAttempts int) (bool, error)
+	DeleteChallenge(id uint) (bool, error)
+	DeleteExpiredChallenges(now time.Time) error
+}
+
+type twoFactorRepository struct {
+	db *gorm.DB
+}
+
+// NewTwoFactorRepository creates a new TwoFactorRepository
+func NewTwoFactorRepository(db *gorm.DB) TwoFactorRepository {
+	return &twoFactorRepository{db: db}
+}
+
+// GetSecret retrieves a user's TOTP secret, pending or confirmed
+func (r *twoFactorRepository) GetSecret(userID uint) (*models.TwoFactorSecret, error) {
+	var secret models.TwoFactorSecret
+	if err := r.db.Where("user_id = ?", userID).First(&secret).Error; err != nil {
+		return nil, err
+	}
+	return &secret, nil
+}
+
+// SavePendingSecret stores a new unconfirmed secret, replacing any earlier one
+func (r *twoFactorRepository) SavePendingSecret(secret *models.TwoFactorSecret) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"secret", "confirmed_at", "last_used_step", "created_at"}),
+	}).Create(secret).Error
+}
+
+// EnableTwoFactor confirms the user's secret, consuming step, and gives them fresh recovery codes
+func (r *twoFactorRepository) EnableTwoFactor(userID uint, step int64, codes []models.RecoveryCode, now time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.TwoFactorSecret{}).Where("user_id = ?", userID).
+			Updates(map[string]interface{}{"confirmed_at": now, "last_used_step": step}).Error; err != nil {
+			return err
+		}
+		if err := replaceRecoveryCodes(tx, userID, codes); err != nil {
+			return err
+		}
+		return tx.Model(&models.User{}).Where("id = ?", userID).Update("two_factor_enabled", true).Error
+	})
+}
+
+// DisableTwoFactor removes the user's secret and recovery codes
+func (r *twoFactorRepository) DisableTwoFactor(userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.TwoFactorSecret{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.User{}).Where("id = ?", userID).Update("two_factor_enabled", false).Error
+	})
+}
+
+// UseTOTPStep records that a code for step was accepted. It reports false when that step or a
+// later one was already used, so each code works only once.
+func (r *twoFactorRepository) UseTOTPStep(userID uint, step int64) (bool, error) {
+	result := r.db.Model(&models.TwoFactorSecret{}).
+		Where("user_id = ? AND confirmed_at IS NOT NULL AND last_used_step < ?", userID, step).
+		Update("last_used_step", step)
+	return result.RowsAffected > 0, result.Error
+}
+
+// ReplaceRecoveryCodes swaps the user's recovery codes for a new set
+func (r *twoFactorRepository) ReplaceRecoveryCodes(userID uint, codes []models.RecoveryCode) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return replaceRecoveryCodes(tx, userID, codes)
+	})
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, userID uint, codes []models.RecoveryCode) error {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return err
+	}
+	for i := range codes {
+		codes[i].UserID = userID
+	}
+	return tx.Create(&codes).Error
+}
+
+// UseRecoveryCode marks an unused recovery code used; it reports false if there was none
+func (r *twoFactorRepository) UseRecoveryCode(userID uint, codeHash string, now time.Time) (bool, error) {
+	result := r.db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", now)
+	return result.RowsAffected > 0, result.Error
+}
+
+// CountUnusedRecoveryCodes counts the recovery codes the user has left
+func (r *twoFactorRepository) CountUnusedRecoveryCodes(userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", userID).Count(&count).Error
+	return count, err
+}
+
+// CreateChallenge creates a pending second-factor login
+func (r *twoFactorRepository) CreateChallenge(challenge *models.LoginChallenge) error {
+	return r.db.Create(challenge).Error
+}
+
+// GetChallengeByHash retrieves a login challenge by the hash of its token
+func (r *twoFactorRepository) GetChallengeByHash(tokenHash string) (*models.LoginChallenge, error) {
+	var challenge models.LoginChallenge
+	if err := r.db.Where("token_hash = ?", tokenHash).First(&challenge).Error; err != nil {
+		return nil, err
+	}
+	return &challenge, nil
+}
+
+// UseChallengeAttempt counts a code attempt against a challenge. It reports false once
+// maxAttempts have been made, which also holds off concurrent guesses.
+func (r *twoFactorRepository) UseChallengeAttempt(id uint, maxAttempts int) (bool, error) {
+	result := r.db.Model(&models.LoginChallenge{}).Where("id = ? AND attempts < ?", id, maxAttempts).
+		Update("attempts", gorm.Expr("attempts + 1"))
+	return result.RowsAffected > 0, result.Error
+}
+
+// DeleteChallenge removes a challenge; it reports false if it was already gone
+func (r *twoFactorRepository) DeleteChallenge(id uint) (bool, error) {
+	result := r.db.Delete(&models.LoginChallenge{}, id)
+	return result.RowsAffected > 0, result.Error
+}
+
+// DeleteExpiredChallenges removes challenges that were never completed
+func (r *twoFactorRepository) DeleteExpiredChallenges(now time.Time) error {
+	return r.db.Where("expires_at <= ?", now).Delete(&models.LoginChallenge{}).Error
+}
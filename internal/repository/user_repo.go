@@ -1,16 +1,46 @@
 package repository
 
 import (
+	"errors"
+	"fmt"
+	"time"
+
 	"clipboard-sync-backend/internal/models"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// User statuses an administrator can filter by
+const (
+	UserStatusActive    = "active"
+	UserStatusSuspended = "suspended"
+	UserStatusAdmin     = "admin"
 )
 
+// UserSearchFilter selects users in the administration API; zero values are ignored
+type UserSearchFilter struct {
+	Query  string // Matched against email, username and display name
+	Status string // One of the UserStatus constants
+}
+
 // UserRepository defines the interface for user data operations
 type UserRepository interface {
 	CreateUser(user *models.User) error
 	GetUserByEmail(email string) (*models.User, error)
 	GetUserByID(id uint) (*models.User, error)
+	MarkEmailVerified(id uint, at time.Time) error
+	UpdatePassword(id uint, passwordHash string) error
+	UpdateProfile(id uint, updates map[string]interface{}) error
+	UsernameTaken(username string, exceptID uint) (bool, error)
+	TouchLastLogin(id uint, at time.Time) error
+	SetPendingEmail(id uint, email string) error
+	ChangeEmail(id uint, email string, verifiedAt time.Time) error
+	ScheduleDeletion(id uint, at *time.Time) error
+	GetUsersDueForDeletion(now time.Time, limit int) ([]models.User, error)
+	PurgeUser(id uint) ([]string, error)
+	SearchUsers(filter UserSearchFilter, limit, offset int) ([]models.User, int64, error)
+	SetSuspension(id uint, at *time.Time, reason string) error
 	// Add more user-related repository methods as needed
 }
 
@@ -45,3 +75,195 @@ func (r *userRepository) GetUserByID(id uint) (*models.User, error) {
 	}
 	return &user, nil
 }
+
+// MarkEmailVerified records that the user proved they own their email address
+func (r *userRepository) MarkEmailVerified(id uint, at time.Time) error {
+	return r.db.Model(&models.User{}).Where("id = ? AND email_verified_at IS NULL", id).Update("email_verified_at", at).Error
+}
+
+// UpdatePassword replaces the user's password hash
+func (r *userRepository) UpdatePassword(id uint, passwordHash string) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).Update("password", passwordHash).Error
+}
+
+// UpdateProfile sets the given profile columns
+func (r *userRepository) UpdateProfile(id uint, updates map[string]interface{}) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// UsernameTaken reports whether another user has the username, ignoring case
+func (r *userRepository) UsernameTaken(username string, exceptID uint) (bool, error) {
+	var count int64
+	err := r.db.Unscoped().Model(&models.User{}).Where("LOWER(username) = LOWER(?) AND id <> ?", username, exceptID).Count(&count).Error
+	return count > 0, err
+}
+
+// TouchLastLogin records when the user last signed in
+func (r *userRepository) TouchLastLogin(id uint, at time.Time) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).UpdateColumn("last_login_at", at).Error
+}
+
+// SetPendingEmail records an address the user wants to switch to; empty clears it
+func (r *userRepository) SetPendingEmail(id uint, email string) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).Update("pending_email", email).Error
+}
+
+// ChangeEmail replaces the user's address with a verified new one
+func (r *userRepository) ChangeEmail(id uint, email string, verifiedAt time.Time) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"email": email, "pending_email": "", "email_verified_at": verifiedAt}).Error
+}
+
+// ScheduleDeletion sets when the account will be erased; nil cancels a scheduled deletion
+func (r *userRepository) ScheduleDeletion(id uint, at *time.Time) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).Update("deletion_scheduled_at", at).Error
+}
+
+// GetUsersDueForDeletion retrieves users whose grace period has ended, oldest first
+func (r *userRepository) GetUsersDueForDeletion(now time.Time, limit int) ([]models.User, error) {
+	var users []models.User
+	if err := r.db.Where("deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= ?", now).
+		Order("deletion_scheduled_at ASC").Limit(limit).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// SearchUsers retrieves users matching the filter, oldest first, with the total number of matches
+func (r *userRepository) SearchUsers(filter UserSearchFilter, limit, offset int) ([]models.User, int64, error) {
+	query := r.db.Model(&models.User{})
+	if filter.Query != "" {
+		pattern := "%" + escapeLike(filter.Query) + "%"
+		query = query.Where("email ILIKE ? OR username ILIKE ? OR display_name ILIKE ?", pattern, pattern, pattern)
+	}
+	switch filter.Status {
+	case UserStatusActive:
+		query = query.Where("suspended_at IS NULL")
+	case UserStatusSuspended:
+		query = query.Where("suspended_at IS NOT NULL")
+	case UserStatusAdmin:
+		query = query.Where("is_admin")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var users []models.User
+	if err := query.Order("id ASC").Limit(limit).Offset(offset).Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+// SetSuspension suspends a user when at is set and lifts the suspension when it is nil
+func (r *userRepository) SetSuspension(id uint, at *time.Time, reason string) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"suspended_at":      at,
+		"suspension_reason": reason,
+	}).Error
+}
+
+// PurgeUser erases a user's data in one transaction: clipboard entries, snippets, webhooks,
+// transfer jobs, data exports, settings, sessions, tokens, linked identities, two-factor secrets
+// and team memberships. Teams the user was the last owner of pass to their longest-standing admin, or
+// member, and teams left empty are deleted. The user row is scrubbed and soft-deleted so audit history
+// keeps pointing at it. It returns the files of the user's transfer jobs and data exports for the
+// caller to remove.
+func (r *userRepository) PurgeUser(id uint) ([]string, error) {
+	var files []string
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var memberships []models.TeamMember
+		if err := tx.Where("user_id = ?", id).Find(&memberships).Error; err != nil {
+			return err
+		}
+		for _, m := range memberships {
+			if err := handOverTeam(tx, m.TeamID, id); err != nil {
+				return err
+			}
+		}
+		if err := tx.Unscoped().Where("user_id = ?", id).Delete(&models.TeamMember{}).Error; err != nil {
+			return err
+		}
+
+		var paths []string
+		for _, model := range []interface{}{&models.ExportJob{}, &models.ImportJob{}, &models.DataExport{}} {
+			if err := tx.Model(model).Where("user_id = ? AND file_path <> ''", id).Pluck("file_path", &paths).Error; err != nil {
+				return err
+			}
+			files = append(files, paths...)
+		}
+
+		// Subqueries need a fresh statement or they pick up the outer one's clauses
+		userSessions := tx.Session(&gorm.Session{NewDB: true}).Model(&models.Session{}).Select("id").Where("user_id = ?", id)
+		userWebhooks := tx.Session(&gorm.Session{NewDB: true}).Unscoped().Model(&models.WebhookSubscription{}).Select("id").Where("user_id = ?", id)
+		steps := []*gorm.DB{
+			tx.Unscoped().Where("user_id = ?", id).Delete(&models.ClipboardEntry{}),
+			tx.Unscoped().Where("user_id = ?", id).Delete(&models.Snippet{}),
+			tx.Unscoped().Where("user_id = ?", id).Delete(&models.SnippetFolder{}),
+			tx.Where("subscription_id IN (?)", userWebhooks).Delete(&models.WebhookDelivery{}),
+			tx.Unscoped().Where("user_id = ?", id).Delete(&models.WebhookSubscription{}),
+			tx.Where("user_id = ?", id).Delete(&models.ExportJob{}),
+			tx.Where("user_id = ?", id).Delete(&models.ImportJob{}),
+			tx.Where("user_id = ?", id).Delete(&models.DataExport{}),
+			tx.Where("session_id IN (?)", userSessions).Delete(&models.RefreshToken{}),
+			tx.Where("user_id = ?", id).Delete(&models.Session{}),
+			tx.Where("user_id = ?", id).Delete(&models.PersonalAccessToken{}),
+			tx.Where("user_id = ?", id).Delete(&models.UserIdentity{}),
+			tx.Where("user_id = ?", id).Delete(&models.TwoFactorSecret{}),
+			tx.Where("user_id = ?", id).Delete(&models.RecoveryCode{}),
+			tx.Where("user_id = ?", id).Delete(&models.LoginChallenge{}),
+			tx.Where("user_id = ?", id).Delete(&models.AccountToken{}),
+			tx.Where("user_id = ?", id).Delete(&models.PasswordHistory{}),
+			tx.Where("user_id = ?", id).Delete(&models.UserSettings{}),
+		}
+		for _, step := range steps {
+			if step.Error != nil {
+				return step.Error
+			}
+		}
+
+		return tx.Model(&models.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"email":                 fmt.Sprintf("deleted-%d@deleted.invalid", id),
+			"pending_email":         "",
+			"password":              "",
+			"username":              nil,
+			"display_name":          "",
+			"profile_picture_url":   "",
+			"two_factor_enabled":    false,
+			"is_admin":              false,
+			"deletion_scheduled_at": nil,
+			"deleted_at":            time.Now(),
+		}).Error
+	})
+	return files, err
+}
+
+// handOverTeam prepares a team for a member's departure: if nobody else is in it the team is
+// deleted, and if the member is its last owner (or last admin, for teams from before the owner
+// role) the longest-standing admin, or failing that member, becomes owner
+func handOverTeam(tx *gorm.DB, teamID, userID uint) error {
+	var others []models.TeamMember
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("team_id = ? AND user_id <> ?", teamID, userID).Order("joined_at ASC").Find(&others).Error; err != nil {
+		return err
+	}
+	if len(others) == 0 {
+		if err := tx.Unscoped().Where("team_id = ?", teamID).Delete(&models.TeamMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Team{}, teamID).Error
+	}
+
+	if err := ensureOtherOwner(tx, teamID, userID); !errors.Is(err, ErrLastTeamOwner) {
+		return err
+	}
+	successor := others[0]
+	for _, m := range others {
+		if m.Role == models.TeamRoleAdmin {
+			successor = m
+			break
+		}
+	}
+	return tx.Model(&models.TeamMember{}).Where("id = ?", successor.ID).Update("role", models.TeamRoleOwner).Error
+}
@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"time"
+
+	"clipboard-sync-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// WebhookRepository defines the interface for webhook subscription and delivery data operations
+type WebhookRepository interface {
+	CreateSubscription(sub *models.WebhookSubscription) error
+	UpdateSubscription(sub *models.WebhookSubscription) error
+	DeleteSubscription(id uint) error
+	GetSubscriptionByID(id uint) (*models.WebhookSubscription, error)
+	GetSubscriptionsByUserID(userID uint) ([]models.WebhookSubscription, error)
+	GetSubscriptionsByTeamID(teamID uint) ([]models.WebhookSubscription, error)
+	GetActiveSubscriptions(userID uint, teamID *uint) ([]models.WebhookSubscription, error)
+	CreateDeliveries(deliveries []models.WebhookDelivery) error
+	UpdateDelivery(delivery *models.WebhookDelivery) error
+	GetDeliveryByID(id uint) (*models.WebhookDelivery, error)
+	GetDeliveriesBySubscriptionID(subscriptionID uint, limit, offset int) ([]models.WebhookDelivery, error)
+	ClaimDueDeliveries(now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error)
+}
+
+type webhookRepository struct {
+	db *gorm.DB
+}
+
+// NewWebhookRepository creates a new WebhookRepository
+func NewWebhookRepository(db *gorm.DB) WebhookRepository {
+	return &webhookRepository{db: db}
+}
+
+// CreateSubscription creates a new webhook subscription
+func (r *webhookRepository) CreateSubscription(sub *models.WebhookSubscription) error {
+	return r.db.Create(sub).Error
+}
+
+// UpdateSubscription saves changes to a webhook subscription
+func (r *webhookRepository) UpdateSubscription(sub *models.WebhookSubscription) error {
+	return r.db.Save(sub).Error
+}
+
+// DeleteSubscription soft-deletes a webhook subscription
+func (r *webhookRepository) DeleteSubscription(id uint) error {
+	return r.db.Delete(&models.WebhookSubscription{}, id).Error
+}
+
+// GetSubscriptionByID retrieves a webhook subscription by its ID
+func (r *webhookRepository) GetSubscriptionByID(id uint) (*models.WebhookSubscription, error) {
+	var sub models.WebhookSubscription
+	if err := r.db.First(&sub, id).Error; err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// GetSubscriptionsByUserID retrieves a user's personal webhook subscriptions
+func (r *webhookRepository) GetSubscriptionsByUserID(userID uint) ([]models.WebhookSubscription, error) {
+	var subs []models.WebhookSubscription
+	if err := r.db.Where("user_id = ? AND team_id IS NULL", userID).Order("created_at DESC").Find(&subs).Error; err != nil {
+		return nil, err
+	}
+	return subs, nil
+}
+
+// GetSubscriptionsByTeamID retrieves a team's webhook subscriptions
+func (r *webhookRepository) GetSubscriptionsByTeamID(teamID uint) ([]models.WebhookSubscription, error) {
+	var subs []models.WebhookSubscription
+	if err := r.db.Where("team_id = ?", teamID).Order("created_at DESC").Find(&subs).Error; err != nil {
+		return nil, err
+	}
+	return subs, nil
+}
+
+// GetActiveSubscriptions retrieves the active subscriptions that should see an event: the team's
+// webhooks for team events, otherwise the user's personal webhooks
+func (r *webhookRepository) GetActiveSubscriptions(userID uint, teamID *uint) ([]models.WebhookSubscription, error) {
+	var subs []models.WebhookSubscription
+	query := r.db.Where("active = ?", true)
+	if teamID != nil {
+		query = query.Where("team_id = ?", *teamID)
+	} else {
+		query = query.Where("user_id = ? AND team_id IS NULL", userID)
+	}
+	if err := query.Find(&subs).Error; err != nil {
+		return nil, err
+	}
+	return subs, nil
+}
+
+// CreateDeliveries enqueues deliveries in a single insert
+func (r *webhookRepository) CreateDeliveries(deliveries []models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return r.db.Create(&deliveries).Error
+}
+
+// UpdateDelivery saves the outcome of a delivery attempt
+func (r *webhookRepository) UpdateDelivery(delivery *models.WebhookDelivery) error {
+	return r.db.Save(delivery).Error
+}
+
+// GetDeliveryByID retrieves a delivery by its ID
+func (r *webhookRepository) GetDeliveryByID(id uint) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	if err := r.db.First(&delivery, id).Error; err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// GetDeliveriesBySubscriptionID retrieves the delivery log of a subscription, most recent first
+func (r *webhookRepository) GetDeliveriesBySubscriptionID(subscriptionID uint, limit, offset int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	if err := r.db.Where("subscription_id = ?", subscriptionID).Order("created_at DESC").Limit(limit).Offset(offset).Find(&deliveries).Error; err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// ClaimDueDeliveries selects deliveries whose next attempt is due and pushes their next attempt
+// out by lease, so that concurrent workers (or replicas) don't send the same delivery twice
+func (r *webhookRepository) ClaimDueDeliveries(now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Raw(`SELECT * FROM webhook_deliveries
+			WHERE status IN (?, ?) AND next_attempt_at <= ?
+			ORDER BY next_attempt_at
+			LIMIT ? FOR UPDATE SKIP LOCKED`,
+			models.DeliveryPending, models.DeliveryFailed, now, limit).Scan(&deliveries).Error; err != nil {
+			return err
+		}
+		if len(deliveries) == 0 {
+			return nil
+		}
+		ids := make([]uint, len(deliveries))
+		for i, d := range deliveries {
+			ids[i] = d.ID
+		}
+		return tx.Model(&models.WebhookDelivery{}).Where("id IN ?", ids).Update("next_attempt_at", now.Add(lease)).Error
+	})
+	return deliveries, err
+}
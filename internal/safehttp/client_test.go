@@ -0,0 +1,48 @@
+package safehttp
+
+import (
+	"net"
+	"testing"
+)
+
+func TestIsBlockedIP(t *testing.T) {
+	tests := []struct {
+		ip      string
+		blocked bool
+	}{
+		{"127.0.0.1", true},        // Loopback
+		{"127.255.255.254", true},  // Loopback
+		{"::1", true},              // Loopback
+		{"::ffff:127.0.0.1", true}, // IPv4-mapped loopback
+		{"10.1.2.3", true},         // RFC 1918
+		{"172.16.0.1", true},       // RFC 1918
+		{"172.31.255.255", true},   // RFC 1918
+		{"192.168.1.1", true},      // RFC 1918
+		{"fd00::1", true},          // Unique local
+		{"169.254.169.254", true},  // Link-local, cloud metadata
+		{"fe80::1", true},          // Link-local
+		{"100.64.0.1", true},       // Carrier-grade NAT
+		{"100.127.255.255", true},  // Carrier-grade NAT
+		{"0.0.0.0", true},          // Unspecified
+		{"::", true},               // Unspecified
+		{"224.0.0.1", true},        // Multicast
+		{"198.18.0.1", true},       // Benchmarking
+		{"240.0.0.1", true},        // Reserved
+		{"64:ff9b::a00:1", true},   // NAT64
+		{"2001:db8::1", true},      // Documentation
+		{"8.8.8.8", false},
+		{"172.32.0.1", false},
+		{"100.128.0.1", false},
+		{"93.184.216.34", false},
+		{"2606:4700::1111", false},
+	}
+	for _, tt := range tests {
+		ip := net.ParseIP(tt.ip)
+		if ip == nil {
+			t.Fatalf("bad test address %q", tt.ip)
+		}
+		if got := IsBlockedIP(ip); got != tt.blocked {
+			t.Errorf("IsBlockedIP(%s) = %v, want %v", tt.ip, got, tt.blocked)
+		}
+	}
+}
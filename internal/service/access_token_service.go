@@ -0,0 +1,192 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"clipboard-sync-backend/internal/auth"
+	"clipboard-sync-backend/internal/models"
+	"clipboard-sync-backend/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+const (
+	maxAccessTokensPerUser = 50
+	accessTokenTouchEvery  = time.Minute // Last-used tracking is written at most this often per token
+	accessTokenPrefixLen   = len(auth.PersonalAccessTokenPrefix) + 6
+)
+
+var (
+	ErrAccessTokenNotFound = errors.New("access token not found")
+	ErrInvalidAccessToken  = auth.ErrInvalidToken
+	ErrInvalidTokenName    = errors.New("token name must be between 1 and 100 characters")
+	ErrInvalidTokenScope   = errors.New("unknown token scope")
+	ErrNoTokenScopes       = errors.New("at least one scope is required")
+	ErrInvalidTokenExpiry  = errors.New("expiry must be in the future")
+	ErrTooManyAccessTokens = fmt.Errorf("a user can have at most %d active access tokens", maxAccessTokensPerUser)
+)
+
+// AccessTokenService defines the interface for personal access token management
+type AccessTokenService interface {
+	CreateToken(userID uint, name string, scopes []string, expiresIn time.Duration, req *RequestInfo) (*models.PersonalAccessToken, string, error)
+	ListTokens(userID uint) ([]models.PersonalAccessToken, error)
+	RevokeToken(userID, tokenID uint, req *RequestInfo) error
+	AuthenticatePersonalAccessToken(token, ip string) (uint, []string, error)
+}
+
+type accessTokenService struct {
+	tokenRepo    repository.AccessTokenRepository
+	auditService AuditService
+}
+
+// NewAccessTokenService creates a new AccessTokenService
+func NewAccessTokenService(tokenRepo repository.AccessTokenRepository, auditService AuditService) AccessTokenService {
+	return &accessTokenService{tokenRepo: tokenRepo, auditService: auditService}
+}
+
+func hashAccessToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func validateTokenScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return nil, ErrNoTokenScopes
+	}
+	seen := make(map[string]bool, len(scopes))
+	var out []string
+	for _, scope := range scopes {
+		valid := false
+		for _, known := range models.TokenScopes {
+			if scope == known {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidTokenScope, scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			out = append(out, scope)
+		}
+	}
+	return out, nil
+}
+
+// CreateToken mints a personal access token. expiresIn of zero means it never expires. The
+// token itself is only returned here.
+func (s *accessTokenService) CreateToken(userID uint, name string, scopes []string, expiresIn time.Duration, req *RequestInfo) (*models.PersonalAccessToken, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > 100 {
+		return nil, "", ErrInvalidTokenName
+	}
+	scopes, err := validateTokenScopes(scopes)
+	if err != nil {
+		return nil, "", err
+	}
+	if expiresIn < 0 {
+		return nil, "", ErrInvalidTokenExpiry
+	}
+	now := time.Now()
+	count, err := s.tokenRepo.CountActiveTokens(userID, now)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to count access tokens: %w", err)
+	}
+	if count >= maxAccessTokensPerUser {
+		return nil, "", ErrTooManyAccessTokens
+	}
+
+	secret, err := randomHex(32)
+	if err != nil {
+		return nil, "", err
+	}
+	token := auth.PersonalAccessTokenPrefix + secret
+	pat := &models.PersonalAccessToken{
+		UserID:    userID,
+		Name:      name,
+		TokenHash: hashAccessToken(token),
+		Prefix:    token[:accessTokenPrefixLen],
+		ScopeList: scopes,
+	}
+	if expiresIn > 0 {
+		expiresAt := now.Add(expiresIn)
+		pat.ExpiresAt = &expiresAt
+	}
+	if err := s.tokenRepo.CreateToken(pat); err != nil {
+		return nil, "", fmt.Errorf("failed to create access token: %w", err)
+	}
+
+	log.Printf("User %d created access token %d (%s)", userID, pat.ID, strings.Join(scopes, ","))
+	recordAudit(s.auditService, AuditEvent{
+		UserID:  &userID,
+		Action:  models.AuditTokenCreated,
+		Details: map[string]interface{}{"token_id": pat.ID, "name": name, "scopes": scopes, "expires_at": pat.ExpiresAt},
+		Request: req,
+	})
+	return pat, token, nil
+}
+
+// ListTokens lists the user's unrevoked tokens, including expired ones so they can be cleaned up
+func (s *accessTokenService) ListTokens(userID uint) ([]models.PersonalAccessToken, error) {
+	tokens, err := s.tokenRepo.GetTokensByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list access tokens: %w", err)
+	}
+	return tokens, nil
+}
+
+// RevokeToken revokes one of the user's tokens
+func (s *accessTokenService) RevokeToken(userID, tokenID uint, req *RequestInfo) error {
+	pat, err := s.tokenRepo.GetTokenByID(tokenID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrAccessTokenNotFound
+		}
+		return fmt.Errorf("failed to get access token: %w", err)
+	}
+	if pat.UserID != userID || pat.RevokedAt != nil {
+		return ErrAccessTokenNotFound
+	}
+	if err := s.tokenRepo.RevokeToken(tokenID, time.Now()); err != nil {
+		return fmt.Errorf("failed to revoke access token: %w", err)
+	}
+
+	recordAudit(s.auditService, AuditEvent{
+		UserID:  &userID,
+		Action:  models.AuditTokenRevoked,
+		Details: map[string]interface{}{"token_id": tokenID, "name": pat.Name},
+		Request: req,
+	})
+	return nil
+}
+
+// AuthenticatePersonalAccessToken resolves a token to its user and scopes; it backs
+// auth.AuthMiddleware
+func (s *accessTokenService) AuthenticatePersonalAccessToken(token, ip string) (uint, []string, error) {
+	pat, err := s.tokenRepo.GetTokenByHash(hashAccessToken(token))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, nil, ErrInvalidAccessToken
+		}
+		return 0, nil, fmt.Errorf("failed to get access token: %w", err)
+	}
+	now := time.Now()
+	// Tokens of suspended users stop working until the suspension is lifted
+	if !pat.Active(now) || pat.User.SuspendedAt != nil {
+		return 0, nil, ErrInvalidAccessToken
+	}
+
+	if pat.LastUsedAt == nil || now.Sub(*pat.LastUsedAt) >= accessTokenTouchEvery || pat.LastUsedIP != ip {
+		if err := s.tokenRepo.TouchToken(pat.ID, now, ip); err != nil {
+			log.Printf("Failed to record use of access token %d: %v", pat.ID, err)
+		}
+	}
+	return pat.UserID, pat.ScopeList, nil
+}
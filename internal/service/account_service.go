@@ -0,0 +1,360 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"clipboard-sync-backend/internal/auth"
+	"clipboard-sync-backend/internal/mail"
+	"clipboard-sync-backend/internal/models"
+	"clipboard-sync-backend/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultEmailVerificationTTL = 48 * time.Hour
+	defaultPasswordResetTTL     = time.Hour
+)
+
+var (
+	ErrInvalidAccountToken  = errors.New("link is invalid or has expired")
+	ErrEmailAlreadyVerified = errors.New("email address is already verified")
+)
+
+// AccountService defines the interface for email verification and password recovery
+type AccountService interface {
+	SendVerificationEmail(userID uint) error
+	VerifyEmail(token string, req *RequestInfo) error
+	RequestPasswordReset(email string, req *RequestInfo) error
+	ResetPassword(token, newPassword string, req *RequestInfo) error
+	ChangePassword(userID, sessionID uint, currentPassword, newPassword string, req *RequestInfo) error
+	RequestEmailChange(userID uint, newEmail string, req *RequestInfo) error
+	ConfirmEmailChange(token string, req *RequestInfo) error
+}
+
+type accountService struct {
+	userRepo       repository.UserRepository
+	tokenRepo      repository.AccountTokenRepository
+	tokens         *auth.TokenManager
+	passwords      PasswordService
+	mailer         mail.Sender
+	sessionService SessionService
+	auditService   AuditService
+	appURL         string
+	verifyTTL      time.Duration
+	resetTTL       time.Duration
+}
+
+// NewAccountService creates a new AccountService. Links in emails point at appURL; verification
+// links work for verifyTTL and password reset links for resetTTL.
+func NewAccountService(userRepo repository.UserRepository, tokenRepo repository.AccountTokenRepository, tokens *auth.TokenManager, passwords PasswordService, mailer mail.Sender, sessionService SessionService, auditService AuditService, appURL string, verifyTTL, resetTTL time.Duration) AccountService {
+	if verifyTTL <= 0 {
+		verifyTTL = defaultEmailVerificationTTL
+	}
+	if resetTTL <= 0 {
+		resetTTL = defaultPasswordResetTTL
+	}
+	return &accountService{
+		userRepo:       userRepo,
+		tokenRepo:      tokenRepo,
+		tokens:         tokens,
+		passwords:      passwords,
+		mailer:         mailer,
+		sessionService: sessionService,
+		auditService:   auditService,
+		appURL:         strings.TrimRight(appURL, "/"),
+		verifyTTL:      verifyTTL,
+		resetTTL:       resetTTL,
+	}
+}
+
+// issueLink records a single-use token for purpose, sent to email, and returns the link carrying it
+func (s *accountService) issueLink(user *models.User, email, purpose, path string, ttl time.Duration) (string, error) {
+	tokenID, err := randomHex(16)
+	if err != nil {
+		return "", err
+	}
+	token, err := s.tokens.GenerateActionToken(purpose, user.ID, tokenID, ttl)
+	if err != nil {
+		return "", err
+	}
+	if err := s.tokenRepo.CreateToken(&models.AccountToken{
+		UserID:    user.ID,
+		Purpose:   purpose,
+		TokenID:   tokenID,
+		Email:     email,
+		ExpiresAt: time.Now().Add(ttl),
+	}); err != nil {
+		return "", fmt.Errorf("failed to save token: %w", err)
+	}
+	return s.appURL + path + "?token=" + url.QueryEscape(token), nil
+}
+
+// consume checks a token's signature, expiry and purpose and uses it up, returning its user.
+// check, when set, can reject the request before the token is used up.
+func (s *accountService) consume(token, purpose string, check func(*models.User) error) (*models.User, error) {
+	userID, tokenID, err := s.tokens.ParseActionToken(token, purpose)
+	if err != nil {
+		return nil, ErrInvalidAccountToken
+	}
+	record, err := s.tokenRepo.GetTokenByTokenID(tokenID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidAccountToken
+		}
+		return nil, fmt.Errorf("failed to get token: %w", err)
+	}
+	if record.UserID != userID || record.Purpose != purpose {
+		return nil, ErrInvalidAccountToken
+	}
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidAccountToken
+		}
+		return nil, fmt.Errorf("error retrieving user: %w", err)
+	}
+	expected := user.Email
+	if purpose == models.TokenPurposeChangeEmail {
+		expected = user.PendingEmail
+	}
+	if expected != record.Email {
+		return nil, ErrInvalidAccountToken
+	}
+	if check != nil {
+		if err := check(user); err != nil {
+			return nil, err
+		}
+	}
+	used, err := s.tokenRepo.UseToken(record.ID, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to use token: %w", err)
+	}
+	if !used {
+		return nil, ErrInvalidAccountToken
+	}
+	return user, nil
+}
+
+// SendVerificationEmail mails the user a link that verifies their address. Sending a new link
+// voids earlier ones.
+func (s *accountService) SendVerificationEmail(userID uint) error {
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return fmt.Errorf("error retrieving user: %w", err)
+	}
+	if user.EmailVerifiedAt != nil {
+		return ErrEmailAlreadyVerified
+	}
+	link, err := s.issueLink(user, user.Email, models.TokenPurposeVerifyEmail, "/verify-email", s.verifyTTL)
+	if err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf("Confirm that %s is your email address by opening this link:\n\n%s\n\nThe link expires in %s. If you did not create an account, you can ignore this email.\n",
+		user.Email, link, formatTTL(s.verifyTTL))
+	if err := s.mailer.Send(mail.Message{To: user.Email, Subject: "Verify your email address", Body: body}); err != nil {
+		return fmt.Errorf("failed to send verification email: %w", err)
+	}
+	return nil
+}
+
+// VerifyEmail marks the address a verification link was sent to as verified
+func (s *accountService) VerifyEmail(token string, req *RequestInfo) error {
+	user, err := s.consume(token, models.TokenPurposeVerifyEmail, nil)
+	if err != nil {
+		return err
+	}
+	if err := s.userRepo.MarkEmailVerified(user.ID, time.Now()); err != nil {
+		return fmt.Errorf("failed to verify email: %w", err)
+	}
+
+	log.Printf("User %d verified %s", user.ID, user.Email)
+	recordAudit(s.auditService, AuditEvent{UserID: &user.ID, Action: models.AuditEmailVerified, Details: map[string]interface{}{"email": user.Email}, Request: req})
+	return nil
+}
+
+// RequestPasswordReset mails a reset link if an account has the address. It succeeds either way
+// so the endpoint cannot be used to find out which addresses have accounts.
+func (s *accountService) RequestPasswordReset(email string, req *RequestInfo) error {
+	user, err := s.userRepo.GetUserByEmail(strings.TrimSpace(email))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return fmt.Errorf("error retrieving user: %w", err)
+	}
+	link, err := s.issueLink(user, user.Email, models.TokenPurposePasswordReset, "/reset-password", s.resetTTL)
+	if err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf("Someone asked to reset the password for %s. To choose a new password, open this link:\n\n%s\n\nThe link expires in %s and can be used once. If you did not ask for this, you can ignore this email; your password has not changed.\n",
+		user.Email, link, formatTTL(s.resetTTL))
+	if err := s.mailer.Send(mail.Message{To: user.Email, Subject: "Reset your password", Body: body}); err != nil {
+		return fmt.Errorf("failed to send password reset email: %w", err)
+	}
+	recordAudit(s.auditService, AuditEvent{UserID: &user.ID, Action: models.AuditPasswordResetRequest, Request: req})
+	return nil
+}
+
+// ResetPassword sets a new password with a reset link and signs the user out everywhere. Having
+// received the link also proves the user owns their address.
+func (s *accountService) ResetPassword(token, newPassword string, req *RequestInfo) error {
+	// Checked before the token is used up so a rejected password does not cost the link
+	user, err := s.consume(token, models.TokenPurposePasswordReset, func(user *models.User) error {
+		return s.passwords.Validate(user, newPassword)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := s.passwords.SetPassword(user, newPassword); err != nil {
+		return err
+	}
+	now := time.Now()
+	if err := s.tokenRepo.VoidUserTokens(user.ID, models.TokenPurposePasswordReset, now); err != nil {
+		log.Printf("Failed to void password reset tokens of user %d: %v", user.ID, err)
+	}
+	if err := s.userRepo.MarkEmailVerified(user.ID, now); err != nil {
+		log.Printf("Failed to mark email of user %d verified: %v", user.ID, err)
+	}
+	if s.sessionService != nil {
+		if err := s.sessionService.RevokeAllSessions(user.ID, 0, models.SessionRevokedReset, req); err != nil {
+			log.Printf("Failed to revoke sessions of user %d after password reset: %v", user.ID, err)
+		}
+	}
+
+	log.Printf("User %d reset their password", user.ID)
+	recordAudit(s.auditService, AuditEvent{UserID: &user.ID, Action: models.AuditPasswordReset, Request: req})
+	return nil
+}
+
+// ChangePassword replaces a signed-in user's password and signs out their other sessions. The
+// current password is required unless the account only used social login so far.
+func (s *accountService) ChangePassword(userID, sessionID uint, currentPassword, newPassword string, req *RequestInfo) error {
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return fmt.Errorf("error retrieving user: %w", err)
+	}
+	if user.Password != "" {
+		ok, err := s.passwords.Verify(user, currentPassword)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrWrongPassword
+		}
+	}
+	if err := s.passwords.SetPassword(user, newPassword); err != nil {
+		return err
+	}
+	if err := s.tokenRepo.VoidUserTokens(user.ID, models.TokenPurposePasswordReset, time.Now()); err != nil {
+		log.Printf("Failed to void password reset tokens of user %d: %v", user.ID, err)
+	}
+	if s.sessionService != nil {
+		if err := s.sessionService.RevokeAllSessions(user.ID, sessionID, models.SessionRevokedPasswordChange, req); err != nil {
+			log.Printf("Failed to revoke sessions of user %d after password change: %v", user.ID, err)
+		}
+	}
+
+	log.Printf("User %d changed their password", user.ID)
+	recordAudit(s.auditService, AuditEvent{UserID: &user.ID, Action: models.AuditPasswordChanged, Request: req})
+	body := fmt.Sprintf("The password for %s was just changed and your other devices were signed out.\n\nIf you did not do this, reset your password right away:\n\n%s/forgot-password\n",
+		user.Email, s.appURL)
+	if err := s.mailer.Send(mail.Message{To: user.Email, Subject: "Your password was changed", Body: body}); err != nil {
+		log.Printf("Failed to send password change notice to %s: %v", user.Email, err)
+	}
+	return nil
+}
+
+// RequestEmailChange mails a verification link to a new address; the account keeps its current
+// address until the link is followed. The current address is told about the request.
+func (s *accountService) RequestEmailChange(userID uint, newEmail string, req *RequestInfo) error {
+	newEmail = strings.TrimSpace(newEmail)
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return fmt.Errorf("error retrieving user: %w", err)
+	}
+	if strings.EqualFold(newEmail, user.Email) {
+		return nil
+	}
+	if _, err := s.userRepo.GetUserByEmail(newEmail); err == nil {
+		return ErrEmailTaken
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("error checking existing user: %w", err)
+	}
+
+	if err := s.userRepo.SetPendingEmail(user.ID, newEmail); err != nil {
+		return fmt.Errorf("failed to save new email: %w", err)
+	}
+	user.PendingEmail = newEmail
+	link, err := s.issueLink(user, newEmail, models.TokenPurposeChangeEmail, "/confirm-email", s.verifyTTL)
+	if err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf("Confirm that %s should become the email address of your account by opening this link:\n\n%s\n\nThe link expires in %s. Until then your account keeps using %s.\n",
+		newEmail, link, formatTTL(s.verifyTTL), user.Email)
+	if err := s.mailer.Send(mail.Message{To: newEmail, Subject: "Confirm your new email address", Body: body}); err != nil {
+		return fmt.Errorf("failed to send verification email: %w", err)
+	}
+	notice := fmt.Sprintf("Someone signed in to your account asked to change its email address from %s to %s. The change only happens once the new address is confirmed.\n\nIf this was not you, change your password right away:\n\n%s/forgot-password\n",
+		user.Email, newEmail, s.appURL)
+	if err := s.mailer.Send(mail.Message{To: user.Email, Subject: "Email address change requested", Body: notice}); err != nil {
+		log.Printf("Failed to send email change notice to %s: %v", user.Email, err)
+	}
+	return nil
+}
+
+// ConfirmEmailChange switches the account to the address an email change link was sent to
+func (s *accountService) ConfirmEmailChange(token string, req *RequestInfo) error {
+	user, err := s.consume(token, models.TokenPurposeChangeEmail, func(user *models.User) error {
+		// The address may have been registered by someone else since the link was sent
+		if _, err := s.userRepo.GetUserByEmail(user.PendingEmail); err == nil {
+			return ErrEmailTaken
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("error checking existing user: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	oldEmail, newEmail := user.Email, user.PendingEmail
+	if err := s.userRepo.ChangeEmail(user.ID, newEmail, time.Now()); err != nil {
+		return fmt.Errorf("failed to change email: %w", err)
+	}
+	// Links sent to the old address must not work any more
+	now := time.Now()
+	for _, purpose := range []string{models.TokenPurposeVerifyEmail, models.TokenPurposePasswordReset} {
+		if err := s.tokenRepo.VoidUserTokens(user.ID, purpose, now); err != nil {
+			log.Printf("Failed to void %s tokens of user %d: %v", purpose, user.ID, err)
+		}
+	}
+
+	log.Printf("User %d changed their email from %s to %s", user.ID, oldEmail, newEmail)
+	recordAudit(s.auditService, AuditEvent{
+		UserID:  &user.ID,
+		Action:  models.AuditEmailChanged,
+		Details: map[string]interface{}{"old_email": oldEmail, "new_email": newEmail},
+		Request: req,
+	})
+	return nil
+}
+
+// formatTTL renders a link lifetime for email text, e.g. "48 hours"
+func formatTTL(d time.Duration) string {
+	if d >= time.Hour && d%time.Hour == 0 {
+		if d == time.Hour {
+			return "1 hour"
+		}
+		return fmt.Sprintf("%d hours", d/time.Hour)
+	}
+	return d.Round(time.Minute).String()
+}
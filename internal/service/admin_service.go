@@ -0,0 +1,355 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"clipboard-sync-backend/internal/mail"
+	"clipboard-sync-backend/internal/models"
+	"clipboard-sync-backend/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+const (
+	maxAdminReasonLength = 255
+	defaultStatsWindow   = 30 * 24 * time.Hour
+)
+
+var (
+	ErrAdminSelfAction         = errors.New("administrators cannot do this to their own account")
+	ErrTargetIsAdmin           = errors.New("administrators cannot be suspended or impersonated")
+	ErrUserSuspended           = errors.New("user is suspended")
+	ErrUserNotSuspended        = errors.New("user is not suspended")
+	ErrAdminReasonRequired     = fmt.Errorf("a reason of at most %d characters is required", maxAdminReasonLength)
+	ErrInvalidUserStatus       = errors.New("status must be active, suspended or admin")
+	ErrInvalidStatsWindow      = errors.New("stats window must be positive")
+	ErrImpersonationNotAudited = errors.New("impersonation could not be audited and was not started")
+)
+
+// ConnectionCounter reports how many users are online and over how many live connections
+// (implemented by websocket.Manager)
+type ConnectionCounter interface {
+	ConnectionCount() (int, int)
+}
+
+// AdminUser is a user as administrators see it, with their active sessions
+type AdminUser struct {
+	*models.User
+	Sessions []models.Session `json:"sessions"`
+}
+
+// AdminStats are the usage statistics of the administration API
+type AdminStats struct {
+	repository.UsageStats
+	Since           time.Time `json:"since"`
+	OnlineUsers     int       `json:"online_users"`
+	OpenConnections int       `json:"open_connections"`
+	GeneratedAt     time.Time `json:"generated_at"`
+}
+
+// Impersonation is handed to an administrator who starts acting as a user
+type Impersonation struct {
+	AccessToken string    `json:"token"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	SessionID   uint      `json:"session_id"`
+	UserID      uint      `json:"user_id"`
+}
+
+// AdminService defines the interface for system administration of user accounts
+type AdminService interface {
+	SearchUsers(filter repository.UserSearchFilter, limit, offset int) ([]models.User, int64, error)
+	GetUser(userID uint) (*AdminUser, error)
+	SuspendUser(adminID, userID uint, reason string, req *RequestInfo) (*models.User, error)
+	UnsuspendUser(adminID, userID uint, req *RequestInfo) (*models.User, error)
+	ForceLogout(adminID, userID uint, req *RequestInfo) error
+	ResetTwoFactor(adminID, userID uint, req *RequestInfo) error
+	Impersonate(adminID, userID uint, reason string, req *RequestInfo) (*Impersonation, error)
+	Stats(window time.Duration) (*AdminStats, error)
+}
+
+type adminService struct {
+	userRepo       repository.UserRepository
+	sessionRepo    repository.SessionRepository
+	twoFactorRepo  repository.TwoFactorRepository
+	statsRepo      repository.StatsRepository
+	sessionService SessionService
+	notifier       SessionNotifier
+	connections    ConnectionCounter
+	mailer         mail.Sender
+	auditService   AuditService
+}
+
+// NewAdminService creates a new AdminService. The notifier closes live connections of users
+// who are signed out; connections, when set, adds online counts to the stats.
+func NewAdminService(userRepo repository.UserRepository, sessionRepo repository.SessionRepository, twoFactorRepo repository.TwoFactorRepository, statsRepo repository.StatsRepository, sessionService SessionService, notifier SessionNotifier, connections ConnectionCounter, mailer mail.Sender, auditService AuditService) AdminService {
+	return &adminService{
+		userRepo:       userRepo,
+		sessionRepo:    sessionRepo,
+		twoFactorRepo:  twoFactorRepo,
+		statsRepo:      statsRepo,
+		sessionService: sessionService,
+		notifier:       notifier,
+		connections:    connections,
+		mailer:         mailer,
+		auditService:   auditService,
+	}
+}
+
+// normalizeAdminReason trims a reason an administrator must give and checks its length
+func normalizeAdminReason(reason string) (string, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" || len(reason) > maxAdminReasonLength {
+		return "", ErrAdminReasonRequired
+	}
+	return reason, nil
+}
+
+// getUser loads a user administrators may act on
+func (s *adminService) getUser(userID uint) (*models.User, error) {
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("error retrieving user: %w", err)
+	}
+	return user, nil
+}
+
+// SearchUsers pages through users matching the filter and reports the total number of matches
+func (s *adminService) SearchUsers(filter repository.UserSearchFilter, limit, offset int) ([]models.User, int64, error) {
+	filter.Query = strings.TrimSpace(filter.Query)
+	switch filter.Status {
+	case "", repository.UserStatusActive, repository.UserStatusSuspended, repository.UserStatusAdmin:
+	default:
+		return nil, 0, ErrInvalidUserStatus
+	}
+	users, total, err := s.userRepo.SearchUsers(filter, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to search users: %w", err)
+	}
+	return users, total, nil
+}
+
+// GetUser returns a user with their active sessions
+func (s *adminService) GetUser(userID uint) (*AdminUser, error) {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := s.sessionRepo.GetActiveSessionsByUserID(userID, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	return &AdminUser{User: user, Sessions: sessions}, nil
+}
+
+// SuspendUser disables an account: it is signed out everywhere, its access tokens stop working
+// and it cannot sign in until the suspension is lifted
+func (s *adminService) SuspendUser(adminID, userID uint, reason string, req *RequestInfo) (*models.User, error) {
+	reason, err := normalizeAdminReason(reason)
+	if err != nil {
+		return nil, err
+	}
+	if adminID == userID {
+		return nil, ErrAdminSelfAction
+	}
+	user, err := s.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.IsAdmin {
+		return nil, ErrTargetIsAdmin
+	}
+	if user.SuspendedAt != nil {
+		return nil, ErrUserSuspended
+	}
+
+	now := time.Now()
+	if err := s.userRepo.SetSuspension(userID, &now, reason); err != nil {
+		return nil, fmt.Errorf("failed to suspend user: %w", err)
+	}
+	user.SuspendedAt = &now
+	user.SuspensionReason = reason
+	s.signOut(userID, models.SessionRevokedSuspended, req)
+
+	log.Printf("Admin %d suspended user %d", adminID, userID)
+	recordAudit(s.auditService, AuditEvent{
+		UserID:  &adminID,
+		Action:  models.AuditAccountSuspended,
+		Details: map[string]interface{}{"target_user_id": userID, "email": user.Email, "reason": reason},
+		Request: req,
+	})
+	body := fmt.Sprintf("Your Clipboard Sync account %s has been suspended by an administrator.\n\nReason: %s\n\nReply to this email if you believe this is a mistake.\n", user.Email, reason)
+	if err := s.mailer.Send(mail.Message{To: user.Email, Subject: "Your account has been suspended", Body: body}); err != nil {
+		log.Printf("Failed to send suspension notice to %s: %v", user.Email, err)
+	}
+	return user, nil
+}
+
+// UnsuspendUser lifts a suspension; the user signs in again as usual
+func (s *adminService) UnsuspendUser(adminID, userID uint, req *RequestInfo) (*models.User, error) {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.SuspendedAt == nil {
+		return nil, ErrUserNotSuspended
+	}
+	if err := s.userRepo.SetSuspension(userID, nil, ""); err != nil {
+		return nil, fmt.Errorf("failed to lift suspension: %w", err)
+	}
+	user.SuspendedAt = nil
+	user.SuspensionReason = ""
+
+	log.Printf("Admin %d lifted the suspension of user %d", adminID, userID)
+	recordAudit(s.auditService, AuditEvent{
+		UserID:  &adminID,
+		Action:  models.AuditAccountUnsuspended,
+		Details: map[string]interface{}{"target_user_id": userID, "email": user.Email},
+		Request: req,
+	})
+	body := fmt.Sprintf("The suspension of your Clipboard Sync account %s has been lifted. You can sign in again.\n", user.Email)
+	if err := s.mailer.Send(mail.Message{To: user.Email, Subject: "Your account has been reinstated", Body: body}); err != nil {
+		log.Printf("Failed to send reinstatement notice to %s: %v", user.Email, err)
+	}
+	return user, nil
+}
+
+// ForceLogout revokes every session of a user and closes all of their live connections
+func (s *adminService) ForceLogout(adminID, userID uint, req *RequestInfo) error {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return err
+	}
+	if err := s.signOut(userID, models.SessionRevokedByAdmin, req); err != nil {
+		return err
+	}
+
+	log.Printf("Admin %d signed out user %d everywhere", adminID, userID)
+	recordAudit(s.auditService, AuditEvent{
+		UserID:  &adminID,
+		Action:  models.AuditForcedLogout,
+		Details: map[string]interface{}{"target_user_id": userID, "email": user.Email},
+		Request: req,
+	})
+	return nil
+}
+
+// signOut revokes the user's sessions and closes their connections, including those opened
+// with personal access tokens, which have no session
+func (s *adminService) signOut(userID uint, reason string, req *RequestInfo) error {
+	err := s.sessionService.RevokeAllSessions(userID, 0, reason, req)
+	if err != nil {
+		log.Printf("Failed to revoke sessions of user %d: %v", userID, err)
+	}
+	if s.notifier != nil {
+		s.notifier.DisconnectUser(userID)
+	}
+	return err
+}
+
+// ResetTwoFactor removes a user's second factor and recovery codes, e.g. after they lost their
+// authenticator. They sign in with their password alone until they enroll again.
+func (s *adminService) ResetTwoFactor(adminID, userID uint, req *RequestInfo) error {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return err
+	}
+	if !user.TwoFactorEnabled {
+		return ErrTwoFactorNotEnabled
+	}
+	if err := s.twoFactorRepo.DisableTwoFactor(userID); err != nil {
+		return fmt.Errorf("failed to reset two-factor authentication: %w", err)
+	}
+
+	log.Printf("Admin %d reset two-factor authentication of user %d", adminID, userID)
+	recordAudit(s.auditService, AuditEvent{
+		UserID:  &adminID,
+		Action:  models.AuditTwoFactorReset,
+		Details: map[string]interface{}{"target_user_id": userID, "email": user.Email},
+		Request: req,
+	})
+	body := fmt.Sprintf("An administrator turned off two-factor authentication for your Clipboard Sync account %s. Sign in and set it up again to keep your account protected.\n\nIf you did not ask for this, contact us straight away.\n", user.Email)
+	if err := s.mailer.Send(mail.Message{To: user.Email, Subject: "Two-factor authentication was reset", Body: body}); err != nil {
+		log.Printf("Failed to send two-factor reset notice to %s: %v", user.Email, err)
+	}
+	return nil
+}
+
+// Impersonate lets an administrator act as a user for the lifetime of one access token. The
+// start is written to the audit log before the token is handed out, and every request made with
+// it is audited as well.
+func (s *adminService) Impersonate(adminID, userID uint, reason string, req *RequestInfo) (*Impersonation, error) {
+	reason, err := normalizeAdminReason(reason)
+	if err != nil {
+		return nil, err
+	}
+	if adminID == userID {
+		return nil, ErrAdminSelfAction
+	}
+	user, err := s.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.IsAdmin {
+		return nil, ErrTargetIsAdmin
+	}
+	if user.SuspendedAt != nil {
+		return nil, ErrUserSuspended
+	}
+	if s.auditService == nil {
+		return nil, ErrImpersonationNotAudited
+	}
+
+	tokens, err := s.sessionService.CreateImpersonationSession(userID, adminID, fmt.Sprintf("Impersonated by administrator %d", adminID), req)
+	if err != nil {
+		return nil, err
+	}
+	err = s.auditService.RecordNow(AuditEvent{
+		UserID:  &adminID,
+		Action:  models.AuditImpersonationStarted,
+		Details: map[string]interface{}{"target_user_id": userID, "email": user.Email, "session_id": tokens.SessionID, "reason": reason},
+		Request: req,
+	})
+	if err != nil {
+		log.Printf("Failed to audit impersonation of user %d by admin %d: %v", userID, adminID, err)
+		if revokeErr := s.sessionService.RevokeSession(userID, tokens.SessionID, models.SessionRevokedByAdmin, nil); revokeErr != nil {
+			log.Printf("Failed to revoke unaudited impersonation session %d: %v", tokens.SessionID, revokeErr)
+		}
+		return nil, ErrImpersonationNotAudited
+	}
+
+	log.Printf("Admin %d is impersonating user %d (session %d)", adminID, userID, tokens.SessionID)
+	return &Impersonation{
+		AccessToken: tokens.AccessToken,
+		ExpiresAt:   time.Now().Add(time.Duration(tokens.ExpiresIn) * time.Second),
+		SessionID:   tokens.SessionID,
+		UserID:      userID,
+	}, nil
+}
+
+// Stats reports server-wide usage; recent counts cover the last window
+func (s *adminService) Stats(window time.Duration) (*AdminStats, error) {
+	if window == 0 {
+		window = defaultStatsWindow
+	}
+	if window < 0 {
+		return nil, ErrInvalidStatsWindow
+	}
+	now := time.Now()
+	since := now.Add(-window)
+	usage, err := s.statsRepo.GetUsageStats(now, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to gather usage statistics: %w", err)
+	}
+	stats := &AdminStats{UsageStats: *usage, Since: since.UTC(), GeneratedAt: now.UTC()}
+	if s.connections != nil {
+		stats.OnlineUsers, stats.OpenConnections = s.connections.ConnectionCount()
+	}
+	return stats, nil
+}
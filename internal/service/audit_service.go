@@ -0,0 +1,329 @@
+package service
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"clipboard-sync-backend/internal/models"
+	"clipboard-sync-backend/internal/policy"
+	"clipboard-sync-backend/internal/repository"
+)
+
+const (
+	auditExportPage    = 500
+	auditExportMaxRows = 50000
+	auditMaxUserAgent  = 512
+	auditDefaultBatch  = 100
+	auditDefaultFlush  = 2 * time.Second
+	auditDefaultQueue  = 4096
+)
+
+// Audit export formats
+const (
+	AuditFormatJSON = "json"
+	AuditFormatCSV  = "csv"
+)
+
+var ErrUnsupportedAuditFormat = errors.New("unsupported format, expected json or csv")
+
+// RequestInfo describes the client request behind an audited action
+type RequestInfo struct {
+	IP        string
+	UserAgent string
+	Method    string
+	Path      string
+	// ImpersonatorID is the administrator who made the request while impersonating the user
+	ImpersonatorID *uint
+}
+
+// AuditEvent is an action to record. UserID is the acting user and TeamID the team acted on.
+type AuditEvent struct {
+	UserID  *uint
+	TeamID  *uint
+	Action  string
+	Details map[string]interface{}
+	Request *RequestInfo
+}
+
+// AuditQuery selects the audit records to list; zero values are ignored
+type AuditQuery struct {
+	TeamID  *uint // The team's log instead of the caller's own actions
+	Actions []string
+	Since   *time.Time
+	Until   *time.Time
+}
+
+// AuditService defines the interface for recording and reading the audit log
+type AuditService interface {
+	// Record queues an event without blocking; events are written in batches by Run
+	Record(event AuditEvent)
+	// RecordNow writes an event before returning, for actions that must not happen unaudited
+	RecordNow(event AuditEvent) error
+	List(userID uint, query AuditQuery, limit, offset int) ([]models.AuditLog, error)
+	Export(userID uint, query AuditQuery, format string, w io.Writer) (int, error)
+	// Run writes queued events until stop is closed, then writes what is still queued
+	Run(stop <-chan struct{})
+}
+
+type auditService struct {
+	auditRepo     repository.AuditRepository
+	teamRepo      repository.TeamRepository
+	queue         chan models.AuditLog
+	batchSize     int
+	flushInterval time.Duration
+}
+
+// NewAuditService creates a new AuditService. Queued events are written once batchSize have
+// accumulated or flushInterval has passed; when queueSize events are waiting, new ones are dropped.
+func NewAuditService(auditRepo repository.AuditRepository, teamRepo repository.TeamRepository, batchSize int, flushInterval time.Duration, queueSize int) AuditService {
+	if batchSize <= 0 {
+		batchSize = auditDefaultBatch
+	}
+	if flushInterval <= 0 {
+		flushInterval = auditDefaultFlush
+	}
+	if queueSize <= 0 {
+		queueSize = auditDefaultQueue
+	}
+	return &auditService{
+		auditRepo:     auditRepo,
+		teamRepo:      teamRepo,
+		queue:         make(chan models.AuditLog, queueSize),
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+	}
+}
+
+// recordAudit records an event when an audit service is configured
+func recordAudit(a AuditService, event AuditEvent) {
+	if a != nil {
+		a.Record(event)
+	}
+}
+
+// Record queues an event for the batch writer
+func (s *auditService) Record(event AuditEvent) {
+	entry := newAuditLog(event)
+	select {
+	case s.queue <- entry:
+	default:
+		log.Printf("Audit queue full, dropping %s event", event.Action)
+	}
+}
+
+// RecordNow writes an event straight away, bypassing the queue
+func (s *auditService) RecordNow(event AuditEvent) error {
+	if err := s.auditRepo.CreateLogs([]models.AuditLog{newAuditLog(event)}); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
+
+// newAuditLog builds the record of an event
+func newAuditLog(event AuditEvent) models.AuditLog {
+	entry := models.AuditLog{
+		UserID:        event.UserID,
+		TeamID:        event.TeamID,
+		ActionType:    event.Action,
+		ActionDetails: event.Details,
+		Timestamp:     time.Now().UTC(),
+	}
+	if r := event.Request; r != nil {
+		entry.IPAddress = r.IP
+		entry.UserAgent = r.UserAgent
+		if len(entry.UserAgent) > auditMaxUserAgent {
+			entry.UserAgent = entry.UserAgent[:auditMaxUserAgent]
+		}
+		entry.RequestDetails = models.AuditDetails{"method": r.Method, "path": r.Path}
+		if r.ImpersonatorID != nil {
+			entry.RequestDetails["impersonator_id"] = *r.ImpersonatorID
+		}
+	}
+	return entry
+}
+
+// Run drains the queue, writing a batch whenever it is full or the flush interval elapses. Once
+// stop is closed it flushes everything queued so far and returns.
+func (s *auditService) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(s.flushInterval)
+	defer ticker.Stop()
+	batch := make([]models.AuditLog, 0, s.batchSize)
+
+	for {
+		select {
+		case <-stop:
+			s.flush(batch)
+			return
+		case entry := <-s.queue:
+			batch = append(batch, entry)
+			if len(batch) < s.batchSize {
+				continue
+			}
+		case <-ticker.C:
+			if len(batch) == 0 {
+				continue
+			}
+		}
+
+		if err := s.auditRepo.CreateLogs(batch); err != nil {
+			log.Printf("Failed to write %d audit log records: %v", len(batch), err)
+		}
+		batch = make([]models.AuditLog, 0, s.batchSize)
+	}
+}
+
+// flush writes batch and everything still queued
+func (s *auditService) flush(batch []models.AuditLog) {
+	for {
+		select {
+		case entry := <-s.queue:
+			batch = append(batch, entry)
+			if len(batch) < s.batchSize {
+				continue
+			}
+		default:
+		}
+		if len(batch) == 0 {
+			return
+		}
+		if err := s.auditRepo.CreateLogs(batch); err != nil {
+			log.Printf("Failed to write %d audit log records: %v", len(batch), err)
+		}
+		batch = make([]models.AuditLog, 0, s.batchSize)
+	}
+}
+
+// filter checks the caller may read the requested log: their own actions, or a team's actions
+// when their role there allows viewing the audit log
+func (s *auditService) filter(userID uint, query AuditQuery) (repository.AuditFilter, error) {
+	filter := repository.AuditFilter{
+		TeamID:  query.TeamID,
+		Actions: query.Actions,
+		Since:   query.Since,
+		Until:   query.Until,
+	}
+	if query.TeamID != nil {
+		if _, err := authorize(s.teamRepo, *query.TeamID, userID, policy.ViewAuditLog); err != nil {
+			return filter, err
+		}
+		return filter, nil
+	}
+	filter.UserID = &userID
+	return filter, nil
+}
+
+// List returns audit records the user may see, newest first
+func (s *auditService) List(userID uint, query AuditQuery, limit, offset int) ([]models.AuditLog, error) {
+	filter, err := s.filter(userID, query)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	logs, err := s.auditRepo.ListLogs(filter, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit log: %w", err)
+	}
+	return logs, nil
+}
+
+var auditCSVHeader = []string{"timestamp", "action_type", "user_id", "team_id", "ip_address", "user_agent", "action_details"}
+
+// Export writes every audit record the user may see to w as json or csv and returns how many
+// were written
+func (s *auditService) Export(userID uint, query AuditQuery, format string, w io.Writer) (int, error) {
+	format = strings.ToLower(strings.TrimSpace(format))
+	if format != AuditFormatJSON && format != AuditFormatCSV {
+		return 0, ErrUnsupportedAuditFormat
+	}
+	filter, err := s.filter(userID, query)
+	if err != nil {
+		return 0, err
+	}
+
+	var write func(entry models.AuditLog) error
+	var finish func() error
+	switch format {
+	case AuditFormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(auditCSVHeader); err != nil {
+			return 0, err
+		}
+		write = func(entry models.AuditLog) error {
+			details, _ := json.Marshal(entry.ActionDetails)
+			return cw.Write([]string{
+				entry.Timestamp.Format(time.RFC3339),
+				entry.ActionType,
+				optionalID(entry.UserID),
+				optionalID(entry.TeamID),
+				entry.IPAddress,
+				entry.UserAgent,
+				string(details),
+			})
+		}
+		finish = func() error {
+			cw.Flush()
+			return cw.Error()
+		}
+	default:
+		if _, err := io.WriteString(w, "["); err != nil {
+			return 0, err
+		}
+		first := true
+		write = func(entry models.AuditLog) error {
+			b, err := json.Marshal(entry)
+			if err != nil {
+				return err
+			}
+			if !first {
+				if _, err := io.WriteString(w, ","); err != nil {
+					return err
+				}
+			}
+			first = false
+			_, err = w.Write(b)
+			return err
+		}
+		finish = func() error {
+			_, err := io.WriteString(w, "]\n")
+			return err
+		}
+	}
+
+	count := 0
+	for offset := 0; offset < auditExportMaxRows; offset += auditExportPage {
+		logs, err := s.auditRepo.ListLogs(filter, auditExportPage, offset)
+		if err != nil {
+			return count, fmt.Errorf("failed to export audit log: %w", err)
+		}
+		for _, entry := range logs {
+			if err := write(entry); err != nil {
+				return count, err
+			}
+			count++
+		}
+		if len(logs) < auditExportPage {
+			break
+		}
+	}
+	return count, finish()
+}
+
+func optionalID(id *uint) string {
+	if id == nil {
+		return ""
+	}
+	return strconv.FormatUint(uint64(*id), 10)
+}
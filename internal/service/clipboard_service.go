@@ -1,47 +1,113 @@
 package service
 
 import (
-	_ "errors"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"log"
+	"strings"
 
+	"clipboard-sync-backend/internal/clipformat"
 	"clipboard-sync-backend/internal/models"
+	"clipboard-sync-backend/internal/policy"
 	"clipboard-sync-backend/internal/repository"
+
+	"gorm.io/gorm"
 )
 
 // ClipboardService defines the interface for clipboard-related business logic
 type ClipboardService interface {
 	CreateClipboardEntry(userID uint, contentType, content, sourceDevice string) (*models.ClipboardEntry, error)
+	CreateClipboardEntryWithFormats(userID uint, formats []clipformat.Representation, sourceDevice string) (*models.ClipboardEntry, error)
 	GetUserClipboardHistory(userID uint, limit, offset int) ([]models.ClipboardEntry, error)
+	DeleteClipboardEntry(userID, entryID uint) error
+	CreateTeamClipboardEntry(userID, teamID uint, formats []clipformat.Representation, sourceDevice string) (*models.ClipboardEntry, error)
+	GetTeamClipboardHistory(userID, teamID uint, limit, offset int) ([]models.ClipboardEntry, error)
+	SearchTeamClipboard(userID, teamID uint, query string, limit, offset int) ([]models.ClipboardEntry, error)
 	// Add more clipboard-related service methods as needed
 }
 
+var ErrEntryNotFound = errors.New("clipboard entry not found")
+
 type clipboardService struct {
-	clipboardRepo repository.ClipboardRepository
+	clipboardRepo      repository.ClipboardRepository
+	teamRepo           repository.TeamRepository
+	linkPreviewService LinkPreviewService
+	webhookService     WebhookService
+	teamNotifier       TeamNotifier
+	auditService       AuditService
 }
 
 // NewClipboardService creates a new ClipboardService
-func NewClipboardService(clipboardRepo repository.ClipboardRepository) ClipboardService {
-	return &clipboardService{clipboardRepo: clipboardRepo}
+func NewClipboardService(clipboardRepo repository.ClipboardRepository, teamRepo repository.TeamRepository, linkPreviewService LinkPreviewService, webhookService WebhookService, teamNotifier TeamNotifier, auditService AuditService) ClipboardService {
+	return &clipboardService{
+		clipboardRepo:      clipboardRepo,
+		teamRepo:           teamRepo,
+		linkPreviewService: linkPreviewService,
+		webhookService:     webhookService,
+		teamNotifier:       teamNotifier,
+		auditService:       auditService,
+	}
 }
 
-// CreateClipboardEntry handles the creation of a new clipboard entry
+// CreateClipboardEntry handles the creation of a new single-format clipboard entry
 func (s *clipboardService) CreateClipboardEntry(userID uint, contentType, content, sourceDevice string) (*models.ClipboardEntry, error) {
+	return s.CreateClipboardEntryWithFormats(userID, []clipformat.Representation{{MIMEType: contentType, Content: content}}, sourceDevice)
+}
+
+// CreateClipboardEntryWithFormats handles the creation of a clipboard entry carrying one or more
+// typed representations. MIME types are checked against the allowlist, HTML is sanitized and a
+// plain-text fallback is derived when only rich formats are supplied.
+func (s *clipboardService) CreateClipboardEntryWithFormats(userID uint, formats []clipformat.Representation, sourceDevice string) (*models.ClipboardEntry, error) {
+	return s.createEntry(userID, nil, formats, sourceDevice)
+}
+
+// CreateTeamClipboardEntry posts an entry to a team's shared clipboard and delivers it live to
+// every online member
+func (s *clipboardService) CreateTeamClipboardEntry(userID, teamID uint, formats []clipformat.Representation, sourceDevice string) (*models.ClipboardEntry, error) {
+	if _, err := authorize(s.teamRepo, teamID, userID, policy.PostEntry); err != nil {
+		return nil, err
+	}
+	entry, err := s.createEntry(userID, &teamID, formats, sourceDevice)
+	if err != nil {
+		return nil, err
+	}
+	if s.teamNotifier != nil {
+		s.teamNotifier.SendEntryToTeam(teamID, entry)
+	}
+	return entry, nil
+}
+
+// createEntry validates formats and stores a personal entry, or a shared one when teamID is set
+func (s *clipboardService) createEntry(userID uint, teamID *uint, formats []clipformat.Representation, sourceDevice string) (*models.ClipboardEntry, error) {
+	prepared, err := clipformat.Prepare(formats)
+	if err != nil {
+		return nil, err
+	}
+
 	// TODO: Implement content encryption before saving
 
-	entry := &models.ClipboardEntry{
-		UserID:       userID,
-		ContentType:  contentType,
-		Content:      content, // This should be encrypted content
-		SourceDevice: sourceDevice,
-		IsShared:     false, // Default to personal entry
+	entry := buildEntry(userID, prepared, sourceDevice)
+	if teamID != nil {
+		entry.IsShared = true
+		entry.TeamID = teamID
 	}
 
 	if err := s.clipboardRepo.CreateEntry(entry); err != nil {
 		return nil, fmt.Errorf("failed to create clipboard entry: %w", err)
 	}
 
-	log.Printf("Clipboard entry created for user %d, type: %s", userID, contentType)
+	log.Printf("Clipboard entry created for user %d, type: %s, formats: %d", userID, entry.ContentType, len(entry.Representations))
+
+	// Copied links are enriched in the background and pushed as an entry_updated event
+	if s.linkPreviewService != nil && entry.ContentType == clipformat.MIMEPlainText {
+		s.linkPreviewService.EnrichEntry(entry)
+	}
+	// Webhook subscriptions are looked up off the request path
+	if s.webhookService != nil {
+		go s.webhookService.Publish(models.WebhookEventEntryCreated, entry.UserID, entry.TeamID, entryEventData(entry))
+	}
 	return entry, nil
 }
 
@@ -63,3 +129,169 @@ func (s *clipboardService) GetUserClipboardHistory(userID uint, limit, offset in
 
 	return entries, nil
 }
+
+// GetTeamClipboardHistory retrieves a team's shared clipboard history
+func (s *clipboardService) GetTeamClipboardHistory(userID, teamID uint, limit, offset int) ([]models.ClipboardEntry, error) {
+	if _, err := authorize(s.teamRepo, teamID, userID, policy.ReadHistory); err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	entries, err := s.clipboardRepo.GetEntriesByTeamID(teamID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get team clipboard history: %w", err)
+	}
+	return entries, nil
+}
+
+// SearchTeamClipboard finds team entries whose text contains query
+func (s *clipboardService) SearchTeamClipboard(userID, teamID uint, query string, limit, offset int) ([]models.ClipboardEntry, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return s.GetTeamClipboardHistory(userID, teamID, limit, offset)
+	}
+	if _, err := authorize(s.teamRepo, teamID, userID, policy.ReadHistory); err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	entries, err := s.clipboardRepo.SearchTeamEntries(teamID, query, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search team clipboard: %w", err)
+	}
+	return entries, nil
+}
+
+// DeleteClipboardEntry deletes one of the user's entries. Team entries posted by someone else
+// can be deleted by members whose role allows deleting others' entries.
+func (s *clipboardService) DeleteClipboardEntry(userID, entryID uint) error {
+	entry, err := s.clipboardRepo.GetEntryByID(entryID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrEntryNotFound
+		}
+		return fmt.Errorf("failed to get clipboard entry: %w", err)
+	}
+	if entry.UserID != userID {
+		if entry.TeamID == nil {
+			return ErrEntryNotFound
+		}
+		if _, err := authorize(s.teamRepo, *entry.TeamID, userID, policy.DeleteOthersEntries); err != nil {
+			if errors.Is(err, ErrNotTeamMember) {
+				return ErrEntryNotFound
+			}
+			return err
+		}
+	}
+
+	if err := s.clipboardRepo.DeleteEntry(entryID); err != nil {
+		return fmt.Errorf("failed to delete clipboard entry: %w", err)
+	}
+
+	log.Printf("Clipboard entry %d deleted by user %d", entryID, userID)
+	recordAudit(s.auditService, AuditEvent{
+		UserID: &userID,
+		TeamID: entry.TeamID,
+		Action: models.AuditEntryDeleted,
+		Details: map[string]interface{}{
+			"entry_id":     entry.ID,
+			"owner_id":     entry.UserID,
+			"content_type": entry.ContentType,
+		},
+	})
+	if s.webhookService != nil {
+		go s.webhookService.Publish(models.WebhookEventEntryDeleted, entry.UserID, entry.TeamID, map[string]interface{}{
+			"entry_id": entry.ID,
+			"team_id":  entry.TeamID,
+		})
+	}
+	return nil
+}
+
+// entryEventData is the webhook payload describing an entry. The content itself only goes to
+// webhooks that opted into it.
+func entryEventData(entry *models.ClipboardEntry) WebhookEntryData {
+	return WebhookEntryData{
+		Metadata: map[string]interface{}{
+			"entry_id":      entry.ID,
+			"user_id":       entry.UserID,
+			"team_id":       entry.TeamID,
+			"content_type":  entry.ContentType,
+			"size":          len(entry.Content),
+			"source_device": entry.SourceDevice,
+			"created_at":    entry.CreatedAt,
+		},
+		Content: entry.Content,
+	}
+}
+
+// buildEntry assembles an entry from already prepared representations
+func buildEntry(userID uint, prepared []clipformat.Representation, sourceDevice string) *models.ClipboardEntry {
+	primary := clipformat.Primary(prepared)
+	entry := &models.ClipboardEntry{
+		UserID:       userID,
+		ContentType:  primary.MIMEType,
+		Content:      primary.Content, // This should be encrypted content
+		ContentHash:  contentHash(primary.MIMEType, primary.Content),
+		SourceDevice: sourceDevice,
+		IsShared:     false, // Default to personal entry
+	}
+	for _, rep := range prepared {
+		entry.Representations = append(entry.Representations, models.ClipboardRepresentation{
+			MIMEType: rep.MIMEType,
+			Content:  rep.Content,
+			Size:     len(rep.Content),
+		})
+	}
+	return entry
+}
+
+// contentHash fingerprints an entry's primary content for deduplication
+func contentHash(contentType, content string) string {
+	sum := sha256.Sum256([]byte(contentType + "\x00" + content))
+	return hex.EncodeToString(sum[:])
+}
+
+// SelectRepresentations returns a copy of the entry carrying only the representations the client accepts
+func SelectRepresentations(entry models.ClipboardEntry, accept []string) models.ClipboardEntry {
+	if len(accept) == 0 || len(entry.Representations) == 0 {
+		return entry
+	}
+
+	reps := make([]clipformat.Representation, len(entry.Representations))
+	for i, rep := range entry.Representations {
+		reps[i] = clipformat.Representation{MIMEType: rep.MIMEType, Content: rep.Content}
+	}
+	selected := clipformat.Select(reps, accept)
+
+	filtered := make([]models.ClipboardRepresentation, 0, len(selected))
+	for _, sel := range selected {
+		for _, rep := range entry.Representations {
+			if rep.MIMEType == sel.MIMEType {
+				filtered = append(filtered, rep)
+				break
+			}
+		}
+	}
+	entry.Representations = filtered
+	return entry
+}
+
+// IsClipboardValidationError reports whether err was caused by invalid client-supplied formats
+func IsClipboardValidationError(err error) bool {
+	return errors.Is(err, clipformat.ErrUnsupportedMIMEType) ||
+		errors.Is(err, clipformat.ErrEmptyRepresentation) ||
+		errors.Is(err, clipformat.ErrRepresentationTooLarge) ||
+		errors.Is(err, clipformat.ErrNoRepresentations) ||
+		errors.Is(err, clipformat.ErrDuplicateRepresentation)
+}
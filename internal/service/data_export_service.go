@@ -0,0 +1,631 @@
+package service
+
+import (
+	"archive/zip"
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"clipboard-sync-backend/internal/auth"
+	"clipboard-sync-backend/internal/mail"
+	"clipboard-sync-backend/internal/models"
+	"clipboard-sync-backend/internal/repository"
+	"clipboard-sync-backend/internal/settings"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// DataExportDocumentKind is the kind data export manifests are signed as
+	DataExportDocumentKind = "data_export"
+
+	dataExportFormatVersion = 1
+	defaultDataExportTTL    = 7 * 24 * time.Hour
+	dataExportSignatureTTL  = 365 * 24 * time.Hour // Archives stay verifiable long after the download expires
+	dataExportPageSize      = 500
+	dataExportSweepInterval = 10 * time.Minute
+	dataExportSweepBatch    = 50
+)
+
+var (
+	ErrDataExportNotFound         = errors.New("data export not found")
+	ErrDataExportInProgress       = errors.New("a data export is already being prepared")
+	ErrDataExportNotReady         = errors.New("data export is not ready yet")
+	ErrDataExportExpired          = errors.New("data export has expired")
+	ErrInvalidDataExportSignature = errors.New("signature is invalid or was not issued for a data export")
+)
+
+// DataExportSources are the repositories a personal data export reads from
+type DataExportSources struct {
+	Users        repository.UserRepository
+	Sessions     repository.SessionRepository
+	Identities   repository.OAuthRepository
+	TwoFactor    repository.TwoFactorRepository
+	AccessTokens repository.AccessTokenRepository
+	Teams        repository.TeamRepository
+	Invitations  repository.InvitationRepository
+	Webhooks     repository.WebhookRepository
+	Snippets     repository.SnippetRepository
+	Clipboard    repository.ClipboardRepository
+	Audit        repository.AuditRepository
+	Settings     repository.SettingsRepository
+}
+
+// DataExportSignature is what a valid manifest signature vouches for
+type DataExportSignature struct {
+	ExportID       uint      `json:"export_id"`
+	UserID         uint      `json:"user_id"`
+	ManifestSHA256 string    `json:"manifest_sha256"`
+	SignedAt       time.Time `json:"signed_at"`
+}
+
+// DataExportService defines the interface for exports of everything we hold about a user
+type DataExportService interface {
+	RequestExport(userID uint, req *RequestInfo) (*models.DataExport, error)
+	ListExports(userID uint) ([]models.DataExport, error)
+	GetExport(userID, exportID uint) (*models.DataExport, error)
+	OpenExport(userID, exportID uint, req *RequestInfo) (*models.DataExport, *os.File, error)
+	VerifySignature(signature string) (*DataExportSignature, error)
+	Run(stop <-chan struct{})
+}
+
+type dataExportService struct {
+	exportRepo   repository.DataExportRepository
+	sources      DataExportSources
+	tokens       *auth.TokenManager
+	mailer       mail.Sender
+	auditService AuditService
+	dir          string
+	ttl          time.Duration
+	running      sync.WaitGroup // Archives being written
+}
+
+// NewDataExportService creates a new DataExportService that keeps archives under dir for ttl
+func NewDataExportService(exportRepo repository.DataExportRepository, sources DataExportSources, tokens *auth.TokenManager, mailer mail.Sender, auditService AuditService, dir string, ttl time.Duration) DataExportService {
+	if ttl <= 0 {
+		ttl = defaultDataExportTTL
+	}
+	return &dataExportService{
+		exportRepo:   exportRepo,
+		sources:      sources,
+		tokens:       tokens,
+		mailer:       mailer,
+		auditService: auditService,
+		dir:          dir,
+		ttl:          ttl,
+	}
+}
+
+// dataExportManifest is manifest.json; its SHA-256 is what manifest.sig signs
+type dataExportManifest struct {
+	Version     int              `json:"version"`
+	ExportID    uint             `json:"export_id"`
+	UserID      uint             `json:"user_id"`
+	GeneratedAt time.Time        `json:"generated_at"`
+	Files       []dataExportFile `json:"files"`
+}
+
+type dataExportFile struct {
+	Name    string `json:"name"`
+	SHA256  string `json:"sha256"`
+	Size    int64  `json:"size"`
+	Records int    `json:"records"`
+}
+
+const dataExportReadme = `This archive holds the personal data Clipboard Sync keeps about your account.
+
+manifest.json lists every other file with its size and SHA-256. manifest.sig is a
+JWT signed by the server whose "sha256" claim is the SHA-256 of manifest.json. Check
+it against the keys published at /.well-known/jwks.json, or post it to
+/api/v1/data-exports/verify, then compare the hashes of the files with the manifest.
+
+clipboard.json uses the clipboard export format and can be imported back.
+`
+
+// RequestExport queues an export of the user's data; only one can be in progress at a time
+func (s *dataExportService) RequestExport(userID uint, req *RequestInfo) (*models.DataExport, error) {
+	busy, err := s.exportRepo.HasUnfinishedDataExport(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check data exports: %w", err)
+	}
+	if busy {
+		return nil, ErrDataExportInProgress
+	}
+
+	export := &models.DataExport{UserID: userID, Status: models.JobPending}
+	if err := s.exportRepo.CreateDataExport(export); err != nil {
+		return nil, fmt.Errorf("failed to create data export: %w", err)
+	}
+
+	recordAudit(s.auditService, AuditEvent{
+		UserID:  &userID,
+		Action:  models.AuditDataExportRequested,
+		Details: map[string]interface{}{"export_id": export.ID},
+		Request: req,
+	})
+	s.running.Add(1)
+	go func(export models.DataExport) {
+		defer s.running.Done()
+		s.runExport(export)
+	}(*export)
+	return export, nil
+}
+
+// ListExports lists the user's data exports, newest first
+func (s *dataExportService) ListExports(userID uint) ([]models.DataExport, error) {
+	exports, err := s.exportRepo.GetDataExportsByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list data exports: %w", err)
+	}
+	return exports, nil
+}
+
+// GetExport returns a data export owned by the user
+func (s *dataExportService) GetExport(userID, exportID uint) (*models.DataExport, error) {
+	export, err := s.exportRepo.GetDataExportByID(exportID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDataExportNotFound
+		}
+		return nil, fmt.Errorf("failed to get data export: %w", err)
+	}
+	if export.UserID != userID {
+		return nil, ErrDataExportNotFound
+	}
+	return export, nil
+}
+
+// OpenExport opens a completed, unexpired archive for download. Every download is counted and
+// audited with the archive's SHA-256, so it can later be matched against a copy of the file.
+func (s *dataExportService) OpenExport(userID, exportID uint, req *RequestInfo) (*models.DataExport, *os.File, error) {
+	export, err := s.GetExport(userID, exportID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if export.Status != models.JobCompleted {
+		return nil, nil, ErrDataExportNotReady
+	}
+	now := time.Now()
+	if export.FilePath == "" || (export.ExpiresAt != nil && now.After(*export.ExpiresAt)) {
+		return nil, nil, ErrDataExportExpired
+	}
+	f, err := os.Open(export.FilePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil, ErrDataExportExpired
+		}
+		return nil, nil, fmt.Errorf("failed to open data export: %w", err)
+	}
+
+	if err := s.exportRepo.RecordDataExportDownload(export.ID, now); err != nil {
+		log.Printf("Failed to count download of data export %d: %v", export.ID, err)
+	}
+	export.DownloadCount++
+	export.LastDownloadedAt = &now
+
+	log.Printf("User %d downloaded data export %d (sha256 %s)", userID, export.ID, export.ArchiveSHA256)
+	recordAudit(s.auditService, AuditEvent{
+		UserID: &userID,
+		Action: models.AuditDataExportDownloaded,
+		Details: map[string]interface{}{
+			"export_id":       export.ID,
+			"archive_sha256":  export.ArchiveSHA256,
+			"manifest_sha256": export.ManifestSHA256,
+			"file_size":       export.FileSize,
+			"download":        export.DownloadCount,
+		},
+		Request: req,
+	})
+	return export, f, nil
+}
+
+// VerifySignature checks a manifest.sig from one of our archives
+func (s *dataExportService) VerifySignature(signature string) (*DataExportSignature, error) {
+	claims, err := s.tokens.VerifyDocument(signature, DataExportDocumentKind)
+	if err != nil {
+		return nil, ErrInvalidDataExportSignature
+	}
+	exportID, err := strconv.ParseUint(claims.ID, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidDataExportSignature
+	}
+	userID, err := strconv.ParseUint(claims.Subject, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidDataExportSignature
+	}
+	result := &DataExportSignature{
+		ExportID:       uint(exportID),
+		UserID:         uint(userID),
+		ManifestSHA256: claims.Digest,
+	}
+	if claims.IssuedAt != nil {
+		result.SignedAt = claims.IssuedAt.Time
+	}
+	return result, nil
+}
+
+// Run removes archives once their download period is over. Once stop is closed it waits for
+// archives being written and returns.
+func (s *dataExportService) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(dataExportSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			s.running.Wait()
+			return
+		case <-ticker.C:
+		}
+
+		exports, err := s.exportRepo.GetExpiredDataExports(time.Now(), dataExportSweepBatch)
+		if err != nil {
+			log.Printf("Failed to find expired data exports: %v", err)
+			continue
+		}
+		for _, export := range exports {
+			if err := os.Remove(export.FilePath); err != nil && !os.IsNotExist(err) {
+				log.Printf("Failed to remove data export %d: %v", export.ID, err)
+				continue
+			}
+			if err := s.exportRepo.ClearDataExportFile(export.ID); err != nil {
+				log.Printf("Failed to clear data export %d: %v", export.ID, err)
+			}
+		}
+	}
+}
+
+func (s *dataExportService) runExport(export models.DataExport) {
+	export.Status = models.JobRunning
+	if err := s.exportRepo.UpdateDataExport(&export); err != nil {
+		log.Printf("Failed to mark data export %d as running: %v", export.ID, err)
+	}
+
+	user, err := s.sources.Users.GetUserByID(export.UserID)
+	var path string
+	if err == nil {
+		path, err = s.writeArchive(&export, user)
+	} else {
+		err = fmt.Errorf("failed to load user: %w", err)
+	}
+	now := time.Now()
+	export.CompletedAt = &now
+	if err != nil {
+		log.Printf("Data export %d failed: %v", export.ID, err)
+		export.Status = models.JobFailed
+		export.Error = err.Error()
+		if path != "" {
+			os.Remove(path)
+		}
+		if saveErr := s.exportRepo.UpdateDataExport(&export); saveErr != nil {
+			log.Printf("Failed to save data export %d: %v", export.ID, saveErr)
+		}
+		return
+	}
+
+	expires := now.Add(s.ttl)
+	export.Status = models.JobCompleted
+	export.FilePath = path
+	export.ExpiresAt = &expires
+	if err := s.exportRepo.UpdateDataExport(&export); err != nil {
+		log.Printf("Failed to save data export %d: %v", export.ID, err)
+		return
+	}
+	log.Printf("Data export %d completed for user %d: %d bytes", export.ID, export.UserID, export.FileSize)
+
+	body := fmt.Sprintf("The copy of your Clipboard Sync data you asked for is ready. You can download it from your account settings until %s.\n\nIf you did not ask for it, change your password and sign out your other devices.\n",
+		expires.UTC().Format(time.RFC1123))
+	if err := s.mailer.Send(mail.Message{To: user.Email, Subject: "Your data export is ready", Body: body}); err != nil {
+		log.Printf("Failed to send data export notice to %s: %v", user.Email, err)
+	}
+}
+
+// writeArchive gathers the user's data into a ZIP, recording the hashes of the archive and its
+// manifest on export
+func (s *dataExportService) writeArchive(export *models.DataExport, user *models.User) (string, error) {
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return "", fmt.Errorf("failed to create export directory: %w", err)
+	}
+	path := filepath.Join(s.dir, fmt.Sprintf("data-export-%d-%d.zip", export.UserID, export.ID))
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return "", fmt.Errorf("failed to create export file: %w", err)
+	}
+	defer f.Close()
+
+	archiveHash := sha256.New()
+	counter := &countingWriter{w: io.MultiWriter(f, archiveHash)}
+	bw := bufio.NewWriter(counter)
+	a := &dataArchive{zw: zip.NewWriter(bw)}
+
+	if err := a.addText("README.txt", dataExportReadme); err != nil {
+		return path, err
+	}
+	if err := s.writeSections(a, user); err != nil {
+		return path, err
+	}
+
+	manifest, err := json.MarshalIndent(dataExportManifest{
+		Version:     dataExportFormatVersion,
+		ExportID:    export.ID,
+		UserID:      export.UserID,
+		GeneratedAt: time.Now().UTC(),
+		Files:       a.files,
+	}, "", "  ")
+	if err != nil {
+		return path, err
+	}
+	manifestSum := sha256.Sum256(manifest)
+	manifestSHA256 := hex.EncodeToString(manifestSum[:])
+	signature, err := s.tokens.SignDocument(DataExportDocumentKind, export.UserID, strconv.FormatUint(uint64(export.ID), 10), manifestSHA256, dataExportSignatureTTL)
+	if err != nil {
+		return path, err
+	}
+	if err := a.addRaw("manifest.json", manifest); err != nil {
+		return path, err
+	}
+	if err := a.addRaw("manifest.sig", []byte(signature+"\n")); err != nil {
+		return path, err
+	}
+
+	if err := a.zw.Close(); err != nil {
+		return path, err
+	}
+	if err := bw.Flush(); err != nil {
+		return path, err
+	}
+	if err := f.Sync(); err != nil {
+		return path, err
+	}
+	export.FileSize = counter.n
+	export.ArchiveSHA256 = hex.EncodeToString(archiveHash.Sum(nil))
+	export.ManifestSHA256 = manifestSHA256
+	return path, nil
+}
+
+// writeSections writes one JSON file per kind of data we hold
+func (s *dataExportService) writeSections(a *dataArchive, user *models.User) error {
+	userID := user.ID
+	src := s.sources
+
+	if err := a.addJSON("profile.json", 1, user); err != nil {
+		return err
+	}
+	stored, err := src.Settings.GetSettingsByUserID(userID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("failed to load settings: %w", err)
+	}
+	var changed models.SettingsDocument
+	if stored != nil {
+		changed = stored.SettingsData
+	}
+	if err := a.addJSON("settings.json", 1, map[string]interface{}{
+		"preferred_language": user.PreferredLanguage,
+		"settings":           settings.Effective(changed),
+		"changed_settings":   changed,
+	}); err != nil {
+		return err
+	}
+
+	sessions, err := src.Sessions.GetSessionsByUserID(userID)
+	if err != nil {
+		return fmt.Errorf("failed to load sessions: %w", err)
+	}
+	if err := a.addJSON("devices.json", len(sessions), sessions); err != nil {
+		return err
+	}
+
+	identities, err := src.Identities.GetIdentitiesByUserID(userID)
+	if err != nil {
+		return fmt.Errorf("failed to load linked accounts: %w", err)
+	}
+	if err := a.addJSON("linked_accounts.json", len(identities), identities); err != nil {
+		return err
+	}
+
+	twoFactor := map[string]interface{}{"enabled": user.TwoFactorEnabled}
+	secret, err := src.TwoFactor.GetSecret(userID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("failed to load two-factor settings: %w", err)
+	}
+	if secret != nil && secret.ConfirmedAt != nil {
+		remaining, err := src.TwoFactor.CountUnusedRecoveryCodes(userID)
+		if err != nil {
+			return fmt.Errorf("failed to count recovery codes: %w", err)
+		}
+		twoFactor["enabled_at"] = secret.ConfirmedAt
+		twoFactor["recovery_codes_remaining"] = remaining
+	}
+	if err := a.addJSON("two_factor.json", 1, twoFactor); err != nil {
+		return err
+	}
+
+	tokens, err := src.AccessTokens.GetTokensByUserID(userID)
+	if err != nil {
+		return fmt.Errorf("failed to load access tokens: %w", err)
+	}
+	if err := a.addJSON("access_tokens.json", len(tokens), tokens); err != nil {
+		return err
+	}
+
+	memberships, err := src.Teams.GetMembershipsByUserID(userID)
+	if err != nil {
+		return fmt.Errorf("failed to load team memberships: %w", err)
+	}
+	teams := make([]map[string]interface{}, 0, len(memberships))
+	for _, m := range memberships {
+		teams = append(teams, map[string]interface{}{
+			"team_id":   m.TeamID,
+			"team_name": m.Team.Name,
+			"role":      m.Role,
+			"joined_at": m.JoinedAt,
+		})
+	}
+	if err := a.addJSON("teams.json", len(teams), teams); err != nil {
+		return err
+	}
+
+	invitations, err := src.Invitations.GetInvitationsByInviterID(userID)
+	if err != nil {
+		return fmt.Errorf("failed to load invitations: %w", err)
+	}
+	for i := range invitations {
+		invitations[i].TeamName = invitations[i].Team.Name
+	}
+	if err := a.addJSON("share_links.json", len(invitations), invitations); err != nil {
+		return err
+	}
+
+	webhooks, err := src.Webhooks.GetSubscriptionsByUserID(userID)
+	if err != nil {
+		return fmt.Errorf("failed to load webhooks: %w", err)
+	}
+	if err := a.addJSON("webhooks.json", len(webhooks), webhooks); err != nil {
+		return err
+	}
+
+	folders, err := src.Snippets.GetFoldersByUserID(userID)
+	if err != nil {
+		return fmt.Errorf("failed to load snippet folders: %w", err)
+	}
+	var snippets []models.Snippet
+	for offset := 0; ; offset += dataExportPageSize {
+		page, err := src.Snippets.ListSnippets(userID, repository.SnippetFilter{}, dataExportPageSize, offset)
+		if err != nil {
+			return fmt.Errorf("failed to load snippets: %w", err)
+		}
+		snippets = append(snippets, page...)
+		if len(page) < dataExportPageSize {
+			break
+		}
+	}
+	if err := a.addJSON("snippets.json", len(folders)+len(snippets), map[string]interface{}{
+		"folders":  folders,
+		"snippets": snippets,
+	}); err != nil {
+		return err
+	}
+
+	// Clipboard history uses the clipboard export format so it can be imported again
+	history := &transferService{clipboardRepo: src.Clipboard}
+	if err := a.addStream("clipboard.json", func(w io.Writer) (int, error) {
+		return history.writeJSON(w, userID, nil)
+	}); err != nil {
+		return err
+	}
+
+	return a.addStream("audit_events.json", func(w io.Writer) (int, error) {
+		return writeAuditEvents(w, src.Audit, userID)
+	})
+}
+
+// writeAuditEvents streams every audit event about the user as a JSON array, newest first
+func writeAuditEvents(w io.Writer, auditRepo repository.AuditRepository, userID uint) (int, error) {
+	if _, err := io.WriteString(w, "["); err != nil {
+		return 0, err
+	}
+	count := 0
+	filter := repository.AuditFilter{UserID: &userID}
+	for offset := 0; ; offset += dataExportPageSize {
+		logs, err := auditRepo.ListLogs(filter, dataExportPageSize, offset)
+		if err != nil {
+			return count, fmt.Errorf("failed to load audit events: %w", err)
+		}
+		for _, entry := range logs {
+			b, err := json.Marshal(entry)
+			if err != nil {
+				return count, err
+			}
+			if count > 0 {
+				if _, err := io.WriteString(w, ","); err != nil {
+					return count, err
+				}
+			}
+			if _, err := w.Write(b); err != nil {
+				return count, err
+			}
+			count++
+		}
+		if len(logs) < dataExportPageSize {
+			break
+		}
+	}
+	_, err := io.WriteString(w, "]")
+	return count, err
+}
+
+// dataArchive writes files into a ZIP and remembers their hashes for the manifest
+type dataArchive struct {
+	zw    *zip.Writer
+	files []dataExportFile
+}
+
+func (a *dataArchive) addStream(name string, write func(w io.Writer) (int, error)) error {
+	fw, err := a.zw.Create(name)
+	if err != nil {
+		return err
+	}
+	h := sha256.New()
+	counter := &countingWriter{w: io.MultiWriter(fw, h)}
+	records, err := write(counter)
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	a.files = append(a.files, dataExportFile{
+		Name:    name,
+		SHA256:  hex.EncodeToString(h.Sum(nil)),
+		Size:    counter.n,
+		Records: records,
+	})
+	return nil
+}
+
+func (a *dataArchive) addJSON(name string, records int, v interface{}) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", name, err)
+	}
+	return a.addStream(name, func(w io.Writer) (int, error) {
+		_, err := w.Write(b)
+		return records, err
+	})
+}
+
+func (a *dataArchive) addText(name, text string) error {
+	return a.addStream(name, func(w io.Writer) (int, error) {
+		_, err := io.WriteString(w, text)
+		return 0, err
+	})
+}
+
+// addRaw writes a file that is not listed in the manifest
+func (a *dataArchive) addRaw(name string, data []byte) error {
+	fw, err := a.zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = fw.Write(data)
+	return err
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"clipboard-sync-backend/internal/mail"
+	"clipboard-sync-backend/internal/models"
+	"clipboard-sync-backend/internal/repository"
+)
+
+const (
+	emailPollInterval = 5 * time.Second
+	emailClaimBatch   = 20
+	emailClaimLease   = 2 * time.Minute // Longer than one SMTP transaction
+	emailBaseBackoff  = time.Minute
+	emailMaxBackoff   = 2 * time.Hour
+)
+
+// EmailOutbox queues mail in the email_notifications table and delivers it in the background.
+// It is a mail.Sender, so services send mail as before and only the outbox talks to the transport.
+type EmailOutbox interface {
+	mail.Sender
+	// Run delivers queued mail until stop is closed
+	Run(stop <-chan struct{})
+}
+
+type emailOutbox struct {
+	emailRepo   repository.EmailRepository
+	transport   mail.Sender
+	maxAttempts int
+	wake        chan struct{}
+}
+
+// NewEmailOutbox creates a new EmailOutbox delivering through transport. Messages are marked
+// failed after maxAttempts.
+func NewEmailOutbox(emailRepo repository.EmailRepository, transport mail.Sender, maxAttempts int) EmailOutbox {
+	if maxAttempts <= 0 {
+		maxAttempts = 6
+	}
+	return &emailOutbox{
+		emailRepo:   emailRepo,
+		transport:   transport,
+		maxAttempts: maxAttempts,
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+// Send queues the message; delivery happens in Run
+func (o *emailOutbox) Send(msg mail.Message) error {
+	email := &models.EmailNotification{
+		RecipientEmail: msg.To,
+		Subject:        msg.Subject,
+		Body:           msg.Body,
+		Status:         models.EmailPending,
+		NextAttemptAt:  time.Now(),
+	}
+	if err := o.emailRepo.EnqueueEmail(email); err != nil {
+		return fmt.Errorf("failed to queue email: %w", err)
+	}
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Run delivers due mail on every tick or wake-up. Once stop is closed it returns, after finishing
+// the batch in progress; mail still queued is sent by the next process.
+func (o *emailOutbox) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(emailPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		case <-o.wake:
+		}
+
+		emails, err := o.emailRepo.ClaimDueEmails(time.Now(), emailClaimLease, emailClaimBatch)
+		if err != nil {
+			log.Printf("Failed to claim queued email: %v", err)
+			continue
+		}
+		for i := range emails {
+			o.attempt(&emails[i])
+		}
+	}
+}
+
+// attempt hands one message to the transport and records the outcome, scheduling a retry or
+// marking it failed
+func (o *emailOutbox) attempt(email *models.EmailNotification) {
+	email.Attempts++
+	sendErr := o.transport.Send(mail.Message{To: email.RecipientEmail, Subject: email.Subject, Body: email.Body})
+	now := time.Now()
+
+	switch {
+	case sendErr == nil:
+		email.Status = models.EmailSent
+		email.SentAt = &now
+		email.LastError = ""
+	case email.Attempts >= o.maxAttempts:
+		email.Status = models.EmailFailed
+		email.LastError = truncateError(sendErr)
+		log.Printf("Email %d to %s failed after %d attempts: %v", email.ID, email.RecipientEmail, email.Attempts, sendErr)
+	default:
+		email.LastError = truncateError(sendErr)
+		email.NextAttemptAt = now.Add(retryBackoff(emailBaseBackoff, emailMaxBackoff, email.Attempts))
+	}
+
+	if err := o.emailRepo.UpdateEmail(email); err != nil {
+		log.Printf("Failed to update email %d: %v", email.ID, err)
+	}
+}
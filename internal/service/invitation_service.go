@@ -0,0 +1,375 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"clipboard-sync-backend/internal/mail"
+	"clipboard-sync-backend/internal/models"
+	"clipboard-sync-backend/internal/policy"
+	"clipboard-sync-backend/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvitationNotFound = errors.New("invitation not found")
+	ErrInvitationExpired  = errors.New("invitation has expired")
+	ErrInvitationUsed     = errors.New("invitation is no longer valid")
+	ErrAlreadyTeamMember  = errors.New("user is already a member of this team")
+	ErrInvalidMaxUses     = errors.New("max_uses cannot be negative")
+)
+
+// InvitationService defines the interface for team invitation business logic
+type InvitationService interface {
+	InviteByEmail(userID, teamID uint, email, role string) (*models.TeamInvitation, error)
+	CreateJoinLink(userID, teamID uint, role string, expiresIn time.Duration, maxUses int) (*models.TeamInvitation, string, error)
+	ListTeamInvitations(userID, teamID uint) ([]models.TeamInvitation, error)
+	RevokeInvitation(userID, teamID, invitationID uint) error
+	ListMyInvitations(userID uint) ([]models.TeamInvitation, error)
+	AcceptInvitation(userID, invitationID uint) (*models.Team, error)
+	DeclineInvitation(userID, invitationID uint) error
+	JoinWithToken(userID uint, token string) (*models.Team, error)
+	ClaimPendingInvitations(user *models.User)
+	JoinURL(token string) string
+}
+
+type invitationService struct {
+	invitationRepo repository.InvitationRepository
+	teamRepo       repository.TeamRepository
+	userRepo       repository.UserRepository
+	mailer         mail.Sender
+	notifier       TeamNotifier
+	auditService   AuditService
+	appURL         string
+	inviteTTL      time.Duration
+}
+
+// NewInvitationService creates a new InvitationService. appURL is the public base URL used in
+// invitation links and inviteTTL how long email invitations stay valid.
+func NewInvitationService(invitationRepo repository.InvitationRepository, teamRepo repository.TeamRepository, userRepo repository.UserRepository, mailer mail.Sender, notifier TeamNotifier, auditService AuditService, appURL string, inviteTTL time.Duration) InvitationService {
+	if inviteTTL <= 0 {
+		inviteTTL = 7 * 24 * time.Hour
+	}
+	return &invitationService{
+		invitationRepo: invitationRepo,
+		teamRepo:       teamRepo,
+		userRepo:       userRepo,
+		mailer:         mailer,
+		notifier:       notifier,
+		auditService:   auditService,
+		appURL:         strings.TrimRight(appURL, "/"),
+		inviteTTL:      inviteTTL,
+	}
+}
+
+func hashInvitationToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// JoinURL returns the link an invitee follows to accept with token
+func (s *invitationService) JoinURL(token string) string {
+	return s.appURL + "/join/" + token
+}
+
+// newInvitation builds a pending invitation with a fresh secret token
+func (s *invitationService) newInvitation(userID, teamID uint, kind, role string) (*models.TeamInvitation, string, error) {
+	if role == "" {
+		role = models.TeamRoleEditor
+	}
+	if !policy.ValidRole(role) {
+		return nil, "", ErrInvalidTeamRole
+	}
+	member, err := authorize(s.teamRepo, teamID, userID, policy.ManageMembers)
+	if err != nil {
+		return nil, "", err
+	}
+	if !policy.CanAssign(member.Role, role) {
+		return nil, "", ErrTeamPermission
+	}
+	token, err := randomHex(24)
+	if err != nil {
+		return nil, "", err
+	}
+	return &models.TeamInvitation{
+		TeamID:    teamID,
+		InviterID: userID,
+		Kind:      kind,
+		TokenHash: hashInvitationToken(token),
+		Role:      role,
+		Status:    models.InvitationPending,
+	}, token, nil
+}
+
+// InviteByEmail invites an email address into a team and mails it a join link.
+// Re-inviting the same address replaces its earlier pending invitation.
+func (s *invitationService) InviteByEmail(userID, teamID uint, email, role string) (*models.TeamInvitation, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	invitation, token, err := s.newInvitation(userID, teamID, models.InvitationKindEmail, role)
+	if err != nil {
+		return nil, err
+	}
+
+	if invitee, err := s.userRepo.GetUserByEmail(email); err == nil {
+		if _, err := s.teamRepo.GetMember(teamID, invitee.ID); err == nil {
+			return nil, ErrAlreadyTeamMember
+		}
+	}
+
+	pending, err := s.invitationRepo.GetPendingInvitationsByTeamID(teamID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load invitations: %w", err)
+	}
+	for i := range pending {
+		if pending[i].Kind == models.InvitationKindEmail && pending[i].Email == email {
+			pending[i].Status = models.InvitationRevoked
+			if err := s.invitationRepo.UpdateInvitation(&pending[i]); err != nil {
+				return nil, fmt.Errorf("failed to replace invitation: %w", err)
+			}
+		}
+	}
+
+	expiresAt := time.Now().Add(s.inviteTTL)
+	invitation.Email = email
+	invitation.MaxUses = 1
+	invitation.ExpiresAt = &expiresAt
+	if err := s.invitationRepo.CreateInvitation(invitation); err != nil {
+		return nil, fmt.Errorf("failed to create invitation: %w", err)
+	}
+
+	s.sendInvitationEmail(userID, invitation, token)
+	log.Printf("Team %d: user %d invited %s as %s", teamID, userID, email, invitation.Role)
+	s.audit(userID, teamID, models.AuditTeamInvitationSent, map[string]interface{}{
+		"invitation_id": invitation.ID,
+		"email":         email,
+		"role":          invitation.Role,
+	})
+	return invitation, nil
+}
+
+// sendInvitationEmail mails the join link. Failures are only logged: the invitee can still find
+// the invitation under their pending invitations once signed in.
+func (s *invitationService) sendInvitationEmail(inviterID uint, invitation *models.TeamInvitation, token string) {
+	if s.mailer == nil {
+		return
+	}
+	teamName := "a team"
+	if team, err := s.teamRepo.GetTeamByID(invitation.TeamID); err == nil {
+		teamName = fmt.Sprintf("the team %q", team.Name)
+	}
+	inviter := "Someone"
+	if user, err := s.userRepo.GetUserByID(inviterID); err == nil {
+		inviter = user.Email
+	}
+
+	body := fmt.Sprintf("%s invited you to join %s on Clipboard Sync as %s.\n\nAccept the invitation:\n%s\n\nThis invitation expires on %s. If you don't have an account yet, sign up with this email address and you will be added automatically.\n",
+		inviter, teamName, invitation.Role, s.JoinURL(token), invitation.ExpiresAt.UTC().Format("2 Jan 2006 15:04 MST"))
+	if err := s.mailer.Send(mail.Message{To: invitation.Email, Subject: "You've been invited to " + teamName, Body: body}); err != nil {
+		log.Printf("Failed to send invitation %d email: %v", invitation.ID, err)
+	}
+}
+
+// CreateJoinLink mints a shareable join link. expiresIn of zero means the link never
+// expires and maxUses of zero means unlimited uses. The token is only returned here.
+func (s *invitationService) CreateJoinLink(userID, teamID uint, role string, expiresIn time.Duration, maxUses int) (*models.TeamInvitation, string, error) {
+	if maxUses < 0 {
+		return nil, "", ErrInvalidMaxUses
+	}
+	invitation, token, err := s.newInvitation(userID, teamID, models.InvitationKindLink, role)
+	if err != nil {
+		return nil, "", err
+	}
+	invitation.MaxUses = maxUses
+	if expiresIn > 0 {
+		expiresAt := time.Now().Add(expiresIn)
+		invitation.ExpiresAt = &expiresAt
+	}
+	if err := s.invitationRepo.CreateInvitation(invitation); err != nil {
+		return nil, "", fmt.Errorf("failed to create join link: %w", err)
+	}
+
+	log.Printf("Team %d: user %d created join link %d", teamID, userID, invitation.ID)
+	s.audit(userID, teamID, models.AuditTeamJoinLinkCreated, map[string]interface{}{
+		"invitation_id": invitation.ID,
+		"role":          invitation.Role,
+		"max_uses":      invitation.MaxUses,
+		"expires_at":    invitation.ExpiresAt,
+	})
+	return invitation, token, nil
+}
+
+// ListTeamInvitations lists a team's pending invitations and join links
+func (s *invitationService) ListTeamInvitations(userID, teamID uint) ([]models.TeamInvitation, error) {
+	if _, err := authorize(s.teamRepo, teamID, userID, policy.ManageMembers); err != nil {
+		return nil, err
+	}
+	invitations, err := s.invitationRepo.GetPendingInvitationsByTeamID(teamID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list invitations: %w", err)
+	}
+	return invitations, nil
+}
+
+// RevokeInvitation withdraws a pending invitation or join link
+func (s *invitationService) RevokeInvitation(userID, teamID, invitationID uint) error {
+	if _, err := authorize(s.teamRepo, teamID, userID, policy.ManageMembers); err != nil {
+		return err
+	}
+	invitation, err := s.invitationRepo.GetInvitationByID(invitationID)
+	if err != nil || invitation.TeamID != teamID {
+		return ErrInvitationNotFound
+	}
+	if invitation.Status != models.InvitationPending {
+		return ErrInvitationUsed
+	}
+	invitation.Status = models.InvitationRevoked
+	if err := s.invitationRepo.UpdateInvitation(invitation); err != nil {
+		return fmt.Errorf("failed to revoke invitation: %w", err)
+	}
+	s.audit(userID, teamID, models.AuditTeamInvitationRevoked, map[string]interface{}{
+		"invitation_id": invitation.ID,
+		"kind":          invitation.Kind,
+	})
+	return nil
+}
+
+// ListMyInvitations lists pending email invitations addressed to the user
+func (s *invitationService) ListMyInvitations(userID uint) ([]models.TeamInvitation, error) {
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	invitations, err := s.invitationRepo.GetPendingInvitationsByEmail(strings.ToLower(user.Email))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list invitations: %w", err)
+	}
+	for i := range invitations {
+		invitations[i].TeamName = invitations[i].Team.Name
+	}
+	return invitations, nil
+}
+
+// addressedTo loads an email invitation addressed to the user
+func (s *invitationService) addressedTo(userID, invitationID uint) (*models.TeamInvitation, *models.User, error) {
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	invitation, err := s.invitationRepo.GetInvitationByID(invitationID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrInvitationNotFound
+		}
+		return nil, nil, fmt.Errorf("failed to get invitation: %w", err)
+	}
+	if invitation.Kind != models.InvitationKindEmail || invitation.Email != strings.ToLower(user.Email) {
+		return nil, nil, ErrInvitationNotFound
+	}
+	return invitation, user, nil
+}
+
+// redeem checks an invitation is still usable and adds the user to its team
+func (s *invitationService) redeem(userID uint, invitation *models.TeamInvitation) (*models.Team, error) {
+	if invitation.Status != models.InvitationPending || (invitation.MaxUses > 0 && invitation.Uses >= invitation.MaxUses) {
+		return nil, ErrInvitationUsed
+	}
+	if invitation.Expired(time.Now()) {
+		return nil, ErrInvitationExpired
+	}
+	if _, err := s.teamRepo.GetMember(invitation.TeamID, userID); err == nil {
+		return nil, ErrAlreadyTeamMember
+	}
+	team, err := s.teamRepo.GetTeamByID(invitation.TeamID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvitationNotFound
+		}
+		return nil, fmt.Errorf("failed to get team: %w", err)
+	}
+
+	if err := s.invitationRepo.RedeemInvitation(invitation, userID); err != nil {
+		if errors.Is(err, repository.ErrInvitationUnavailable) {
+			return nil, ErrInvitationUsed
+		}
+		return nil, fmt.Errorf("failed to accept invitation: %w", err)
+	}
+	if s.notifier != nil {
+		s.notifier.AddTeamMember(team.ID, userID)
+	}
+
+	log.Printf("Team %d: user %d joined as %s via invitation %d", team.ID, userID, invitation.Role, invitation.ID)
+	s.audit(userID, team.ID, models.AuditTeamMemberJoined, map[string]interface{}{
+		"invitation_id": invitation.ID,
+		"kind":          invitation.Kind,
+		"role":          invitation.Role,
+	})
+	return team, nil
+}
+
+// AcceptInvitation accepts an email invitation addressed to the user
+func (s *invitationService) AcceptInvitation(userID, invitationID uint) (*models.Team, error) {
+	invitation, _, err := s.addressedTo(userID, invitationID)
+	if err != nil {
+		return nil, err
+	}
+	return s.redeem(userID, invitation)
+}
+
+// DeclineInvitation declines an email invitation addressed to the user
+func (s *invitationService) DeclineInvitation(userID, invitationID uint) error {
+	invitation, _, err := s.addressedTo(userID, invitationID)
+	if err != nil {
+		return err
+	}
+	if invitation.Status != models.InvitationPending {
+		return ErrInvitationUsed
+	}
+	invitation.Status = models.InvitationDeclined
+	if err := s.invitationRepo.UpdateInvitation(invitation); err != nil {
+		return fmt.Errorf("failed to decline invitation: %w", err)
+	}
+	return nil
+}
+
+// JoinWithToken redeems a join link, or the link from an invitation email addressed to the user
+func (s *invitationService) JoinWithToken(userID uint, token string) (*models.Team, error) {
+	invitation, err := s.invitationRepo.GetInvitationByTokenHash(hashInvitationToken(strings.TrimSpace(token)))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvitationNotFound
+		}
+		return nil, fmt.Errorf("failed to get invitation: %w", err)
+	}
+	if invitation.Kind == models.InvitationKindEmail {
+		// Email invitations only work for the address they were sent to
+		if _, _, err := s.addressedTo(userID, invitation.ID); err != nil {
+			return nil, err
+		}
+	}
+	return s.redeem(userID, invitation)
+}
+
+// audit records an action a user performed on a team
+func (s *invitationService) audit(userID, teamID uint, action string, details map[string]interface{}) {
+	recordAudit(s.auditService, AuditEvent{UserID: &userID, TeamID: &teamID, Action: action, Details: details})
+}
+
+// ClaimPendingInvitations accepts every pending invitation addressed to a newly registered user
+func (s *invitationService) ClaimPendingInvitations(user *models.User) {
+	invitations, err := s.invitationRepo.GetPendingInvitationsByEmail(strings.ToLower(user.Email))
+	if err != nil {
+		log.Printf("Failed to load pending invitations for user %d: %v", user.ID, err)
+		return
+	}
+	for i := range invitations {
+		if _, err := s.redeem(user.ID, &invitations[i]); err != nil && !errors.Is(err, ErrAlreadyTeamMember) {
+			log.Printf("Failed to claim invitation %d for user %d: %v", invitations[i].ID, user.ID, err)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"clipboard-sync-backend/internal/models"
+	"clipboard-sync-backend/internal/repository"
+	"clipboard-sync-backend/internal/unfurl"
+)
+
+// maxConcurrentUnfurls bounds the number of link previews fetched at once
+const maxConcurrentUnfurls = 8
+
+// LinkPreviewService defines the interface for enriching URL entries with page metadata
+type LinkPreviewService interface {
+	// EnrichEntry starts an asynchronous unfurl if the entry's content is a URL
+	EnrichEntry(entry *models.ClipboardEntry)
+}
+
+type linkPreviewService struct {
+	previewRepo repository.LinkPreviewRepository
+	fetcher     unfurl.Fetcher
+	notifier    Notifier
+	timeout     time.Duration
+	slots       chan struct{}
+}
+
+// NewLinkPreviewService creates a new LinkPreviewService. The fetcher is injected so tests can
+// point it at a local server.
+func NewLinkPreviewService(previewRepo repository.LinkPreviewRepository, fetcher unfurl.Fetcher, notifier Notifier, timeout time.Duration) LinkPreviewService {
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return &linkPreviewService{
+		previewRepo: previewRepo,
+		fetcher:     fetcher,
+		notifier:    notifier,
+		timeout:     timeout,
+		slots:       make(chan struct{}, maxConcurrentUnfurls),
+	}
+}
+
+// EnrichEntry starts an asynchronous unfurl if the entry's content is a URL
+func (s *linkPreviewService) EnrichEntry(entry *models.ClipboardEntry) {
+	target := unfurl.ExtractURL(entry.Content)
+	if target == "" {
+		return
+	}
+
+	select {
+	case s.slots <- struct{}{}:
+	default:
+		log.Printf("Link preview queue full, skipping entry %d", entry.ID)
+		return
+	}
+
+	go func(entryID, userID uint, teamID *uint) {
+		defer func() { <-s.slots }()
+		s.unfurl(entryID, userID, teamID, target)
+	}(entry.ID, entry.UserID, entry.TeamID)
+}
+
+func (s *linkPreviewService) unfurl(entryID, userID uint, teamID *uint, target string) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	now := time.Now()
+	preview := &models.LinkPreview{EntryID: entryID, URL: target, FetchedAt: &now}
+
+	meta, err := s.fetcher.Fetch(ctx, target)
+	if err != nil {
+		log.Printf("Failed to unfurl link for entry %d: %v", entryID, err)
+		preview.Status = models.LinkPreviewFailed
+		preview.Error = err.Error()
+	} else {
+		preview.Status = models.LinkPreviewReady
+		preview.CanonicalURL = meta.CanonicalURL
+		preview.Title = meta.Title
+		preview.Description = meta.Description
+		preview.FaviconURL = meta.FaviconURL
+	}
+
+	if err := s.previewRepo.UpsertPreview(preview); err != nil {
+		log.Printf("Failed to save link preview for entry %d: %v", entryID, err)
+		return
+	}
+	if preview.Status != models.LinkPreviewReady {
+		return
+	}
+
+	data := map[string]interface{}{
+		"entry_id":     entryID,
+		"link_preview": preview,
+	}
+	// Team entries are updated for every online member, personal ones for the owner's devices
+	if teamNotifier, ok := s.notifier.(TeamNotifier); ok && teamID != nil {
+		notifyTeam(teamNotifier, *teamID, EventEntryUpdated, data)
+		return
+	}
+	notifyUser(s.notifier, userID, EventEntryUpdated, data)
+}
@@ -0,0 +1,248 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"clipboard-sync-backend/internal/mail"
+	"clipboard-sync-backend/internal/models"
+	"clipboard-sync-backend/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultMaxFailedLogins      = 5
+	defaultMaxFailedLoginsPerIP = 50
+	defaultLockoutDuration      = 15 * time.Minute
+	defaultLoginFailureWindow   = 15 * time.Minute
+	firstLoginDelay             = time.Second      // Wait after the second failure in a row
+	maxLoginDelay               = 30 * time.Second // Delays double with every failure up to this
+)
+
+var ErrLoginThrottled = errors.New("too many failed sign-in attempts")
+
+// LoginThrottledError rejects a sign-in attempt without checking the password. Locked is set
+// for lockouts and unset while a progressive delay runs; either way RetryAfter says how long to wait.
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+	Locked     bool
+}
+
+func (e *LoginThrottledError) Error() string {
+	if e.Locked {
+		return "too many failed sign-in attempts; try again later"
+	}
+	return "too many failed sign-in attempts; wait before trying again"
+}
+
+func (e *LoginThrottledError) Unwrap() error {
+	return ErrLoginThrottled
+}
+
+// LoginGuardOptions configures brute-force protection; zero values use defaults
+type LoginGuardOptions struct {
+	MaxFailures      int           // Failures in a row that lock an account
+	MaxFailuresPerIP int           // Failures in a row that lock out a client address
+	LockoutDuration  time.Duration // How long lockouts last
+	FailureWindow    time.Duration // Failures are forgotten after this long without another
+}
+
+// LoginGuard defines the interface for password sign-in brute-force protection. Accounts are
+// tracked by the email being tried, whether or not it has an account, so throttling reveals
+// nothing about which addresses are registered.
+type LoginGuard interface {
+	Check(email, ip string) error
+	RecordFailure(email, ip string, user *models.User, req *RequestInfo)
+	RecordSuccess(email string)
+	Unlock(adminID, userID uint, req *RequestInfo) error
+}
+
+type loginGuard struct {
+	throttleRepo repository.LoginThrottleRepository
+	userRepo     repository.UserRepository
+	mailer       mail.Sender
+	auditService AuditService
+	appURL       string
+	opts         LoginGuardOptions
+}
+
+// NewLoginGuard creates a new LoginGuard. Owners of locked accounts are emailed through mailer,
+// with a link to reset their password at appURL.
+func NewLoginGuard(throttleRepo repository.LoginThrottleRepository, userRepo repository.UserRepository, mailer mail.Sender, auditService AuditService, appURL string, opts LoginGuardOptions) LoginGuard {
+	if opts.MaxFailures <= 0 {
+		opts.MaxFailures = defaultMaxFailedLogins
+	}
+	if opts.MaxFailuresPerIP <= 0 {
+		opts.MaxFailuresPerIP = defaultMaxFailedLoginsPerIP
+	}
+	if opts.LockoutDuration <= 0 {
+		opts.LockoutDuration = defaultLockoutDuration
+	}
+	if opts.FailureWindow <= 0 {
+		opts.FailureWindow = defaultLoginFailureWindow
+	}
+	return &loginGuard{
+		throttleRepo: throttleRepo,
+		userRepo:     userRepo,
+		mailer:       mailer,
+		auditService: auditService,
+		appURL:       strings.TrimRight(appURL, "/"),
+		opts:         opts,
+	}
+}
+
+// accountKey normalizes an email so differently cased attempts share one counter
+func accountKey(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// loginDelay is how long to wait after the given number of failures in a row: nothing after
+// the first, then one second, doubling up to maxLoginDelay
+func loginDelay(failures int) time.Duration {
+	if failures < 2 {
+		return 0
+	}
+	delay := firstLoginDelay
+	for i := 2; i < failures && delay < maxLoginDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxLoginDelay {
+		delay = maxLoginDelay
+	}
+	return delay
+}
+
+func (g *loginGuard) getThrottle(scope, key string) (*models.LoginThrottle, error) {
+	throttle, err := g.throttleRepo.GetThrottle(scope, key)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get login throttle: %w", err)
+	}
+	return throttle, nil
+}
+
+// Check returns a *LoginThrottledError when the client address or account is locked out, or
+// the account's progressive delay has not passed yet
+func (g *loginGuard) Check(email, ip string) error {
+	now := time.Now()
+	if ip != "" {
+		throttle, err := g.getThrottle(models.ThrottleScopeIP, ip)
+		if err != nil {
+			return err
+		}
+		if throttle != nil && throttle.LockedUntil != nil && now.Before(*throttle.LockedUntil) {
+			return &LoginThrottledError{RetryAfter: throttle.LockedUntil.Sub(now), Locked: true}
+		}
+	}
+
+	throttle, err := g.getThrottle(models.ThrottleScopeAccount, accountKey(email))
+	if err != nil || throttle == nil {
+		return err
+	}
+	if throttle.LockedUntil != nil && now.Before(*throttle.LockedUntil) {
+		return &LoginThrottledError{RetryAfter: throttle.LockedUntil.Sub(now), Locked: true}
+	}
+	if now.Sub(throttle.LastFailureAt) < g.opts.FailureWindow {
+		if wait := loginDelay(throttle.Failures) - now.Sub(throttle.LastFailureAt); wait > 0 {
+			return &LoginThrottledError{RetryAfter: wait}
+		}
+	}
+	return nil
+}
+
+// RecordFailure counts a failed sign-in against the account and the client address, locking
+// either out once it reaches its threshold. user is nil when no account has the email; owners
+// of existing accounts are told when theirs is locked.
+func (g *loginGuard) RecordFailure(email, ip string, user *models.User, req *RequestInfo) {
+	now := time.Now()
+	key := accountKey(email)
+	if throttle, err := g.throttleRepo.RecordFailure(models.ThrottleScopeAccount, key, now, g.opts.FailureWindow); err != nil {
+		log.Printf("Failed to record failed sign-in for %s: %v", key, err)
+	} else if throttle.Failures >= g.opts.MaxFailures {
+		until := now.Add(g.opts.LockoutDuration)
+		if err := g.throttleRepo.Lock(models.ThrottleScopeAccount, key, until); err != nil {
+			log.Printf("Failed to lock sign-in for %s: %v", key, err)
+		} else if throttle.Failures == g.opts.MaxFailures {
+			// Only the attempt that reached the threshold reports it, however many raced it
+			g.accountLocked(key, user, until, req)
+		}
+	}
+
+	if ip == "" {
+		return
+	}
+	if throttle, err := g.throttleRepo.RecordFailure(models.ThrottleScopeIP, ip, now, g.opts.FailureWindow); err != nil {
+		log.Printf("Failed to record failed sign-in from %s: %v", ip, err)
+	} else if throttle.Failures >= g.opts.MaxFailuresPerIP {
+		if err := g.throttleRepo.Lock(models.ThrottleScopeIP, ip, now.Add(g.opts.LockoutDuration)); err != nil {
+			log.Printf("Failed to lock sign-in from %s: %v", ip, err)
+		} else if throttle.Failures == g.opts.MaxFailuresPerIP {
+			log.Printf("Locked sign-in from %s for %s after %d failed attempts", ip, g.opts.LockoutDuration, throttle.Failures)
+		}
+	}
+}
+
+// accountLocked audits a lockout and emails the owner of the account, if there is one
+func (g *loginGuard) accountLocked(key string, user *models.User, until time.Time, req *RequestInfo) {
+	log.Printf("Locked sign-in for %s until %s after %d failed attempts", key, until.Format(time.RFC3339), g.opts.MaxFailures)
+	var userID *uint
+	if user != nil {
+		userID = &user.ID
+	}
+	recordAudit(g.auditService, AuditEvent{
+		UserID:  userID,
+		Action:  models.AuditAccountLocked,
+		Details: map[string]interface{}{"email": key, "failures": g.opts.MaxFailures, "locked_until": until},
+		Request: req,
+	})
+	if user == nil {
+		return
+	}
+
+	from := ""
+	if req != nil && req.IP != "" {
+		from = fmt.Sprintf(", most recently from %s", req.IP)
+	}
+	body := fmt.Sprintf("There were %d failed attempts to sign in to %s%s, so signing in with a password is blocked for %s.\n\nIf this was you, wait and try again. If not, someone may be guessing your password; you can choose a new one here:\n\n%s/forgot-password\n",
+		g.opts.MaxFailures, user.Email, from, formatTTL(g.opts.LockoutDuration), g.appURL)
+	if err := g.mailer.Send(mail.Message{To: user.Email, Subject: "Sign-in to your account was locked", Body: body}); err != nil {
+		log.Printf("Failed to send lockout notice to %s: %v", user.Email, err)
+	}
+}
+
+// RecordSuccess forgets the account's failed attempts after a successful sign-in. Failures from
+// the client address still count, so one valid account cannot be used to reset them.
+func (g *loginGuard) RecordSuccess(email string) {
+	if err := g.throttleRepo.ClearThrottle(models.ThrottleScopeAccount, accountKey(email)); err != nil {
+		log.Printf("Failed to clear failed sign-ins for %s: %v", accountKey(email), err)
+	}
+}
+
+// Unlock lifts a lockout on a user's account and forgets its failed attempts
+func (g *loginGuard) Unlock(adminID, userID uint, req *RequestInfo) error {
+	user, err := g.userRepo.GetUserByID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		return fmt.Errorf("error retrieving user: %w", err)
+	}
+	if err := g.throttleRepo.ClearThrottle(models.ThrottleScopeAccount, accountKey(user.Email)); err != nil {
+		return fmt.Errorf("failed to unlock account: %w", err)
+	}
+
+	log.Printf("Admin %d unlocked sign-in for user %d", adminID, userID)
+	recordAudit(g.auditService, AuditEvent{
+		UserID:  &adminID,
+		Action:  models.AuditAccountUnlocked,
+		Details: map[string]interface{}{"target_user_id": userID, "email": user.Email},
+		Request: req,
+	})
+	return nil
+}
@@ -0,0 +1,386 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"clipboard-sync-backend/internal/mail"
+	"clipboard-sync-backend/internal/models"
+	"clipboard-sync-backend/internal/password"
+	"clipboard-sync-backend/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// fakeThrottleRepo keeps login throttles in memory with the semantics of the SQL repository
+type fakeThrottleRepo struct {
+	throttles map[string]*models.LoginThrottle
+}
+
+func newFakeThrottleRepo() *fakeThrottleRepo {
+	return &fakeThrottleRepo{throttles: make(map[string]*models.LoginThrottle)}
+}
+
+func (r *fakeThrottleRepo) get(scope, key string) *models.LoginThrottle {
+	return r.throttles[scope+"|"+key]
+}
+
+func (r *fakeThrottleRepo) GetThrottle(scope, key string) (*models.LoginThrottle, error) {
+	t := r.get(scope, key)
+	if t == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *t
+	return &copied, nil
+}
+
+func (r *fakeThrottleRepo) RecordFailure(scope, key string, now time.Time, window time.Duration) (*models.LoginThrottle, error) {
+	t := r.get(scope, key)
+	switch {
+	case t == nil:
+		t = &models.LoginThrottle{Scope: scope, Key: key, Failures: 1}
+		r.throttles[scope+"|"+key] = t
+	case t.LastFailureAt.Before(now.Add(-window)):
+		t.Failures = 1
+	default:
+		t.Failures++
+	}
+	t.LastFailureAt = now
+	copied := *t
+	return &copied, nil
+}
+
+func (r *fakeThrottleRepo) Lock(scope, key string, until time.Time) error {
+	if t := r.get(scope, key); t != nil {
+		t.LockedUntil = &until
+		t.Failures = 0
+	}
+	return nil
+}
+
+func (r *fakeThrottleRepo) ClearThrottle(scope, key string) error {
+	delete(r.throttles, scope+"|"+key)
+	return nil
+}
+
+// fakeUserRepo serves users from memory; methods the tests do not need panic
+type fakeUserRepo struct {
+	repository.UserRepository
+	users []*models.User
+}
+
+func (r *fakeUserRepo) GetUserByEmail(email string) (*models.User, error) {
+	for _, u := range r.users {
+		if u.Email == email {
+			return u, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeUserRepo) GetUserByID(id uint) (*models.User, error) {
+	for _, u := range r.users {
+		if u.ID == id {
+			return u, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeUserRepo) TouchLastLogin(id uint, at time.Time) error {
+	return nil
+}
+
+func (r *fakeUserRepo) MarkEmailVerified(id uint, at time.Time) error {
+	user, err := r.GetUserByID(id)
+	if err != nil {
+		return err
+	}
+	user.EmailVerifiedAt = &at
+	return nil
+}
+
+type fakeMailer struct {
+	sent []mail.Message
+}
+
+func (m *fakeMailer) Send(msg mail.Message) error {
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+// fakeAudit records events instead of queueing them
+type fakeAudit struct {
+	AuditService
+	events []AuditEvent
+}
+
+func (a *fakeAudit) Record(event AuditEvent) {
+	a.events = append(a.events, event)
+}
+
+func (a *fakeAudit) actions() []string {
+	var actions []string
+	for _, e := range a.events {
+		actions = append(actions, e.Action)
+	}
+	return actions
+}
+
+// fakeHasher "hashes" by prefixing and remembers which hashes it verified against
+type fakeHasher struct {
+	verified []string
+}
+
+func (h *fakeHasher) Hash(pw string) (string, error) { return "hash:" + pw, nil }
+
+func (h *fakeHasher) Verify(hash, pw string) (bool, error) {
+	h.verified = append(h.verified, hash)
+	return hash == "hash:"+pw, nil
+}
+
+func (h *fakeHasher) NeedsRehash(hash string) bool { return false }
+
+type guardFixture struct {
+	guard    LoginGuard
+	throttle *fakeThrottleRepo
+	users    *fakeUserRepo
+	mailer   *fakeMailer
+	audit    *fakeAudit
+}
+
+func newGuardFixture(opts LoginGuardOptions) *guardFixture {
+	f := &guardFixture{
+		throttle: newFakeThrottleRepo(),
+		users: &fakeUserRepo{users: []*models.User{
+			{ID: 1, Email: "alice@example.com", Password: "hash:correct horse"},
+			{ID: 2, Email: "admin@example.com", IsAdmin: true},
+		}},
+		mailer: &fakeMailer{},
+		audit:  &fakeAudit{},
+	}
+	f.guard = NewLoginGuard(f.throttle, f.users, f.mailer, f.audit, "https://clip.example.com/", opts)
+	return f
+}
+
+// fail records n failed sign-ins to email from ip
+func (f *guardFixture) fail(t *testing.T, n int, email, ip string) {
+	t.Helper()
+	user, _ := f.users.GetUserByEmail(email)
+	for i := 0; i < n; i++ {
+		f.guard.RecordFailure(email, ip, user, &RequestInfo{IP: ip})
+	}
+}
+
+func throttled(t *testing.T, err error) *LoginThrottledError {
+	t.Helper()
+	var te *LoginThrottledError
+	if !errors.As(err, &te) || !errors.Is(err, ErrLoginThrottled) {
+		t.Fatalf("error = %v, want a *LoginThrottledError", err)
+	}
+	return te
+}
+
+func TestLoginDelay(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{1, 0},
+		{2, time.Second},
+		{3, 2 * time.Second},
+		{4, 4 * time.Second},
+		{6, 16 * time.Second},
+		{7, maxLoginDelay},
+		{100, maxLoginDelay},
+	}
+	for _, tt := range tests {
+		if got := loginDelay(tt.failures); got != tt.want {
+			t.Errorf("loginDelay(%d) = %s, want %s", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestLoginGuardProgressiveDelay(t *testing.T) {
+	f := newGuardFixture(LoginGuardOptions{MaxFailures: 10})
+
+	f.fail(t, 1, "alice@example.com", "203.0.113.1")
+	if err := f.guard.Check("alice@example.com", "203.0.113.1"); err != nil {
+		t.Fatalf("Check() after one failure = %v, want nil", err)
+	}
+
+	f.fail(t, 1, "alice@example.com", "203.0.113.1")
+	te := throttled(t, f.guard.Check("alice@example.com", "203.0.113.1"))
+	if te.Locked || te.RetryAfter <= 0 || te.RetryAfter > time.Second {
+		t.Fatalf("after two failures got Locked = %v, RetryAfter = %s; want a delay of up to 1s", te.Locked, te.RetryAfter)
+	}
+
+	f.fail(t, 2, "alice@example.com", "203.0.113.1")
+	te = throttled(t, f.guard.Check("alice@example.com", "203.0.113.1"))
+	if te.RetryAfter <= 2*time.Second || te.RetryAfter > 4*time.Second {
+		t.Fatalf("after four failures RetryAfter = %s, want up to 4s", te.RetryAfter)
+	}
+
+	// Once the delay has passed the next attempt is allowed
+	f.throttle.get(models.ThrottleScopeAccount, "alice@example.com").LastFailureAt = time.Now().Add(-5 * time.Second)
+	if err := f.guard.Check("alice@example.com", "203.0.113.1"); err != nil {
+		t.Fatalf("Check() after the delay = %v, want nil", err)
+	}
+
+	// Failures older than the window no longer slow anything down
+	throttle := f.throttle.get(models.ThrottleScopeAccount, "alice@example.com")
+	throttle.Failures = 9
+	throttle.LastFailureAt = time.Now().Add(-defaultLoginFailureWindow - time.Second)
+	if err := f.guard.Check("alice@example.com", "203.0.113.1"); err != nil {
+		t.Fatalf("Check() after the failure window = %v, want nil", err)
+	}
+}
+
+func TestLoginGuardLockout(t *testing.T) {
+	f := newGuardFixture(LoginGuardOptions{MaxFailures: 3, LockoutDuration: time.Hour})
+
+	f.fail(t, 2, "alice@example.com", "203.0.113.1")
+	if te := throttled(t, f.guard.Check("alice@example.com", "203.0.113.1")); te.Locked {
+		t.Fatal("account locked before reaching the threshold")
+	}
+
+	f.fail(t, 1, "alice@example.com", "203.0.113.1")
+	te := throttled(t, f.guard.Check("Alice@Example.com ", "198.51.100.7"))
+	if !te.Locked || te.RetryAfter <= 59*time.Minute || te.RetryAfter > time.Hour {
+		t.Fatalf("after reaching the threshold got Locked = %v, RetryAfter = %s; want locked for an hour", te.Locked, te.RetryAfter)
+	}
+
+	if len(f.mailer.sent) != 1 {
+		t.Fatalf("sent %d lockout notices, want 1", len(f.mailer.sent))
+	}
+	msg := f.mailer.sent[0]
+	if msg.To != "alice@example.com" || !strings.Contains(msg.Body, "https://clip.example.com/forgot-password") {
+		t.Fatalf("lockout notice to %q with body %q", msg.To, msg.Body)
+	}
+	if got := f.audit.actions(); len(got) != 1 || got[0] != models.AuditAccountLocked {
+		t.Fatalf("audited %v, want [%s]", got, models.AuditAccountLocked)
+	}
+
+	// The lock expires on its own
+	past := time.Now().Add(-time.Second)
+	f.throttle.get(models.ThrottleScopeAccount, "alice@example.com").LockedUntil = &past
+	if err := f.guard.Check("alice@example.com", "203.0.113.1"); err != nil {
+		t.Fatalf("Check() after the lockout expired = %v, want nil", err)
+	}
+}
+
+func TestLoginGuardLockoutOfUnknownEmail(t *testing.T) {
+	f := newGuardFixture(LoginGuardOptions{MaxFailures: 3})
+
+	f.fail(t, 3, "nobody@example.com", "203.0.113.1")
+	if te := throttled(t, f.guard.Check("nobody@example.com", "203.0.113.1")); !te.Locked {
+		t.Fatal("unknown email was not locked like a registered one")
+	}
+	if len(f.mailer.sent) != 0 {
+		t.Fatalf("sent %d lockout notices for an email without an account", len(f.mailer.sent))
+	}
+}
+
+func TestLoginGuardScopes(t *testing.T) {
+	f := newGuardFixture(LoginGuardOptions{MaxFailures: 3, MaxFailuresPerIP: 4})
+
+	// A locked account stays locked from every address
+	f.fail(t, 3, "alice@example.com", "203.0.113.1")
+	if te := throttled(t, f.guard.Check("alice@example.com", "198.51.100.7")); !te.Locked {
+		t.Fatal("account lock did not apply to another address")
+	}
+	// The address itself is not locked, so other accounts can still be tried from it
+	if err := f.guard.Check("bob@example.com", "203.0.113.1"); err != nil {
+		t.Fatalf("Check() of another account = %v, want nil", err)
+	}
+
+	// One more failure from the address, on any account, locks the address out
+	f.fail(t, 1, "bob@example.com", "203.0.113.1")
+	if te := throttled(t, f.guard.Check("carol@example.com", "203.0.113.1")); !te.Locked {
+		t.Fatal("address was not locked after reaching its threshold")
+	}
+	if err := f.guard.Check("carol@example.com", "198.51.100.7"); err != nil {
+		t.Fatalf("Check() from another address = %v, want nil", err)
+	}
+
+	// Signing in successfully clears the account but not the address
+	f.guard.RecordSuccess("Bob@example.com")
+	if f.throttle.get(models.ThrottleScopeAccount, "bob@example.com") != nil {
+		t.Fatal("success did not clear the account's failures")
+	}
+	if te := throttled(t, f.guard.Check("bob@example.com", "203.0.113.1")); !te.Locked {
+		t.Fatal("success cleared the address lock")
+	}
+}
+
+func TestLoginGuardUnlock(t *testing.T) {
+	f := newGuardFixture(LoginGuardOptions{MaxFailures: 3})
+
+	f.fail(t, 3, "alice@example.com", "203.0.113.1")
+	throttled(t, f.guard.Check("alice@example.com", "198.51.100.7"))
+
+	if err := f.guard.Unlock(2, 1, nil); err != nil {
+		t.Fatalf("Unlock() error = %v", err)
+	}
+	if err := f.guard.Check("alice@example.com", "198.51.100.7"); err != nil {
+		t.Fatalf("Check() after Unlock() = %v, want nil", err)
+	}
+	last := f.audit.events[len(f.audit.events)-1]
+	if last.Action != models.AuditAccountUnlocked || last.UserID == nil || *last.UserID != 2 {
+		t.Fatalf("last audit event = %+v, want %s by user 2", last, models.AuditAccountUnlocked)
+	}
+
+	if err := f.guard.Unlock(2, 99, nil); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("Unlock() of a missing user error = %v, want ErrUserNotFound", err)
+	}
+}
+
+func TestLoginUserThrottling(t *testing.T) {
+	f := newGuardFixture(LoginGuardOptions{MaxFailures: 3})
+	hasher := &fakeHasher{}
+	passwords := NewPasswordService(hasher, password.Policy{}, nil, f.users, 0)
+	users := NewUserService(f.users, passwords, nil, nil, f.audit, f.guard)
+	req := &RequestInfo{IP: "203.0.113.1"}
+
+	// Unknown emails are compared against the dummy hash and counted like wrong passwords
+	if _, err := users.LoginUser("nobody@example.com", "guess", req); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("LoginUser() of an unknown email error = %v, want ErrInvalidCredentials", err)
+	}
+	if len(hasher.verified) != 1 || hasher.verified[0] != "hash:clipboard-sync-dummy-password" {
+		t.Fatalf("verified against %v, want only the dummy hash", hasher.verified)
+	}
+	if th := f.throttle.get(models.ThrottleScopeAccount, "nobody@example.com"); th == nil || th.Failures != 1 {
+		t.Fatalf("unknown email throttle = %+v, want one failure", th)
+	}
+	if th := f.throttle.get(models.ThrottleScopeIP, "203.0.113.1"); th == nil || th.Failures != 1 {
+		t.Fatalf("address throttle = %+v, want one failure", th)
+	}
+
+	// Wrong passwords count; a throttled attempt is refused before the password is checked
+	if _, err := users.LoginUser("alice@example.com", "wrong", req); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("LoginUser() with a wrong password error = %v, want ErrInvalidCredentials", err)
+	}
+	if _, err := users.LoginUser("alice@example.com", "wrong", req); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("LoginUser() with a wrong password error = %v, want ErrInvalidCredentials", err)
+	}
+	hasher.verified = nil
+	if _, err := users.LoginUser("alice@example.com", "correct horse", req); !errors.Is(err, ErrLoginThrottled) {
+		t.Fatalf("LoginUser() during the delay error = %v, want ErrLoginThrottled", err)
+	}
+	if len(hasher.verified) != 0 {
+		t.Fatal("throttled attempt still checked the password")
+	}
+
+	// After the delay the right password signs in and clears the account's failures
+	f.throttle.get(models.ThrottleScopeAccount, "alice@example.com").LastFailureAt = time.Now().Add(-time.Minute)
+	user, err := users.LoginUser("alice@example.com", "correct horse", req)
+	if err != nil || user.ID != 1 {
+		t.Fatalf("LoginUser() = %v, %v; want user 1", user, err)
+	}
+	if f.throttle.get(models.ThrottleScopeAccount, "alice@example.com") != nil {
+		t.Fatal("successful sign-in did not clear the account's failures")
+	}
+}
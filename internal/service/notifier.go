@@ -0,0 +1,76 @@
+package service
+
+import (
+	"encoding/json"
+	"log"
+
+	"clipboard-sync-backend/internal/models"
+)
+
+// Notifier pushes real-time messages to a user's connected devices (implemented by websocket.Manager)
+type Notifier interface {
+	SendToUser(userID uint, message []byte)
+}
+
+// DeviceNotifier can additionally target a single named device of a user
+type DeviceNotifier interface {
+	Notifier
+	IsDeviceConnected(userID uint, device string) bool
+	SendToDevice(userID uint, device string, message []byte) bool
+}
+
+// SessionNotifier closes the live connections opened with a session once it is revoked, or
+// every connection of a user when an administrator signs them out
+type SessionNotifier interface {
+	DisconnectSession(sessionID uint)
+	DisconnectUser(userID uint)
+}
+
+// TeamNotifier fans messages out to the online members of a team. Membership changes are
+// reported so that live delivery starts or stops without waiting for a reconnect.
+type TeamNotifier interface {
+	SendToTeam(teamID uint, message []byte)
+	SendEntryToTeam(teamID uint, entry *models.ClipboardEntry)
+	AddTeamMember(teamID, userID uint)
+	RemoveTeamMember(teamID, userID uint)
+	RemoveTeam(teamID uint)
+}
+
+// Event types pushed to clients in addition to raw clipboard entries
+const (
+	EventEntryUpdated      = "entry_updated"
+	EventTeamAccessRevoked = "team_access_revoked"
+	EventError             = "error"
+)
+
+// Event is the envelope for server-pushed events
+type Event struct {
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+}
+
+// notifyUser marshals an event and pushes it to the user's devices; a nil notifier is a no-op
+func notifyUser(notifier Notifier, userID uint, eventType string, data interface{}) {
+	if notifier == nil {
+		return
+	}
+	message, err := json.Marshal(Event{Type: eventType, Data: data})
+	if err != nil {
+		log.Printf("Error marshalling %s event: %v", eventType, err)
+		return
+	}
+	notifier.SendToUser(userID, message)
+}
+
+// notifyTeam marshals an event and pushes it to a team's online members; a nil notifier is a no-op
+func notifyTeam(notifier TeamNotifier, teamID uint, eventType string, data interface{}) {
+	if notifier == nil {
+		return
+	}
+	message, err := json.Marshal(Event{Type: eventType, Data: data})
+	if err != nil {
+		log.Printf("Error marshalling %s event: %v", eventType, err)
+		return
+	}
+	notifier.SendToTeam(teamID, message)
+}
@@ -0,0 +1,268 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"clipboard-sync-backend/internal/models"
+	"clipboard-sync-backend/internal/oauth"
+	"clipboard-sync-backend/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+const defaultOAuthStateTTL = 10 * time.Minute
+
+var (
+	ErrUnknownOAuthProvider = errors.New("unknown login provider")
+	ErrInvalidOAuthState    = errors.New("login request is invalid or has expired; please start again")
+	ErrOAuthEmailUnverified = errors.New("the provider did not return a verified email address")
+	ErrOAuthProviderFailed  = errors.New("could not complete sign-in with the provider")
+)
+
+// OAuthLogin is the outcome of a completed social login
+type OAuthLogin struct {
+	User    *models.User
+	Device  string // Device name requested when the login started
+	NewUser bool   // Whether the account was provisioned by this login
+}
+
+// OAuthService defines the interface for signing in with OAuth/OIDC providers
+type OAuthService interface {
+	Providers() []string
+	StartLogin(provider, device string) (string, error)
+	CompleteLogin(provider, state, code string, req *RequestInfo) (*OAuthLogin, error)
+}
+
+type oauthService struct {
+	oauthRepo         repository.OAuthRepository
+	userRepo          repository.UserRepository
+	invitationService InvitationService
+	auditService      AuditService
+	providers         map[string]oauth.Provider
+	callbackBaseURL   string
+	stateTTL          time.Duration
+	timeout           time.Duration
+}
+
+// NewOAuthService creates a new OAuthService. Providers redirect back to
+// <callbackBaseURL>/api/v1/oauth/<name>/callback; logins must finish within stateTTL.
+func NewOAuthService(oauthRepo repository.OAuthRepository, userRepo repository.UserRepository, invitationService InvitationService, auditService AuditService, providers map[string]oauth.Provider, callbackBaseURL string, stateTTL, timeout time.Duration) OAuthService {
+	if stateTTL <= 0 {
+		stateTTL = defaultOAuthStateTTL
+	}
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return &oauthService{
+		oauthRepo:         oauthRepo,
+		userRepo:          userRepo,
+		invitationService: invitationService,
+		auditService:      auditService,
+		providers:         providers,
+		callbackBaseURL:   strings.TrimSuffix(callbackBaseURL, "/"),
+		stateTTL:          stateTTL,
+		timeout:           timeout,
+	}
+}
+
+func hashOAuthState(state string) string {
+	sum := sha256.Sum256([]byte(state))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *oauthService) redirectURI(provider string) string {
+	return s.callbackBaseURL + "/api/v1/oauth/" + provider + "/callback"
+}
+
+// Providers lists the names of the configured providers
+func (s *oauthService) Providers() []string {
+	names := make([]string, 0, len(s.providers))
+	for name := range s.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// StartLogin records a pending login and returns the provider URL to send the user to
+func (s *oauthService) StartLogin(provider, device string) (string, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return "", ErrUnknownOAuthProvider
+	}
+	state, err := randomHex(32)
+	if err != nil {
+		return "", err
+	}
+	nonce, err := randomHex(16)
+	if err != nil {
+		return "", err
+	}
+	verifier, challenge, err := oauth.NewPKCE()
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	authURL, err := p.AuthCodeURL(ctx, state, nonce, challenge, s.redirectURI(provider))
+	if err != nil {
+		log.Printf("Failed to start %s login: %v", provider, err)
+		return "", ErrOAuthProviderFailed
+	}
+
+	now := time.Now()
+	if err := s.oauthRepo.DeleteExpiredStates(now); err != nil {
+		log.Printf("Failed to delete expired login states: %v", err)
+	}
+	if len(device) > 255 {
+		device = device[:255]
+	}
+	if err := s.oauthRepo.CreateState(&models.OAuthState{
+		StateHash:    hashOAuthState(state),
+		Provider:     provider,
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+		Device:       device,
+		ExpiresAt:    now.Add(s.stateTTL),
+	}); err != nil {
+		return "", fmt.Errorf("failed to save login state: %w", err)
+	}
+	return authURL, nil
+}
+
+// CompleteLogin finishes a login when the provider redirects back. The user is found by the
+// identity they signed in with; failing that, the identity is linked to the account with the
+// same verified email, or a new account is provisioned.
+func (s *oauthService) CompleteLogin(provider, state, code string, req *RequestInfo) (*OAuthLogin, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return nil, ErrUnknownOAuthProvider
+	}
+	if state == "" || code == "" {
+		return nil, ErrInvalidOAuthState
+	}
+	pending, err := s.oauthRepo.ConsumeState(hashOAuthState(state))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidOAuthState
+		}
+		return nil, fmt.Errorf("failed to load login state: %w", err)
+	}
+	now := time.Now()
+	if pending.Provider != provider || !now.Before(pending.ExpiresAt) {
+		return nil, ErrInvalidOAuthState
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	identity, err := p.Exchange(ctx, code, pending.CodeVerifier, pending.Nonce, s.redirectURI(provider))
+	if err != nil {
+		log.Printf("Failed to complete %s login: %v", provider, err)
+		return nil, ErrOAuthProviderFailed
+	}
+
+	login, err := s.resolveUser(provider, identity, req)
+	if err != nil {
+		return nil, err
+	}
+	login.Device = pending.Device
+	if login.User.SuspendedAt != nil {
+		recordAudit(s.auditService, AuditEvent{
+			UserID:  &login.User.ID,
+			Action:  models.AuditUserLoginFailed,
+			Details: map[string]interface{}{"provider": provider, "reason": "suspended"},
+			Request: req,
+		})
+		return nil, ErrAccountSuspended
+	}
+
+	log.Printf("User logged in with %s: %s", provider, login.User.Email)
+	// Logins of users with two-factor authentication are audited once the second factor passes
+	if !login.User.TwoFactorEnabled {
+		touchLastLogin(s.userRepo, login.User.ID)
+		recordAudit(s.auditService, AuditEvent{
+			UserID:  &login.User.ID,
+			Action:  models.AuditUserLogin,
+			Details: map[string]interface{}{"provider": provider},
+			Request: req,
+		})
+	}
+	return login, nil
+}
+
+// resolveUser finds or provisions the user behind a provider identity
+func (s *oauthService) resolveUser(provider string, identity *oauth.Identity, req *RequestInfo) (*OAuthLogin, error) {
+	now := time.Now()
+	existing, err := s.oauthRepo.GetIdentity(provider, identity.Subject)
+	if err == nil {
+		user, err := s.userRepo.GetUserByID(existing.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving user: %w", err)
+		}
+		if err := s.oauthRepo.TouchIdentity(existing.ID, now); err != nil {
+			log.Printf("Failed to record sign-in through identity %d: %v", existing.ID, err)
+		}
+		return &OAuthLogin{User: user}, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("error retrieving identity: %w", err)
+	}
+
+	// Without a verified address anyone could claim an account by its email
+	email := strings.ToLower(strings.TrimSpace(identity.Email))
+	if email == "" || !identity.EmailVerified {
+		return nil, ErrOAuthEmailUnverified
+	}
+	link := &models.UserIdentity{Provider: provider, Subject: identity.Subject, Email: email, LastLoginAt: &now}
+
+	user, err := s.userRepo.GetUserByEmail(email)
+	if err == nil {
+		link.UserID = user.ID
+		if err := s.oauthRepo.CreateIdentity(link); err != nil {
+			return nil, fmt.Errorf("failed to link identity: %w", err)
+		}
+		// The provider vouched for the address, which is as good as following our own link
+		if user.EmailVerifiedAt == nil {
+			if err := s.userRepo.MarkEmailVerified(user.ID, now); err != nil {
+				log.Printf("Failed to mark email of user %d verified: %v", user.ID, err)
+			}
+		}
+		log.Printf("Linked %s identity to user %s", provider, user.Email)
+		recordAudit(s.auditService, AuditEvent{
+			UserID:  &user.ID,
+			Action:  models.AuditIdentityLinked,
+			Details: map[string]interface{}{"provider": provider, "email": email},
+			Request: req,
+		})
+		return &OAuthLogin{User: user}, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("error checking existing user: %w", err)
+	}
+
+	// Provision the account just in time; it has no password until the user sets one
+	user = &models.User{Email: email, AuthProvider: provider, EmailVerifiedAt: &now}
+	if err := s.oauthRepo.CreateUserWithIdentity(user, link); err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+	log.Printf("User registered with %s: %s", provider, user.Email)
+	recordAudit(s.auditService, AuditEvent{
+		UserID:  &user.ID,
+		Action:  models.AuditUserRegistered,
+		Details: map[string]interface{}{"provider": provider},
+		Request: req,
+	})
+	if s.invitationService != nil {
+		s.invitationService.ClaimPendingInvitations(user)
+	}
+	return &OAuthLogin{User: user, NewUser: true}, nil
+}
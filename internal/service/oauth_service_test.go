@@ -0,0 +1,284 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"clipboard-sync-backend/configs"
+	"clipboard-sync-backend/internal/models"
+	"clipboard-sync-backend/internal/oauth"
+	"clipboard-sync-backend/internal/oauth/oauthtest"
+
+	"gorm.io/gorm"
+)
+
+// fakeOAuthRepo keeps login states and identities in memory and creates users in users
+type fakeOAuthRepo struct {
+	users      *fakeUserRepo
+	states     map[string]*models.OAuthState
+	identities []*models.UserIdentity
+}
+
+func (r *fakeOAuthRepo) CreateState(state *models.OAuthState) error {
+	r.states[state.StateHash] = state
+	return nil
+}
+
+func (r *fakeOAuthRepo) ConsumeState(stateHash string) (*models.OAuthState, error) {
+	state, ok := r.states[stateHash]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	delete(r.states, stateHash)
+	return state, nil
+}
+
+func (r *fakeOAuthRepo) DeleteExpiredStates(now time.Time) error {
+	return nil
+}
+
+func (r *fakeOAuthRepo) GetIdentity(provider, subject string) (*models.UserIdentity, error) {
+	for _, i := range r.identities {
+		if i.Provider == provider && i.Subject == subject {
+			return i, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeOAuthRepo) CreateIdentity(identity *models.UserIdentity) error {
+	identity.ID = uint(len(r.identities) + 1)
+	r.identities = append(r.identities, identity)
+	return nil
+}
+
+func (r *fakeOAuthRepo) CreateUserWithIdentity(user *models.User, identity *models.UserIdentity) error {
+	user.ID = uint(len(r.users.users) + 1)
+	r.users.users = append(r.users.users, user)
+	identity.UserID = user.ID
+	return r.CreateIdentity(identity)
+}
+
+func (r *fakeOAuthRepo) TouchIdentity(id uint, now time.Time) error {
+	return nil
+}
+
+func (r *fakeOAuthRepo) GetIdentitiesByUserID(userID uint) ([]models.UserIdentity, error) {
+	var identities []models.UserIdentity
+	for _, i := range r.identities {
+		if i.UserID == userID {
+			identities = append(identities, *i)
+		}
+	}
+	return identities, nil
+}
+
+type oauthFixture struct {
+	service OAuthService
+	idp     *oauthtest.Server
+	repo    *fakeOAuthRepo
+	users   *fakeUserRepo
+	audit   *fakeAudit
+}
+
+// newOAuthFixture wires the service to an OIDC provider running in-process, configured twice
+// as "idp" and "other"
+func newOAuthFixture(t *testing.T) *oauthFixture {
+	t.Helper()
+	idp := oauthtest.NewServer("client-1", "secret-1")
+	t.Cleanup(idp.Close)
+
+	providers := make(map[string]oauth.Provider)
+	for _, name := range []string{"idp", "other"} {
+		p, err := oauth.NewOIDCProvider(configs.OAuthProviderConfig{
+			Name:         name,
+			Type:         oauth.TypeOIDC,
+			ClientID:     idp.ClientID,
+			ClientSecret: idp.ClientSecret,
+			IssuerURL:    idp.URL,
+		}, idp.Client())
+		if err != nil {
+			t.Fatal(err)
+		}
+		providers[name] = p
+	}
+
+	users := &fakeUserRepo{users: []*models.User{
+		{ID: 1, Email: "alice@example.com", Password: "hash:correct horse"},
+	}}
+	repo := &fakeOAuthRepo{users: users, states: make(map[string]*models.OAuthState)}
+	audit := &fakeAudit{}
+	return &oauthFixture{
+		service: NewOAuthService(repo, users, nil, audit, providers, "https://clip.example.com/", 0, 0),
+		idp:     idp,
+		repo:    repo,
+		users:   users,
+		audit:   audit,
+	}
+}
+
+// authorize starts a login with provider and signs user in at the provider, returning the state
+// and code the callback receives
+func (f *oauthFixture) authorize(t *testing.T, provider string, user oauthtest.User) (state, code string) {
+	t.Helper()
+	authURL, err := f.service.StartLogin(provider, "laptop")
+	if err != nil {
+		t.Fatalf("StartLogin() error = %v", err)
+	}
+	code, state, err = f.idp.Authorize(authURL, user)
+	if err != nil {
+		t.Fatalf("provider rejected the authorization request: %v", err)
+	}
+	return state, code
+}
+
+func TestOAuthLoginProvisionsNewUser(t *testing.T) {
+	f := newOAuthFixture(t)
+	bob := oauthtest.User{Subject: "bob-1", Email: "Bob@Example.com", EmailVerified: true, Name: "Bob"}
+
+	state, code := f.authorize(t, "idp", bob)
+	login, err := f.service.CompleteLogin("idp", state, code, nil)
+	if err != nil {
+		t.Fatalf("CompleteLogin() error = %v", err)
+	}
+	if !login.NewUser || login.Device != "laptop" {
+		t.Fatalf("CompleteLogin() = %+v, want a new user on device laptop", login)
+	}
+	if login.User.Email != "bob@example.com" || login.User.AuthProvider != "idp" || login.User.EmailVerifiedAt == nil || login.User.Password != "" {
+		t.Fatalf("provisioned user = %+v", login.User)
+	}
+	if len(f.repo.identities) != 1 || f.repo.identities[0].UserID != login.User.ID || f.repo.identities[0].Subject != "bob-1" {
+		t.Fatalf("identities = %+v, want bob-1 linked to the new user", f.repo.identities)
+	}
+
+	// The next login finds the user by the identity, not the email
+	state, code = f.authorize(t, "idp", oauthtest.User{Subject: "bob-1", Email: "bob@elsewhere.example", EmailVerified: true})
+	again, err := f.service.CompleteLogin("idp", state, code, nil)
+	if err != nil {
+		t.Fatalf("second CompleteLogin() error = %v", err)
+	}
+	if again.NewUser || again.User.ID != login.User.ID {
+		t.Fatalf("second CompleteLogin() = %+v, want the existing user %d", again, login.User.ID)
+	}
+}
+
+func TestOAuthLoginLinksVerifiedEmail(t *testing.T) {
+	f := newOAuthFixture(t)
+
+	state, code := f.authorize(t, "idp", oauthtest.User{Subject: "alice-1", Email: "ALICE@example.com", EmailVerified: true})
+	login, err := f.service.CompleteLogin("idp", state, code, nil)
+	if err != nil {
+		t.Fatalf("CompleteLogin() error = %v", err)
+	}
+	if login.NewUser || login.User.ID != 1 {
+		t.Fatalf("CompleteLogin() = %+v, want existing user 1", login)
+	}
+	if len(f.repo.identities) != 1 || f.repo.identities[0].UserID != 1 {
+		t.Fatalf("identities = %+v, want one linked to user 1", f.repo.identities)
+	}
+	if f.users.users[0].EmailVerifiedAt == nil {
+		t.Fatal("linking through a verified address did not mark the email verified")
+	}
+	linked := false
+	for _, action := range f.audit.actions() {
+		linked = linked || action == models.AuditIdentityLinked
+	}
+	if !linked {
+		t.Fatalf("audited %v, want %s", f.audit.actions(), models.AuditIdentityLinked)
+	}
+}
+
+func TestOAuthLoginRefusesUnverifiedEmail(t *testing.T) {
+	f := newOAuthFixture(t)
+
+	state, code := f.authorize(t, "idp", oauthtest.User{Subject: "mallory-1", Email: "alice@example.com"})
+	if _, err := f.service.CompleteLogin("idp", state, code, nil); !errors.Is(err, ErrOAuthEmailUnverified) {
+		t.Fatalf("CompleteLogin() error = %v, want ErrOAuthEmailUnverified", err)
+	}
+	if len(f.repo.identities) != 0 || len(f.users.users) != 1 {
+		t.Fatal("an unverified email was linked or provisioned")
+	}
+}
+
+func TestOAuthLoginState(t *testing.T) {
+	alice := oauthtest.User{Subject: "alice-1", Email: "alice@example.com", EmailVerified: true}
+	tests := []struct {
+		name    string
+		run     func(f *oauthFixture, state, code string) error
+		wantErr error
+	}{
+		{
+			name: "unknown state",
+			run: func(f *oauthFixture, state, code string) error {
+				_, err := f.service.CompleteLogin("idp", "forged", code, nil)
+				return err
+			},
+			wantErr: ErrInvalidOAuthState,
+		},
+		{
+			name: "missing code",
+			run: func(f *oauthFixture, state, code string) error {
+				_, err := f.service.CompleteLogin("idp", state, "", nil)
+				return err
+			},
+			wantErr: ErrInvalidOAuthState,
+		},
+		{
+			name: "replayed state",
+			run: func(f *oauthFixture, state, code string) error {
+				if _, err := f.service.CompleteLogin("idp", state, code, nil); err != nil {
+					return err
+				}
+				_, err := f.service.CompleteLogin("idp", state, code, nil)
+				return err
+			},
+			wantErr: ErrInvalidOAuthState,
+		},
+		{
+			name: "expired state",
+			run: func(f *oauthFixture, state, code string) error {
+				f.repo.states[hashOAuthState(state)].ExpiresAt = time.Now().Add(-time.Second)
+				_, err := f.service.CompleteLogin("idp", state, code, nil)
+				return err
+			},
+			wantErr: ErrInvalidOAuthState,
+		},
+		{
+			name: "state of another provider",
+			run: func(f *oauthFixture, state, code string) error {
+				_, err := f.service.CompleteLogin("other", state, code, nil)
+				return err
+			},
+			wantErr: ErrInvalidOAuthState,
+		},
+		{
+			name: "code of another login",
+			run: func(f *oauthFixture, state, code string) error {
+				// The second login's verifier and nonce do not match the first login's code
+				other, _ := f.authorize(t, "idp", alice)
+				_, err := f.service.CompleteLogin("idp", other, code, nil)
+				return err
+			},
+			wantErr: ErrOAuthProviderFailed,
+		},
+		{
+			name: "ID token with another nonce",
+			run: func(f *oauthFixture, state, code string) error {
+				f.repo.states[hashOAuthState(state)].Nonce = "from-another-login"
+				_, err := f.service.CompleteLogin("idp", state, code, nil)
+				return err
+			},
+			wantErr: ErrOAuthProviderFailed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newOAuthFixture(t)
+			state, code := f.authorize(t, "idp", alice)
+			if err := tt.run(f, state, code); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
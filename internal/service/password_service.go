@@ -0,0 +1,148 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"clipboard-sync-backend/internal/models"
+	"clipboard-sync-backend/internal/password"
+	"clipboard-sync-backend/internal/repository"
+)
+
+const (
+	defaultMinPasswordLength = 8
+	defaultMaxPasswordLength = 128
+)
+
+var (
+	ErrPasswordTooShort = password.ErrTooShort
+	ErrPasswordTooLong  = password.ErrTooLong
+	ErrPasswordBreached = password.ErrBreached
+	ErrPasswordReused   = errors.New("password was used recently; choose a different one")
+	ErrWrongPassword    = errors.New("current password is incorrect")
+)
+
+// PasswordService defines the interface for hashing, checking and changing passwords
+type PasswordService interface {
+	Validate(user *models.User, newPassword string) error
+	Hash(newPassword string) (string, error)
+	Verify(user *models.User, password string) (bool, error)
+	SetPassword(user *models.User, newPassword string) error
+}
+
+type passwordService struct {
+	hasher      password.Hasher
+	policy      password.Policy
+	historyRepo repository.PasswordHistoryRepository
+	userRepo    repository.UserRepository
+	historySize int
+	dummyHash   string // Verified against when there is no hash, so that takes as long as a wrong password
+}
+
+// NewPasswordService creates a new PasswordService. New passwords must satisfy policy and differ
+// from the user's last historySize passwords, counting the current one; zero allows reuse.
+func NewPasswordService(hasher password.Hasher, policy password.Policy, historyRepo repository.PasswordHistoryRepository, userRepo repository.UserRepository, historySize int) PasswordService {
+	if policy.MinLength <= 0 {
+		policy.MinLength = defaultMinPasswordLength
+	}
+	if policy.MaxLength <= 0 {
+		policy.MaxLength = defaultMaxPasswordLength
+	}
+	dummyHash, err := hasher.Hash("clipboard-sync-dummy-password")
+	if err != nil {
+		log.Fatalf("Failed to prepare password hashing: %v", err)
+	}
+	return &passwordService{
+		hasher:      hasher,
+		policy:      policy,
+		historyRepo: historyRepo,
+		userRepo:    userRepo,
+		historySize: historySize,
+		dummyHash:   dummyHash,
+	}
+}
+
+// Validate checks a new password against the policy and, for an existing user, their recent
+// passwords
+func (s *passwordService) Validate(user *models.User, newPassword string) error {
+	if err := s.policy.Check(newPassword); err != nil {
+		return err
+	}
+	if user == nil || s.historySize <= 0 {
+		return nil
+	}
+
+	hashes := []string{}
+	if user.Password != "" {
+		hashes = append(hashes, user.Password)
+	}
+	if s.historySize > 1 {
+		history, err := s.historyRepo.GetPasswordHistory(user.ID, s.historySize-1)
+		if err != nil {
+			return fmt.Errorf("failed to get password history: %w", err)
+		}
+		for _, h := range history {
+			hashes = append(hashes, h.PasswordHash)
+		}
+	}
+	for _, hash := range hashes {
+		if ok, _ := s.hasher.Verify(hash, newPassword); ok {
+			return ErrPasswordReused
+		}
+	}
+	return nil
+}
+
+// Hash hashes a new password with the current algorithm and parameters
+func (s *passwordService) Hash(newPassword string) (string, error) {
+	hash, err := s.hasher.Hash(newPassword)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return hash, nil
+}
+
+// Verify checks a user's password. A nil user or one without a password is checked against a
+// dummy hash and never matches. Matching hashes made with bcrypt or weaker parameters are
+// replaced with a current one.
+func (s *passwordService) Verify(user *models.User, password string) (bool, error) {
+	if user == nil || user.Password == "" {
+		s.hasher.Verify(s.dummyHash, password)
+		return false, nil
+	}
+	ok, err := s.hasher.Verify(user.Password, password)
+	if err != nil {
+		return false, fmt.Errorf("failed to verify password of user %d: %w", user.ID, err)
+	}
+	if ok && s.hasher.NeedsRehash(user.Password) {
+		if hash, err := s.hasher.Hash(password); err != nil {
+			log.Printf("Failed to rehash password of user %d: %v", user.ID, err)
+		} else if err := s.userRepo.UpdatePassword(user.ID, hash); err != nil {
+			log.Printf("Failed to store rehashed password of user %d: %v", user.ID, err)
+		} else {
+			user.Password = hash
+		}
+	}
+	return ok, nil
+}
+
+// SetPassword validates and stores a user's new password, keeping the old one in their history
+func (s *passwordService) SetPassword(user *models.User, newPassword string) error {
+	if err := s.Validate(user, newPassword); err != nil {
+		return err
+	}
+	hash, err := s.Hash(newPassword)
+	if err != nil {
+		return err
+	}
+	keep := s.historySize - 1
+	if keep < 0 {
+		keep = 0
+	}
+	if err := s.historyRepo.ChangePassword(user.ID, user.Password, hash, keep); err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+	user.Password = hash
+	return nil
+}
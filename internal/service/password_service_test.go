@@ -0,0 +1,172 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"clipboard-sync-backend/internal/models"
+	"clipboard-sync-backend/internal/password"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// fakePasswordHistoryRepo keeps each user's earlier hashes in memory, newest first
+type fakePasswordHistoryRepo struct {
+	history map[uint][]string
+	users   *fakeUserRepo
+}
+
+func (r *fakePasswordHistoryRepo) GetPasswordHistory(userID uint, limit int) ([]models.PasswordHistory, error) {
+	var history []models.PasswordHistory
+	for i, hash := range r.history[userID] {
+		if i == limit {
+			break
+		}
+		history = append(history, models.PasswordHistory{UserID: userID, PasswordHash: hash})
+	}
+	return history, nil
+}
+
+func (r *fakePasswordHistoryRepo) ChangePassword(userID uint, oldHash, newHash string, keep int) error {
+	if user, err := r.users.GetUserByID(userID); err == nil {
+		user.Password = newHash
+	}
+	history := r.history[userID]
+	if oldHash != "" {
+		history = append([]string{oldHash}, history...)
+	}
+	if len(history) > keep {
+		history = history[:keep]
+	}
+	r.history[userID] = history
+	return nil
+}
+
+// UpdatePassword stores a rehashed password
+func (r *fakeUserRepo) UpdatePassword(id uint, hash string) error {
+	user, err := r.GetUserByID(id)
+	if err != nil {
+		return err
+	}
+	user.Password = hash
+	return nil
+}
+
+var cheapPasswordParams = password.Params{Memory: 1024, Iterations: 1, Parallelism: 1}
+
+func newTestPasswordService(t *testing.T, params password.Params, historySize int) (PasswordService, *fakePasswordHistoryRepo, *models.User) {
+	t.Helper()
+	users := &fakeUserRepo{users: []*models.User{{ID: 1, Email: "alice@example.com"}}}
+	history := &fakePasswordHistoryRepo{history: make(map[uint][]string), users: users}
+	s := NewPasswordService(password.NewHasher(params), password.Policy{}, history, users, historySize)
+	return s, history, users.users[0]
+}
+
+func TestPasswordHistoryReuse(t *testing.T) {
+	s, history, user := newTestPasswordService(t, cheapPasswordParams, 3)
+
+	for _, pw := range []string{"first password", "second password", "third password"} {
+		if err := s.SetPassword(user, pw); err != nil {
+			t.Fatalf("SetPassword(%q) error = %v", pw, err)
+		}
+	}
+	if n := len(history.history[1]); n != 2 {
+		t.Fatalf("history holds %d hashes, want 2 besides the current password", n)
+	}
+
+	for _, pw := range []string{"third password", "second password", "first password"} {
+		if err := s.Validate(user, pw); !errors.Is(err, ErrPasswordReused) {
+			t.Errorf("Validate(%q) = %v, want ErrPasswordReused", pw, err)
+		}
+	}
+	if err := s.SetPassword(user, "second password"); !errors.Is(err, ErrPasswordReused) {
+		t.Fatalf("SetPassword() with a recent password = %v, want ErrPasswordReused", err)
+	}
+
+	// A fourth password pushes the first out of the history
+	if err := s.SetPassword(user, "fourth password"); err != nil {
+		t.Fatalf("SetPassword() error = %v", err)
+	}
+	if err := s.Validate(user, "first password"); err != nil {
+		t.Fatalf("Validate() with a password older than the history = %v", err)
+	}
+	if err := s.Validate(user, "second password"); !errors.Is(err, ErrPasswordReused) {
+		t.Fatalf("Validate() with a password still in the history = %v, want ErrPasswordReused", err)
+	}
+	// New users have no history to check
+	if err := s.Validate(nil, "fourth password"); err != nil {
+		t.Fatalf("Validate() without a user = %v", err)
+	}
+}
+
+func TestPasswordHistoryLegacyHashes(t *testing.T) {
+	s, history, user := newTestPasswordService(t, cheapPasswordParams, 2)
+	legacy, err := bcrypt.GenerateFromPassword([]byte("old bcrypt password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	history.history[1] = []string{string(legacy)}
+	user.Password, _ = s.Hash("current password")
+
+	if err := s.Validate(user, "old bcrypt password"); !errors.Is(err, ErrPasswordReused) {
+		t.Fatalf("Validate() with a password from a bcrypt history entry = %v, want ErrPasswordReused", err)
+	}
+}
+
+func TestPasswordHistoryDisabled(t *testing.T) {
+	s, history, user := newTestPasswordService(t, cheapPasswordParams, 0)
+	if err := s.SetPassword(user, "same password"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetPassword(user, "same password"); err != nil {
+		t.Fatalf("SetPassword() reusing a password without history = %v", err)
+	}
+	if n := len(history.history[1]); n != 0 {
+		t.Fatalf("history holds %d hashes, want none", n)
+	}
+}
+
+func TestPasswordPolicy(t *testing.T) {
+	s, _, user := newTestPasswordService(t, cheapPasswordParams, 3)
+	if err := s.Validate(user, "short"); !errors.Is(err, ErrPasswordTooShort) {
+		t.Fatalf("Validate() with a short password = %v, want ErrPasswordTooShort", err)
+	}
+	if err := s.Validate(user, strings.Repeat("a", defaultMaxPasswordLength+1)); !errors.Is(err, ErrPasswordTooLong) {
+		t.Fatalf("Validate() with a long password = %v, want ErrPasswordTooLong", err)
+	}
+}
+
+func TestPasswordVerifyRehashes(t *testing.T) {
+	s, _, user := newTestPasswordService(t, cheapPasswordParams, 3)
+	legacy, err := bcrypt.GenerateFromPassword([]byte("correct horse"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user.Password = string(legacy)
+
+	if ok, err := s.Verify(user, "wrong"); ok || err != nil {
+		t.Fatalf("Verify() with a wrong password = %v, %v", ok, err)
+	}
+	if user.Password != string(legacy) {
+		t.Fatal("a wrong password rehashed the stored hash")
+	}
+	if ok, err := s.Verify(user, "correct horse"); !ok || err != nil {
+		t.Fatalf("Verify() = %v, %v", ok, err)
+	}
+	if !strings.HasPrefix(user.Password, "$argon2id$") {
+		t.Fatalf("password = %s, want it upgraded to argon2id", user.Password)
+	}
+	upgraded := user.Password
+
+	// Raising the parameters upgrades the hash again on the next sign-in
+	stronger := cheapPasswordParams
+	stronger.Iterations = 2
+	s2, _, _ := newTestPasswordService(t, stronger, 3)
+	if ok, err := s2.Verify(user, "correct horse"); !ok || err != nil {
+		t.Fatalf("Verify() after raising the parameters = %v, %v", ok, err)
+	}
+	if user.Password == upgraded || !strings.Contains(user.Password, "t=2") {
+		t.Fatalf("password = %s, want it rehashed with t=2", user.Password)
+	}
+}
@@ -0,0 +1,282 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"clipboard-sync-backend/internal/mail"
+	"clipboard-sync-backend/internal/models"
+	"clipboard-sync-backend/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultDeletionGracePeriod = 30 * 24 * time.Hour
+	accountDeletionInterval    = 10 * time.Minute
+	accountDeletionBatch       = 20
+)
+
+var (
+	ErrInvalidUsername      = errors.New("username must be 3 to 50 letters, digits, dots, dashes or underscores")
+	ErrUsernameTaken        = errors.New("username is already taken")
+	ErrInvalidDisplayName   = errors.New("display name must be at most 100 characters")
+	ErrInvalidPictureURL    = errors.New("profile picture must be an http or https URL of at most 255 characters")
+	ErrInvalidLanguage      = errors.New("preferred language must be a language tag such as en-US")
+	ErrDeletionNotScheduled = errors.New("account is not scheduled for deletion")
+)
+
+var (
+	usernamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{3,50}$`)
+	languagePattern = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+)
+
+// ProfileUpdate holds the profile fields a PATCH changes; nil fields are left alone
+type ProfileUpdate struct {
+	Username          *string // Empty clears it
+	DisplayName       *string
+	ProfilePictureURL *string
+	PreferredLanguage *string
+	Email             *string // Takes effect once the new address is verified
+}
+
+// ProfileService defines the interface for a user's own profile and account deletion
+type ProfileService interface {
+	GetProfile(userID uint) (*models.User, error)
+	UpdateProfile(userID uint, update ProfileUpdate, req *RequestInfo) (*models.User, error)
+	ScheduleDeletion(userID uint, password string, req *RequestInfo) (*models.User, error)
+	CancelDeletion(userID uint, req *RequestInfo) (*models.User, error)
+	Run(stop <-chan struct{})
+}
+
+type profileService struct {
+	userRepo       repository.UserRepository
+	accountService AccountService
+	passwords      PasswordService
+	sessionService SessionService
+	mailer         mail.Sender
+	auditService   AuditService
+	gracePeriod    time.Duration
+}
+
+// NewProfileService creates a new ProfileService. Accounts are erased gracePeriod after their
+// owner asks, by Run.
+func NewProfileService(userRepo repository.UserRepository, accountService AccountService, passwords PasswordService, sessionService SessionService, mailer mail.Sender, auditService AuditService, gracePeriod time.Duration) ProfileService {
+	if gracePeriod <= 0 {
+		gracePeriod = defaultDeletionGracePeriod
+	}
+	return &profileService{
+		userRepo:       userRepo,
+		accountService: accountService,
+		passwords:      passwords,
+		sessionService: sessionService,
+		mailer:         mailer,
+		auditService:   auditService,
+		gracePeriod:    gracePeriod,
+	}
+}
+
+func (s *profileService) getUser(userID uint) (*models.User, error) {
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("error retrieving user: %w", err)
+	}
+	return user, nil
+}
+
+// GetProfile returns the user's profile
+func (s *profileService) GetProfile(userID uint) (*models.User, error) {
+	return s.getUser(userID)
+}
+
+// UpdateProfile validates and applies a profile update. A new email address is not applied
+// here; a verification link is sent to it instead.
+func (s *profileService) UpdateProfile(userID uint, update ProfileUpdate, req *RequestInfo) (*models.User, error) {
+	updates := map[string]interface{}{}
+	if update.Username != nil {
+		username := strings.TrimSpace(*update.Username)
+		if username == "" {
+			updates["username"] = nil
+		} else {
+			if !usernamePattern.MatchString(username) {
+				return nil, ErrInvalidUsername
+			}
+			taken, err := s.userRepo.UsernameTaken(username, userID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to check username: %w", err)
+			}
+			if taken {
+				return nil, ErrUsernameTaken
+			}
+			updates["username"] = username
+		}
+	}
+	if update.DisplayName != nil {
+		name := strings.TrimSpace(*update.DisplayName)
+		if len([]rune(name)) > 100 {
+			return nil, ErrInvalidDisplayName
+		}
+		updates["display_name"] = name
+	}
+	if update.ProfilePictureURL != nil {
+		picture := strings.TrimSpace(*update.ProfilePictureURL)
+		if picture != "" {
+			u, err := url.Parse(picture)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || len(picture) > 255 {
+				return nil, ErrInvalidPictureURL
+			}
+		}
+		updates["profile_picture_url"] = picture
+	}
+	if update.PreferredLanguage != nil {
+		language := strings.TrimSpace(*update.PreferredLanguage)
+		if language == "" {
+			language = models.DefaultLanguage
+		}
+		if len(language) > 10 || !languagePattern.MatchString(language) {
+			return nil, ErrInvalidLanguage
+		}
+		updates["preferred_language"] = language
+	}
+
+	if len(updates) > 0 {
+		if err := s.userRepo.UpdateProfile(userID, updates); err != nil {
+			return nil, fmt.Errorf("failed to update profile: %w", err)
+		}
+		fields := make([]string, 0, len(updates))
+		for field := range updates {
+			fields = append(fields, field)
+		}
+		recordAudit(s.auditService, AuditEvent{
+			UserID:  &userID,
+			Action:  models.AuditProfileUpdated,
+			Details: map[string]interface{}{"fields": fields},
+			Request: req,
+		})
+	}
+	if update.Email != nil {
+		if err := s.accountService.RequestEmailChange(userID, *update.Email, req); err != nil {
+			return nil, err
+		}
+	}
+	return s.getUser(userID)
+}
+
+// ScheduleDeletion schedules the account to be erased after the grace period. The user
+// re-authenticates with their password, when the account has one. Scheduling again keeps the
+// original date.
+func (s *profileService) ScheduleDeletion(userID uint, password string, req *RequestInfo) (*models.User, error) {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Password != "" {
+		ok, err := s.passwords.Verify(user, password)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, ErrWrongPassword
+		}
+	}
+	if user.DeletionScheduledAt != nil {
+		return user, nil
+	}
+
+	at := time.Now().Add(s.gracePeriod)
+	if err := s.userRepo.ScheduleDeletion(userID, &at); err != nil {
+		return nil, fmt.Errorf("failed to schedule account deletion: %w", err)
+	}
+	user.DeletionScheduledAt = &at
+
+	log.Printf("User %d scheduled their account for deletion on %s", userID, at.Format(time.RFC3339))
+	recordAudit(s.auditService, AuditEvent{
+		UserID:  &userID,
+		Action:  models.AuditDeletionScheduled,
+		Details: map[string]interface{}{"delete_at": at},
+		Request: req,
+	})
+	body := fmt.Sprintf("Your account %s and everything in it will be deleted on %s.\n\nChanged your mind? Sign in and cancel the deletion before then.\n",
+		user.Email, at.UTC().Format("2 January 2006 15:04 MST"))
+	if err := s.mailer.Send(mail.Message{To: user.Email, Subject: "Your account is scheduled for deletion", Body: body}); err != nil {
+		log.Printf("Failed to send deletion notice to %s: %v", user.Email, err)
+	}
+	return user, nil
+}
+
+// CancelDeletion keeps an account that was scheduled for deletion
+func (s *profileService) CancelDeletion(userID uint, req *RequestInfo) (*models.User, error) {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.DeletionScheduledAt == nil {
+		return nil, ErrDeletionNotScheduled
+	}
+	if err := s.userRepo.ScheduleDeletion(userID, nil); err != nil {
+		return nil, fmt.Errorf("failed to cancel account deletion: %w", err)
+	}
+	user.DeletionScheduledAt = nil
+
+	log.Printf("User %d cancelled the deletion of their account", userID)
+	recordAudit(s.auditService, AuditEvent{UserID: &userID, Action: models.AuditDeletionCancelled, Request: req})
+	return user, nil
+}
+
+// Run erases accounts whose grace period has ended; it runs until stop is closed
+func (s *profileService) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(accountDeletionInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		users, err := s.userRepo.GetUsersDueForDeletion(time.Now(), accountDeletionBatch)
+		if err != nil {
+			log.Printf("Failed to find accounts due for deletion: %v", err)
+			continue
+		}
+		for i := range users {
+			s.purge(&users[i])
+		}
+	}
+}
+
+// purge signs the user out everywhere, erases their data and says goodbye
+func (s *profileService) purge(user *models.User) {
+	if s.sessionService != nil {
+		if err := s.sessionService.RevokeAllSessions(user.ID, 0, models.SessionRevokedAccountDeleted, nil); err != nil {
+			log.Printf("Failed to revoke sessions of user %d before deletion: %v", user.ID, err)
+		}
+	}
+	files, err := s.userRepo.PurgeUser(user.ID)
+	if err != nil {
+		log.Printf("Failed to delete account of user %d: %v", user.ID, err)
+		return
+	}
+	for _, file := range files {
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			log.Printf("Failed to remove %s of deleted user %d: %v", file, user.ID, err)
+		}
+	}
+
+	log.Printf("Deleted account of user %d", user.ID)
+	recordAudit(s.auditService, AuditEvent{UserID: &user.ID, Action: models.AuditAccountDeleted})
+	body := fmt.Sprintf("Your account %s and its data have been deleted, as you asked. Thank you for using Clipboard Sync.\n", user.Email)
+	if err := s.mailer.Send(mail.Message{To: user.Email, Subject: "Your account has been deleted", Body: body}); err != nil {
+		log.Printf("Failed to send deletion confirmation to %s: %v", user.Email, err)
+	}
+}
@@ -0,0 +1,278 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"clipboard-sync-backend/internal/auth"
+	"clipboard-sync-backend/internal/models"
+	"clipboard-sync-backend/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+const refreshTokenPrefix = "rt_"
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token was already used; the session has been revoked")
+	ErrSessionNotFound     = errors.New("session not found")
+)
+
+// TokenPair is what a client receives when signing in or refreshing
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	ExpiresIn    int    `json:"expires_in"` // Access token lifetime in seconds
+	SessionID    uint   `json:"session_id"`
+}
+
+// SessionService defines the interface for signed-in sessions and their tokens
+type SessionService interface {
+	CreateSession(userID uint, device string, req *RequestInfo) (*TokenPair, error)
+	CreateImpersonationSession(userID, impersonatorID uint, device string, req *RequestInfo) (*TokenPair, error)
+	Refresh(refreshToken string, req *RequestInfo) (*TokenPair, error)
+	ListSessions(userID, currentSessionID uint) ([]models.Session, error)
+	RevokeSession(userID, sessionID uint, reason string, req *RequestInfo) error
+	RevokeAllSessions(userID, exceptSessionID uint, reason string, req *RequestInfo) error
+	SessionActive(sessionID uint) (bool, error)
+}
+
+type sessionService struct {
+	sessionRepo  repository.SessionRepository
+	tokens       *auth.TokenManager
+	notifier     SessionNotifier
+	auditService AuditService
+	refreshTTL   time.Duration
+}
+
+// NewSessionService creates a new SessionService. Sessions end when their refresh token goes
+// unused for refreshTTL; the notifier, when set, disconnects live connections of revoked sessions.
+func NewSessionService(sessionRepo repository.SessionRepository, tokens *auth.TokenManager, notifier SessionNotifier, auditService AuditService, refreshTTL time.Duration) SessionService {
+	if refreshTTL <= 0 {
+		refreshTTL = 30 * 24 * time.Hour
+	}
+	return &sessionService{
+		sessionRepo:  sessionRepo,
+		tokens:       tokens,
+		notifier:     notifier,
+		auditService: auditService,
+		refreshTTL:   refreshTTL,
+	}
+}
+
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// newRefreshToken returns a fresh token and its stored record
+func (s *sessionService) newRefreshToken(now time.Time) (string, *models.RefreshToken, error) {
+	secret, err := randomHex(32)
+	if err != nil {
+		return "", nil, err
+	}
+	token := refreshTokenPrefix + secret
+	return token, &models.RefreshToken{TokenHash: hashRefreshToken(token), ExpiresAt: now.Add(s.refreshTTL)}, nil
+}
+
+// tokenPair issues an access token for the session alongside its new refresh token
+func (s *sessionService) tokenPair(session *models.Session, refreshToken string) (*TokenPair, error) {
+	accessToken, err := s.tokens.GenerateToken(session.UserID, session.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(s.tokens.AccessTokenTTL().Seconds()),
+		SessionID:    session.ID,
+	}, nil
+}
+
+// CreateSession signs a user in on a device and returns its first token pair
+func (s *sessionService) CreateSession(userID uint, device string, req *RequestInfo) (*TokenPair, error) {
+	now := time.Now()
+	token, record, err := s.newRefreshToken(now)
+	if err != nil {
+		return nil, err
+	}
+	session := &models.Session{
+		UserID:     userID,
+		Device:     device,
+		LastUsedAt: now,
+		ExpiresAt:  record.ExpiresAt,
+	}
+	if req != nil {
+		session.IPAddress = req.IP
+		session.UserAgent = req.UserAgent
+		if session.Device == "" {
+			session.Device = req.UserAgent
+		}
+	}
+	if len(session.Device) > 255 {
+		session.Device = session.Device[:255]
+	}
+	if err := s.sessionRepo.CreateSession(session, record); err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+	return s.tokenPair(session, token)
+}
+
+// CreateImpersonationSession lets an administrator act as a user for the lifetime of one access
+// token. No refresh token is handed out, so the session cannot be extended.
+func (s *sessionService) CreateImpersonationSession(userID, impersonatorID uint, device string, req *RequestInfo) (*TokenPair, error) {
+	now := time.Now()
+	// The session still needs a refresh token record; it is never revealed
+	_, record, err := s.newRefreshToken(now)
+	if err != nil {
+		return nil, err
+	}
+	record.ExpiresAt = now.Add(s.tokens.AccessTokenTTL())
+	session := &models.Session{
+		UserID:         userID,
+		Device:         device,
+		LastUsedAt:     now,
+		ExpiresAt:      record.ExpiresAt,
+		ImpersonatorID: &impersonatorID,
+	}
+	if req != nil {
+		session.IPAddress = req.IP
+		session.UserAgent = req.UserAgent
+	}
+	if err := s.sessionRepo.CreateSession(session, record); err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	accessToken, err := s.tokens.GenerateImpersonationToken(userID, session.ID, impersonatorID)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenPair{
+		AccessToken: accessToken,
+		ExpiresIn:   int(s.tokens.AccessTokenTTL().Seconds()),
+		SessionID:   session.ID,
+	}, nil
+}
+
+// Refresh exchanges a refresh token for a new pair. Each refresh token works once; presenting
+// one again revokes the whole session, since either the client or an attacker holds a copy.
+func (s *sessionService) Refresh(refreshToken string, req *RequestInfo) (*TokenPair, error) {
+	refreshToken = strings.TrimSpace(refreshToken)
+	if !strings.HasPrefix(refreshToken, refreshTokenPrefix) {
+		return nil, ErrInvalidRefreshToken
+	}
+	now := time.Now()
+	token, next, err := s.newRefreshToken(now)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := s.sessionRepo.RotateRefreshToken(hashRefreshToken(refreshToken), next, now)
+	switch {
+	case errors.Is(err, repository.ErrRefreshTokenReused):
+		log.Printf("Refresh token reuse detected for session %d of user %d", session.ID, session.UserID)
+		s.disconnect(session.ID)
+		recordAudit(s.auditService, AuditEvent{
+			UserID:  &session.UserID,
+			Action:  models.AuditSessionRevoked,
+			Details: map[string]interface{}{"session_id": session.ID, "reason": models.SessionRevokedReuse},
+			Request: req,
+		})
+		return nil, ErrRefreshTokenReused
+	case errors.Is(err, gorm.ErrRecordNotFound), errors.Is(err, repository.ErrSessionInactive):
+		return nil, ErrInvalidRefreshToken
+	case err != nil:
+		return nil, fmt.Errorf("failed to refresh session: %w", err)
+	}
+	return s.tokenPair(session, token)
+}
+
+// ListSessions lists the user's active sessions, flagging the one making the request
+func (s *sessionService) ListSessions(userID, currentSessionID uint) ([]models.Session, error) {
+	sessions, err := s.sessionRepo.GetActiveSessionsByUserID(userID, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentSessionID
+	}
+	return sessions, nil
+}
+
+// RevokeSession signs one of the user's sessions out and closes its live connections
+func (s *sessionService) RevokeSession(userID, sessionID uint, reason string, req *RequestInfo) error {
+	session, err := s.sessionRepo.GetSessionByID(sessionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrSessionNotFound
+		}
+		return fmt.Errorf("failed to get session: %w", err)
+	}
+	if session.UserID != userID || session.RevokedAt != nil {
+		return ErrSessionNotFound
+	}
+	if err := s.sessionRepo.RevokeSession(sessionID, reason, time.Now()); err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	s.disconnect(sessionID)
+
+	action := models.AuditSessionRevoked
+	if reason == models.SessionRevokedLogout {
+		action = models.AuditUserLogout
+	}
+	recordAudit(s.auditService, AuditEvent{
+		UserID:  &userID,
+		Action:  action,
+		Details: map[string]interface{}{"session_id": sessionID, "device": session.Device, "reason": reason},
+		Request: req,
+	})
+	return nil
+}
+
+// RevokeAllSessions signs the user out everywhere except exceptSessionID (0 to include every
+// session), e.g. after a password change
+func (s *sessionService) RevokeAllSessions(userID, exceptSessionID uint, reason string, req *RequestInfo) error {
+	ids, err := s.sessionRepo.RevokeUserSessions(userID, exceptSessionID, reason, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	for _, id := range ids {
+		s.disconnect(id)
+	}
+	if len(ids) > 0 {
+		recordAudit(s.auditService, AuditEvent{
+			UserID:  &userID,
+			Action:  models.AuditSessionRevoked,
+			Details: map[string]interface{}{"session_ids": ids, "reason": reason},
+			Request: req,
+		})
+	}
+	return nil
+}
+
+// SessionActive reports whether a session may still be used; it backs auth.AuthMiddleware
+func (s *sessionService) SessionActive(sessionID uint) (bool, error) {
+	if sessionID == 0 {
+		return false, nil
+	}
+	session, err := s.sessionRepo.GetSessionByID(sessionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return session.Active(time.Now()), nil
+}
+
+func (s *sessionService) disconnect(sessionID uint) {
+	if s.notifier != nil {
+		s.notifier.DisconnectSession(sessionID)
+	}
+}
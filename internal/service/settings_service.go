@@ -0,0 +1,135 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"time"
+
+	"clipboard-sync-backend/internal/models"
+	"clipboard-sync-backend/internal/repository"
+	"clipboard-sync-backend/internal/settings"
+
+	"gorm.io/gorm"
+)
+
+// EventSettingsUpdated is pushed to a user's devices when their settings change
+const EventSettingsUpdated = "settings_updated"
+
+// settingsSaveAttempts bounds how often a patch is reapplied when it races another change
+const settingsSaveAttempts = 3
+
+var (
+	ErrInvalidSettingsPatch = errors.New("settings patch must be a JSON object")
+	ErrSettingsBusy         = errors.New("settings are being changed elsewhere; try again")
+)
+
+// UserSettingsView is a user's effective settings: what they changed on top of the defaults
+type UserSettingsView struct {
+	Settings  map[string]interface{} `json:"settings"`
+	Version   int64                  `json:"version"` // Zero until the user first changes a setting
+	UpdatedAt *time.Time             `json:"updated_at,omitempty"`
+}
+
+// SettingsService defines the interface for per-user settings
+type SettingsService interface {
+	GetSettings(userID uint) (*UserSettingsView, error)
+	UpdateSettings(userID uint, patch map[string]interface{}, req *RequestInfo) (*UserSettingsView, error)
+}
+
+type settingsService struct {
+	settingsRepo repository.SettingsRepository
+	notifier     Notifier
+	auditService AuditService
+}
+
+// NewSettingsService creates a new SettingsService. Changes are pushed to the user's connected
+// devices through notifier.
+func NewSettingsService(settingsRepo repository.SettingsRepository, notifier Notifier, auditService AuditService) SettingsService {
+	return &settingsService{settingsRepo: settingsRepo, notifier: notifier, auditService: auditService}
+}
+
+// loadSettings returns the user's stored settings, or an unsaved empty row if they never changed any
+func (s *settingsService) loadSettings(userID uint) (*models.UserSettings, error) {
+	stored, err := s.settingsRepo.GetSettingsByUserID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &models.UserSettings{UserID: userID, SettingsData: models.SettingsDocument{}}, nil
+		}
+		return nil, fmt.Errorf("failed to get settings: %w", err)
+	}
+	return stored, nil
+}
+
+func settingsView(stored *models.UserSettings) *UserSettingsView {
+	view := &UserSettingsView{
+		Settings: settings.Effective(stored.SettingsData),
+		Version:  stored.Version,
+	}
+	if stored.ID != 0 {
+		updatedAt := stored.UpdatedAt
+		view.UpdatedAt = &updatedAt
+	}
+	return view
+}
+
+// GetSettings returns the user's effective settings
+func (s *settingsService) GetSettings(userID uint) (*UserSettingsView, error) {
+	stored, err := s.loadSettings(userID)
+	if err != nil {
+		return nil, err
+	}
+	return settingsView(stored), nil
+}
+
+// UpdateSettings applies a JSON merge patch (RFC 7396) to the user's settings; null resets a
+// setting to its default. The result must satisfy the settings schema, otherwise nothing is
+// saved and a *settings.ValidationError lists every problem. Connected devices are sent the new
+// settings.
+func (s *settingsService) UpdateSettings(userID uint, patch map[string]interface{}, req *RequestInfo) (*UserSettingsView, error) {
+	if patch == nil {
+		return nil, ErrInvalidSettingsPatch
+	}
+
+	for attempt := 0; attempt < settingsSaveAttempts; attempt++ {
+		stored, err := s.loadSettings(userID)
+		if err != nil {
+			return nil, err
+		}
+		next := settings.MergePatch(stored.SettingsData, patch)
+		if err := settings.Validate(next); err != nil {
+			return nil, err
+		}
+		if reflect.DeepEqual(next, map[string]interface{}(stored.SettingsData)) {
+			return settingsView(stored), nil
+		}
+
+		stored.SettingsData = next
+		if err := s.settingsRepo.SaveSettings(stored); err != nil {
+			if errors.Is(err, repository.ErrSettingsVersionConflict) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to save settings: %w", err)
+		}
+
+		view := settingsView(stored)
+		changed := make([]string, 0, len(patch))
+		for key := range patch {
+			changed = append(changed, key)
+		}
+		sort.Strings(changed)
+		recordAudit(s.auditService, AuditEvent{
+			UserID:  &userID,
+			Action:  models.AuditSettingsUpdated,
+			Details: map[string]interface{}{"changed": changed, "version": stored.Version},
+			Request: req,
+		})
+		notifyUser(s.notifier, userID, EventSettingsUpdated, view)
+		return view, nil
+	}
+
+	log.Printf("Gave up updating settings of user %d after %d conflicting attempts", userID, settingsSaveAttempts)
+	return nil, ErrSettingsBusy
+}
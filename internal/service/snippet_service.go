@@ -0,0 +1,444 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"clipboard-sync-backend/internal/models"
+	"clipboard-sync-backend/internal/policy"
+	"clipboard-sync-backend/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrSnippetNotFound       = errors.New("snippet not found")
+	ErrSnippetFolderNotFound = errors.New("snippet folder not found")
+	ErrSnippetReadOnly       = errors.New("only the owner can modify a shared snippet")
+	ErrAbbreviationTaken     = errors.New("abbreviation is already used by another snippet")
+	ErrDeviceNotConnected    = errors.New("target device is not connected")
+	ErrFolderCycle           = errors.New("a folder cannot be moved into itself")
+	ErrInvalidTimezone       = errors.New("invalid timezone")
+)
+
+// MissingVariablesError lists template placeholders that had neither a value nor a default
+type MissingVariablesError struct {
+	Names []string
+}
+
+func (e *MissingVariablesError) Error() string {
+	return "missing values for placeholders: " + strings.Join(e.Names, ", ")
+}
+
+// SnippetInput holds the fields of a new snippet
+type SnippetInput struct {
+	Name         string
+	Body         string
+	Abbreviation string
+	FolderID     *uint
+	TeamID       *uint
+	Tags         []string
+}
+
+// SnippetUpdate holds optional changes to a snippet; nil fields are left unchanged.
+// ClearFolder and Unshare remove the folder and team share respectively.
+type SnippetUpdate struct {
+	Name         *string
+	Body         *string
+	Abbreviation *string
+	FolderID     *uint
+	ClearFolder  bool
+	TeamID       *uint
+	Unshare      bool
+	Tags         []string
+}
+
+// ExpandOptions controls placeholder expansion
+type ExpandOptions struct {
+	Variables map[string]string
+	Timezone  string // IANA name used for {{date}}/{{time}}; defaults to UTC
+}
+
+// SnippetService defines the interface for snippet library business logic
+type SnippetService interface {
+	CreateSnippet(userID uint, input SnippetInput) (*models.Snippet, error)
+	GetSnippet(userID, snippetID uint) (*models.Snippet, error)
+	GetSnippetByAbbreviation(userID uint, abbreviation string) (*models.Snippet, error)
+	ListSnippets(userID uint, filter repository.SnippetFilter, limit, offset int) ([]models.Snippet, error)
+	UpdateSnippet(userID, snippetID uint, update SnippetUpdate) (*models.Snippet, error)
+	DeleteSnippet(userID, snippetID uint) error
+	ExpandSnippet(userID, snippetID uint, opts ExpandOptions) (string, error)
+	PushSnippet(userID, snippetID uint, opts ExpandOptions, device string) (*models.ClipboardEntry, error)
+
+	CreateFolder(userID uint, name string, parentID *uint) (*models.SnippetFolder, error)
+	UpdateFolder(userID, folderID uint, name *string, parentID *uint, moveToRoot bool) (*models.SnippetFolder, error)
+	DeleteFolder(userID, folderID uint) error
+	ListFolders(userID uint) ([]models.SnippetFolder, error)
+}
+
+type snippetService struct {
+	snippetRepo      repository.SnippetRepository
+	teamRepo         repository.TeamRepository
+	clipboardRepo    repository.ClipboardRepository
+	clipboardService ClipboardService
+	notifier         DeviceNotifier
+}
+
+// NewSnippetService creates a new SnippetService
+func NewSnippetService(snippetRepo repository.SnippetRepository, teamRepo repository.TeamRepository, clipboardRepo repository.ClipboardRepository, clipboardService ClipboardService, notifier DeviceNotifier) SnippetService {
+	return &snippetService{
+		snippetRepo:      snippetRepo,
+		teamRepo:         teamRepo,
+		clipboardRepo:    clipboardRepo,
+		clipboardService: clipboardService,
+		notifier:         notifier,
+	}
+}
+
+func normalizeTags(tags []string) []string {
+	if tags == nil {
+		return nil
+	}
+	seen := make(map[string]bool, len(tags))
+	out := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(strings.ReplaceAll(tag, ",", " ")))
+		if tag != "" && !seen[tag] {
+			seen[tag] = true
+			out = append(out, tag)
+		}
+	}
+	return out
+}
+
+// checkTeam verifies the user's team role grants perm; sharing a snippet counts as posting
+func (s *snippetService) checkTeam(userID uint, teamID *uint, perm policy.Permission) error {
+	if teamID == nil {
+		return nil
+	}
+	_, err := authorize(s.teamRepo, *teamID, userID, perm)
+	return err
+}
+
+func (s *snippetService) checkFolder(userID uint, folderID *uint) error {
+	if folderID == nil {
+		return nil
+	}
+	folder, err := s.snippetRepo.GetFolderByID(*folderID)
+	if err != nil || folder.UserID != userID {
+		return ErrSnippetFolderNotFound
+	}
+	return nil
+}
+
+func (s *snippetService) checkAbbreviation(userID uint, abbreviation string, exceptID uint) error {
+	if abbreviation == "" {
+		return nil
+	}
+	existing, err := s.snippetRepo.GetSnippetByAbbreviation(userID, abbreviation)
+	if err == nil && existing.ID != exceptID {
+		return ErrAbbreviationTaken
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("failed to check abbreviation: %w", err)
+	}
+	return nil
+}
+
+// CreateSnippet stores a new snippet in the user's library
+func (s *snippetService) CreateSnippet(userID uint, input SnippetInput) (*models.Snippet, error) {
+	abbreviation := strings.TrimSpace(input.Abbreviation)
+	if err := s.checkFolder(userID, input.FolderID); err != nil {
+		return nil, err
+	}
+	if err := s.checkTeam(userID, input.TeamID, policy.PostEntry); err != nil {
+		return nil, err
+	}
+	if err := s.checkAbbreviation(userID, abbreviation, 0); err != nil {
+		return nil, err
+	}
+
+	tags := normalizeTags(input.Tags)
+	if tags == nil {
+		tags = []string{}
+	}
+	snippet := &models.Snippet{
+		UserID:       userID,
+		FolderID:     input.FolderID,
+		TeamID:       input.TeamID,
+		Name:         strings.TrimSpace(input.Name),
+		Body:         input.Body,
+		Abbreviation: abbreviation,
+		TagList:      tags,
+	}
+	if err := s.snippetRepo.CreateSnippet(snippet); err != nil {
+		return nil, fmt.Errorf("failed to create snippet: %w", err)
+	}
+
+	log.Printf("Snippet %d created by user %d", snippet.ID, userID)
+	return snippet, nil
+}
+
+// GetSnippet returns a snippet the user owns or that is shared with one of their teams
+func (s *snippetService) GetSnippet(userID, snippetID uint) (*models.Snippet, error) {
+	snippet, err := s.snippetRepo.GetSnippetByID(snippetID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSnippetNotFound
+		}
+		return nil, fmt.Errorf("failed to get snippet: %w", err)
+	}
+	if snippet.UserID == userID {
+		return snippet, nil
+	}
+	if snippet.TeamID != nil {
+		if err := s.checkTeam(userID, snippet.TeamID, policy.ReadHistory); err == nil {
+			return snippet, nil
+		}
+	}
+	return nil, ErrSnippetNotFound
+}
+
+// GetSnippetByAbbreviation resolves a keyboard trigger to one of the user's snippets
+func (s *snippetService) GetSnippetByAbbreviation(userID uint, abbreviation string) (*models.Snippet, error) {
+	snippet, err := s.snippetRepo.GetSnippetByAbbreviation(userID, strings.TrimSpace(abbreviation))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSnippetNotFound
+		}
+		return nil, fmt.Errorf("failed to get snippet: %w", err)
+	}
+	return snippet, nil
+}
+
+// ListSnippets lists the user's snippets, or a team's shared snippets when filter.TeamID is set
+func (s *snippetService) ListSnippets(userID uint, filter repository.SnippetFilter, limit, offset int) ([]models.Snippet, error) {
+	if err := s.checkTeam(userID, filter.TeamID, policy.ReadHistory); err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	filter.Tag = strings.ToLower(strings.TrimSpace(filter.Tag))
+
+	snippets, err := s.snippetRepo.ListSnippets(userID, filter, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snippets: %w", err)
+	}
+	return snippets, nil
+}
+
+// UpdateSnippet applies partial changes; only the owner may modify a snippet
+func (s *snippetService) UpdateSnippet(userID, snippetID uint, update SnippetUpdate) (*models.Snippet, error) {
+	snippet, err := s.GetSnippet(userID, snippetID)
+	if err != nil {
+		return nil, err
+	}
+	if snippet.UserID != userID {
+		return nil, ErrSnippetReadOnly
+	}
+
+	if update.Name != nil {
+		snippet.Name = strings.TrimSpace(*update.Name)
+	}
+	if update.Body != nil {
+		snippet.Body = *update.Body
+	}
+	if update.Abbreviation != nil {
+		abbreviation := strings.TrimSpace(*update.Abbreviation)
+		if err := s.checkAbbreviation(userID, abbreviation, snippet.ID); err != nil {
+			return nil, err
+		}
+		snippet.Abbreviation = abbreviation
+	}
+	switch {
+	case update.ClearFolder:
+		snippet.FolderID = nil
+	case update.FolderID != nil:
+		if err := s.checkFolder(userID, update.FolderID); err != nil {
+			return nil, err
+		}
+		snippet.FolderID = update.FolderID
+	}
+	switch {
+	case update.Unshare:
+		snippet.TeamID = nil
+	case update.TeamID != nil:
+		if err := s.checkTeam(userID, update.TeamID, policy.PostEntry); err != nil {
+			return nil, err
+		}
+		snippet.TeamID = update.TeamID
+	}
+	if update.Tags != nil {
+		snippet.TagList = normalizeTags(update.Tags)
+	}
+
+	if err := s.snippetRepo.UpdateSnippet(snippet); err != nil {
+		return nil, fmt.Errorf("failed to update snippet: %w", err)
+	}
+	return snippet, nil
+}
+
+// DeleteSnippet removes one of the user's snippets
+func (s *snippetService) DeleteSnippet(userID, snippetID uint) error {
+	snippet, err := s.GetSnippet(userID, snippetID)
+	if err != nil {
+		return err
+	}
+	if snippet.UserID != userID {
+		return ErrSnippetReadOnly
+	}
+	if err := s.snippetRepo.DeleteSnippet(snippetID); err != nil {
+		return fmt.Errorf("failed to delete snippet: %w", err)
+	}
+	log.Printf("Snippet %d deleted by user %d", snippetID, userID)
+	return nil
+}
+
+// ExpandSnippet renders a snippet's placeholders
+func (s *snippetService) ExpandSnippet(userID, snippetID uint, opts ExpandOptions) (string, error) {
+	snippet, err := s.GetSnippet(userID, snippetID)
+	if err != nil {
+		return "", err
+	}
+
+	loc := time.UTC
+	if opts.Timezone != "" {
+		if loc, err = time.LoadLocation(opts.Timezone); err != nil {
+			return "", ErrInvalidTimezone
+		}
+	}
+
+	ctx := &templateContext{
+		vars: opts.Variables,
+		now:  time.Now().In(loc),
+		clipboard: func() (string, error) {
+			entries, err := s.clipboardRepo.GetEntriesByUserID(userID, 1, 0)
+			if err != nil {
+				return "", fmt.Errorf("failed to load clipboard: %w", err)
+			}
+			// Only textual entries make sense inside a text snippet
+			if len(entries) == 0 || !strings.HasPrefix(entries[0].ContentType, "text/") {
+				return "", nil
+			}
+			return entries[0].Content, nil
+		},
+	}
+	text, missing, err := expandTemplate(snippet.Body, ctx)
+	if err != nil {
+		return "", err
+	}
+	if len(missing) > 0 {
+		return "", &MissingVariablesError{Names: missing}
+	}
+	return text, nil
+}
+
+// PushSnippet expands a snippet, stores the result as a normal clipboard entry and pushes it to
+// the chosen device, or to all of the user's devices when device is empty
+func (s *snippetService) PushSnippet(userID, snippetID uint, opts ExpandOptions, device string) (*models.ClipboardEntry, error) {
+	if device != "" && (s.notifier == nil || !s.notifier.IsDeviceConnected(userID, device)) {
+		return nil, ErrDeviceNotConnected
+	}
+
+	text, err := s.ExpandSnippet(userID, snippetID, opts)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := s.clipboardService.CreateClipboardEntry(userID, "text/plain", text, "snippet")
+	if err != nil {
+		return nil, err
+	}
+
+	if s.notifier != nil {
+		message, err := json.Marshal(entry)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal entry: %w", err)
+		}
+		if device != "" {
+			if !s.notifier.SendToDevice(userID, device, message) {
+				log.Printf("Snippet %d: device %q disconnected before delivery", snippetID, device)
+			}
+		} else {
+			s.notifier.SendToUser(userID, message)
+		}
+	}
+	return entry, nil
+}
+
+// CreateFolder creates a snippet folder, optionally nested under parentID
+func (s *snippetService) CreateFolder(userID uint, name string, parentID *uint) (*models.SnippetFolder, error) {
+	if err := s.checkFolder(userID, parentID); err != nil {
+		return nil, err
+	}
+	folder := &models.SnippetFolder{UserID: userID, Name: strings.TrimSpace(name), ParentID: parentID}
+	if err := s.snippetRepo.CreateFolder(folder); err != nil {
+		return nil, fmt.Errorf("failed to create folder: %w", err)
+	}
+	return folder, nil
+}
+
+// UpdateFolder renames and/or moves a folder
+func (s *snippetService) UpdateFolder(userID, folderID uint, name *string, parentID *uint, moveToRoot bool) (*models.SnippetFolder, error) {
+	folder, err := s.snippetRepo.GetFolderByID(folderID)
+	if err != nil || folder.UserID != userID {
+		return nil, ErrSnippetFolderNotFound
+	}
+
+	if name != nil {
+		folder.Name = strings.TrimSpace(*name)
+	}
+	switch {
+	case moveToRoot:
+		folder.ParentID = nil
+	case parentID != nil:
+		if err := s.checkFolder(userID, parentID); err != nil {
+			return nil, err
+		}
+		// Walk up from the new parent to make sure we're not creating a cycle
+		for id := parentID; id != nil; {
+			if *id == folder.ID {
+				return nil, ErrFolderCycle
+			}
+			ancestor, err := s.snippetRepo.GetFolderByID(*id)
+			if err != nil {
+				return nil, fmt.Errorf("failed to check folder hierarchy: %w", err)
+			}
+			id = ancestor.ParentID
+		}
+		folder.ParentID = parentID
+	}
+
+	if err := s.snippetRepo.UpdateFolder(folder); err != nil {
+		return nil, fmt.Errorf("failed to update folder: %w", err)
+	}
+	return folder, nil
+}
+
+// DeleteFolder deletes a folder; its contents move up to the parent folder
+func (s *snippetService) DeleteFolder(userID, folderID uint) error {
+	folder, err := s.snippetRepo.GetFolderByID(folderID)
+	if err != nil || folder.UserID != userID {
+		return ErrSnippetFolderNotFound
+	}
+	if err := s.snippetRepo.DeleteFolder(folderID); err != nil {
+		return fmt.Errorf("failed to delete folder: %w", err)
+	}
+	return nil
+}
+
+// ListFolders lists all of the user's snippet folders
+func (s *snippetService) ListFolders(userID uint) ([]models.SnippetFolder, error) {
+	folders, err := s.snippetRepo.GetFoldersByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list folders: %w", err)
+	}
+	return folders, nil
+}
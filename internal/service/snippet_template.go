@@ -0,0 +1,88 @@
+package service
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// placeholderPattern matches {{name}} and {{name|default value}}
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_.-]*)\s*(?:\|([^}]*))?\}\}`)
+
+// Built-in snippet placeholders
+const (
+	PlaceholderDate      = "date"      // 2006-01-02
+	PlaceholderTime      = "time"      // 15:04
+	PlaceholderDateTime  = "datetime"  // RFC 3339
+	PlaceholderWeekday   = "weekday"   // Monday
+	PlaceholderClipboard = "clipboard" // Most recent clipboard entry
+)
+
+// templateContext resolves placeholders. Caller-supplied variables win over built-ins so a
+// client can, for example, pass its own local {{date}}.
+type templateContext struct {
+	vars      map[string]string
+	now       time.Time
+	clipboard func() (string, error)
+}
+
+func (t *templateContext) lookup(name string) (string, bool, error) {
+	if v, ok := t.vars[name]; ok {
+		return v, true, nil
+	}
+	switch name {
+	case PlaceholderDate:
+		return t.now.Format("2006-01-02"), true, nil
+	case PlaceholderTime:
+		return t.now.Format("15:04"), true, nil
+	case PlaceholderDateTime:
+		return t.now.Format(time.RFC3339), true, nil
+	case PlaceholderWeekday:
+		return t.now.Weekday().String(), true, nil
+	case PlaceholderClipboard:
+		if t.clipboard == nil {
+			return "", false, nil
+		}
+		v, err := t.clipboard()
+		return v, err == nil, err
+	}
+	return "", false, nil
+}
+
+// expandTemplate replaces every placeholder in body. Placeholders without a value or default are
+// returned as missing, sorted and deduplicated.
+func expandTemplate(body string, ctx *templateContext) (string, []string, error) {
+	var (
+		missing  = map[string]bool{}
+		firstErr error
+	)
+	out := placeholderPattern.ReplaceAllStringFunc(body, func(match string) string {
+		parts := placeholderPattern.FindStringSubmatch(match)
+		name, def := parts[1], parts[2]
+		hasDefault := strings.Contains(match, "|")
+
+		v, ok, err := ctx.lookup(name)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		if ok {
+			return v
+		}
+		if hasDefault {
+			return def
+		}
+		missing[name] = true
+		return match
+	})
+	if firstErr != nil {
+		return "", nil, firstErr
+	}
+
+	names := make([]string, 0, len(missing))
+	for name := range missing {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return out, names, nil
+}
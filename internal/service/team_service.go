@@ -0,0 +1,408 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"clipboard-sync-backend/internal/models"
+	"clipboard-sync-backend/internal/policy"
+	"clipboard-sync-backend/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrTeamNotFound       = errors.New("team not found")
+	ErrTeamNameTaken      = errors.New("team name is already taken")
+	ErrInvalidTeamName    = errors.New("team name must be between 1 and 100 characters")
+	ErrNotTeamMember      = errors.New("you are not a member of this team")
+	ErrTeamPermission     = errors.New("your team role does not allow this")
+	ErrTeamMemberNotFound = errors.New("team member not found")
+	ErrInvalidTeamRole    = errors.New("invalid team role")
+	ErrLastTeamOwner      = repository.ErrLastTeamOwner
+	// ErrTwoFactorRequired wraps ErrTeamPermission so existing handling treats it as forbidden
+	ErrTwoFactorRequired = fmt.Errorf("%w: this team requires two-factor authentication", ErrTeamPermission)
+)
+
+// TeamMembership is one of the caller's teams together with their role in it
+type TeamMembership struct {
+	Team     models.Team `json:"team"`
+	Role     string      `json:"role"`
+	JoinedAt time.Time   `json:"joined_at"`
+}
+
+// TeamMemberInfo describes a member of a team
+type TeamMemberInfo struct {
+	UserID   uint      `json:"user_id"`
+	Email    string    `json:"email"`
+	Role     string    `json:"role"`
+	JoinedAt time.Time `json:"joined_at"`
+	// Whether the member has two-factor authentication, so admins can see who a requirement locks out
+	TwoFactorEnabled bool `json:"two_factor_enabled"`
+}
+
+// TeamService defines the interface for team management business logic
+type TeamService interface {
+	CreateTeam(userID uint, name string) (*models.Team, error)
+	GetTeam(userID, teamID uint) (*models.Team, error)
+	RenameTeam(userID, teamID uint, name string) (*models.Team, error)
+	SetTwoFactorRequired(userID, teamID uint, required bool) (*models.Team, error)
+	DeleteTeam(userID, teamID uint) error
+	ListMyTeams(userID uint) ([]TeamMembership, error)
+	ListMembers(userID, teamID uint) ([]TeamMemberInfo, error)
+	ChangeMemberRole(userID, teamID, memberUserID uint, role string) error
+	RemoveMember(userID, teamID, memberUserID uint) error
+	LeaveTeam(userID, teamID uint) error
+	Authorize(userID, teamID uint, perm policy.Permission) error
+}
+
+type teamService struct {
+	teamRepo     repository.TeamRepository
+	notifier     TeamNotifier
+	auditService AuditService
+}
+
+// NewTeamService creates a new TeamService. The notifier, when set, is told about membership
+// changes so live team delivery follows them immediately.
+func NewTeamService(teamRepo repository.TeamRepository, notifier TeamNotifier, auditService AuditService) TeamService {
+	return &teamService{teamRepo: teamRepo, notifier: notifier, auditService: auditService}
+}
+
+// requireMembership returns the user's membership in a team, or ErrNotTeamMember
+func requireMembership(teamRepo repository.TeamRepository, teamID, userID uint) (*models.TeamMember, error) {
+	member, err := teamRepo.GetMember(teamID, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotTeamMember
+		}
+		return nil, fmt.Errorf("failed to check team membership: %w", err)
+	}
+	return member, nil
+}
+
+// authorize returns the user's membership in a team if their role grants perm. Teams that
+// require two-factor authentication refuse members who have not enabled it.
+func authorize(teamRepo repository.TeamRepository, teamID, userID uint, perm policy.Permission) (*models.TeamMember, error) {
+	member, err := requireMembership(teamRepo, teamID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if member.Team.RequireTwoFactor && !member.User.TwoFactorEnabled {
+		return nil, ErrTwoFactorRequired
+	}
+	if !policy.Can(member.Role, perm) {
+		return nil, ErrTeamPermission
+	}
+	return member, nil
+}
+
+func normalizeTeamName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > 100 {
+		return "", ErrInvalidTeamName
+	}
+	return name, nil
+}
+
+// teamFor loads a team the user belongs to; non-members get ErrTeamNotFound so team IDs don't leak
+func (s *teamService) teamFor(userID, teamID uint) (*models.Team, *models.TeamMember, error) {
+	member, err := requireMembership(s.teamRepo, teamID, userID)
+	if err != nil {
+		if errors.Is(err, ErrNotTeamMember) {
+			return nil, nil, ErrTeamNotFound
+		}
+		return nil, nil, err
+	}
+	team, err := s.teamRepo.GetTeamByID(teamID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrTeamNotFound
+		}
+		return nil, nil, fmt.Errorf("failed to get team: %w", err)
+	}
+	return team, member, nil
+}
+
+// CreateTeam creates a team; the creator becomes its first owner
+func (s *teamService) CreateTeam(userID uint, name string) (*models.Team, error) {
+	name, err := normalizeTeamName(name)
+	if err != nil {
+		return nil, err
+	}
+	taken, err := s.teamRepo.TeamNameExists(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check team name: %w", err)
+	}
+	if taken {
+		return nil, ErrTeamNameTaken
+	}
+
+	team := &models.Team{Name: name, CreatorID: userID}
+	if err := s.teamRepo.CreateTeam(team, models.TeamRoleOwner); err != nil {
+		return nil, fmt.Errorf("failed to create team: %w", err)
+	}
+	if s.notifier != nil {
+		s.notifier.AddTeamMember(team.ID, userID)
+	}
+
+	log.Printf("Team %d (%s) created by user %d", team.ID, team.Name, userID)
+	s.audit(userID, team.ID, models.AuditTeamCreated, map[string]interface{}{"name": team.Name})
+	return team, nil
+}
+
+// GetTeam returns a team the user belongs to
+func (s *teamService) GetTeam(userID, teamID uint) (*models.Team, error) {
+	team, _, err := s.teamFor(userID, teamID)
+	return team, err
+}
+
+// RenameTeam changes a team's name
+func (s *teamService) RenameTeam(userID, teamID uint, name string) (*models.Team, error) {
+	team, member, err := s.teamFor(userID, teamID)
+	if err != nil {
+		return nil, err
+	}
+	if !policy.Can(member.Role, policy.ManageTeam) {
+		return nil, ErrTeamPermission
+	}
+	name, err = normalizeTeamName(name)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(name, team.Name) {
+		taken, err := s.teamRepo.TeamNameExists(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check team name: %w", err)
+		}
+		if taken {
+			return nil, ErrTeamNameTaken
+		}
+	}
+
+	oldName := team.Name
+	team.Name = name
+	if err := s.teamRepo.UpdateTeam(team); err != nil {
+		return nil, fmt.Errorf("failed to rename team: %w", err)
+	}
+	s.audit(userID, teamID, models.AuditTeamRenamed, map[string]interface{}{"old_name": oldName, "name": name})
+	return team, nil
+}
+
+// SetTwoFactorRequired turns the team's two-factor requirement on or off. Admins must have
+// two-factor authentication themselves before requiring it, so they cannot lock themselves out.
+func (s *teamService) SetTwoFactorRequired(userID, teamID uint, required bool) (*models.Team, error) {
+	team, member, err := s.teamFor(userID, teamID)
+	if err != nil {
+		return nil, err
+	}
+	if !policy.Can(member.Role, policy.ManageTeam) {
+		return nil, ErrTeamPermission
+	}
+	if required && !member.User.TwoFactorEnabled {
+		return nil, ErrTwoFactorRequired
+	}
+	if team.RequireTwoFactor == required {
+		return team, nil
+	}
+
+	team.RequireTwoFactor = required
+	if err := s.teamRepo.UpdateTeam(team); err != nil {
+		return nil, fmt.Errorf("failed to update team: %w", err)
+	}
+	log.Printf("Team %d: two-factor requirement set to %t by user %d", teamID, required, userID)
+	s.audit(userID, teamID, models.AuditTeamTwoFactorChanged, map[string]interface{}{"required": required})
+
+	// Connected members without two-factor authentication stop receiving team entries now rather
+	// than when they next reconnect; they are subscribed again on a connection made after enabling it
+	if required {
+		members, err := s.teamRepo.GetMembers(teamID)
+		if err != nil {
+			log.Printf("Failed to load members of team %d to apply its two-factor requirement: %v", teamID, err)
+			return team, nil
+		}
+		for _, m := range members {
+			if !m.User.TwoFactorEnabled {
+				s.revokeLiveAccess(teamID, m.UserID, "two_factor_required")
+			}
+		}
+	}
+	return team, nil
+}
+
+// DeleteTeam deletes a team and all memberships
+func (s *teamService) DeleteTeam(userID, teamID uint) error {
+	team, member, err := s.teamFor(userID, teamID)
+	if err != nil {
+		return err
+	}
+	if !policy.Can(member.Role, policy.DeleteTeam) {
+		return ErrTeamPermission
+	}
+	if err := s.teamRepo.DeleteTeam(teamID); err != nil {
+		return fmt.Errorf("failed to delete team: %w", err)
+	}
+	if s.notifier != nil {
+		notifyTeam(s.notifier, teamID, EventTeamAccessRevoked, map[string]interface{}{"team_id": teamID, "reason": "team_deleted"})
+		s.notifier.RemoveTeam(teamID)
+	}
+
+	log.Printf("Team %d deleted by user %d", teamID, userID)
+	s.audit(userID, teamID, models.AuditTeamDeleted, map[string]interface{}{"name": team.Name})
+	return nil
+}
+
+// ListMyTeams lists the teams the user belongs to
+func (s *teamService) ListMyTeams(userID uint) ([]TeamMembership, error) {
+	members, err := s.teamRepo.GetMembershipsByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list teams: %w", err)
+	}
+	teams := make([]TeamMembership, len(members))
+	for i, m := range members {
+		teams[i] = TeamMembership{Team: m.Team, Role: policy.Normalize(m.Role), JoinedAt: m.JoinedAt}
+	}
+	return teams, nil
+}
+
+// ListMembers lists a team's members; any member may see them
+func (s *teamService) ListMembers(userID, teamID uint) ([]TeamMemberInfo, error) {
+	if _, _, err := s.teamFor(userID, teamID); err != nil {
+		return nil, err
+	}
+	members, err := s.teamRepo.GetMembers(teamID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list team members: %w", err)
+	}
+	infos := make([]TeamMemberInfo, len(members))
+	for i, m := range members {
+		infos[i] = TeamMemberInfo{UserID: m.UserID, Email: m.User.Email, Role: policy.Normalize(m.Role), JoinedAt: m.JoinedAt, TwoFactorEnabled: m.User.TwoFactorEnabled}
+	}
+	return infos, nil
+}
+
+// ChangeMemberRole sets a member's role. Members can only manage roles below their own (owners
+// manage anyone), cannot grant a role above their own, and the last owner cannot be demoted.
+func (s *teamService) ChangeMemberRole(userID, teamID, memberUserID uint, role string) error {
+	if !policy.ValidRole(role) {
+		return ErrInvalidTeamRole
+	}
+	_, member, err := s.teamFor(userID, teamID)
+	if err != nil {
+		return err
+	}
+	target, err := s.member(teamID, memberUserID)
+	if err != nil {
+		return err
+	}
+	if memberUserID != userID && !policy.CanManage(member.Role, target.Role) {
+		return ErrTeamPermission
+	}
+	if !policy.CanAssign(member.Role, role) {
+		return ErrTeamPermission
+	}
+	if err := s.teamRepo.UpdateMemberRole(teamID, memberUserID, role); err != nil {
+		return s.membershipError(err, "failed to change member role")
+	}
+
+	log.Printf("Team %d: user %d set role of user %d to %s", teamID, userID, memberUserID, role)
+	s.audit(userID, teamID, models.AuditTeamMemberRoleChanged, map[string]interface{}{
+		"member_user_id": memberUserID,
+		"old_role":       policy.Normalize(target.Role),
+		"role":           role,
+	})
+	return nil
+}
+
+// RemoveMember removes another member from a team; only members ranked below the caller can be
+// removed, except by owners
+func (s *teamService) RemoveMember(userID, teamID, memberUserID uint) error {
+	if memberUserID == userID {
+		return s.LeaveTeam(userID, teamID)
+	}
+	_, member, err := s.teamFor(userID, teamID)
+	if err != nil {
+		return err
+	}
+	target, err := s.member(teamID, memberUserID)
+	if err != nil {
+		return err
+	}
+	if !policy.CanManage(member.Role, target.Role) {
+		return ErrTeamPermission
+	}
+	if err := s.teamRepo.RemoveMember(teamID, memberUserID); err != nil {
+		return s.membershipError(err, "failed to remove team member")
+	}
+	s.revokeLiveAccess(teamID, memberUserID, "removed")
+
+	log.Printf("Team %d: user %d removed user %d", teamID, userID, memberUserID)
+	s.audit(userID, teamID, models.AuditTeamMemberRemoved, map[string]interface{}{
+		"member_user_id": memberUserID,
+		"role":           policy.Normalize(target.Role),
+	})
+	return nil
+}
+
+// LeaveTeam removes the user from a team; the last owner must promote someone first
+func (s *teamService) LeaveTeam(userID, teamID uint) error {
+	_, member, err := s.teamFor(userID, teamID)
+	if err != nil {
+		return err
+	}
+	if err := s.teamRepo.RemoveMember(teamID, userID); err != nil {
+		return s.membershipError(err, "failed to leave team")
+	}
+	s.revokeLiveAccess(teamID, userID, "left")
+
+	log.Printf("Team %d: user %d left", teamID, userID)
+	s.audit(userID, teamID, models.AuditTeamMemberLeft, map[string]interface{}{"role": policy.Normalize(member.Role)})
+	return nil
+}
+
+// Authorize checks that the user's role in a team grants perm. Non-members get ErrNotTeamMember.
+func (s *teamService) Authorize(userID, teamID uint, perm policy.Permission) error {
+	_, err := authorize(s.teamRepo, teamID, userID, perm)
+	return err
+}
+
+// member loads another member of a team
+func (s *teamService) member(teamID, userID uint) (*models.TeamMember, error) {
+	member, err := s.teamRepo.GetMember(teamID, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTeamMemberNotFound
+		}
+		return nil, fmt.Errorf("failed to get team member: %w", err)
+	}
+	return member, nil
+}
+
+// audit records an action a user performed on a team
+func (s *teamService) audit(userID, teamID uint, action string, details map[string]interface{}) {
+	recordAudit(s.auditService, AuditEvent{UserID: &userID, TeamID: &teamID, Action: action, Details: details})
+}
+
+// revokeLiveAccess stops team delivery to a former member's connected devices and tells them why
+func (s *teamService) revokeLiveAccess(teamID, userID uint, reason string) {
+	if s.notifier == nil {
+		return
+	}
+	s.notifier.RemoveTeamMember(teamID, userID)
+	if n, ok := s.notifier.(Notifier); ok {
+		notifyUser(n, userID, EventTeamAccessRevoked, map[string]interface{}{"team_id": teamID, "reason": reason})
+	}
+}
+
+func (s *teamService) membershipError(err error, msg string) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return ErrTeamMemberNotFound
+	case errors.Is(err, repository.ErrLastTeamOwner):
+		return ErrLastTeamOwner
+	default:
+		return fmt.Errorf("%s: %w", msg, err)
+	}
+}
@@ -0,0 +1,851 @@
+package service
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"clipboard-sync-backend/internal/clipformat"
+	"clipboard-sync-backend/internal/models"
+	"clipboard-sync-backend/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// Supported export/import formats
+const (
+	TransferFormatJSON = "json"
+	TransferFormatCSV  = "csv"
+	TransferFormatZIP  = "zip"
+)
+
+// exportFormatVersion is written to every export so future importers can migrate old files
+const exportFormatVersion = 1
+
+// exportBatchSize is the number of entries loaded per query while exporting
+const exportBatchSize = 200
+
+// importProgressInterval controls how often import progress is persisted
+const importProgressInterval = 50
+
+// maxImportRowErrors caps the number of per-row errors kept on an import job
+const maxImportRowErrors = 500
+
+// transferSweepInterval is how often expired export files are removed
+const transferSweepInterval = 10 * time.Minute
+
+// transferSweepBatch is the number of expired exports removed per query
+const transferSweepBatch = 50
+
+// maxImportExpansion bounds how far a ZIP import may decompress: entries.json may be at most this
+// many times transfer.max_import_bytes
+const maxImportExpansion = 10
+
+var (
+	ErrTransferJobNotFound       = errors.New("job not found")
+	ErrUnsupportedTransferFormat = errors.New("unsupported format, expected json, csv or zip")
+	ErrExportNotReady            = errors.New("export is not ready yet")
+	ErrExportExpired             = errors.New("export has expired")
+	ErrImportTooLarge            = errors.New("import file is too large")
+	ErrTransferUnavailable       = errors.New("the server is shutting down; try again shortly")
+)
+
+// errTransferInterrupted fails jobs cut short by a shutdown. Imports can simply be repeated since
+// rows that were already imported are skipped as duplicates.
+var errTransferInterrupted = errors.New("interrupted by a server restart; start it again")
+
+// TransferService defines the interface for clipboard history export and import
+type TransferService interface {
+	StartExport(userID uint, format string) (*models.ExportJob, error)
+	GetExportJob(userID, jobID uint) (*models.ExportJob, error)
+	OpenExport(userID, jobID uint) (*models.ExportJob, *os.File, error)
+	StartImport(userID uint, format string, src io.Reader) (*models.ImportJob, error)
+	GetImportJob(userID, jobID uint) (*models.ImportJob, error)
+
+	// Run removes expired export files until stop is closed. Jobs still running then are
+	// interrupted and marked failed before it returns.
+	Run(stop <-chan struct{})
+}
+
+type transferService struct {
+	jobRepo        repository.TransferJobRepository
+	clipboardRepo  repository.ClipboardRepository
+	dir            string
+	exportTTL      time.Duration
+	maxImportBytes int64
+
+	mu       sync.Mutex
+	stopped  bool
+	stopping chan struct{}  // Closed when Run is told to stop; running jobs check it between rows
+	running  sync.WaitGroup // Jobs in progress
+}
+
+// NewTransferService creates a new TransferService that keeps export and import files under dir
+func NewTransferService(jobRepo repository.TransferJobRepository, clipboardRepo repository.ClipboardRepository, dir string, exportTTL time.Duration, maxImportBytes int64) TransferService {
+	if exportTTL <= 0 {
+		exportTTL = 24 * time.Hour
+	}
+	if maxImportBytes <= 0 {
+		maxImportBytes = 100 << 20
+	}
+	return &transferService{
+		jobRepo:        jobRepo,
+		clipboardRepo:  clipboardRepo,
+		dir:            dir,
+		exportTTL:      exportTTL,
+		maxImportBytes: maxImportBytes,
+		stopping:       make(chan struct{}),
+	}
+}
+
+// start runs a job in the background unless the service is stopping
+func (s *transferService) start(job func()) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped {
+		return false
+	}
+	s.running.Add(1)
+	go func() {
+		defer s.running.Done()
+		job()
+	}()
+	return true
+}
+
+// interrupted reports whether running jobs should give up because the server is stopping
+func (s *transferService) interrupted() bool {
+	select {
+	case <-s.stopping:
+		return true
+	default:
+		return false
+	}
+}
+
+// Run removes expired export files until stop is closed, then interrupts running jobs and
+// waits for them to record that they failed
+func (s *transferService) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(transferSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			s.mu.Lock()
+			s.stopped = true
+			close(s.stopping)
+			s.mu.Unlock()
+			s.running.Wait()
+			return
+		case <-ticker.C:
+		}
+
+		jobs, err := s.jobRepo.GetExpiredExportJobs(time.Now(), transferSweepBatch)
+		if err != nil {
+			log.Printf("Failed to find expired exports: %v", err)
+			continue
+		}
+		for _, job := range jobs {
+			if err := os.Remove(job.FilePath); err != nil && !os.IsNotExist(err) {
+				log.Printf("Failed to remove export %d: %v", job.ID, err)
+				continue
+			}
+			if err := s.jobRepo.ClearExportJobFile(job.ID); err != nil {
+				log.Printf("Failed to clear export %d: %v", job.ID, err)
+			}
+		}
+	}
+}
+
+// exportDocument is the top-level structure of our JSON export format (also entries.json inside
+// ZIPs). writeJSON and readJSONImport stream it an entry at a time rather than using it whole.
+type exportDocument struct {
+	Version    int           `json:"version"`
+	ExportedAt time.Time     `json:"exported_at"`
+	Entries    []exportEntry `json:"entries"`
+}
+
+type exportEntry struct {
+	ContentType  string         `json:"content_type"`
+	Content      string         `json:"content,omitempty"`
+	SourceDevice string         `json:"source_device,omitempty"`
+	CreatedAt    time.Time      `json:"created_at"`
+	Formats      []exportFormat `json:"formats,omitempty"`
+}
+
+type exportFormat struct {
+	MIMEType string `json:"mime_type"`
+	Content  string `json:"content,omitempty"`
+	Blob     string `json:"blob,omitempty"` // Path of the decoded binary inside a ZIP export
+}
+
+var csvHeader = []string{"created_at", "content_type", "source_device", "content"}
+
+func normalizeTransferFormat(format string) (string, error) {
+	switch f := strings.ToLower(strings.TrimSpace(format)); f {
+	case TransferFormatJSON, TransferFormatCSV, TransferFormatZIP:
+		return f, nil
+	case "":
+		return TransferFormatJSON, nil
+	default:
+		return "", ErrUnsupportedTransferFormat
+	}
+}
+
+// StartExport queues an asynchronous export of the user's personal history
+func (s *transferService) StartExport(userID uint, format string) (*models.ExportJob, error) {
+	format, err := normalizeTransferFormat(format)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &models.ExportJob{UserID: userID, Format: format, Status: models.JobPending}
+	if err := s.jobRepo.CreateExportJob(job); err != nil {
+		return nil, fmt.Errorf("failed to create export job: %w", err)
+	}
+
+	queued := *job
+	if !s.start(func() { s.runExport(queued) }) {
+		job.Status = models.JobFailed
+		job.Error = ErrTransferUnavailable.Error()
+		if err := s.jobRepo.UpdateExportJob(job); err != nil {
+			log.Printf("Failed to save export job %d: %v", job.ID, err)
+		}
+		return nil, ErrTransferUnavailable
+	}
+	return job, nil
+}
+
+// GetExportJob returns an export job owned by the user
+func (s *transferService) GetExportJob(userID, jobID uint) (*models.ExportJob, error) {
+	job, err := s.jobRepo.GetExportJobByID(jobID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTransferJobNotFound
+		}
+		return nil, fmt.Errorf("failed to get export job: %w", err)
+	}
+	if job.UserID != userID {
+		return nil, ErrTransferJobNotFound
+	}
+	return job, nil
+}
+
+// OpenExport opens a completed, unexpired export file for download
+func (s *transferService) OpenExport(userID, jobID uint) (*models.ExportJob, *os.File, error) {
+	job, err := s.GetExportJob(userID, jobID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if job.Status != models.JobCompleted {
+		return nil, nil, ErrExportNotReady
+	}
+	if job.ExpiresAt != nil && time.Now().After(*job.ExpiresAt) {
+		return nil, nil, ErrExportExpired
+	}
+	f, err := os.Open(job.FilePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil, ErrExportExpired
+		}
+		return nil, nil, fmt.Errorf("failed to open export: %w", err)
+	}
+	return job, f, nil
+}
+
+func (s *transferService) runExport(job models.ExportJob) {
+	job.Status = models.JobRunning
+	if err := s.jobRepo.UpdateExportJob(&job); err != nil {
+		log.Printf("Failed to mark export job %d as running: %v", job.ID, err)
+	}
+
+	path, count, err := s.writeExport(&job)
+	now := time.Now()
+	job.CompletedAt = &now
+	if err != nil {
+		log.Printf("Export job %d failed: %v", job.ID, err)
+		job.Status = models.JobFailed
+		job.Error = err.Error()
+		if path != "" {
+			os.Remove(path)
+		}
+	} else {
+		expires := now.Add(s.exportTTL)
+		job.Status = models.JobCompleted
+		job.FilePath = path
+		job.EntryCount = count
+		job.ExpiresAt = &expires
+		if info, statErr := os.Stat(path); statErr == nil {
+			job.FileSize = info.Size()
+		}
+		log.Printf("Export job %d completed for user %d: %d entries", job.ID, job.UserID, count)
+	}
+	if err := s.jobRepo.UpdateExportJob(&job); err != nil {
+		log.Printf("Failed to save export job %d: %v", job.ID, err)
+	}
+}
+
+// writeExport streams the user's entries into a file in the requested format
+func (s *transferService) writeExport(job *models.ExportJob) (string, int, error) {
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return "", 0, fmt.Errorf("failed to create export directory: %w", err)
+	}
+	path := filepath.Join(s.dir, fmt.Sprintf("export-%d-%d.%s", job.UserID, job.ID, job.Format))
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create export file: %w", err)
+	}
+	defer f.Close()
+
+	var count int
+	switch job.Format {
+	case TransferFormatCSV:
+		count, err = s.writeCSV(f, job.UserID)
+	case TransferFormatZIP:
+		count, err = s.writeZIP(f, job.UserID)
+	default:
+		w := bufio.NewWriter(f)
+		count, err = s.writeJSON(w, job.UserID, nil)
+		if err == nil {
+			err = w.Flush()
+		}
+	}
+	if err != nil {
+		return path, count, err
+	}
+	return path, count, f.Sync()
+}
+
+// eachEntry iterates over all of the user's personal entries in batches
+func (s *transferService) eachEntry(userID uint, fn func(entry models.ClipboardEntry) error) (int, error) {
+	var afterID uint
+	count := 0
+	for {
+		entries, err := s.clipboardRepo.GetEntriesAfterID(userID, afterID, exportBatchSize)
+		if err != nil {
+			return count, fmt.Errorf("failed to load entries: %w", err)
+		}
+		for _, entry := range entries {
+			if s.interrupted() {
+				return count, errTransferInterrupted
+			}
+			if err := fn(entry); err != nil {
+				return count, err
+			}
+			count++
+			afterID = entry.ID
+		}
+		if len(entries) < exportBatchSize {
+			return count, nil
+		}
+	}
+}
+
+func toExportEntry(entry models.ClipboardEntry) exportEntry {
+	e := exportEntry{
+		ContentType:  entry.ContentType,
+		Content:      entry.Content,
+		SourceDevice: entry.SourceDevice,
+		CreatedAt:    entry.CreatedAt.UTC(),
+	}
+	for _, rep := range entry.Representations {
+		e.Formats = append(e.Formats, exportFormat{MIMEType: rep.MIMEType, Content: rep.Content})
+	}
+	return e
+}
+
+// writeJSON writes the export document entry by entry to avoid holding the whole history in memory.
+// If blobs is non-nil, binary formats are handed to it and referenced by path instead of inlined.
+func (s *transferService) writeJSON(w io.Writer, userID uint, blobs func(index int, format exportFormat) (string, error)) (int, error) {
+	header, err := json.Marshal(struct {
+		Version    int       `json:"version"`
+		ExportedAt time.Time `json:"exported_at"`
+	}{exportFormatVersion, time.Now().UTC()})
+	if err != nil {
+		return 0, err
+	}
+	// Re-open the header object to append the entries array
+	if _, err := io.WriteString(w, string(header[:len(header)-1])+`,"entries":[`); err != nil {
+		return 0, err
+	}
+
+	index := 0
+	count, err := s.eachEntry(userID, func(entry models.ClipboardEntry) error {
+		e := toExportEntry(entry)
+		if blobs != nil {
+			for i, format := range e.Formats {
+				if !strings.HasPrefix(format.MIMEType, "image/") {
+					continue
+				}
+				path, err := blobs(index, format)
+				if err != nil {
+					return err
+				}
+				if path != "" {
+					e.Formats[i].Content = ""
+					e.Formats[i].Blob = path
+				}
+			}
+		}
+		b, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		if index > 0 {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+		index++
+		_, err = w.Write(b)
+		return err
+	})
+	if err != nil {
+		return count, err
+	}
+	_, err = io.WriteString(w, "]}")
+	return count, err
+}
+
+func (s *transferService) writeCSV(w io.Writer, userID uint) (int, error) {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return 0, err
+	}
+	count, err := s.eachEntry(userID, func(entry models.ClipboardEntry) error {
+		return cw.Write([]string{
+			entry.CreatedAt.UTC().Format(time.RFC3339),
+			entry.ContentType,
+			entry.SourceDevice,
+			entry.Content,
+		})
+	})
+	if err != nil {
+		return count, err
+	}
+	cw.Flush()
+	return count, cw.Error()
+}
+
+// writeZIP writes entries.json plus every image representation decoded into blobs/
+func (s *transferService) writeZIP(w io.Writer, userID uint) (int, error) {
+	zw := zip.NewWriter(w)
+
+	// Blobs are written before entries.json is finished, so buffer it in a temp file
+	tmp, err := os.CreateTemp(s.dir, "entries-*.json")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	bw := bufio.NewWriter(tmp)
+	count, err := s.writeJSON(bw, userID, func(index int, format exportFormat) (string, error) {
+		data, decodeErr := base64.StdEncoding.DecodeString(format.Content)
+		if decodeErr != nil {
+			// Not valid base64; keep it inline
+			return "", nil
+		}
+		ext := strings.TrimPrefix(format.MIMEType, "image/")
+		name := fmt.Sprintf("blobs/%06d.%s", index, ext)
+		fw, err := zw.Create(name)
+		if err != nil {
+			return "", err
+		}
+		_, err = fw.Write(data)
+		return name, err
+	})
+	if err != nil {
+		return count, err
+	}
+	if err := bw.Flush(); err != nil {
+		return count, err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return count, err
+	}
+
+	fw, err := zw.Create("entries.json")
+	if err != nil {
+		return count, err
+	}
+	if _, err := io.Copy(fw, tmp); err != nil {
+		return count, err
+	}
+	return count, zw.Close()
+}
+
+// StartImport stores the uploaded file and imports it asynchronously
+func (s *transferService) StartImport(userID uint, format string, src io.Reader) (*models.ImportJob, error) {
+	format, err := normalizeTransferFormat(format)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create import directory: %w", err)
+	}
+
+	f, err := os.CreateTemp(s.dir, fmt.Sprintf("import-%d-*.%s", userID, format))
+	if err != nil {
+		return nil, fmt.Errorf("failed to store import file: %w", err)
+	}
+	n, err := io.Copy(f, io.LimitReader(src, s.maxImportBytes+1))
+	f.Close()
+	if err != nil {
+		os.Remove(f.Name())
+		return nil, fmt.Errorf("failed to store import file: %w", err)
+	}
+	if n > s.maxImportBytes {
+		os.Remove(f.Name())
+		return nil, ErrImportTooLarge
+	}
+
+	job := &models.ImportJob{UserID: userID, Format: format, Status: models.JobPending, FilePath: f.Name()}
+	if err := s.jobRepo.CreateImportJob(job); err != nil {
+		os.Remove(f.Name())
+		return nil, fmt.Errorf("failed to create import job: %w", err)
+	}
+
+	queued := *job
+	if !s.start(func() { s.runImport(queued) }) {
+		os.Remove(f.Name())
+		job.Status = models.JobFailed
+		job.Error = ErrTransferUnavailable.Error()
+		if err := s.jobRepo.UpdateImportJob(job); err != nil {
+			log.Printf("Failed to save import job %d: %v", job.ID, err)
+		}
+		return nil, ErrTransferUnavailable
+	}
+	return job, nil
+}
+
+// GetImportJob returns an import job owned by the user, including progress and row errors
+func (s *transferService) GetImportJob(userID, jobID uint) (*models.ImportJob, error) {
+	job, err := s.jobRepo.GetImportJobByID(jobID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTransferJobNotFound
+		}
+		return nil, fmt.Errorf("failed to get import job: %w", err)
+	}
+	if job.UserID != userID {
+		return nil, ErrTransferJobNotFound
+	}
+	return job, nil
+}
+
+func (s *transferService) runImport(job models.ImportJob) {
+	defer os.Remove(job.FilePath)
+
+	job.Status = models.JobRunning
+	if err := s.jobRepo.UpdateImportJob(&job); err != nil {
+		log.Printf("Failed to mark import job %d as running: %v", job.ID, err)
+	}
+
+	// Rows are imported as they are read, so large files are never held in memory at once
+	seen := make(map[string]bool)
+	err := s.readImport(job.Format, job.FilePath, func(e exportEntry) error {
+		if s.interrupted() {
+			return errTransferInterrupted
+		}
+		row := job.Processed + 1
+		if err := s.importEntry(job.UserID, e, seen); err != nil {
+			if errors.Is(err, errDuplicateImport) {
+				job.Duplicates++
+			} else {
+				job.Failed++
+				if len(job.RowErrors) < maxImportRowErrors {
+					job.RowErrors = append(job.RowErrors, models.ImportRowError{Row: row, Error: err.Error()})
+				}
+			}
+		} else {
+			job.Imported++
+		}
+		job.Processed = row
+		if row%importProgressInterval == 0 {
+			if err := s.jobRepo.UpdateImportJob(&job); err != nil {
+				log.Printf("Failed to save import progress for job %d: %v", job.ID, err)
+			}
+		}
+		return nil
+	})
+	job.TotalRows = job.Processed
+	now := time.Now()
+	if err != nil {
+		log.Printf("Import job %d failed after %d rows: %v", job.ID, job.Processed, err)
+		job.Status = models.JobFailed
+		job.Error = err.Error()
+		job.CompletedAt = &now
+		if err := s.jobRepo.UpdateImportJob(&job); err != nil {
+			log.Printf("Failed to save import job %d: %v", job.ID, err)
+		}
+		return
+	}
+
+	now = time.Now()
+	job.Status = models.JobCompleted
+	job.CompletedAt = &now
+	if err := s.jobRepo.UpdateImportJob(&job); err != nil {
+		log.Printf("Failed to save import job %d: %v", job.ID, err)
+	}
+	log.Printf("Import job %d completed for user %d: %d imported, %d duplicates, %d failed", job.ID, job.UserID, job.Imported, job.Duplicates, job.Failed)
+}
+
+var errDuplicateImport = errors.New("duplicate entry")
+
+// importEntry validates a single row and stores it unless it duplicates an existing entry
+func (s *transferService) importEntry(userID uint, e exportEntry, seen map[string]bool) error {
+	var formats []clipformat.Representation
+	for _, f := range e.Formats {
+		formats = append(formats, clipformat.Representation{MIMEType: f.MIMEType, Content: f.Content})
+	}
+	if len(formats) == 0 {
+		formats = append(formats, clipformat.Representation{MIMEType: e.ContentType, Content: e.Content})
+	}
+	prepared, err := clipformat.Prepare(formats)
+	if err != nil {
+		return err
+	}
+	if e.CreatedAt.IsZero() {
+		return errors.New("missing or invalid created_at")
+	}
+
+	entry := buildEntry(userID, prepared, e.SourceDevice)
+	entry.CreatedAt = e.CreatedAt
+
+	key := fmt.Sprintf("%s@%d", entry.ContentHash, e.CreatedAt.Unix())
+	if seen[key] {
+		return errDuplicateImport
+	}
+	exists, err := s.clipboardRepo.EntryExists(userID, entry.ContentHash, e.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to check for duplicates: %w", err)
+	}
+	seen[key] = true
+	if exists {
+		return errDuplicateImport
+	}
+
+	if err := s.clipboardRepo.CreateEntry(entry); err != nil {
+		return fmt.Errorf("failed to create entry: %w", err)
+	}
+	return nil
+}
+
+// readImport parses an import file and hands each row to fn as it is read
+func (s *transferService) readImport(format, path string, fn func(exportEntry) error) error {
+	if format == TransferFormatZIP {
+		return readZIPImport(path, s.maxImportBytes*maxImportExpansion, fn)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if format == TransferFormatCSV {
+		return readCSVImport(f, fn)
+	}
+	return readJSONImport(f, fn)
+}
+
+// readJSONImport decodes the export document one entry at a time
+func readJSONImport(r io.Reader, fn func(exportEntry) error) error {
+	dec := json.NewDecoder(r)
+	invalid := func(err error) error {
+		if errors.Is(err, ErrImportTooLarge) {
+			return err
+		}
+		return fmt.Errorf("invalid JSON export: %w", err)
+	}
+	expectDelim := func(want json.Delim) error {
+		tok, err := dec.Token()
+		if err != nil {
+			return invalid(err)
+		}
+		if d, ok := tok.(json.Delim); !ok || d != want {
+			return invalid(fmt.Errorf("expected %q, found %v", want, tok))
+		}
+		return nil
+	}
+
+	if err := expectDelim('{'); err != nil {
+		return err
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return invalid(err)
+		}
+		switch tok {
+		case "version":
+			var version int
+			if err := dec.Decode(&version); err != nil {
+				return invalid(err)
+			}
+			if version > exportFormatVersion {
+				return fmt.Errorf("unsupported export version %d", version)
+			}
+		case "entries":
+			if err := expectDelim('['); err != nil {
+				return err
+			}
+			for dec.More() {
+				var e exportEntry
+				if err := dec.Decode(&e); err != nil {
+					return invalid(err)
+				}
+				if err := fn(e); err != nil {
+					return err
+				}
+			}
+			if err := expectDelim(']'); err != nil {
+				return err
+			}
+		default:
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return invalid(err)
+			}
+		}
+	}
+	return expectDelim('}')
+}
+
+// readCSVImport reads the export one record at a time
+func readCSVImport(r io.Reader, fn func(exportEntry) error) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("invalid CSV export: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.TrimSpace(strings.ToLower(name))] = i
+	}
+	for _, name := range []string{"created_at", "content_type", "content"} {
+		if _, ok := columns[name]; !ok {
+			return fmt.Errorf("CSV export is missing the %q column", name)
+		}
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return record[i]
+		}
+		return ""
+	}
+
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("invalid CSV export: %w", err)
+		}
+		// An unparsable timestamp leaves CreatedAt zero and is reported as a row error
+		createdAt, _ := time.Parse(time.RFC3339, field(record, "created_at"))
+		err = fn(exportEntry{
+			ContentType:  field(record, "content_type"),
+			Content:      field(record, "content"),
+			SourceDevice: field(record, "source_device"),
+			CreatedAt:    createdAt,
+		})
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// readZIPImport streams entries.json, which may decompress to at most maxBytes, inlining each
+// entry's blobs as it goes
+func readZIPImport(path string, maxBytes int64, fn func(exportEntry) error) error {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return fmt.Errorf("invalid ZIP export: %w", err)
+	}
+	defer zr.Close()
+
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+	manifest, ok := files["entries.json"]
+	if !ok {
+		return errors.New("ZIP export is missing entries.json")
+	}
+	if manifest.UncompressedSize64 > uint64(maxBytes) {
+		return ErrImportTooLarge
+	}
+	rc, err := manifest.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	// The declared size is not trusted: reading stops once maxBytes have been decompressed
+	return readJSONImport(&capReader{r: rc, n: maxBytes}, func(e exportEntry) error {
+		// Inline blobs back as base64; missing blobs surface as row errors via empty content
+		for j, format := range e.Formats {
+			if format.Blob == "" {
+				continue
+			}
+			blob, ok := files[format.Blob]
+			if !ok || blob.UncompressedSize64 > clipformat.MaxRepresentationSize {
+				continue
+			}
+			brc, err := blob.Open()
+			if err != nil {
+				continue
+			}
+			data, err := io.ReadAll(io.LimitReader(brc, clipformat.MaxRepresentationSize))
+			brc.Close()
+			if err != nil {
+				continue
+			}
+			e.Formats[j].Content = base64.StdEncoding.EncodeToString(data)
+		}
+		return fn(e)
+	})
+}
+
+// capReader reads at most n bytes from r and fails with ErrImportTooLarge past that, where
+// io.LimitReader would end in a misleading EOF
+type capReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *capReader) Read(p []byte) (int, error) {
+	if c.n <= 0 {
+		// Exactly n bytes is fine as long as nothing follows
+		var probe [1]byte
+		if n, err := c.r.Read(probe[:]); n == 0 && err != nil {
+			return 0, err
+		}
+		return 0, ErrImportTooLarge
+	}
+	if int64(len(p)) > c.n {
+		p = p[:c.n]
+	}
+	n, err := c.r.Read(p)
+	c.n -= int64(n)
+	return n, err
+}
@@ -0,0 +1,386 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"clipboard-sync-backend/internal/models"
+	"clipboard-sync-backend/internal/repository"
+	"clipboard-sync-backend/internal/totp"
+
+	"gorm.io/gorm"
+)
+
+const (
+	twoFactorIssuer           = "Clipboard Sync" // Shown next to the account in authenticator apps
+	recoveryCodeCount         = 10
+	loginChallengePrefix      = "mfa_"
+	loginChallengeTTL         = 5 * time.Minute
+	maxLoginChallengeAttempts = 5
+)
+
+// Second factors accepted at login
+const (
+	SecondFactorTOTP         = "totp"
+	SecondFactorRecoveryCode = "recovery_code"
+)
+
+var (
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrTwoFactorNotPending     = errors.New("start two-factor enrollment first")
+	ErrInvalidTwoFactorCode    = errors.New("invalid two-factor code")
+	ErrInvalidLoginChallenge   = errors.New("login challenge is invalid or has expired; please sign in again")
+	ErrReauthenticationFailed  = errors.New("password is incorrect")
+)
+
+// TwoFactorStatus describes a user's two-factor setup
+type TwoFactorStatus struct {
+	Enabled                bool       `json:"enabled"`
+	Pending                bool       `json:"pending"` // Enrollment started but not confirmed
+	EnabledAt              *time.Time `json:"enabled_at,omitempty"`
+	RecoveryCodesRemaining int64      `json:"recovery_codes_remaining"`
+}
+
+// TwoFactorEnrollment is what an authenticator app needs; clients render URI as a QR code
+type TwoFactorEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+// LoginChallengeResult identifies the login a completed challenge belongs to
+type LoginChallengeResult struct {
+	UserID uint
+	Device string
+}
+
+// TwoFactorService defines the interface for TOTP two-factor authentication
+type TwoFactorService interface {
+	Status(userID uint) (*TwoFactorStatus, error)
+	BeginEnrollment(userID uint) (*TwoFactorEnrollment, error)
+	ConfirmEnrollment(userID uint, code string, req *RequestInfo) ([]string, error)
+	Disable(userID uint, password, code string, req *RequestInfo) error
+	RegenerateRecoveryCodes(userID uint, code string, req *RequestInfo) ([]string, error)
+	CreateLoginChallenge(userID uint, method, device string) (string, error)
+	CompleteLoginChallenge(token, code string, req *RequestInfo) (*LoginChallengeResult, error)
+}
+
+type twoFactorService struct {
+	twoFactorRepo repository.TwoFactorRepository
+	userRepo      repository.UserRepository
+	passwords     PasswordService
+	auditService  AuditService
+}
+
+// NewTwoFactorService creates a new TwoFactorService
+func NewTwoFactorService(twoFactorRepo repository.TwoFactorRepository, userRepo repository.UserRepository, passwords PasswordService, auditService AuditService) TwoFactorService {
+	return &twoFactorService{twoFactorRepo: twoFactorRepo, userRepo: userRepo, passwords: passwords, auditService: auditService}
+}
+
+func hashSecondFactorToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeRecoveryCode lets users type codes with or without the dash and in any case
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
+
+// newRecoveryCodes returns codes to show the user once and the records that store their hashes
+func newRecoveryCodes() ([]string, []models.RecoveryCode, error) {
+	codes := make([]string, recoveryCodeCount)
+	records := make([]models.RecoveryCode, recoveryCodeCount)
+	for i := range codes {
+		raw, err := randomHex(5)
+		if err != nil {
+			return nil, nil, err
+		}
+		codes[i] = raw[:5] + "-" + raw[5:]
+		records[i] = models.RecoveryCode{CodeHash: hashSecondFactorToken(raw)}
+	}
+	return codes, records, nil
+}
+
+// getSecret returns the user's secret, or nil if they have none
+func (s *twoFactorService) getSecret(userID uint) (*models.TwoFactorSecret, error) {
+	secret, err := s.twoFactorRepo.GetSecret(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get two-factor secret: %w", err)
+	}
+	return secret, nil
+}
+
+// Status reports whether the user has two-factor authentication and how many recovery codes remain
+func (s *twoFactorService) Status(userID uint) (*TwoFactorStatus, error) {
+	secret, err := s.getSecret(userID)
+	if err != nil {
+		return nil, err
+	}
+	status := &TwoFactorStatus{}
+	if secret == nil {
+		return status, nil
+	}
+	status.Enabled = secret.ConfirmedAt != nil
+	status.Pending = !status.Enabled
+	status.EnabledAt = secret.ConfirmedAt
+	if status.Enabled {
+		if status.RecoveryCodesRemaining, err = s.twoFactorRepo.CountUnusedRecoveryCodes(userID); err != nil {
+			return nil, fmt.Errorf("failed to count recovery codes: %w", err)
+		}
+	}
+	return status, nil
+}
+
+// BeginEnrollment generates a new secret for the user's authenticator app. Two-factor
+// authentication stays off until ConfirmEnrollment sees a valid code.
+func (s *twoFactorService) BeginEnrollment(userID uint) (*TwoFactorEnrollment, error) {
+	existing, err := s.getSecret(userID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil && existing.ConfirmedAt != nil {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving user: %w", err)
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.twoFactorRepo.SavePendingSecret(&models.TwoFactorSecret{UserID: userID, Secret: secret}); err != nil {
+		return nil, fmt.Errorf("failed to save two-factor secret: %w", err)
+	}
+	return &TwoFactorEnrollment{Secret: secret, URI: totp.URI(twoFactorIssuer, user.Email, secret)}, nil
+}
+
+// ConfirmEnrollment turns two-factor authentication on once the user enters a code from their
+// app, and returns recovery codes to show them once
+func (s *twoFactorService) ConfirmEnrollment(userID uint, code string, req *RequestInfo) ([]string, error) {
+	secret, err := s.getSecret(userID)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil {
+		return nil, ErrTwoFactorNotPending
+	}
+	if secret.ConfirmedAt != nil {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	step, ok := totp.Validate(secret.Secret, code, time.Now())
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	codes, records, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.twoFactorRepo.EnableTwoFactor(userID, step, records, time.Now()); err != nil {
+		return nil, fmt.Errorf("failed to enable two-factor authentication: %w", err)
+	}
+	log.Printf("User %d enabled two-factor authentication", userID)
+	recordAudit(s.auditService, AuditEvent{UserID: &userID, Action: models.AuditTwoFactorEnabled, Request: req})
+	return codes, nil
+}
+
+// Disable turns two-factor authentication off. The user re-authenticates with their password,
+// when the account has one, and a current TOTP or recovery code.
+func (s *twoFactorService) Disable(userID uint, password, code string, req *RequestInfo) error {
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return fmt.Errorf("error retrieving user: %w", err)
+	}
+	if !user.TwoFactorEnabled {
+		return ErrTwoFactorNotEnabled
+	}
+	if user.Password != "" {
+		ok, err := s.passwords.Verify(user, password)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrReauthenticationFailed
+		}
+	}
+	if _, err := s.verifyCode(userID, code, req); err != nil {
+		return err
+	}
+
+	if err := s.twoFactorRepo.DisableTwoFactor(userID); err != nil {
+		return fmt.Errorf("failed to disable two-factor authentication: %w", err)
+	}
+	log.Printf("User %d disabled two-factor authentication", userID)
+	recordAudit(s.auditService, AuditEvent{UserID: &userID, Action: models.AuditTwoFactorDisabled, Request: req})
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes after checking a current code
+func (s *twoFactorService) RegenerateRecoveryCodes(userID uint, code string, req *RequestInfo) ([]string, error) {
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving user: %w", err)
+	}
+	if !user.TwoFactorEnabled {
+		return nil, ErrTwoFactorNotEnabled
+	}
+	if _, err := s.verifyCode(userID, code, req); err != nil {
+		return nil, err
+	}
+
+	codes, records, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.twoFactorRepo.ReplaceRecoveryCodes(userID, records); err != nil {
+		return nil, fmt.Errorf("failed to save recovery codes: %w", err)
+	}
+	recordAudit(s.auditService, AuditEvent{UserID: &userID, Action: models.AuditRecoveryCodesReset, Request: req})
+	return codes, nil
+}
+
+// verifyCode accepts a TOTP code or an unused recovery code and reports which it was. Each TOTP
+// code and each recovery code is accepted only once.
+func (s *twoFactorService) verifyCode(userID uint, code string, req *RequestInfo) (string, error) {
+	code = strings.TrimSpace(code)
+	if len(code) == totp.Digits {
+		secret, err := s.getSecret(userID)
+		if err != nil {
+			return "", err
+		}
+		if secret == nil || secret.ConfirmedAt == nil {
+			return "", ErrTwoFactorNotEnabled
+		}
+		step, ok := totp.Validate(secret.Secret, code, time.Now())
+		if !ok {
+			return "", ErrInvalidTwoFactorCode
+		}
+		fresh, err := s.twoFactorRepo.UseTOTPStep(userID, step)
+		if err != nil {
+			return "", fmt.Errorf("failed to record two-factor code: %w", err)
+		}
+		if !fresh {
+			return "", ErrInvalidTwoFactorCode
+		}
+		return SecondFactorTOTP, nil
+	}
+
+	used, err := s.twoFactorRepo.UseRecoveryCode(userID, hashSecondFactorToken(normalizeRecoveryCode(code)), time.Now())
+	if err != nil {
+		return "", fmt.Errorf("failed to check recovery code: %w", err)
+	}
+	if !used {
+		return "", ErrInvalidTwoFactorCode
+	}
+	remaining, err := s.twoFactorRepo.CountUnusedRecoveryCodes(userID)
+	if err != nil {
+		log.Printf("Failed to count recovery codes of user %d: %v", userID, err)
+	}
+	recordAudit(s.auditService, AuditEvent{
+		UserID:  &userID,
+		Action:  models.AuditRecoveryCodeUsed,
+		Details: map[string]interface{}{"remaining": remaining},
+		Request: req,
+	})
+	return SecondFactorRecoveryCode, nil
+}
+
+// CreateLoginChallenge holds a login that passed its first factor (method, e.g. "password")
+// until the user enters a second factor, and returns the token the client answers it with
+func (s *twoFactorService) CreateLoginChallenge(userID uint, method, device string) (string, error) {
+	secret, err := randomHex(32)
+	if err != nil {
+		return "", err
+	}
+	token := loginChallengePrefix + secret
+	now := time.Now()
+	if err := s.twoFactorRepo.DeleteExpiredChallenges(now); err != nil {
+		log.Printf("Failed to delete expired login challenges: %v", err)
+	}
+	if len(device) > 255 {
+		device = device[:255]
+	}
+	if err := s.twoFactorRepo.CreateChallenge(&models.LoginChallenge{
+		TokenHash: hashSecondFactorToken(token),
+		UserID:    userID,
+		Method:    method,
+		Device:    device,
+		ExpiresAt: now.Add(loginChallengeTTL),
+	}); err != nil {
+		return "", fmt.Errorf("failed to create login challenge: %w", err)
+	}
+	return token, nil
+}
+
+// CompleteLoginChallenge checks the second factor for a pending login. A challenge allows a
+// few attempts and can be completed only once.
+func (s *twoFactorService) CompleteLoginChallenge(token, code string, req *RequestInfo) (*LoginChallengeResult, error) {
+	challenge, err := s.twoFactorRepo.GetChallengeByHash(hashSecondFactorToken(token))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidLoginChallenge
+		}
+		return nil, fmt.Errorf("failed to get login challenge: %w", err)
+	}
+	if !time.Now().Before(challenge.ExpiresAt) {
+		return nil, ErrInvalidLoginChallenge
+	}
+	allowed, err := s.twoFactorRepo.UseChallengeAttempt(challenge.ID, maxLoginChallengeAttempts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record login attempt: %w", err)
+	}
+	if !allowed {
+		if _, err := s.twoFactorRepo.DeleteChallenge(challenge.ID); err != nil {
+			log.Printf("Failed to delete login challenge %d: %v", challenge.ID, err)
+		}
+		return nil, ErrInvalidLoginChallenge
+	}
+
+	factor, err := s.verifyCode(challenge.UserID, code, req)
+	if err != nil {
+		if errors.Is(err, ErrInvalidTwoFactorCode) {
+			recordAudit(s.auditService, AuditEvent{
+				UserID:  &challenge.UserID,
+				Action:  models.AuditUserLoginFailed,
+				Details: map[string]interface{}{"method": challenge.Method, "reason": "wrong_second_factor"},
+				Request: req,
+			})
+		}
+		return nil, err
+	}
+	completed, err := s.twoFactorRepo.DeleteChallenge(challenge.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to complete login challenge: %w", err)
+	}
+	if !completed {
+		return nil, ErrInvalidLoginChallenge
+	}
+	// The account may have been suspended since the first factor passed
+	user, err := s.userRepo.GetUserByID(challenge.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving user: %w", err)
+	}
+	if user.SuspendedAt != nil {
+		return nil, ErrAccountSuspended
+	}
+
+	touchLastLogin(s.userRepo, challenge.UserID)
+	recordAudit(s.auditService, AuditEvent{
+		UserID:  &challenge.UserID,
+		Action:  models.AuditUserLogin,
+		Details: map[string]interface{}{"method": challenge.Method, "second_factor": factor},
+		Request: req,
+	})
+	return &LoginChallengeResult{UserID: challenge.UserID, Device: challenge.Device}, nil
+}
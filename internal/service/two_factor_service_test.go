@@ -0,0 +1,375 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"clipboard-sync-backend/internal/models"
+	"clipboard-sync-backend/internal/totp"
+
+	"gorm.io/gorm"
+)
+
+// fakeTwoFactorRepo keeps secrets, recovery codes and challenges in memory with the semantics
+// of the SQL repository
+type fakeTwoFactorRepo struct {
+	secrets    map[uint]*models.TwoFactorSecret
+	codes      []*models.RecoveryCode
+	challenges map[uint]*models.LoginChallenge
+	nextID     uint
+}
+
+func newFakeTwoFactorRepo() *fakeTwoFactorRepo {
+	return &fakeTwoFactorRepo{secrets: make(map[uint]*models.TwoFactorSecret), challenges: make(map[uint]*models.LoginChallenge)}
+}
+
+func (r *fakeTwoFactorRepo) GetSecret(userID uint) (*models.TwoFactorSecret, error) {
+	secret, ok := r.secrets[userID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *secret
+	return &copied, nil
+}
+
+func (r *fakeTwoFactorRepo) SavePendingSecret(secret *models.TwoFactorSecret) error {
+	copied := *secret
+	r.secrets[secret.UserID] = &copied
+	return nil
+}
+
+func (r *fakeTwoFactorRepo) EnableTwoFactor(userID uint, step int64, codes []models.RecoveryCode, now time.Time) error {
+	secret := r.secrets[userID]
+	secret.ConfirmedAt = &now
+	secret.LastUsedStep = step
+	return r.ReplaceRecoveryCodes(userID, codes)
+}
+
+func (r *fakeTwoFactorRepo) DisableTwoFactor(userID uint) error {
+	delete(r.secrets, userID)
+	return r.ReplaceRecoveryCodes(userID, nil)
+}
+
+func (r *fakeTwoFactorRepo) UseTOTPStep(userID uint, step int64) (bool, error) {
+	secret, ok := r.secrets[userID]
+	if !ok || secret.ConfirmedAt == nil || secret.LastUsedStep >= step {
+		return false, nil
+	}
+	secret.LastUsedStep = step
+	return true, nil
+}
+
+func (r *fakeTwoFactorRepo) ReplaceRecoveryCodes(userID uint, codes []models.RecoveryCode) error {
+	kept := r.codes[:0]
+	for _, c := range r.codes {
+		if c.UserID != userID {
+			kept = append(kept, c)
+		}
+	}
+	r.codes = kept
+	for i := range codes {
+		c := codes[i]
+		c.UserID = userID
+		r.codes = append(r.codes, &c)
+	}
+	return nil
+}
+
+func (r *fakeTwoFactorRepo) UseRecoveryCode(userID uint, codeHash string, now time.Time) (bool, error) {
+	for _, c := range r.codes {
+		if c.UserID == userID && c.CodeHash == codeHash && c.UsedAt == nil {
+			c.UsedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeTwoFactorRepo) CountUnusedRecoveryCodes(userID uint) (int64, error) {
+	var count int64
+	for _, c := range r.codes {
+		if c.UserID == userID && c.UsedAt == nil {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *fakeTwoFactorRepo) CreateChallenge(challenge *models.LoginChallenge) error {
+	r.nextID++
+	challenge.ID = r.nextID
+	copied := *challenge
+	r.challenges[challenge.ID] = &copied
+	return nil
+}
+
+func (r *fakeTwoFactorRepo) GetChallengeByHash(tokenHash string) (*models.LoginChallenge, error) {
+	for _, c := range r.challenges {
+		if c.TokenHash == tokenHash {
+			copied := *c
+			return &copied, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeTwoFactorRepo) UseChallengeAttempt(id uint, maxAttempts int) (bool, error) {
+	c, ok := r.challenges[id]
+	if !ok || c.Attempts >= maxAttempts {
+		return false, nil
+	}
+	c.Attempts++
+	return true, nil
+}
+
+func (r *fakeTwoFactorRepo) DeleteChallenge(id uint) (bool, error) {
+	_, ok := r.challenges[id]
+	delete(r.challenges, id)
+	return ok, nil
+}
+
+func (r *fakeTwoFactorRepo) DeleteExpiredChallenges(now time.Time) error {
+	for id, c := range r.challenges {
+		if !c.ExpiresAt.After(now) {
+			delete(r.challenges, id)
+		}
+	}
+	return nil
+}
+
+type twoFactorFixture struct {
+	service TwoFactorService
+	repo    *fakeTwoFactorRepo
+	users   *fakeUserRepo
+	audit   *fakeAudit
+	secret  string
+	codes   []string
+}
+
+// newTwoFactorFixture enrolls user 1 and returns the recovery codes they were shown
+func newTwoFactorFixture(t *testing.T) *twoFactorFixture {
+	t.Helper()
+	f := &twoFactorFixture{
+		repo:  newFakeTwoFactorRepo(),
+		users: &fakeUserRepo{users: []*models.User{{ID: 1, Email: "alice@example.com", Password: "hash:correct horse"}}},
+		audit: &fakeAudit{},
+	}
+	f.service = NewTwoFactorService(f.repo, f.users, nil, f.audit)
+
+	enrollment, err := f.service.BeginEnrollment(1)
+	if err != nil {
+		t.Fatalf("BeginEnrollment() error = %v", err)
+	}
+	f.secret = enrollment.Secret
+	// Enroll with the previous step's code so the current one is still fresh for the test
+	if f.codes, err = f.service.ConfirmEnrollment(1, f.code(t, -1), nil); err != nil {
+		t.Fatalf("ConfirmEnrollment() error = %v", err)
+	}
+	f.users.users[0].TwoFactorEnabled = true
+	return f
+}
+
+// code returns the TOTP code offset steps from now
+func (f *twoFactorFixture) code(t *testing.T, offset int64) string {
+	t.Helper()
+	code, err := totp.Code(f.secret, totp.Step(time.Now())+offset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+func (f *twoFactorFixture) challenge(t *testing.T) string {
+	t.Helper()
+	token, err := f.service.CreateLoginChallenge(1, "password", "laptop")
+	if err != nil {
+		t.Fatalf("CreateLoginChallenge() error = %v", err)
+	}
+	return token
+}
+
+func TestConfirmEnrollment(t *testing.T) {
+	f := newTwoFactorFixture(t)
+	if len(f.codes) != recoveryCodeCount {
+		t.Fatalf("got %d recovery codes, want %d", len(f.codes), recoveryCodeCount)
+	}
+	status, err := f.service.Status(1)
+	if err != nil || !status.Enabled || status.RecoveryCodesRemaining != recoveryCodeCount {
+		t.Fatalf("Status() = %+v, %v; want enabled with %d codes", status, err, recoveryCodeCount)
+	}
+	if _, err := f.service.BeginEnrollment(1); !errors.Is(err, ErrTwoFactorAlreadyEnabled) {
+		t.Fatalf("BeginEnrollment() when enabled error = %v, want ErrTwoFactorAlreadyEnabled", err)
+	}
+}
+
+func TestVerifyCodeTOTP(t *testing.T) {
+	f := newTwoFactorFixture(t)
+	s := f.service.(*twoFactorService)
+
+	if _, err := s.verifyCode(1, f.code(t, 5), nil); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Fatalf("verifyCode() with a code outside the window error = %v, want ErrInvalidTwoFactorCode", err)
+	}
+	// The enrollment consumed the previous step
+	if _, err := s.verifyCode(1, f.code(t, -1), nil); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Fatalf("verifyCode() with the enrollment code error = %v, want ErrInvalidTwoFactorCode", err)
+	}
+	factor, err := s.verifyCode(1, " "+f.code(t, 0)+" ", nil)
+	if err != nil || factor != SecondFactorTOTP {
+		t.Fatalf("verifyCode() = %q, %v; want totp", factor, err)
+	}
+	if _, err := s.verifyCode(1, f.code(t, 0), nil); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Fatalf("verifyCode() replaying a code error = %v, want ErrInvalidTwoFactorCode", err)
+	}
+	// A later step is still accepted, after which the earlier one is a replay
+	if _, err := s.verifyCode(1, f.code(t, 1), nil); err != nil {
+		t.Fatalf("verifyCode() with the next step's code error = %v", err)
+	}
+	if _, err := s.verifyCode(1, f.code(t, 0), nil); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Fatalf("verifyCode() with a step before the last used error = %v, want ErrInvalidTwoFactorCode", err)
+	}
+	if _, err := s.verifyCode(2, f.code(t, 0), nil); !errors.Is(err, ErrTwoFactorNotEnabled) {
+		t.Fatalf("verifyCode() for a user without two-factor error = %v, want ErrTwoFactorNotEnabled", err)
+	}
+}
+
+func TestVerifyCodeRecoveryCodes(t *testing.T) {
+	f := newTwoFactorFixture(t)
+	s := f.service.(*twoFactorService)
+
+	tests := []struct {
+		name string
+		code string
+	}{
+		{"as shown", f.codes[0]},
+		{"without the dash", strings.Replace(f.codes[1], "-", "", 1)},
+		{"upper case", strings.ToUpper(f.codes[2])},
+		{"upper case with spaces", " " + strings.ToUpper(strings.Replace(f.codes[3], "-", " ", 1)) + " "},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			factor, err := s.verifyCode(1, tt.code, nil)
+			if err != nil || factor != SecondFactorRecoveryCode {
+				t.Fatalf("verifyCode(%q) = %q, %v; want recovery_code", tt.code, factor, err)
+			}
+			if _, err := s.verifyCode(1, tt.code, nil); !errors.Is(err, ErrInvalidTwoFactorCode) {
+				t.Fatalf("verifyCode(%q) a second time error = %v, want ErrInvalidTwoFactorCode", tt.code, err)
+			}
+			if remaining, _ := f.repo.CountUnusedRecoveryCodes(1); remaining != int64(recoveryCodeCount-i-1) {
+				t.Fatalf("%d recovery codes remain, want %d", remaining, recoveryCodeCount-i-1)
+			}
+		})
+	}
+
+	if _, err := s.verifyCode(1, "aaaaa-bbbbb", nil); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Fatalf("verifyCode() with an unknown recovery code error = %v, want ErrInvalidTwoFactorCode", err)
+	}
+	last := f.audit.events[len(f.audit.events)-1]
+	if last.Action != models.AuditRecoveryCodeUsed || last.Details["remaining"] != int64(recoveryCodeCount-len(tests)) {
+		t.Fatalf("last audit event = %+v, want recovery code used with %d remaining", last, recoveryCodeCount-len(tests))
+	}
+
+	// Regenerating invalidates the old codes
+	fresh, err := f.service.RegenerateRecoveryCodes(1, f.codes[4], nil)
+	if err != nil {
+		t.Fatalf("RegenerateRecoveryCodes() error = %v", err)
+	}
+	if _, err := s.verifyCode(1, f.codes[5], nil); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Fatalf("verifyCode() with a replaced recovery code error = %v, want ErrInvalidTwoFactorCode", err)
+	}
+	if _, err := s.verifyCode(1, fresh[0], nil); err != nil {
+		t.Fatalf("verifyCode() with a new recovery code error = %v", err)
+	}
+}
+
+func TestCompleteLoginChallenge(t *testing.T) {
+	f := newTwoFactorFixture(t)
+	token := f.challenge(t)
+
+	result, err := f.service.CompleteLoginChallenge(token, f.code(t, 0), nil)
+	if err != nil {
+		t.Fatalf("CompleteLoginChallenge() error = %v", err)
+	}
+	if result.UserID != 1 || result.Device != "laptop" {
+		t.Fatalf("result = %+v, want user 1 on laptop", result)
+	}
+	last := f.audit.events[len(f.audit.events)-1]
+	if last.Action != models.AuditUserLogin || last.Details["second_factor"] != SecondFactorTOTP {
+		t.Fatalf("last audit event = %+v, want a login with a TOTP second factor", last)
+	}
+	if _, err := f.service.CompleteLoginChallenge(token, f.codes[0], nil); !errors.Is(err, ErrInvalidLoginChallenge) {
+		t.Fatalf("completing a challenge twice error = %v, want ErrInvalidLoginChallenge", err)
+	}
+	if _, err := f.service.CompleteLoginChallenge("mfa_unknown", f.codes[0], nil); !errors.Is(err, ErrInvalidLoginChallenge) {
+		t.Fatalf("unknown challenge error = %v, want ErrInvalidLoginChallenge", err)
+	}
+}
+
+func TestCompleteLoginChallengeAttemptLimit(t *testing.T) {
+	f := newTwoFactorFixture(t)
+	token := f.challenge(t)
+
+	for i := 0; i < maxLoginChallengeAttempts; i++ {
+		if _, err := f.service.CompleteLoginChallenge(token, "wrong-code", nil); !errors.Is(err, ErrInvalidTwoFactorCode) {
+			t.Fatalf("attempt %d error = %v, want ErrInvalidTwoFactorCode", i+1, err)
+		}
+	}
+	if _, err := f.service.CompleteLoginChallenge(token, f.codes[0], nil); !errors.Is(err, ErrInvalidLoginChallenge) {
+		t.Fatalf("attempt after the limit error = %v, want ErrInvalidLoginChallenge", err)
+	}
+	if len(f.repo.challenges) != 0 {
+		t.Fatal("challenge was not deleted after too many attempts")
+	}
+	// The correct code was never checked, so it is still unused
+	if remaining, _ := f.repo.CountUnusedRecoveryCodes(1); remaining != recoveryCodeCount {
+		t.Fatalf("%d recovery codes remain, want %d", remaining, recoveryCodeCount)
+	}
+	failures := 0
+	for _, e := range f.audit.events {
+		if e.Action == models.AuditUserLoginFailed && e.Details["reason"] == "wrong_second_factor" {
+			failures++
+		}
+	}
+	if failures != maxLoginChallengeAttempts {
+		t.Fatalf("audited %d failed logins, want %d", failures, maxLoginChallengeAttempts)
+	}
+}
+
+func TestCompleteLoginChallengeExpired(t *testing.T) {
+	f := newTwoFactorFixture(t)
+	token := f.challenge(t)
+	for _, c := range f.repo.challenges {
+		c.ExpiresAt = time.Now().Add(-time.Second)
+	}
+
+	if _, err := f.service.CompleteLoginChallenge(token, f.code(t, 0), nil); !errors.Is(err, ErrInvalidLoginChallenge) {
+		t.Fatalf("expired challenge error = %v, want ErrInvalidLoginChallenge", err)
+	}
+	// The code was not spent on the expired challenge
+	if _, err := f.service.CompleteLoginChallenge(f.challenge(t), f.code(t, 0), nil); err != nil {
+		t.Fatalf("fresh challenge error = %v", err)
+	}
+	if len(f.repo.challenges) != 0 {
+		t.Fatal("expired challenge was not cleaned up")
+	}
+}
+
+func TestCompleteLoginChallengeSuspendedUser(t *testing.T) {
+	f := newTwoFactorFixture(t)
+	token := f.challenge(t)
+	suspendedAt := time.Now()
+	f.users.users[0].SuspendedAt = &suspendedAt
+
+	if _, err := f.service.CompleteLoginChallenge(token, f.code(t, 0), nil); !errors.Is(err, ErrAccountSuspended) {
+		t.Fatalf("suspended user error = %v, want ErrAccountSuspended", err)
+	}
+	for _, e := range f.audit.events {
+		if e.Action == models.AuditUserLogin {
+			t.Fatalf("audited a login for a suspended user: %+v", e)
+		}
+	}
+	if _, err := f.service.CompleteLoginChallenge(token, f.codes[0], nil); !errors.Is(err, ErrInvalidLoginChallenge) {
+		t.Fatalf("retrying a challenge of a suspended user error = %v, want ErrInvalidLoginChallenge", err)
+	}
+}
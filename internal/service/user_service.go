@@ -4,50 +4,74 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"time"
 
 	"clipboard-sync-backend/internal/models"
 	"clipboard-sync-backend/internal/repository"
 
-	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
+var (
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrUserNotFound       = errors.New("user not found")
+	ErrEmailTaken         = errors.New("user with this email already exists")
+	ErrAccountSuspended   = errors.New("this account has been suspended")
+)
+
 // UserService defines the interface for user-related business logic
 type UserService interface {
-	RegisterUser(email, password string) (*models.User, error)
-	LoginUser(email, password string) (*models.User, error)
+	RegisterUser(email, password string, req *RequestInfo) (*models.User, error)
+	LoginUser(email, password string, req *RequestInfo) (*models.User, error)
+	IsAdmin(userID uint) (bool, error)
 	// Add more user-related service methods as needed
 }
 
 type userService struct {
-	userRepo repository.UserRepository
+	userRepo          repository.UserRepository
+	passwords         PasswordService
+	invitationService InvitationService
+	accountService    AccountService
+	auditService      AuditService
+	loginGuard        LoginGuard
 }
 
-// NewUserService creates a new UserService
-func NewUserService(userRepo repository.UserRepository) UserService {
-	return &userService{userRepo: userRepo}
+// NewUserService creates a new UserService. The account service, when set, sends new users a
+// link to verify their email address; the login guard, when set, throttles password guessing.
+func NewUserService(userRepo repository.UserRepository, passwords PasswordService, invitationService InvitationService, accountService AccountService, auditService AuditService, loginGuard LoginGuard) UserService {
+	return &userService{
+		userRepo:          userRepo,
+		passwords:         passwords,
+		invitationService: invitationService,
+		accountService:    accountService,
+		auditService:      auditService,
+		loginGuard:        loginGuard,
+	}
 }
 
-// RegisterUser handles user registration
-func (s *userService) RegisterUser(email, password string) (*models.User, error) {
+// RegisterUser handles user registration; req describes the client for the audit log
+func (s *userService) RegisterUser(email, password string, req *RequestInfo) (*models.User, error) {
 	// Check if user already exists
 	_, err := s.userRepo.GetUserByEmail(email)
 	if err == nil {
-		return nil, errors.New("user with this email already exists")
+		return nil, ErrEmailTaken
 	}
 	if !errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, fmt.Errorf("error checking existing user: %w", err)
 	}
 
-	// Hash password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err := s.passwords.Validate(nil, password); err != nil {
+		return nil, err
+	}
+	hashedPassword, err := s.passwords.Hash(password)
 	if err != nil {
-		return nil, fmt.Errorf("failed to hash password: %w", err)
+		return nil, err
 	}
 
 	user := &models.User{
-		Email:    email,
-		Password: string(hashedPassword),
+		Email:        email,
+		Password:     hashedPassword,
+		AuthProvider: models.AuthProviderEmailPassword,
 	}
 
 	// Create user in database
@@ -56,24 +80,111 @@ func (s *userService) RegisterUser(email, password string) (*models.User, error)
 	}
 
 	log.Printf("User registered: %s", user.Email)
+	recordAudit(s.auditService, AuditEvent{UserID: &user.ID, Action: models.AuditUserRegistered, Request: req})
+
+	// Join any teams that invited this address before it had an account
+	if s.invitationService != nil {
+		s.invitationService.ClaimPendingInvitations(user)
+	}
+	if s.accountService != nil {
+		if err := s.accountService.SendVerificationEmail(user.ID); err != nil {
+			log.Printf("Failed to send verification email to %s: %v", user.Email, err)
+		}
+	}
 	return user, nil
 }
 
-// LoginUser handles user login
-func (s *userService) LoginUser(email, password string) (*models.User, error) {
+// LoginUser handles user login; successful and failed attempts are audited with req. Unknown
+// emails go through the same password comparison and throttling as wrong passwords, so neither
+// the response nor its timing tells whether an address has an account. Passwords hashed with
+// outdated parameters are rehashed on success.
+func (s *userService) LoginUser(email, password string, req *RequestInfo) (*models.User, error) {
+	ip := ""
+	if req != nil {
+		ip = req.IP
+	}
+	if s.loginGuard != nil {
+		if err := s.loginGuard.Check(email, ip); err != nil {
+			if errors.Is(err, ErrLoginThrottled) {
+				s.loginFailed(nil, email, "throttled", req)
+			}
+			return nil, err
+		}
+	}
+
 	user, err := s.userRepo.GetUserByEmail(email)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("invalid credentials")
+			s.passwords.Verify(nil, password)
+			s.recordLoginFailure(nil, email, ip, "unknown_email", req)
+			return nil, ErrInvalidCredentials
 		}
 		return nil, fmt.Errorf("error retrieving user: %w", err)
 	}
 
-	// Compare hashed password
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
-		return nil, errors.New("invalid credentials")
+	// Accounts that only use social login have no password and never match
+	ok, err := s.passwords.Verify(user, password)
+	if err != nil {
+		log.Printf("Login of %s failed: %v", user.Email, err)
+	}
+	if !ok {
+		s.recordLoginFailure(user, email, ip, "wrong_password", req)
+		return nil, ErrInvalidCredentials
 	}
 
+	if s.loginGuard != nil {
+		s.loginGuard.RecordSuccess(email)
+	}
+	if user.SuspendedAt != nil {
+		s.loginFailed(&user.ID, email, "suspended", req)
+		return nil, ErrAccountSuspended
+	}
 	log.Printf("User logged in: %s", user.Email)
+	// Logins of users with two-factor authentication are audited once the second factor passes
+	if !user.TwoFactorEnabled {
+		touchLastLogin(s.userRepo, user.ID)
+		recordAudit(s.auditService, AuditEvent{UserID: &user.ID, Action: models.AuditUserLogin, Request: req})
+	}
 	return user, nil
 }
+
+// IsAdmin reports whether a user is a system administrator; it backs auth.RequireAdmin
+func (s *userService) IsAdmin(userID uint) (bool, error) {
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("error retrieving user: %w", err)
+	}
+	return user.IsAdmin, nil
+}
+
+// touchLastLogin records a completed sign-in on the user; failures are only logged
+func touchLastLogin(userRepo repository.UserRepository, userID uint) {
+	if err := userRepo.TouchLastLogin(userID, time.Now()); err != nil {
+		log.Printf("Failed to record sign-in of user %d: %v", userID, err)
+	}
+}
+
+// recordLoginFailure audits a rejected password and counts it towards throttling
+func (s *userService) recordLoginFailure(user *models.User, email, ip, reason string, req *RequestInfo) {
+	var userID *uint
+	if user != nil {
+		userID = &user.ID
+	}
+	s.loginFailed(userID, email, reason, req)
+	if s.loginGuard != nil {
+		s.loginGuard.RecordFailure(email, ip, user, req)
+	}
+}
+
+// loginFailed audits a rejected login; userID is nil when no account has the email
+func (s *userService) loginFailed(userID *uint, email, reason string, req *RequestInfo) {
+	recordAudit(s.auditService, AuditEvent{
+		UserID:  userID,
+		Action:  models.AuditUserLoginFailed,
+		Details: map[string]interface{}{"email": email, "reason": reason},
+		Request: req,
+	})
+}
@@ -0,0 +1,591 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"clipboard-sync-backend/internal/models"
+	"clipboard-sync-backend/internal/policy"
+	"clipboard-sync-backend/internal/repository"
+	"clipboard-sync-backend/internal/safehttp"
+
+	"gorm.io/gorm"
+)
+
+const (
+	webhookPollInterval   = 2 * time.Second
+	webhookClaimBatch     = 50
+	webhookClaimLease     = 2 * time.Minute // Longer than one attempt including timeout
+	webhookBaseBackoff    = 30 * time.Second
+	webhookMaxBackoff     = 6 * time.Hour
+	webhookMaxConcurrency = 8
+	webhookMaxErrorLength = 1000
+)
+
+// Headers set on every webhook request
+const (
+	WebhookSignatureHeader = "X-Webhook-Signature" // "t=<unix>,v1=<hex HMAC-SHA256 of "<unix>.<body>">"
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+)
+
+var (
+	ErrWebhookNotFound     = errors.New("webhook not found")
+	ErrDeliveryNotFound    = errors.New("delivery not found")
+	ErrInvalidWebhookURL   = errors.New("webhook URL must be an absolute http or https URL")
+	ErrInvalidWebhookEvent = errors.New("unknown webhook event type")
+	ErrNoWebhookEvents     = errors.New("at least one event type is required")
+	ErrNotTeamWebhookAdmin = errors.New("only team admins can manage team webhooks")
+)
+
+// WebhookUpdate holds optional changes to a subscription; nil fields are left unchanged
+type WebhookUpdate struct {
+	URL            *string
+	Events         []string
+	Description    *string
+	Active         *bool
+	IncludeContent *bool
+}
+
+// WebhookEntryData is published for entry events. Every webhook receives the metadata; the
+// clipboard content is added under "content" only for webhooks with IncludeContent set.
+type WebhookEntryData struct {
+	Metadata map[string]interface{}
+	Content  string
+}
+
+// WebhookService defines the interface for outgoing webhook management and delivery
+type WebhookService interface {
+	CreateWebhook(userID uint, teamID *uint, targetURL string, events []string, description string, includeContent bool) (*models.WebhookSubscription, string, error)
+	ListWebhooks(userID uint, teamID *uint) ([]models.WebhookSubscription, error)
+	GetWebhook(userID, webhookID uint) (*models.WebhookSubscription, error)
+	UpdateWebhook(userID, webhookID uint, update WebhookUpdate) (*models.WebhookSubscription, error)
+	DeleteWebhook(userID, webhookID uint) error
+	RotateSecret(userID, webhookID uint) (string, error)
+	ListDeliveries(userID, webhookID uint, limit, offset int) ([]models.WebhookDelivery, error)
+	Redeliver(userID, webhookID, deliveryID uint) (*models.WebhookDelivery, error)
+	Ping(userID, webhookID uint) (*models.WebhookDelivery, error)
+
+	// Publish enqueues an event for every matching subscription. Team events (teamID set) go to
+	// the team's webhooks, everything else to the user's personal webhooks.
+	Publish(eventType string, userID uint, teamID *uint, data interface{})
+	// Run delivers queued events until stop is closed
+	Run(stop <-chan struct{})
+}
+
+type webhookService struct {
+	webhookRepo repository.WebhookRepository
+	teamRepo    repository.TeamRepository
+	client      *http.Client
+	maxAttempts int
+	wake        chan struct{}
+}
+
+// NewWebhookService creates a new WebhookService. Deliveries are dead-lettered after maxAttempts.
+func NewWebhookService(webhookRepo repository.WebhookRepository, teamRepo repository.TeamRepository, maxAttempts int, timeout time.Duration, allowPrivateNetworks bool) WebhookService {
+	if maxAttempts <= 0 {
+		maxAttempts = 8
+	}
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return &webhookService{
+		webhookRepo: webhookRepo,
+		teamRepo:    teamRepo,
+		client: safehttp.NewClient(safehttp.Options{
+			Timeout:              timeout,
+			AllowPrivateNetworks: allowPrivateNetworks,
+		}),
+		maxAttempts: maxAttempts,
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+func validateWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidWebhookURL
+	}
+	return nil
+}
+
+func validateWebhookEvents(events []string) ([]string, error) {
+	if len(events) == 0 {
+		return nil, ErrNoWebhookEvents
+	}
+	seen := make(map[string]bool, len(events))
+	var out []string
+	for _, e := range events {
+		valid := false
+		for _, known := range models.WebhookEvents {
+			if e == known {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidWebhookEvent, e)
+		}
+		if !seen[e] {
+			seen[e] = true
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func newWebhookSecret() (string, error) {
+	secret, err := randomHex(32)
+	if err != nil {
+		return "", err
+	}
+	return "whsec_" + secret, nil
+}
+
+// authorizeTeam checks that the user may manage webhooks of the team
+func (s *webhookService) authorizeTeam(userID, teamID uint) error {
+	if _, err := authorize(s.teamRepo, teamID, userID, policy.ManageWebhooks); err != nil {
+		if errors.Is(err, ErrTwoFactorRequired) {
+			return err
+		}
+		if errors.Is(err, ErrNotTeamMember) || errors.Is(err, ErrTeamPermission) {
+			return ErrNotTeamWebhookAdmin
+		}
+		return err
+	}
+	return nil
+}
+
+// getOwned loads a subscription the user may manage
+func (s *webhookService) getOwned(userID, webhookID uint) (*models.WebhookSubscription, error) {
+	sub, err := s.webhookRepo.GetSubscriptionByID(webhookID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, fmt.Errorf("failed to get webhook: %w", err)
+	}
+	if sub.TeamID != nil {
+		if err := s.authorizeTeam(userID, *sub.TeamID); err != nil {
+			if errors.Is(err, ErrNotTeamWebhookAdmin) {
+				return nil, ErrWebhookNotFound
+			}
+			return nil, err
+		}
+		return sub, nil
+	}
+	if sub.UserID != userID {
+		return nil, ErrWebhookNotFound
+	}
+	return sub, nil
+}
+
+// CreateWebhook registers a subscription and returns it with its signing secret (shown only once)
+func (s *webhookService) CreateWebhook(userID uint, teamID *uint, targetURL string, events []string, description string, includeContent bool) (*models.WebhookSubscription, string, error) {
+	if err := validateWebhookURL(targetURL); err != nil {
+		return nil, "", err
+	}
+	events, err := validateWebhookEvents(events)
+	if err != nil {
+		return nil, "", err
+	}
+	if teamID != nil {
+		if err := s.authorizeTeam(userID, *teamID); err != nil {
+			return nil, "", err
+		}
+	}
+
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, "", err
+	}
+	sub := &models.WebhookSubscription{
+		UserID:         userID,
+		TeamID:         teamID,
+		URL:            targetURL,
+		Secret:         secret,
+		EventTypes:     events,
+		Description:    description,
+		IncludeContent: includeContent,
+		Active:         true,
+	}
+	if err := s.webhookRepo.CreateSubscription(sub); err != nil {
+		return nil, "", fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	log.Printf("Webhook %d created by user %d for events %v", sub.ID, userID, events)
+	return sub, secret, nil
+}
+
+// ListWebhooks lists the user's personal webhooks, or a team's webhooks for its admins
+func (s *webhookService) ListWebhooks(userID uint, teamID *uint) ([]models.WebhookSubscription, error) {
+	if teamID != nil {
+		if err := s.authorizeTeam(userID, *teamID); err != nil {
+			return nil, err
+		}
+		return s.webhookRepo.GetSubscriptionsByTeamID(*teamID)
+	}
+	return s.webhookRepo.GetSubscriptionsByUserID(userID)
+}
+
+// GetWebhook returns a single subscription the user may manage
+func (s *webhookService) GetWebhook(userID, webhookID uint) (*models.WebhookSubscription, error) {
+	return s.getOwned(userID, webhookID)
+}
+
+// UpdateWebhook applies partial changes to a subscription
+func (s *webhookService) UpdateWebhook(userID, webhookID uint, update WebhookUpdate) (*models.WebhookSubscription, error) {
+	sub, err := s.getOwned(userID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	if update.URL != nil {
+		if err := validateWebhookURL(*update.URL); err != nil {
+			return nil, err
+		}
+		sub.URL = *update.URL
+	}
+	if update.Events != nil {
+		events, err := validateWebhookEvents(update.Events)
+		if err != nil {
+			return nil, err
+		}
+		sub.EventTypes = events
+	}
+	if update.Description != nil {
+		sub.Description = *update.Description
+	}
+	if update.Active != nil {
+		sub.Active = *update.Active
+	}
+	if update.IncludeContent != nil {
+		sub.IncludeContent = *update.IncludeContent
+	}
+	if err := s.webhookRepo.UpdateSubscription(sub); err != nil {
+		return nil, fmt.Errorf("failed to update webhook: %w", err)
+	}
+	return sub, nil
+}
+
+// DeleteWebhook removes a subscription; pending deliveries are dropped by the worker
+func (s *webhookService) DeleteWebhook(userID, webhookID uint) error {
+	if _, err := s.getOwned(userID, webhookID); err != nil {
+		return err
+	}
+	if err := s.webhookRepo.DeleteSubscription(webhookID); err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	log.Printf("Webhook %d deleted by user %d", webhookID, userID)
+	return nil
+}
+
+// RotateSecret replaces the signing secret and returns the new one
+func (s *webhookService) RotateSecret(userID, webhookID uint) (string, error) {
+	sub, err := s.getOwned(userID, webhookID)
+	if err != nil {
+		return "", err
+	}
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return "", err
+	}
+	sub.Secret = secret
+	if err := s.webhookRepo.UpdateSubscription(sub); err != nil {
+		return "", fmt.Errorf("failed to rotate webhook secret: %w", err)
+	}
+	return secret, nil
+}
+
+// ListDeliveries returns the delivery log of a subscription
+func (s *webhookService) ListDeliveries(userID, webhookID uint, limit, offset int) ([]models.WebhookDelivery, error) {
+	if _, err := s.getOwned(userID, webhookID); err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return s.webhookRepo.GetDeliveriesBySubscriptionID(webhookID, limit, offset)
+}
+
+// Redeliver queues a delivery again, including dead-lettered ones, with a fresh attempt budget
+func (s *webhookService) Redeliver(userID, webhookID, deliveryID uint) (*models.WebhookDelivery, error) {
+	if _, err := s.getOwned(userID, webhookID); err != nil {
+		return nil, err
+	}
+	delivery, err := s.webhookRepo.GetDeliveryByID(deliveryID)
+	if err != nil || delivery.SubscriptionID != webhookID {
+		return nil, ErrDeliveryNotFound
+	}
+	delivery.Status = models.DeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = time.Now()
+	delivery.LastError = ""
+	if err := s.webhookRepo.UpdateDelivery(delivery); err != nil {
+		return nil, fmt.Errorf("failed to queue redelivery: %w", err)
+	}
+	s.nudge()
+	return delivery, nil
+}
+
+// Ping queues a test event to a single subscription
+func (s *webhookService) Ping(userID, webhookID uint) (*models.WebhookDelivery, error) {
+	sub, err := s.getOwned(userID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	deliveries, err := s.buildDeliveries([]models.WebhookSubscription{*sub}, models.WebhookEventPing, map[string]interface{}{"webhook_id": sub.ID})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.webhookRepo.CreateDeliveries(deliveries); err != nil {
+		return nil, fmt.Errorf("failed to queue ping: %w", err)
+	}
+	s.nudge()
+	return &deliveries[0], nil
+}
+
+// webhookPayload is the JSON body POSTed to subscribers
+type webhookPayload struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+func (s *webhookService) buildDeliveries(subs []models.WebhookSubscription, eventType string, data interface{}) ([]models.WebhookDelivery, error) {
+	eventID, err := randomHex(16)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+
+	// Entry events have two bodies, with and without the content; both share the event ID
+	bodies := make(map[bool]string, 2)
+	bodyFor := func(includeContent bool) (string, error) {
+		entry, ok := data.(WebhookEntryData)
+		if !ok {
+			includeContent = false
+		}
+		if body, ok := bodies[includeContent]; ok {
+			return body, nil
+		}
+		payloadData := data
+		if ok {
+			fields := make(map[string]interface{}, len(entry.Metadata)+1)
+			for k, v := range entry.Metadata {
+				fields[k] = v
+			}
+			if includeContent {
+				fields["content"] = entry.Content
+			}
+			payloadData = fields
+		}
+		body, err := json.Marshal(webhookPayload{ID: eventID, Type: eventType, CreatedAt: now.UTC(), Data: payloadData})
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal webhook payload: %w", err)
+		}
+		bodies[includeContent] = string(body)
+		return string(body), nil
+	}
+
+	deliveries := make([]models.WebhookDelivery, 0, len(subs))
+	for _, sub := range subs {
+		if eventType != models.WebhookEventPing && !sub.Subscribes(eventType) {
+			continue
+		}
+		body, err := bodyFor(sub.IncludeContent)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, models.WebhookDelivery{
+			SubscriptionID: sub.ID,
+			EventID:        eventID,
+			EventType:      eventType,
+			Payload:        body,
+			Status:         models.DeliveryPending,
+			NextAttemptAt:  now,
+		})
+	}
+	return deliveries, nil
+}
+
+// Publish enqueues an event for every matching subscription. Failures are logged, never returned,
+// so that webhooks can't break the operation that triggered them.
+func (s *webhookService) Publish(eventType string, userID uint, teamID *uint, data interface{}) {
+	subs, err := s.webhookRepo.GetActiveSubscriptions(userID, teamID)
+	if err != nil {
+		log.Printf("Failed to load webhooks for %s event: %v", eventType, err)
+		return
+	}
+	if len(subs) == 0 {
+		return
+	}
+	deliveries, err := s.buildDeliveries(subs, eventType, data)
+	if err != nil {
+		log.Printf("Failed to build webhook deliveries for %s event: %v", eventType, err)
+		return
+	}
+	if len(deliveries) == 0 {
+		return
+	}
+	if err := s.webhookRepo.CreateDeliveries(deliveries); err != nil {
+		log.Printf("Failed to enqueue webhook deliveries for %s event: %v", eventType, err)
+		return
+	}
+	s.nudge()
+}
+
+func (s *webhookService) nudge() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run delivers queued events until stop is closed, then waits for deliveries in flight.
+// Claimed deliveries that were not started are retried once their claim lease runs out.
+func (s *webhookService) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(webhookPollInterval)
+	defer ticker.Stop()
+	slots := make(chan struct{}, webhookMaxConcurrency)
+
+	for {
+		select {
+		case <-stop:
+			for i := 0; i < cap(slots); i++ {
+				slots <- struct{}{}
+			}
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+
+		deliveries, err := s.webhookRepo.ClaimDueDeliveries(time.Now(), webhookClaimLease, webhookClaimBatch)
+		if err != nil {
+			log.Printf("Failed to claim webhook deliveries: %v", err)
+			continue
+		}
+		for i := range deliveries {
+			slots <- struct{}{}
+			go func(delivery models.WebhookDelivery) {
+				defer func() { <-slots }()
+				s.attempt(&delivery)
+			}(deliveries[i])
+		}
+	}
+}
+
+// SignWebhookPayload computes the signature header value for a payload
+func SignWebhookPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return fmt.Sprintf("t=%d,v1=%s", timestamp, hex.EncodeToString(mac.Sum(nil)))
+}
+
+// attempt sends a single delivery and records the outcome, scheduling a retry or dead-lettering it
+func (s *webhookService) attempt(delivery *models.WebhookDelivery) {
+	sub, err := s.webhookRepo.GetSubscriptionByID(delivery.SubscriptionID)
+	if err != nil || !sub.Active {
+		// Subscription deleted or disabled since the event was queued
+		delivery.Status = models.DeliveryDeadLettered
+		delivery.LastError = "webhook deleted or disabled"
+		if err := s.webhookRepo.UpdateDelivery(delivery); err != nil {
+			log.Printf("Failed to update webhook delivery %d: %v", delivery.ID, err)
+		}
+		return
+	}
+
+	delivery.Attempts++
+	statusCode, sendErr := s.send(sub, delivery)
+	delivery.LastStatusCode = statusCode
+	now := time.Now()
+
+	switch {
+	case sendErr == nil:
+		delivery.Status = models.DeliverySucceeded
+		delivery.DeliveredAt = &now
+		delivery.LastError = ""
+	case delivery.Attempts >= s.maxAttempts:
+		delivery.Status = models.DeliveryDeadLettered
+		delivery.LastError = truncateError(sendErr)
+		log.Printf("Webhook delivery %d dead-lettered after %d attempts: %v", delivery.ID, delivery.Attempts, sendErr)
+	default:
+		delivery.Status = models.DeliveryFailed
+		delivery.LastError = truncateError(sendErr)
+		delivery.NextAttemptAt = now.Add(webhookBackoff(delivery.Attempts))
+	}
+
+	if err := s.webhookRepo.UpdateDelivery(delivery); err != nil {
+		log.Printf("Failed to update webhook delivery %d: %v", delivery.ID, err)
+	}
+}
+
+func (s *webhookService) send(sub *models.WebhookSubscription, delivery *models.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ClipboardSync-Webhooks/1.0")
+	req.Header.Set(WebhookEventHeader, delivery.EventType)
+	req.Header.Set(WebhookDeliveryHeader, delivery.EventID)
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(sub.Secret, time.Now().Unix(), body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("endpoint responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// webhookBackoff returns the delay before the next attempt
+func webhookBackoff(attempts int) time.Duration {
+	return retryBackoff(webhookBaseBackoff, webhookMaxBackoff, attempts)
+}
+
+// retryBackoff returns the delay before the next attempt: exponential from base up to max, with
+// ±20% jitter
+func retryBackoff(base, max time.Duration, attempts int) time.Duration {
+	delay := base
+	for i := 1; i < attempts && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	jitter := time.Duration(rand.Int63n(int64(delay)/5*2+1)) - delay/5
+	return delay + jitter
+}
+
+func truncateError(err error) string {
+	msg := err.Error()
+	if len(msg) > webhookMaxErrorLength {
+		return msg[:webhookMaxErrorLength]
+	}
+	return msg
+}
@@ -0,0 +1,408 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"clipboard-sync-backend/internal/models"
+	"clipboard-sync-backend/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// fakeWebhookRepo keeps subscriptions and deliveries in memory. ClaimDueDeliveries leases
+// deliveries the way the SQL repository does, so several workers can share one fake.
+type fakeWebhookRepo struct {
+	repository.WebhookRepository
+	mu         sync.Mutex
+	subs       map[uint]*models.WebhookSubscription
+	deliveries []*models.WebhookDelivery
+}
+
+func newFakeWebhookRepo(subs ...models.WebhookSubscription) *fakeWebhookRepo {
+	r := &fakeWebhookRepo{subs: make(map[uint]*models.WebhookSubscription)}
+	for i := range subs {
+		sub := subs[i]
+		r.subs[sub.ID] = &sub
+	}
+	return r
+}
+
+func (r *fakeWebhookRepo) GetSubscriptionByID(id uint) (*models.WebhookSubscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sub, ok := r.subs[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *sub
+	return &copied, nil
+}
+
+func (r *fakeWebhookRepo) GetActiveSubscriptions(userID uint, teamID *uint) ([]models.WebhookSubscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var subs []models.WebhookSubscription
+	for _, sub := range r.subs {
+		if sub.Active && sub.UserID == userID && sub.TeamID == nil {
+			subs = append(subs, *sub)
+		}
+	}
+	return subs, nil
+}
+
+func (r *fakeWebhookRepo) CreateDeliveries(deliveries []models.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range deliveries {
+		deliveries[i].ID = uint(len(r.deliveries) + 1)
+		d := deliveries[i]
+		r.deliveries = append(r.deliveries, &d)
+	}
+	return nil
+}
+
+func (r *fakeWebhookRepo) UpdateDelivery(delivery *models.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	d := *delivery
+	r.deliveries[delivery.ID-1] = &d
+	return nil
+}
+
+func (r *fakeWebhookRepo) ClaimDueDeliveries(now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var claimed []models.WebhookDelivery
+	for _, d := range r.deliveries {
+		if len(claimed) == limit {
+			break
+		}
+		if (d.Status == models.DeliveryPending || d.Status == models.DeliveryFailed) && !d.NextAttemptAt.After(now) {
+			claimed = append(claimed, *d)
+			d.NextAttemptAt = now.Add(lease)
+		}
+	}
+	return claimed, nil
+}
+
+// delivery returns a copy of the stored delivery with id
+func (r *fakeWebhookRepo) delivery(id uint) models.WebhookDelivery {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return *r.deliveries[id-1]
+}
+
+// webhookReceiver is an httptest endpoint that records requests and checks their signatures
+type webhookReceiver struct {
+	*httptest.Server
+	t      *testing.T
+	secret string
+	status int
+
+	mu       sync.Mutex
+	requests []receivedWebhook
+	block    chan struct{} // When set, requests wait for it to be closed
+}
+
+type receivedWebhook struct {
+	header http.Header
+	body   []byte
+}
+
+func newWebhookReceiver(t *testing.T, secret string) *webhookReceiver {
+	rcv := &webhookReceiver{t: t, secret: secret, status: http.StatusNoContent}
+	rcv.Server = httptest.NewServer(http.HandlerFunc(rcv.serve))
+	t.Cleanup(rcv.Close)
+	return rcv
+}
+
+func (rcv *webhookReceiver) serve(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	if err := verifyWebhookSignature(rcv.secret, r.Header.Get(WebhookSignatureHeader), body, time.Now()); err != "" {
+		rcv.t.Errorf("webhook signature: %s", err)
+	}
+	rcv.mu.Lock()
+	rcv.requests = append(rcv.requests, receivedWebhook{header: r.Header.Clone(), body: body})
+	block, status := rcv.block, rcv.status
+	rcv.mu.Unlock()
+	if block != nil {
+		<-block
+	}
+	w.WriteHeader(status)
+}
+
+func (rcv *webhookReceiver) received() []receivedWebhook {
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+	return append([]receivedWebhook(nil), rcv.requests...)
+}
+
+// verifyWebhookSignature checks a signature header the way a subscriber would and returns what
+// is wrong with it
+func verifyWebhookSignature(secret, header string, body []byte, now time.Time) string {
+	var timestamp, signature string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(part, "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signature = value
+		}
+	}
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || signature == "" {
+		return "malformed header " + strconv.Quote(header)
+	}
+	if d := now.Sub(time.Unix(ts, 0)); d < -time.Minute || d > time.Minute {
+		return "timestamp " + timestamp + " is not current"
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "." + string(body)))
+	if !hmac.Equal([]byte(hex.EncodeToString(mac.Sum(nil))), []byte(signature)) {
+		return "signature does not match the body"
+	}
+	return ""
+}
+
+// waitFor polls cond until it holds or a few seconds pass
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func newTestWebhookService(repo repository.WebhookRepository, maxAttempts int) *webhookService {
+	return NewWebhookService(repo, nil, maxAttempts, 5*time.Second, true).(*webhookService)
+}
+
+func TestSignWebhookPayload(t *testing.T) {
+	got := SignWebhookPayload("whsec_test", 1700000000, []byte(`{"a":1}`))
+	want := "t=1700000000,v1=38877139021993b830af32feea6e18a8da83eb2f6e49ee50bd9e4cf4ca4d3789"
+	if got != want {
+		t.Fatalf("SignWebhookPayload() = %s, want %s", got, want)
+	}
+	if err := verifyWebhookSignature("whsec_other", got, []byte(`{"a":1}`), time.Unix(1700000000, 0)); err == "" {
+		t.Fatal("signature verified with the wrong secret")
+	}
+	if err := verifyWebhookSignature("whsec_test", got, []byte(`{"a":2}`), time.Unix(1700000000, 0)); err == "" {
+		t.Fatal("signature verified for a different body")
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	base, max := 30*time.Second, 6*time.Hour
+	within := func(d, want time.Duration) bool {
+		return d >= want-want/5 && d <= want+want/5
+	}
+	for attempts, want := range map[int]time.Duration{
+		1:  base,
+		2:  2 * base,
+		3:  4 * base,
+		6:  32 * base,
+		10: 512 * base,
+	} {
+		for i := 0; i < 20; i++ {
+			if d := retryBackoff(base, max, attempts); !within(d, want) {
+				t.Fatalf("retryBackoff(%d) = %v, want %v ±20%%", attempts, d, want)
+			}
+		}
+	}
+	for _, attempts := range []int{11, 12, 64, 1000} {
+		for i := 0; i < 20; i++ {
+			if d := retryBackoff(base, max, attempts); !within(d, max) {
+				t.Fatalf("retryBackoff(%d) = %v, want the %v cap ±20%%", attempts, d, max)
+			}
+		}
+	}
+}
+
+func TestWebhookAttemptDeadLetters(t *testing.T) {
+	rcv := newWebhookReceiver(t, "whsec_test")
+	rcv.status = http.StatusInternalServerError
+	repo := newFakeWebhookRepo(models.WebhookSubscription{ID: 1, UserID: 1, URL: rcv.URL, Secret: "whsec_test", EventTypes: []string{models.WebhookEventEntryCreated}, Active: true})
+	s := newTestWebhookService(repo, 3)
+
+	s.Publish(models.WebhookEventEntryCreated, 1, nil, map[string]interface{}{"entry_id": 7})
+	var previous time.Duration
+	for attempt := 1; attempt <= 3; attempt++ {
+		d := repo.delivery(1)
+		s.attempt(&d)
+		d = repo.delivery(1)
+		if d.Attempts != attempt || d.LastStatusCode != http.StatusInternalServerError || !strings.Contains(d.LastError, "status 500") {
+			t.Fatalf("after attempt %d delivery = %+v", attempt, d)
+		}
+		if attempt < 3 {
+			delay := time.Until(d.NextAttemptAt)
+			if d.Status != models.DeliveryFailed || delay <= previous {
+				t.Fatalf("after attempt %d status = %s with the next attempt in %v, want failed and later than %v", attempt, d.Status, delay, previous)
+			}
+			previous = delay
+		} else if d.Status != models.DeliveryDeadLettered {
+			t.Fatalf("after the last attempt status = %s, want dead-lettered", d.Status)
+		}
+	}
+	if n := len(rcv.received()); n != 3 {
+		t.Fatalf("endpoint received %d requests, want 3", n)
+	}
+
+	// A dead-lettered delivery is not claimed again
+	if claimed, _ := repo.ClaimDueDeliveries(time.Now().Add(24*time.Hour), webhookClaimLease, 10); len(claimed) != 0 {
+		t.Fatalf("claimed %+v after dead-lettering", claimed)
+	}
+
+	rcv.status = http.StatusOK
+	d := repo.delivery(1)
+	d.Status, d.Attempts = models.DeliveryPending, 0
+	s.attempt(&d)
+	if d = repo.delivery(1); d.Status != models.DeliverySucceeded || d.DeliveredAt == nil || d.LastError != "" {
+		t.Fatalf("after a successful attempt delivery = %+v", d)
+	}
+}
+
+func TestWebhookAttemptDisabledSubscription(t *testing.T) {
+	rcv := newWebhookReceiver(t, "whsec_test")
+	repo := newFakeWebhookRepo(models.WebhookSubscription{ID: 1, UserID: 1, URL: rcv.URL, Secret: "whsec_test", EventTypes: []string{models.WebhookEventEntryCreated}, Active: true})
+	s := newTestWebhookService(repo, 3)
+	s.Publish(models.WebhookEventEntryCreated, 1, nil, map[string]interface{}{"entry_id": 7})
+	repo.subs[1].Active = false
+
+	d := repo.delivery(1)
+	s.attempt(&d)
+	if d = repo.delivery(1); d.Status != models.DeliveryDeadLettered || d.Attempts != 0 {
+		t.Fatalf("delivery to a disabled webhook = %+v, want dead-lettered without an attempt", d)
+	}
+	if n := len(rcv.received()); n != 0 {
+		t.Fatalf("endpoint received %d requests, want none", n)
+	}
+}
+
+func TestWebhookPublishIncludeContent(t *testing.T) {
+	repo := newFakeWebhookRepo(
+		models.WebhookSubscription{ID: 1, UserID: 1, URL: "https://a.example/hook", Secret: "s1", EventTypes: []string{models.WebhookEventEntryCreated}, Active: true},
+		models.WebhookSubscription{ID: 2, UserID: 1, URL: "https://b.example/hook", Secret: "s2", EventTypes: []string{models.WebhookEventEntryCreated}, IncludeContent: true, Active: true},
+		models.WebhookSubscription{ID: 3, UserID: 1, URL: "https://c.example/hook", Secret: "s3", EventTypes: []string{models.WebhookEventEntryDeleted}, IncludeContent: true, Active: true},
+		models.WebhookSubscription{ID: 4, UserID: 2, URL: "https://d.example/hook", Secret: "s4", EventTypes: []string{models.WebhookEventEntryCreated}, IncludeContent: true, Active: true},
+	)
+	s := newTestWebhookService(repo, 3)
+
+	s.Publish(models.WebhookEventEntryCreated, 1, nil, WebhookEntryData{
+		Metadata: map[string]interface{}{"entry_id": 7, "content_type": "text"},
+		Content:  "secret clipboard text",
+	})
+	payloads := make(map[uint]webhookPayload)
+	var eventIDs []string
+	for _, d := range repo.deliveries {
+		var p webhookPayload
+		if err := json.Unmarshal([]byte(d.Payload), &p); err != nil {
+			t.Fatal(err)
+		}
+		payloads[d.SubscriptionID] = p
+		eventIDs = append(eventIDs, d.EventID)
+	}
+	if len(payloads) != 2 || eventIDs[0] != eventIDs[1] {
+		t.Fatalf("deliveries = %+v, want one event for webhooks 1 and 2", payloads)
+	}
+
+	without := payloads[1].Data.(map[string]interface{})
+	if _, ok := without["content"]; ok || without["entry_id"] != float64(7) {
+		t.Fatalf("payload without content = %v, want the metadata only", without)
+	}
+	with := payloads[2].Data.(map[string]interface{})
+	if with["content"] != "secret clipboard text" || with["entry_id"] != float64(7) {
+		t.Fatalf("payload with content = %v, want the metadata and content", with)
+	}
+	if payloads[1].ID != payloads[2].ID || payloads[1].Type != models.WebhookEventEntryCreated {
+		t.Fatalf("payloads %+v and %+v should describe the same event", payloads[1], payloads[2])
+	}
+}
+
+func TestWebhookRun(t *testing.T) {
+	rcv := newWebhookReceiver(t, "whsec_test")
+	repo := newFakeWebhookRepo(models.WebhookSubscription{ID: 1, UserID: 1, URL: rcv.URL, Secret: "whsec_test", EventTypes: []string{models.WebhookEventEntryCreated}, Active: true})
+	// Two workers sharing the store stand in for two replicas
+	a, b := newTestWebhookService(repo, 3), newTestWebhookService(repo, 3)
+	stop := make(chan struct{})
+	var workers sync.WaitGroup
+	for _, s := range []*webhookService{a, b} {
+		workers.Add(1)
+		go func(s *webhookService) {
+			defer workers.Done()
+			s.Run(stop)
+		}(s)
+	}
+
+	// A delivery another worker claimed is left alone until its lease runs out
+	leased := models.WebhookDelivery{SubscriptionID: 1, EventID: "leased", EventType: models.WebhookEventEntryCreated, Payload: `{}`, Status: models.DeliveryPending, NextAttemptAt: time.Now().Add(webhookClaimLease)}
+	repo.CreateDeliveries([]models.WebhookDelivery{leased})
+
+	const events = 20
+	for i := 0; i < events; i++ {
+		a.Publish(models.WebhookEventEntryCreated, 1, nil, map[string]interface{}{"n": i})
+		b.nudge()
+	}
+	waitFor(t, "deliveries", func() bool { return len(rcv.received()) >= events })
+
+	seen := make(map[string]int)
+	for _, req := range rcv.received() {
+		seen[req.header.Get(WebhookDeliveryHeader)]++
+		if req.header.Get(WebhookEventHeader) != models.WebhookEventEntryCreated || req.header.Get("Content-Type") != "application/json" {
+			t.Errorf("request headers = %v", req.header)
+		}
+	}
+	for id, n := range seen {
+		if n != 1 {
+			t.Errorf("event %s delivered %d times", id, n)
+		}
+	}
+	if seen["leased"] != 0 || repo.delivery(1).Status != models.DeliveryPending {
+		t.Fatal("a leased delivery was sent before its lease ran out")
+	}
+
+	// Once the lease runs out the delivery is picked up again
+	repo.mu.Lock()
+	repo.deliveries[0].NextAttemptAt = time.Now()
+	repo.mu.Unlock()
+	a.nudge()
+	waitFor(t, "the expired lease", func() bool { return repo.delivery(1).Status == models.DeliverySucceeded })
+
+	// Stopping waits for deliveries in flight
+	block := make(chan struct{})
+	rcv.mu.Lock()
+	rcv.block = block
+	rcv.mu.Unlock()
+	a.Publish(models.WebhookEventEntryCreated, 1, nil, map[string]interface{}{"n": "last"})
+	waitFor(t, "the last delivery", func() bool { return len(rcv.received()) == events+2 })
+	stopped := make(chan struct{})
+	go func() {
+		close(stop)
+		workers.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		t.Fatal("Run returned with a delivery in flight")
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(block)
+	<-stopped
+	if d := repo.delivery(events + 2); d.Status != models.DeliverySucceeded {
+		t.Fatalf("delivery in flight at stop = %+v, want succeeded", d)
+	}
+}
@@ -0,0 +1,89 @@
+// Package settings describes per-user settings. The schema in schema.json is the single source of
+// truth for which settings exist, their types and their defaults; users store only the values
+// they changed, and everything else falls back to the defaults.
+package settings
+
+import _ "embed"
+
+//go:embed schema.json
+var schemaJSON []byte
+
+var schema = mustParseSchema(schemaJSON)
+
+func mustParseSchema(data []byte) *Schema {
+	s, err := ParseSchema(data)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// SchemaJSON returns the settings schema document so clients can build forms from it
+func SchemaJSON() []byte {
+	return schemaJSON
+}
+
+// Validate checks a stored settings document against the schema
+func Validate(doc map[string]interface{}) error {
+	return schema.Validate(doc)
+}
+
+// Defaults returns the default value of every setting
+func Defaults() map[string]interface{} {
+	if d, ok := schema.Defaults().(map[string]interface{}); ok {
+		return d
+	}
+	return map[string]interface{}{}
+}
+
+// Effective fills in the defaults for every setting the user has not changed
+func Effective(stored map[string]interface{}) map[string]interface{} {
+	return MergePatch(Defaults(), stored)
+}
+
+// MergePatch applies a JSON merge patch (RFC 7396) to doc and returns the result: members of the
+// patch replace those of doc, objects are merged recursively and null removes a member, so it
+// reverts to its default. doc itself is left unchanged.
+func MergePatch(doc, patch map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(doc)+len(patch))
+	for k, v := range doc {
+		out[k] = deepCopy(v)
+	}
+	for k, v := range patch {
+		switch pv := v.(type) {
+		case nil:
+			delete(out, k)
+		case map[string]interface{}:
+			target, _ := out[k].(map[string]interface{})
+			merged := MergePatch(target, pv)
+			if len(merged) == 0 {
+				// An object emptied by the patch is dropped rather than stored as {}
+				delete(out, k)
+			} else {
+				out[k] = merged
+			}
+		default:
+			out[k] = deepCopy(pv)
+		}
+	}
+	return out
+}
+
+func deepCopy(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(t))
+		for k, item := range t {
+			out[k] = deepCopy(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(t))
+		for i, item := range t {
+			out[i] = deepCopy(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
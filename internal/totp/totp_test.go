@@ -0,0 +1,109 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA-1 key from RFC 6238 appendix B, "12345678901234567890", in base32
+var rfcSecret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestCodeRFC6238Vectors(t *testing.T) {
+	// The RFC lists 8-digit codes; 6-digit codes are their last six digits
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		got, err := Code(rfcSecret, Step(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("Code(T=%d) error = %v", tt.unix, err)
+		}
+		if got != tt.want {
+			t.Errorf("Code(T=%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestCodeAcceptsUnpaddedLowerCaseSecret(t *testing.T) {
+	secret := strings.ToLower(strings.TrimRight(rfcSecret, "="))
+	got, err := Code(secret, Step(time.Unix(59, 0)))
+	if err != nil || got != "287082" {
+		t.Fatalf("Code() = %s, %v; want 287082", got, err)
+	}
+	if _, err := Code("not base32!", 1); err == nil {
+		t.Fatal("Code() with an invalid secret succeeded")
+	}
+}
+
+func TestValidateSkew(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	step := Step(now)
+	tests := []struct {
+		name   string
+		offset int64
+		ok     bool
+	}{
+		{"current step", 0, true},
+		{"previous step", -1, true},
+		{"next step", 1, true},
+		{"two steps behind", -2, false},
+		{"two steps ahead", 2, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := Code(rfcSecret, step+tt.offset)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, ok := Validate(rfcSecret, code, now)
+			if ok != tt.ok {
+				t.Fatalf("Validate() ok = %v, want %v", ok, tt.ok)
+			}
+			if ok && got != step+tt.offset {
+				t.Fatalf("Validate() step = %d, want %d", got, step+tt.offset)
+			}
+		})
+	}
+}
+
+func TestValidateRejectsMalformedCodes(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	if _, ok := Validate(rfcSecret, " 050471 ", now); !ok {
+		t.Fatal("Validate() rejected a code with surrounding spaces")
+	}
+	for _, code := range []string{"", "05047", "0504710", "14050471", "abcdef"} {
+		if _, ok := Validate(rfcSecret, code, now); ok {
+			t.Errorf("Validate(%q) accepted a malformed code", code)
+		}
+	}
+	if _, ok := Validate("not base32!", "050471", now); ok {
+		t.Fatal("Validate() accepted a code for an invalid secret")
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	a, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := GenerateSecret()
+	if a == b {
+		t.Fatal("GenerateSecret() returned the same secret twice")
+	}
+	if _, err := Code(a, 1); err != nil {
+		t.Fatalf("generated secret %q is not usable: %v", a, err)
+	}
+	if len(a) != 32 {
+		t.Fatalf("secret %q is %d characters, want 32 (160 bits)", a, len(a))
+	}
+}
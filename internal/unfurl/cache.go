@@ -0,0 +1,81 @@
+package unfurl
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type cacheItem struct {
+	meta      *Metadata
+	err       error
+	expiresAt time.Time
+}
+
+type cachingFetcher struct {
+	next       Fetcher
+	ttl        time.Duration
+	errorTTL   time.Duration
+	maxEntries int
+	mu         sync.Mutex
+	items      map[string]cacheItem
+}
+
+// NewCachingFetcher wraps a Fetcher with an in-memory cache keyed by URL.
+// Failures are cached for a shorter period so broken links are not hammered.
+func NewCachingFetcher(next Fetcher, ttl time.Duration, maxEntries int) Fetcher {
+	if maxEntries <= 0 {
+		maxEntries = 1000
+	}
+	return &cachingFetcher{
+		next:       next,
+		ttl:        ttl,
+		errorTTL:   ttl / 10,
+		maxEntries: maxEntries,
+		items:      make(map[string]cacheItem),
+	}
+}
+
+// Fetch returns a cached result for rawURL or delegates to the wrapped Fetcher
+func (c *cachingFetcher) Fetch(ctx context.Context, rawURL string) (*Metadata, error) {
+	now := time.Now()
+	c.mu.Lock()
+	if item, ok := c.items[rawURL]; ok && now.Before(item.expiresAt) {
+		c.mu.Unlock()
+		if item.meta != nil {
+			copied := *item.meta
+			return &copied, nil
+		}
+		return nil, item.err
+	}
+	c.mu.Unlock()
+
+	meta, err := c.next.Fetch(ctx, rawURL)
+	if ctx.Err() != nil {
+		// Don't cache results of cancelled lookups
+		return meta, err
+	}
+
+	ttl := c.ttl
+	if err != nil {
+		ttl = c.errorTTL
+	}
+	c.mu.Lock()
+	if len(c.items) >= c.maxEntries {
+		c.evictExpired(now)
+	}
+	if len(c.items) < c.maxEntries {
+		c.items[rawURL] = cacheItem{meta: meta, err: err, expiresAt: now.Add(ttl)}
+	}
+	c.mu.Unlock()
+	return meta, err
+}
+
+// evictExpired drops stale items; must be called with c.mu held
+func (c *cachingFetcher) evictExpired(now time.Time) {
+	for key, item := range c.items {
+		if !now.Before(item.expiresAt) {
+			delete(c.items, key)
+		}
+	}
+}
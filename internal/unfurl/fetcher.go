@@ -0,0 +1,129 @@
+package unfurl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"clipboard-sync-backend/internal/safehttp"
+)
+
+var (
+	ErrUnsupportedURL = errors.New("only http and https URLs can be unfurled")
+	ErrNotHTML        = errors.New("response is not an HTML document")
+)
+
+// Metadata is the preview information extracted from a web page
+type Metadata struct {
+	URL          string `json:"url"`
+	CanonicalURL string `json:"canonical_url"`
+	Title        string `json:"title"`
+	Description  string `json:"description"`
+	FaviconURL   string `json:"favicon_url"`
+}
+
+// Fetcher retrieves preview metadata for a URL
+type Fetcher interface {
+	Fetch(ctx context.Context, rawURL string) (*Metadata, error)
+}
+
+// Options configures the HTTP fetcher
+type Options struct {
+	Timeout      time.Duration // Overall request timeout
+	MaxBodyBytes int64         // Maximum number of bytes read from the response body
+	MaxRedirects int
+	UserAgent    string
+	// AllowPrivateNetworks disables the SSRF guard; only for tests against local servers
+	AllowPrivateNetworks bool
+}
+
+// DefaultOptions returns conservative fetch limits
+func DefaultOptions() Options {
+	return Options{
+		Timeout:      5 * time.Second,
+		MaxBodyBytes: 512 << 10,
+		MaxRedirects: 3,
+		UserAgent:    "ClipboardSyncBot/1.0 (+link preview)",
+	}
+}
+
+type httpFetcher struct {
+	client *http.Client
+	opts   Options
+}
+
+// NewFetcher creates a Fetcher whose HTTP client refuses to connect to private,
+// loopback and link-local addresses and caps response size and time
+func NewFetcher(opts Options) Fetcher {
+	defaults := DefaultOptions()
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaults.Timeout
+	}
+	if opts.MaxBodyBytes <= 0 {
+		opts.MaxBodyBytes = defaults.MaxBodyBytes
+	}
+	if opts.MaxRedirects <= 0 {
+		opts.MaxRedirects = defaults.MaxRedirects
+	}
+	if opts.UserAgent == "" {
+		opts.UserAgent = defaults.UserAgent
+	}
+
+	client := safehttp.NewClient(safehttp.Options{
+		Timeout:              opts.Timeout,
+		MaxRedirects:         opts.MaxRedirects,
+		AllowPrivateNetworks: opts.AllowPrivateNetworks,
+	})
+	return &httpFetcher{client: client, opts: opts}
+}
+
+// Fetch downloads the page at rawURL and extracts its preview metadata
+func (f *httpFetcher) Fetch(ctx context.Context, rawURL string) (*Metadata, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, ErrUnsupportedURL
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", f.opts.UserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", u.Host, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("unexpected status %d fetching %s", resp.StatusCode, u.Host)
+	}
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return nil, ErrNotHTML
+	}
+
+	meta := ParseHTML(io.LimitReader(resp.Body, f.opts.MaxBodyBytes), resp.Request.URL)
+	meta.URL = rawURL
+	return meta, nil
+}
+
+// ExtractURL returns the URL if text consists of a single http(s) URL, otherwise ""
+func ExtractURL(text string) string {
+	text = strings.TrimSpace(text)
+	if text == "" || strings.ContainsAny(text, " \t\r\n") || len(text) > 2048 {
+		return ""
+	}
+	u, err := url.Parse(text)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return ""
+	}
+	return u.String()
+}
@@ -0,0 +1,145 @@
+package unfurl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"clipboard-sync-backend/internal/safehttp"
+)
+
+// newTestServer serves an HTML page at /page, a page whose <head> only ends after padding bytes
+// at /padded?n=padding, a JSON document at /json, and /redirect/<n> redirects n times before
+// reaching /page
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("User-Agent") == "" {
+			t.Error("request has no User-Agent")
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, `<html><head><title>Test page</title><link rel="canonical" href="/canonical"></head></html>`)
+	})
+	mux.HandleFunc("/padded", func(w http.ResponseWriter, r *http.Request) {
+		n, _ := strconv.Atoi(r.URL.Query().Get("n"))
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<html><head><meta name="padding" content="%s"><title>Late title</title></head></html>`, strings.Repeat("x", n))
+	})
+	mux.HandleFunc("/json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"title":"not html"}`)
+	})
+	mux.HandleFunc("/missing", http.NotFound)
+	mux.HandleFunc("/redirect/", func(w http.ResponseWriter, r *http.Request) {
+		n, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/redirect/"))
+		if n <= 1 {
+			http.Redirect(w, r, "/page", http.StatusFound)
+			return
+		}
+		http.Redirect(w, r, fmt.Sprintf("/redirect/%d", n-1), http.StatusFound)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestFetch(t *testing.T) {
+	srv := newTestServer(t)
+	f := NewFetcher(Options{AllowPrivateNetworks: true})
+
+	meta, err := f.Fetch(context.Background(), srv.URL+"/page")
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	want := Metadata{
+		URL:          srv.URL + "/page",
+		CanonicalURL: srv.URL + "/canonical",
+		Title:        "Test page",
+		FaviconURL:   srv.URL + "/favicon.ico",
+	}
+	if *meta != want {
+		t.Fatalf("Fetch() = %+v, want %+v", *meta, want)
+	}
+}
+
+func TestFetchRejects(t *testing.T) {
+	srv := newTestServer(t)
+	f := NewFetcher(Options{AllowPrivateNetworks: true})
+
+	tests := []struct {
+		name    string
+		url     string
+		wantErr error
+	}{
+		{"unsupported scheme", "file:///etc/passwd", ErrUnsupportedURL},
+		{"no host", "http:///page", ErrUnsupportedURL},
+		{"not html", srv.URL + "/json", ErrNotHTML},
+		{"error status", srv.URL + "/missing", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := f.Fetch(context.Background(), tt.url)
+			if err == nil {
+				t.Fatal("Fetch() succeeded")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Fetch() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFetchBodyLimit(t *testing.T) {
+	srv := newTestServer(t)
+
+	f := NewFetcher(Options{AllowPrivateNetworks: true, MaxBodyBytes: 1 << 10})
+	meta, err := f.Fetch(context.Background(), srv.URL+"/padded?n=100")
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if meta.Title != "Late title" {
+		t.Fatalf("title within the limit = %q, want %q", meta.Title, "Late title")
+	}
+
+	// Nothing past MaxBodyBytes is parsed
+	meta, err = f.Fetch(context.Background(), srv.URL+"/padded?n=4096")
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if meta.Title != "" {
+		t.Fatalf("title beyond the limit = %q, want none", meta.Title)
+	}
+}
+
+func TestFetchRedirectLimit(t *testing.T) {
+	srv := newTestServer(t)
+	f := NewFetcher(Options{AllowPrivateNetworks: true, MaxRedirects: 2})
+
+	meta, err := f.Fetch(context.Background(), srv.URL+"/redirect/2")
+	if err != nil {
+		t.Fatalf("Fetch() within the redirect limit error = %v", err)
+	}
+	// Relative links resolve against the page finally fetched, but URL stays what was asked for
+	if meta.URL != srv.URL+"/redirect/2" || meta.CanonicalURL != srv.URL+"/canonical" {
+		t.Fatalf("Fetch() = %+v", *meta)
+	}
+
+	if _, err := f.Fetch(context.Background(), srv.URL+"/redirect/3"); !errors.Is(err, safehttp.ErrTooManyRedirects) {
+		t.Fatalf("Fetch() past the redirect limit error = %v, want ErrTooManyRedirects", err)
+	}
+}
+
+func TestFetchBlocksPrivateNetworks(t *testing.T) {
+	srv := newTestServer(t)
+	f := NewFetcher(Options{})
+
+	if _, err := f.Fetch(context.Background(), srv.URL+"/page"); !errors.Is(err, safehttp.ErrBlockedAddress) {
+		t.Fatalf("Fetch() of a loopback server error = %v, want ErrBlockedAddress", err)
+	}
+}
@@ -0,0 +1,135 @@
+package unfurl
+
+import (
+	"io"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// maxFieldLength truncates extracted titles and descriptions
+const maxFieldLength = 500
+
+// ParseHTML extracts title, description, favicon and canonical URL from a page's head.
+// Open Graph values take precedence over Twitter card values, which take precedence over the
+// plain <title> and meta description.
+func ParseHTML(r io.Reader, base *url.URL) *Metadata {
+	var (
+		title, ogTitle, twitterTitle     string
+		description, ogDesc, twitterDesc string
+		favicon, canonical               string
+		ogURL                            string
+		inTitle                          bool
+	)
+
+	z := html.NewTokenizer(r)
+loop:
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			break loop
+		case html.StartTagToken, html.SelfClosingTagToken:
+			tok := z.Token()
+			switch tok.DataAtom {
+			case atom.Title:
+				inTitle = title == ""
+			case atom.Meta:
+				key := strings.ToLower(attr(tok, "property"))
+				if key == "" {
+					key = strings.ToLower(attr(tok, "name"))
+				}
+				content := attr(tok, "content")
+				switch key {
+				case "og:title":
+					ogTitle = content
+				case "og:description":
+					ogDesc = content
+				case "twitter:title":
+					twitterTitle = content
+				case "twitter:description":
+					twitterDesc = content
+				case "description":
+					description = content
+				case "og:url":
+					ogURL = content
+				}
+			case atom.Link:
+				rels := strings.Fields(strings.ToLower(attr(tok, "rel")))
+				for _, rel := range rels {
+					switch rel {
+					case "icon", "shortcut", "apple-touch-icon":
+						if favicon == "" {
+							favicon = attr(tok, "href")
+						}
+					case "canonical":
+						canonical = attr(tok, "href")
+					}
+				}
+			case atom.Body:
+				// Everything we care about lives in <head>
+				break loop
+			}
+		case html.TextToken:
+			if inTitle {
+				title += string(z.Text())
+			}
+		case html.EndTagToken:
+			if tok := z.Token(); tok.DataAtom == atom.Title {
+				inTitle = false
+			}
+		}
+	}
+
+	meta := &Metadata{
+		Title:       truncate(firstNonEmpty(ogTitle, twitterTitle, title)),
+		Description: truncate(firstNonEmpty(ogDesc, twitterDesc, description)),
+	}
+	if base != nil {
+		meta.CanonicalURL = resolve(base, firstNonEmpty(canonical, ogURL))
+		if meta.CanonicalURL == "" {
+			meta.CanonicalURL = base.String()
+		}
+		meta.FaviconURL = resolve(base, firstNonEmpty(favicon, "/favicon.ico"))
+	}
+	return meta
+}
+
+func attr(tok html.Token, key string) string {
+	for _, a := range tok.Attr {
+		if a.Key == key {
+			return strings.TrimSpace(a.Val)
+		}
+	}
+	return ""
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func truncate(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > maxFieldLength {
+		return string(r[:maxFieldLength])
+	}
+	return s
+}
+
+// resolve makes ref absolute against base and only keeps http(s) results
+func resolve(base *url.URL, ref string) string {
+	if ref == "" {
+		return ""
+	}
+	u, err := base.Parse(ref)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+	return u.String()
+}
@@ -0,0 +1,126 @@
+package unfurl
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestParseHTML(t *testing.T) {
+	base, _ := url.Parse("https://example.com/articles/1?ref=clip")
+	tests := []struct {
+		name string
+		html string
+		want Metadata
+	}{
+		{
+			name: "open graph wins",
+			html: `<html><head>
+				<title>Plain title</title>
+				<meta name="description" content="Plain description">
+				<meta name="twitter:title" content="Twitter title">
+				<meta name="twitter:description" content="Twitter description">
+				<meta property="og:title" content="OG title">
+				<meta property="og:description" content="OG description">
+				<meta property="og:url" content="https://example.com/og">
+				<link rel="icon" href="/static/icon.png">
+				<link rel="canonical" href="/articles/1">
+			</head><body></body></html>`,
+			want: Metadata{
+				Title:        "OG title",
+				Description:  "OG description",
+				CanonicalURL: "https://example.com/articles/1",
+				FaviconURL:   "https://example.com/static/icon.png",
+			},
+		},
+		{
+			name: "twitter card before plain tags",
+			html: `<head>
+				<title>Plain title</title>
+				<meta name="description" content="Plain description">
+				<meta name="twitter:title" content="Twitter title">
+				<meta name="twitter:description" content="Twitter description">
+			</head>`,
+			want: Metadata{
+				Title:        "Twitter title",
+				Description:  "Twitter description",
+				CanonicalURL: "https://example.com/articles/1?ref=clip",
+				FaviconURL:   "https://example.com/favicon.ico",
+			},
+		},
+		{
+			name: "plain title and description",
+			html: `<head><title>  Plain
+				title </title><meta name="Description" content="Plain description">
+				<meta property="og:url" content="https://example.com/og"></head>`,
+			want: Metadata{
+				Title:        "Plain title",
+				Description:  "Plain description",
+				CanonicalURL: "https://example.com/og",
+				FaviconURL:   "https://example.com/favicon.ico",
+			},
+		},
+		{
+			name: "empty open graph falls back",
+			html: `<head><title>Plain title</title><meta property="og:title" content="  "></head>`,
+			want: Metadata{
+				Title:        "Plain title",
+				CanonicalURL: "https://example.com/articles/1?ref=clip",
+				FaviconURL:   "https://example.com/favicon.ico",
+			},
+		},
+		{
+			name: "body is not searched",
+			html: `<head></head><body><title>Not a title</title><meta property="og:title" content="Nope"></body>`,
+			want: Metadata{
+				CanonicalURL: "https://example.com/articles/1?ref=clip",
+				FaviconURL:   "https://example.com/favicon.ico",
+			},
+		},
+		{
+			name: "non-http links are dropped",
+			html: `<head><link rel="shortcut icon" href="javascript:alert(1)"><link rel="canonical" href="data:text/html,hi"></head>`,
+			want: Metadata{
+				CanonicalURL: "https://example.com/articles/1?ref=clip",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseHTML(strings.NewReader(tt.html), base)
+			if *got != tt.want {
+				t.Fatalf("ParseHTML() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseHTMLTruncates(t *testing.T) {
+	long := strings.Repeat("é", maxFieldLength+10)
+	got := ParseHTML(strings.NewReader(`<title>`+long+`</title>`), nil)
+	if n := len([]rune(got.Title)); n != maxFieldLength {
+		t.Fatalf("title has %d characters, want %d", n, maxFieldLength)
+	}
+	if got.CanonicalURL != "" || got.FaviconURL != "" {
+		t.Fatalf("URLs resolved without a base: %+v", got)
+	}
+}
+
+func TestExtractURL(t *testing.T) {
+	tests := []struct {
+		text, want string
+	}{
+		{"  https://example.com/a?b=c \n", "https://example.com/a?b=c"},
+		{"http://example.com", "http://example.com"},
+		{"ftp://example.com/file", ""},
+		{"see https://example.com", ""},
+		{"example.com", ""},
+		{"https://", ""},
+		{"https://example.com/" + strings.Repeat("a", 2048), ""},
+	}
+	for _, tt := range tests {
+		if got := ExtractURL(tt.text); got != tt.want {
+			t.Errorf("ExtractURL(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
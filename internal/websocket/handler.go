@@ -4,31 +4,61 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
+	"net/url"
+	"strings"
 
+	"clipboard-sync-backend/internal/auth"
+	"clipboard-sync-backend/internal/clipformat"
+	"clipboard-sync-backend/internal/models"
+	"clipboard-sync-backend/internal/policy"
 	"clipboard-sync-backend/internal/service"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
 )
 
-var upgrader = websocket.Upgrader{
-	ReadBufferSize:  1024,
-	WriteBufferSize: 1024,
-	CheckOrigin: func(r *http.Request) bool {
-		// TODO: Allow specific origins in production
-		return true
-	},
+// OriginPolicy decides which browser origins may open connections. The CORS middleware is one,
+// so connections follow server.cors.allowed_origins as it is reloaded.
+type OriginPolicy interface {
+	AllowsOrigin(origin string) bool
 }
 
 // WsHandler handles WebSocket connections
 type WsHandler struct {
 	manager          *Manager
 	clipboardService service.ClipboardService
+	webhookService   service.WebhookService
+	teamService      service.TeamService
+	origins          OriginPolicy
+	upgrader         websocket.Upgrader
 }
 
 // NewWsHandler creates a new WsHandler
-func NewWsHandler(manager *Manager, clipboardService service.ClipboardService) *WsHandler {
-	return &WsHandler{manager: manager, clipboardService: clipboardService}
+func NewWsHandler(manager *Manager, clipboardService service.ClipboardService, webhookService service.WebhookService, teamService service.TeamService, origins OriginPolicy) *WsHandler {
+	h := &WsHandler{manager: manager, clipboardService: clipboardService, webhookService: webhookService, teamService: teamService, origins: origins}
+	h.upgrader = websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+		CheckOrigin:     h.checkOrigin,
+	}
+	return h
+}
+
+// checkOrigin lets browsers connect from the API's own origin and from allowed origins. Requests
+// without an Origin header don't come from a browser page (desktop apps, CLIs) and are let through.
+func (h *WsHandler) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	if h.origins != nil && h.origins.AllowsOrigin(origin) {
+		return true
+	}
+	log.Printf("Rejected WebSocket connection from origin %s", origin)
+	return false
 }
 
 // ServeWs handles the WebSocket upgrade and connection lifecycle
@@ -39,19 +69,44 @@ func (h *WsHandler) ServeWs(c *gin.Context) {
 		return
 	}
 
-	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
 		log.Printf("Failed to upgrade to websocket: %v", err)
 		return
 	}
 
 	client := &Client{
-		UserID: userID.(uint),
-		Conn:   conn,
-		Send:   make(chan []byte, 256),
+		UserID:    userID.(uint),
+		SessionID: c.GetUint("sessionID"),
+		Conn:      conn,
+		Send:      make(chan []byte, 256),
+		Accept:    clipformat.ParseAccept(c.Query("formats")), // e.g. /ws?formats=text/html,text/plain
+		Device:    c.DefaultQuery("device", c.Request.UserAgent()),
+		CanWrite:  auth.HasScope(c, models.ScopeClipboardWrite),
+	}
+
+	// Subscribe to live delivery for the user's teams
+	if h.teamService != nil {
+		memberships, err := h.teamService.ListMyTeams(client.UserID)
+		if err != nil {
+			log.Printf("Failed to load teams for user %d: %v", client.UserID, err)
+		}
+		for _, m := range memberships {
+			// Authorize also applies the team's two-factor requirement
+			if h.teamService.Authorize(client.UserID, m.Team.ID, policy.ReadHistory) == nil {
+				client.TeamIDs = append(client.TeamIDs, m.Team.ID)
+			}
+		}
 	}
 
 	h.manager.RegisterClient(client)
+	if h.webhookService != nil {
+		go h.webhookService.Publish(models.WebhookEventDeviceConnected, client.UserID, nil, map[string]interface{}{
+			"user_id":     client.UserID,
+			"device":      client.Device,
+			"remote_addr": c.ClientIP(),
+		})
+	}
 
 	// Allow collection of information about the remote connection.
 	go h.writePump(client)
@@ -72,28 +127,58 @@ func (h *WsHandler) readPump(client *Client) {
 			}
 			break
 		}
+		if !client.CanWrite {
+			h.manager.SendError(client, auth.ErrInsufficientScope)
+			continue
+		}
 
 		// Handle incoming WebSocket message (e.g., new clipboard content from client)
 		var msg struct {
 			ContentType string `json:"content_type"`
 			Content     string `json:"content"`
+			Formats     []struct {
+				MIMEType string `json:"mime_type"`
+				Content  string `json:"content"`
+			} `json:"formats"`
 			SourceDevice string `json:"source_device"`
+			TeamID       *uint  `json:"team_id"` // Post to this team's shared clipboard
 		}
 		if err := json.Unmarshal(message, &msg); err != nil {
 			log.Printf("Error unmarshalling websocket message: %v", err)
 			continue
 		}
 
-		if msg.ContentType == "" || msg.Content == "" {
+		var formats []clipformat.Representation
+		if msg.ContentType != "" && msg.Content != "" {
+			formats = append(formats, clipformat.Representation{MIMEType: msg.ContentType, Content: msg.Content})
+		}
+		for _, f := range msg.Formats {
+			formats = append(formats, clipformat.Representation{MIMEType: f.MIMEType, Content: f.Content})
+		}
+		if len(formats) == 0 {
 			log.Println("Received empty content type or content from websocket")
 			continue
 		}
 
+		// Team entries are fanned out to every online member by the clipboard service
+		if msg.TeamID != nil {
+			if h.teamService != nil {
+				if err := h.teamService.Authorize(client.UserID, *msg.TeamID, policy.PostEntry); err != nil {
+					h.manager.SendError(client, err)
+					continue
+				}
+			}
+			if _, err := h.clipboardService.CreateTeamClipboardEntry(client.UserID, *msg.TeamID, formats, msg.SourceDevice); err != nil {
+				log.Printf("Error saving team clipboard entry from websocket: %v", err)
+				h.manager.SendError(client, err)
+			}
+			continue
+		}
+
 		// Save to database
-		entry, err := h.clipboardService.CreateClipboardEntry(
+		entry, err := h.clipboardService.CreateClipboardEntryWithFormats(
 			client.UserID,
-			msg.ContentType,
-			msg.Content,
+			formats,
 			msg.SourceDevice,
 		)
 		if err != nil {
@@ -101,14 +186,15 @@ func (h *WsHandler) readPump(client *Client) {
 			continue
 		}
 
-		// Broadcast to other devices of the same user
-		// Convert entry to JSON to send back
-		jsonEntry, err := json.Marshal(entry)
-		if err != nil {
-			log.Printf("Error marshalling entry for broadcast: %v", err)
-			continue
-		}
-		h.manager.SendToUser(client.UserID, jsonEntry)
+		// Broadcast to other devices of the same user, each receiving the formats it supports
+		h.manager.SendToUserEach(client.UserID, func(target *Client) []byte {
+			jsonEntry, err := json.Marshal(service.SelectRepresentations(*entry, target.Accept))
+			if err != nil {
+				log.Printf("Error marshalling entry for broadcast: %v", err)
+				return nil
+			}
+			return jsonEntry
+		})
 	}
 }
 
@@ -0,0 +1,77 @@
+package websocket
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"clipboard-sync-backend/configs"
+	"clipboard-sync-backend/internal/api"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+func TestCheckOrigin(t *testing.T) {
+	cors := api.NewCORS(configs.CORSConfig{AllowedOrigins: []string{"https://app.example.com/"}})
+	h := NewWsHandler(nil, nil, nil, nil, cors)
+
+	check := func(origin string) bool {
+		r := httptest.NewRequest(http.MethodGet, "http://api.example.com/ws", nil)
+		if origin != "" {
+			r.Header.Set("Origin", origin)
+		}
+		return h.checkOrigin(r)
+	}
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{"", true}, // Not a browser
+		{"https://api.example.com", true},
+		{"https://app.example.com", true},
+		{"HTTPS://APP.EXAMPLE.COM", true},
+		{"https://evil.example.com", false},
+		{"https://app.example.com.evil.example", false},
+		{"http://app.example.com", false},
+		{"null", false},
+	}
+	for _, tt := range tests {
+		if got := check(tt.origin); got != tt.want {
+			t.Errorf("checkOrigin(%q) = %v, want %v", tt.origin, got, tt.want)
+		}
+	}
+
+	// Reloaded origins apply to the next connection
+	cors.Update(configs.CORSConfig{AllowedOrigins: []string{"https://admin.example.com"}})
+	if check("https://app.example.com") || !check("https://admin.example.com") {
+		t.Fatal("checkOrigin() did not follow the reloaded origins")
+	}
+	cors.Update(configs.CORSConfig{AllowedOrigins: []string{"*"}})
+	if !check("https://anywhere.example") {
+		t.Fatal("checkOrigin() rejected an origin allowed with \"*\"")
+	}
+
+	if !NewWsHandler(nil, nil, nil, nil, nil).checkOrigin(httptest.NewRequest(http.MethodGet, "http://api.example.com/ws", nil)) {
+		t.Fatal("checkOrigin() without an origin policy rejected a request without an Origin header")
+	}
+}
+
+func TestServeWsRejectsOrigin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewWsHandler(nil, nil, nil, nil, api.NewCORS(configs.CORSConfig{AllowedOrigins: []string{"https://app.example.com"}}))
+	router := gin.New()
+	router.GET("/ws", func(c *gin.Context) { c.Set("userID", uint(1)) }, h.ServeWs)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	header := http.Header{"Origin": {"https://evil.example.com"}}
+	conn, resp, err := websocket.DefaultDialer.Dial("ws"+server.URL[len("http"):]+"/ws", header)
+	if err == nil {
+		conn.Close()
+		t.Fatal("Dial() from a disallowed origin succeeded")
+	}
+	if resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("Dial() response = %v, want 403", resp)
+	}
+}
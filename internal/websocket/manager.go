@@ -1,109 +1,418 @@
 package websocket
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"log"
 	"sync"
+	"time"
+
+	"clipboard-sync-backend/internal/models"
+	"clipboard-sync-backend/internal/service"
 
 	"github.com/gorilla/websocket"
 )
 
 // Client represents a single WebSocket connection
 type Client struct {
-	UserID uint
-	Conn   *websocket.Conn
-	Send   chan []byte
+	UserID    uint
+	SessionID uint // Session whose access token opened the connection
+	Conn      *websocket.Conn
+	Send      chan []byte
+	Accept    []string // Clipboard MIME types the client can handle; empty means all
+	Device    string   // Device name reported by the client, e.g. "Chrome on Windows"
+	TeamIDs   []uint   // Teams the user belonged to when connecting
+	CanWrite  bool     // False for access tokens without clipboard:write; such clients only receive
 }
 
 // Manager handles WebSocket client connections and message broadcasting
 type Manager struct {
 	clients    map[uint]map[*Client]bool // UserID -> map of clients
+	teamUsers  map[uint]map[uint]bool    // TeamID -> online member user IDs
+	userTeams  map[uint]map[uint]bool    // UserID -> team IDs, for online users only
 	broadcast  chan []byte
 	register   chan *Client
 	unregister chan *Client
+	done       chan struct{} // Closed once Run has returned
 	mu         sync.RWMutex
 }
 
+// shutdownCloseGrace is how long clients get to answer the going-away close frame before their
+// connections are closed from our side
+const shutdownCloseGrace = 5 * time.Second
+
 // NewManager creates a new WebSocket Manager
 func NewManager() *Manager {
 	return &Manager{
 		clients:    make(map[uint]map[*Client]bool),
+		teamUsers:  make(map[uint]map[uint]bool),
+		userTeams:  make(map[uint]map[uint]bool),
 		broadcast:  make(chan []byte),
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
+		done:       make(chan struct{}),
 	}
 }
 
-// Run starts the WebSocket manager, handling client connections and messages
-func (m *Manager) Run() {
+// Run starts the WebSocket manager, handling client connections and messages until stop is closed
+func (m *Manager) Run(stop <-chan struct{}) {
+	defer close(m.done)
 	for {
 		select {
+			case <-stop:
+				return
+
 			case client := <-m.register:
 				m.mu.Lock()
 				if _, ok := m.clients[client.UserID]; !ok {
 					m.clients[client.UserID] = make(map[*Client]bool)
 				}
 				m.clients[client.UserID][client] = true
+				for _, teamID := range client.TeamIDs {
+					m.addTeamMemberLocked(teamID, client.UserID)
+				}
 				log.Printf("Client registered: UserID %d, Addr %s. Total clients for user: %d", client.UserID, client.Conn.RemoteAddr(), len(m.clients[client.UserID]))
 				m.mu.Unlock()
 
 			case client := <-m.unregister:
 				m.mu.Lock()
-				if _, ok := m.clients[client.UserID]; ok {
-					if _, ok := m.clients[client.UserID][client]; ok {
-						delete(m.clients[client.UserID], client)
-						close(client.Send)
-						log.Printf("Client unregistered: UserID %d, Addr %s. Remaining clients for user: %d", client.UserID, client.Conn.RemoteAddr(), len(m.clients[client.UserID]))
-						if len(m.clients[client.UserID]) == 0 {
-							delete(m.clients, client.UserID)
-						}
-					}
-				}
+				m.removeClientLocked(client)
 				m.mu.Unlock()
 
 			case message := <-m.broadcast:
 				// This broadcast is for all connected clients, regardless of user
 				// For user-specific broadcast, use SendToUser method
-				m.mu.RLock()
+				m.mu.Lock()
 				for _, userClients := range m.clients {
 					for client := range userClients {
 						select {
 							case client.Send <- message:
 							default:
-								close(client.Send)
-								delete(userClients, client)
+								m.removeClientLocked(client)
 						}
 					}
 				}
-				m.mu.RUnlock()
+				m.mu.Unlock()
 		}
 	}
 }
 
-// RegisterClient registers a new WebSocket client
+// RegisterClient registers a new WebSocket client. Once the manager has stopped the client is
+// turned away instead.
 func (m *Manager) RegisterClient(client *Client) {
-	m.register <- client
+	select {
+		case m.register <- client:
+		case <-m.done:
+			close(client.Send)
+	}
 }
 
 // UnregisterClient unregisters a WebSocket client
 func (m *Manager) UnregisterClient(client *Client) {
-	m.unregister <- client
+	select {
+		case m.unregister <- client:
+		case <-m.done:
+	}
+}
+
+// Shutdown tells every client that the server is going away and when to reconnect, then waits
+// until they have all disconnected or ctx is done. Clients that do not answer the close frame in
+// time are disconnected from our side. The server must already refuse new connections.
+func (m *Manager) Shutdown(ctx context.Context, reconnectIn time.Duration) error {
+	reason := fmt.Sprintf("server going away, reconnect in %d s", int(reconnectIn.Round(time.Second).Seconds()))
+	closeMsg := websocket.FormatCloseMessage(websocket.CloseGoingAway, reason)
+	m.mu.RLock()
+	for _, clients := range m.clients {
+		for client := range clients {
+			client.Conn.WriteControl(websocket.CloseMessage, closeMsg, time.Now().Add(time.Second))
+		}
+	}
+	m.mu.RUnlock()
+
+	graceCtx, cancel := context.WithTimeout(ctx, shutdownCloseGrace)
+	defer cancel()
+	if m.waitForClients(graceCtx) {
+		return nil
+	}
+
+	m.mu.RLock()
+	for _, clients := range m.clients {
+		for client := range clients {
+			client.Conn.Close()
+		}
+	}
+	m.mu.RUnlock()
+	if m.waitForClients(ctx) {
+		return nil
+	}
+	return ctx.Err()
+}
+
+// waitForClients reports whether every client disconnected before ctx was done
+func (m *Manager) waitForClients(ctx context.Context) bool {
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		if _, connections := m.ConnectionCount(); connections == 0 {
+			return true
+		}
+		select {
+			case <-ctx.Done():
+				return false
+			case <-ticker.C:
+		}
+	}
 }
 
 // SendToUser sends a message to all connected clients of a specific user
 func (m *Manager) SendToUser(userID uint, message []byte) {
+	m.SendToUserEach(userID, func(*Client) []byte { return message })
+}
+
+// SendToUserEach sends a message built per client to all connected clients of a specific user.
+// Clients for which build returns nil are skipped.
+func (m *Manager) SendToUserEach(userID uint, build func(client *Client) []byte) {
+	var slow []*Client
+	m.mu.RLock()
+	for client := range m.clients[userID] {
+		message := build(client)
+		if message == nil {
+			continue
+		}
+		if !trySend(client, message) {
+			slow = append(slow, client)
+		}
+	}
+	m.mu.RUnlock()
+	m.dropClients(slow)
+}
+
+// SendError reports a rejected message back to the client that sent it. Nothing is sent once the
+// client has been unregistered, since its Send channel is closed by then.
+func (m *Manager) SendError(client *Client, err error) {
+	message, mErr := json.Marshal(service.Event{Type: service.EventError, Data: map[string]string{"message": err.Error()}})
+	if mErr != nil {
+		return
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.clients[client.UserID][client] {
+		trySend(client, message)
+	}
+}
+
+// IsDeviceConnected reports whether the user has a live connection from the named device
+func (m *Manager) IsDeviceConnected(userID uint, device string) bool {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	if clients, ok := m.clients[userID]; ok {
+	for client := range m.clients[userID] {
+		if client.Device == device {
+			return true
+		}
+	}
+	return false
+}
+
+// SendToDevice sends a message to the user's connections from the named device.
+// It reports whether at least one connection received it.
+func (m *Manager) SendToDevice(userID uint, device string, message []byte) bool {
+	var slow []*Client
+	sent := false
+	m.mu.RLock()
+	for client := range m.clients[userID] {
+		if client.Device != device {
+			continue
+		}
+		if trySend(client, message) {
+			sent = true
+		} else {
+			slow = append(slow, client)
+		}
+	}
+	m.mu.RUnlock()
+	m.dropClients(slow)
+	return sent
+}
+
+// SendToTeam sends a message to all connected clients of every online member of a team
+func (m *Manager) SendToTeam(teamID uint, message []byte) {
+	m.SendToTeamEach(teamID, func(*Client) []byte { return message })
+}
+
+// SendToTeamEach sends a message built per client to every online member of a team.
+// Clients for which build returns nil are skipped.
+func (m *Manager) SendToTeamEach(teamID uint, build func(client *Client) []byte) {
+	var slow []*Client
+	m.mu.RLock()
+	for userID := range m.teamUsers[teamID] {
+		for client := range m.clients[userID] {
+			message := build(client)
+			if message == nil {
+				continue
+			}
+			if !trySend(client, message) {
+				slow = append(slow, client)
+			}
+		}
+	}
+	m.mu.RUnlock()
+	m.dropClients(slow)
+}
+
+// trySend queues a message without blocking and reports whether the client had room for it.
+// m.mu must be held, at least for reading, so the channel cannot be closed meanwhile.
+func trySend(client *Client, message []byte) bool {
+	select {
+		case client.Send <- message:
+			return true
+		default:
+			return false
+	}
+}
+
+// dropClients disconnects clients that fell too far behind to keep up. Only clients still
+// registered are removed, so a client dropped by two senders at once is closed once.
+func (m *Manager) dropClients(clients []*Client) {
+	if len(clients) == 0 {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, client := range clients {
+		if m.removeClientLocked(client) {
+			log.Printf("Dropped slow client: UserID %d, Addr %s", client.UserID, client.Conn.RemoteAddr())
+		}
+	}
+}
+
+// removeClientLocked unregisters a client and closes its Send channel, reporting whether it was
+// still registered. A registered client's Send is only ever closed here, so it is closed exactly
+// once; m.mu must be held for writing.
+func (m *Manager) removeClientLocked(client *Client) bool {
+	clients, ok := m.clients[client.UserID]
+	if !ok || !clients[client] {
+		return false
+	}
+	delete(clients, client)
+	close(client.Send)
+	log.Printf("Client unregistered: UserID %d, Addr %s. Remaining clients for user: %d", client.UserID, client.Conn.RemoteAddr(), len(clients))
+	if len(clients) == 0 {
+		delete(m.clients, client.UserID)
+		m.removeUserTeamsLocked(client.UserID)
+	}
+	return true
+}
+
+// SendEntryToTeam sends a team clipboard entry to every online member, each connection
+// receiving only the representations it accepts
+func (m *Manager) SendEntryToTeam(teamID uint, entry *models.ClipboardEntry) {
+	m.SendToTeamEach(teamID, func(client *Client) []byte {
+		jsonEntry, err := json.Marshal(service.SelectRepresentations(*entry, client.Accept))
+		if err != nil {
+			log.Printf("Error marshalling entry for team broadcast: %v", err)
+			return nil
+		}
+		return jsonEntry
+	})
+}
+
+// AddTeamMember starts live team delivery to a user who just joined, if they are online
+func (m *Manager) AddTeamMember(teamID, userID uint) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, online := m.clients[userID]; online {
+		m.addTeamMemberLocked(teamID, userID)
+	}
+}
+
+// RemoveTeamMember stops live team delivery to a user immediately
+func (m *Manager) RemoveTeamMember(teamID, userID uint) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.teamUsers[teamID], userID)
+	if len(m.teamUsers[teamID]) == 0 {
+		delete(m.teamUsers, teamID)
+	}
+	delete(m.userTeams[userID], teamID)
+}
+
+// RemoveTeam stops all live delivery for a deleted team
+func (m *Manager) RemoveTeam(teamID uint) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for userID := range m.teamUsers[teamID] {
+		delete(m.userTeams[userID], teamID)
+	}
+	delete(m.teamUsers, teamID)
+}
+
+// addTeamMemberLocked indexes an online user under a team; m.mu must be held
+func (m *Manager) addTeamMemberLocked(teamID, userID uint) {
+	if _, ok := m.teamUsers[teamID]; !ok {
+		m.teamUsers[teamID] = make(map[uint]bool)
+	}
+	m.teamUsers[teamID][userID] = true
+	if _, ok := m.userTeams[userID]; !ok {
+		m.userTeams[userID] = make(map[uint]bool)
+	}
+	m.userTeams[userID][teamID] = true
+}
+
+// DisconnectSession closes every connection opened with the given session. The read loop then
+// sees the closed connection and unregisters the client as usual.
+func (m *Manager) DisconnectSession(sessionID uint) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	closeMsg := websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "session revoked")
+	for _, clients := range m.clients {
 		for client := range clients {
-			select {
-				case client.Send <- message:
-				default:
-					close(client.Send)
-					delete(clients, client)
+			if client.SessionID != sessionID {
+				continue
 			}
+			client.Conn.WriteControl(websocket.CloseMessage, closeMsg, time.Now().Add(time.Second))
+			client.Conn.Close()
 		}
 	}
 }
 
+// DisconnectUser closes every connection of a user, including those opened with personal access
+// tokens
+func (m *Manager) DisconnectUser(userID uint) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	closeMsg := websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "signed out by an administrator")
+	for client := range m.clients[userID] {
+		client.Conn.WriteControl(websocket.CloseMessage, closeMsg, time.Now().Add(time.Second))
+		client.Conn.Close()
+	}
+}
+
+// ConnectionCount reports how many users are online and over how many connections
+func (m *Manager) ConnectionCount() (int, int) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	connections := 0
+	for _, clients := range m.clients {
+		connections += len(clients)
+	}
+	return len(m.clients), connections
+}
 
+// removeUserTeamsLocked drops a user who went offline from the team index; m.mu must be held
+func (m *Manager) removeUserTeamsLocked(userID uint) {
+	for teamID := range m.userTeams[userID] {
+		delete(m.teamUsers[teamID], userID)
+		if len(m.teamUsers[teamID]) == 0 {
+			delete(m.teamUsers, teamID)
+		}
+	}
+	delete(m.userTeams, userID)
+}